@@ -0,0 +1,323 @@
+package golifx
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+const (
+	// animationFrameInterval is the interval between frames sent by
+	// host-driven animations.  Each frame is sent with a transition duration
+	// equal to the interval, so the device smooths between frames.
+	animationFrameInterval = 100 * time.Millisecond
+)
+
+// AnimationOption configures a host-driven transition, see Transition
+type AnimationOption func(*animationConfig)
+
+type animationConfig struct {
+	onProgress func(fraction float64)
+	easing     common.Easing
+}
+
+// OnProgress calls fn with the fraction (0 to 1) of the transition completed
+// after each frame is sent, every 100ms, and with 1 once the transition
+// completes.  No further calls are made once the transition is cancelled or
+// fails.  fn is called from the animating goroutine, so should return quickly
+// to avoid delaying frames.
+func OnProgress(fn func(fraction float64)) AnimationOption {
+	return func(cfg *animationConfig) {
+		cfg.onProgress = fn
+	}
+}
+
+// Ease samples the color of each frame along easing, rather than linearly, eg
+// common.EaseInOut for a gentle start and finish.  Frames are still sent every
+// 100ms, the easing only changes the color of each frame.  Progress reported
+// via OnProgress remains the fraction of time elapsed.  A nil easing is
+// linear.
+func Ease(easing common.Easing) AnimationOption {
+	return func(cfg *animationConfig) {
+		if easing != nil {
+			cfg.easing = easing
+		}
+	}
+}
+
+func newAnimationConfig(opts []AnimationOption) *animationConfig {
+	cfg := &animationConfig{easing: common.EaseLinear}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// progress reports the fraction completed, if requested
+func (cfg *animationConfig) progress(fraction float64) {
+	if cfg.onProgress != nil {
+		cfg.onProgress(fraction)
+	}
+}
+
+type animation struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// animate registers a host-driven animation with the client, returning a
+// context that is done when parent is done or when animations are cancelled on
+// the client, and a func that must be called when the animation completes.
+func (c *Client) animate(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	a := &animation{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	c.Lock()
+	c.animationSeq++
+	id := c.animationSeq
+	c.animations[id] = a
+	c.Unlock()
+
+	return ctx, func() {
+		cancel()
+		c.Lock()
+		delete(c.animations, id)
+		c.Unlock()
+		close(a.done)
+	}
+}
+
+// CancelAnimations cancels all in-flight host-driven animations (eg Transition,
+// Wake) started via this client, and waits for them to stop.  Animations are
+// also cancelled when the client is closed.
+func (c *Client) CancelAnimations() {
+	c.RLock()
+	animations := make([]*animation, 0, len(c.animations))
+	for _, a := range c.animations {
+		animations = append(animations, a)
+	}
+	c.RUnlock()
+
+	for _, a := range animations {
+		a.cancel()
+	}
+	for _, a := range animations {
+		<-a.done
+	}
+}
+
+// Transition performs a host-driven transition of the light from its current
+// color to color over duration, sending a frame every 100ms.  This blocks until
+// the transition completes, or returns the context error if ctx is done or
+// animations are cancelled on the client.  Simple linear transitions are better
+// performed by the device itself via Light.SetColor, host-driven transitions
+// are the building block for effects the device does not support, including
+// eased transitions, see Ease.  Progress may be observed via OnProgress.  With
+// a span hook set, frames are spanned as children of the span in ctx.
+func (c *Client) Transition(ctx context.Context, light common.Light, color common.Color, duration time.Duration, opts ...AnimationOption) error {
+	ctx, done := c.animate(ctx)
+	defer done()
+
+	from, err := light.GetColor()
+	if err != nil {
+		return err
+	}
+
+	return c.transition(ctx, light, from, color, duration, newAnimationConfig(opts))
+}
+
+// Wake powers on the light at zero brightness, and performs a host-driven
+// transition to color over duration.  Blocks and may be cancelled in the same
+// manner as Transition, and accepts the same options.
+func (c *Client) Wake(ctx context.Context, light common.Light, color common.Color, duration time.Duration, opts ...AnimationOption) error {
+	ctx, done := c.animate(ctx)
+	defer done()
+
+	from := color
+	from.Brightness = 0
+	if err := setColor(ctx, light, from, 0); err != nil {
+		return err
+	}
+	if err := light.SetPower(true); err != nil {
+		return err
+	}
+
+	return c.transition(ctx, light, from, color, duration, newAnimationConfig(opts))
+}
+
+// PowerOnFor powers on the light, then powers it off again once duration has
+// elapsed, like a fan timer.  LIFX firmware has no timed power off, so the
+// timer runs on the host and this blocks until the light is powered off,
+// requiring the process to stay alive for the duration.  If ctx is done or
+// animations are cancelled on the client first, the light is left on and the
+// context error is returned.  Returns common.ErrInvalidArgument if duration is
+// not positive.
+func (c *Client) PowerOnFor(ctx context.Context, light common.Light, duration time.Duration) error {
+	if duration <= 0 {
+		return common.ErrInvalidArgument
+	}
+	ctx, done := c.animate(ctx)
+	defer done()
+
+	if err := light.SetPower(true); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	return light.SetPower(false)
+}
+
+// contextLight is implemented by lights that span requests as children of the
+// span in a context
+type contextLight interface {
+	SetColorContext(ctx context.Context, color common.Color, duration time.Duration) error
+}
+
+// setColor sets the color of light, spanning the request as a child of the span
+// in ctx if the light supports it
+func setColor(ctx context.Context, light common.Light, color common.Color, duration time.Duration) error {
+	if l, ok := light.(contextLight); ok {
+		return l.SetColorContext(ctx, color, duration)
+	}
+	return light.SetColor(color, duration)
+}
+
+func (c *Client) transition(ctx context.Context, light common.Light, from, to common.Color, duration time.Duration, cfg *animationConfig) error {
+	if duration <= 0 {
+		if err := setColor(ctx, light, to, 0); err != nil {
+			return err
+		}
+		cfg.progress(1)
+		return nil
+	}
+
+	ticker := time.NewTicker(animationFrameInterval)
+	defer ticker.Stop()
+	start := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		progress := float64(time.Since(start)) / float64(duration)
+		if progress >= 1 {
+			if err := setColor(ctx, light, to, animationFrameInterval); err != nil {
+				return err
+			}
+			cfg.progress(1)
+			return nil
+		}
+		if err := setColor(ctx, light, common.LerpColor(from, to, cfg.easing(progress)), animationFrameInterval); err != nil {
+			return err
+		}
+		cfg.progress(progress)
+	}
+}
+
+// CycleHue continuously rotates the hue of the light around the color wheel,
+// completing a rotation every period, while keeping the current saturation,
+// brightness and kelvin of the light.  A frame is sent every 100ms, which is
+// well within the device message rate limit.  This blocks until ctx is done or
+// animations are cancelled on the client, and returns the context error.
+// Returns common.ErrInvalidArgument if period is not positive.
+func (c *Client) CycleHue(ctx context.Context, light common.Light, period time.Duration) error {
+	if period <= 0 {
+		return common.ErrInvalidArgument
+	}
+	ctx, done := c.animate(ctx)
+	defer done()
+
+	base, err := light.GetColor()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(animationFrameInterval)
+	defer ticker.Stop()
+	start := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		color := base
+		color.Hue += hueOffset(time.Since(start), period)
+		if err := setColor(ctx, light, color, animationFrameInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// CycleZoneHue behaves as CycleHue, but offsets the hue of each zone of the
+// light so that the full color wheel is spread along the light, and rotates
+// along it.  Each zone keeps its current saturation, brightness and kelvin.
+// Setting distinct colors on each zone requires a message per zone, so frames
+// are sent as fast as the rate limit allows, and each frame transitions over
+// the time taken to send the previous one.
+func (c *Client) CycleZoneHue(ctx context.Context, light common.MultiZoneLight, period time.Duration) error {
+	if period <= 0 {
+		return common.ErrInvalidArgument
+	}
+	ctx, done := c.animate(ctx)
+	defer done()
+
+	base, err := light.GetZoneColors()
+	if err != nil {
+		return err
+	}
+	if len(base) == 0 {
+		return common.ErrInvalidArgument
+	}
+	hue := base[0].Hue
+
+	ticker := time.NewTicker(animationFrameInterval)
+	defer ticker.Stop()
+	start := time.Now()
+	frame := animationFrameInterval
+	colors := make([]common.Color, len(base))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		sent := time.Now()
+		offset := hue + hueOffset(sent.Sub(start), period)
+		for i := range base {
+			colors[i] = base[i]
+			colors[i].Hue = offset + uint16(i*(math.MaxUint16+1)/len(base))
+		}
+		if err := light.SetZoneColors(colors, frame); err != nil {
+			return err
+		}
+		if frame = time.Since(sent); frame < animationFrameInterval {
+			frame = animationFrameInterval
+		}
+	}
+}
+
+// hueOffset returns the hue rotation after elapsed time, for a rotation every
+// period
+func hueOffset(elapsed, period time.Duration) uint16 {
+	return uint16(int(float64(elapsed%period)/float64(period)*(math.MaxUint16+1)) & math.MaxUint16)
+}
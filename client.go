@@ -1,6 +1,9 @@
 package golifx
 
 import (
+	"context"
+	"io"
+	"sort"
 	"sync"
 	"time"
 
@@ -9,15 +12,38 @@ import (
 
 // Client provides a simple interface for interacting with LIFX devices.  Client
 // can not be instantiated manually or it will not function - always use
-// NewClient() to obtain a Client instance.
+// NewClient() to obtain a Client instance.  Client implements common.Client,
+// which consumers may depend on instead to allow substitution in tests, and
+// common.ClientConfig, which it passes to its protocol.
 type Client struct {
-	discoveryInterval     time.Duration
-	quitChan              chan struct{}
+	discoveryInterval time.Duration
+	quitChan          chan struct{}
+	// done is closed by Close, unlike quitChan which also carries tokens to
+	// stop the discovery loop, so any number of goroutines may wait on it
+	done                  chan struct{}
 	protocol              common.Protocol
 	timeout               time.Duration
 	retryInterval         time.Duration
+	pollInterval          time.Duration
+	cacheTTL              time.Duration
+	colorDebounce         time.Duration
+	expectedDeviceCount   int
+	skipRedundantWrites   bool
+	restoreOnPower        bool
+	colorMemory           bool
+	prefetchDeviceInfo    bool
+	minBrightness         uint16
+	signalHistoryLength   int
+	signalSampler         *signalSampler
+	tracer                io.Writer
+	spanHook              common.SpanHook
+	decodeErrorHandler    common.DecodeErrorHandler
+	webhook               *webhook
 	internalRetryInterval time.Duration
 	subscriptions         map[string]*common.Subscription
+	labels                *labelIndex
+	animations            map[uint64]*animation
+	animationSeq          uint64
 	sync.RWMutex
 }
 
@@ -217,10 +243,114 @@ func (c *Client) GetGroupByLabel(label string) (common.Group, error) {
 	}
 }
 
-// GetDevices returns a slice of all devices known to the client, or
-// common.ErrNotFound if no devices are currently known.
+// GetDevices returns a slice of all devices known to the client, ordered by
+// ID, or common.ErrNotFound if no devices are currently known.  If an expected device
+// count has been set via SetExpectedDeviceCount and fewer devices are known,
+// GetDevices waits until that many devices have been discovered, or until the
+// client timeout elapses, and then returns the devices known at that time.
 func (c *Client) GetDevices() (devices []common.Device, err error) {
-	return c.protocol.GetDevices()
+	if expected := c.GetExpectedDeviceCount(); expected > 0 {
+		devices, err = c.waitForDevices(context.Background(), expected)
+	} else {
+		devices, err = c.protocol.GetDevices()
+	}
+	sortDevices(devices)
+	return devices, err
+}
+
+// DeviceCount returns the number of devices currently known to the client,
+// without waiting for the expected device count
+func (c *Client) DeviceCount() int {
+	devices, _ := c.protocol.GetDevices()
+	return len(devices)
+}
+
+// Ready blocks until at least one device has been discovered, so that
+// applications may wait for startup discovery before issuing commands.
+// Returns the context error if ctx is done first, or common.ErrClosed if the
+// client is closed.
+func (c *Client) Ready(ctx context.Context) error {
+	sub, err := c.protocol.NewSubscription()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := sub.Close(); err != nil {
+			common.Log.Warnf("Failed closing device subscription: %+v", err)
+		}
+	}()
+	events := sub.Events()
+
+	// Checked after subscribing, so that a device discovered in between is
+	// not missed
+	if c.DeviceCount() > 0 {
+		return nil
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return common.ErrClosed
+			}
+			if _, ok := event.(common.EventNewDevice); ok {
+				return nil
+			}
+		case <-c.done:
+			return common.ErrClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sortDevices orders devices by ID, so that results are stable between calls
+func sortDevices(devices []common.Device) {
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID() < devices[j].ID() })
+}
+
+// waitForDevices waits until expected devices are known, the client timeout
+// elapses, or ctx is done, and returns the devices known at that time.  If
+// expected is zero, it waits for the timeout or ctx.
+func (c *Client) waitForDevices(ctx context.Context, expected int) ([]common.Device, error) {
+	var timeout <-chan time.Time
+	if c.timeout > 0 {
+		timeout = time.After(c.timeout)
+	} else {
+		timeout = make(<-chan time.Time)
+	}
+
+	// Subscribe before checking the known devices, so that no device
+	// discovered in between is missed
+	sub, err := c.protocol.NewSubscription()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := sub.Close(); err != nil {
+			common.Log.Warnf("Failed closing device subscription: %+v", err)
+		}
+	}()
+	events := sub.Events()
+
+	devices, err := c.protocol.GetDevices()
+	for expected <= 0 || len(devices) < expected {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil, common.ErrClosed
+			}
+			if _, ok := event.(common.EventNewDevice); ok {
+				devices, err = c.protocol.GetDevices()
+			}
+		case <-timeout:
+			return devices, err
+		case <-ctx.Done():
+			return devices, err
+		}
+	}
+
+	return devices, err
 }
 
 // GetDeviceByID looks up a device by its `id` and returns a common.Device.
@@ -269,16 +399,33 @@ func (c *Client) GetDeviceByID(id uint64) (common.Device, error) {
 }
 
 // GetDeviceByLabel looks up a device by its `label` and returns a common.Device.
+// Known labels are resolved from an index without scanning every device.  If
+// several devices share the label, the device with the lowest ID is returned.
 // May return a common.ErrNotFound error if the lookup times out without finding
 // the device.
 func (c *Client) GetDeviceByLabel(label string) (common.Device, error) {
-	devices, _ := c.GetDevices()
+	if id, ok := c.labels.lookup(label); ok {
+		dev, err := c.protocol.GetDevice(id)
+		// The index is updated asynchronously, so confirm the label is still
+		// current
+		if err == nil {
+			if current, err := dev.GetLabel(); err == nil && current == label {
+				return dev, nil
+			}
+		}
+	}
+
+	devices, _ := c.protocol.GetDevices()
+	var found common.Device
 	for _, dev := range devices {
 		res, err := dev.GetLabel()
-		if err == nil && res == label {
-			return dev, nil
+		if err == nil && res == label && (found == nil || dev.ID() < found.ID()) {
+			found = dev
 		}
 	}
+	if found != nil {
+		return found, nil
+	}
 
 	var timeout <-chan time.Time
 	if c.timeout > 0 {
@@ -320,8 +467,8 @@ func (c *Client) GetDeviceByLabel(label string) (common.Device, error) {
 	}
 }
 
-// GetLights returns a slice of all lights known to the client, or
-// common.ErrNotFound if no lights are currently known.
+// GetLights returns a slice of all lights known to the client, ordered by ID,
+// or common.ErrNotFound if no lights are currently known.
 func (c *Client) GetLights() (lights []common.Light, err error) {
 	devices, err := c.GetDevices()
 	if err != nil {
@@ -341,6 +488,126 @@ func (c *Client) GetLights() (lights []common.Light, err error) {
 	return lights, nil
 }
 
+// GetLightsPaged calls fn with the known lights in batches of up to batchSize,
+// for installations where querying every light at once would overwhelm the
+// socket buffer and drop responses.  The state of each light in a batch is
+// requested, waiting for every light in the batch to respond or time out before
+// fn is called, so at most batchSize requests are outstanding at once.  Lights
+// that fail to respond are logged and still passed to fn with their cached
+// state.  Batches are ordered by ascending light ID, so a caller may resume by
+// skipping lights up to the last ID processed.  If fn returns an error,
+// iteration stops and the error is returned.  Returns common.ErrNotFound if no
+// lights are currently known, or common.ErrInvalidArgument if batchSize is not
+// positive.
+func (c *Client) GetLightsPaged(batchSize int, fn func([]common.Light) error) error {
+	if batchSize <= 0 {
+		return common.ErrInvalidArgument
+	}
+	lights, err := c.GetLights()
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(lights); start += batchSize {
+		end := start + batchSize
+		if end > len(lights) {
+			end = len(lights)
+		}
+		batch := lights[start:end]
+
+		var wg sync.WaitGroup
+		for _, light := range batch {
+			wg.Add(1)
+			go func(light common.Light) {
+				defer wg.Done()
+				if _, err := light.GetColor(); err != nil {
+					common.Log.Warnf("Failed querying light %d: %v", light.ID(), err)
+				}
+			}(light)
+		}
+		wg.Wait()
+
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetRooms returns the known lights arranged into rooms by the location and
+// group they belong to, see common.Room.  Lights that report no group are
+// placed in a room labelled common.UngroupedRoomLabel.  Rooms are a snapshot,
+// call GetRooms again to include newly discovered lights.  Returns
+// common.ErrNotFound if no lights are currently known.
+func (c *Client) GetRooms() ([]common.Room, error) {
+	lights, err := c.GetLights()
+	if err != nil {
+		return nil, err
+	}
+	// Missing groups or locations just leave lights ungrouped
+	groups, _ := c.GetGroups()
+	locations, _ := c.GetLocations()
+
+	return common.NewRooms(lights, groups, locations), nil
+}
+
+// GetLightsContext waits for lights to respond to discovery, and returns the
+// lights known once the client timeout elapses, the expected device count (see
+// SetExpectedDeviceCount) is reached, or ctx is done, whichever is first.  When
+// ctx is cancelled, eg because a web request was abandoned, the lights that
+// responded before cancellation are returned without error.  Returns
+// common.ErrNotFound if no lights responded.
+func (c *Client) GetLightsContext(ctx context.Context) ([]common.Light, error) {
+	devices, err := c.waitForDevices(ctx, c.GetExpectedDeviceCount())
+	if err != nil {
+		return nil, err
+	}
+	sortDevices(devices)
+
+	var lights []common.Light
+	for _, dev := range devices {
+		if light, ok := dev.(common.Light); ok {
+			lights = append(lights, light)
+		}
+	}
+	if len(lights) == 0 {
+		return nil, common.ErrNotFound
+	}
+
+	return lights, nil
+}
+
+// GetLightsWithRetry behaves as GetLights, but if no lights are known it sends
+// another discovery broadcast and waits for the client timeout before trying
+// again, up to a total of `attempts` tries.  This helps when the first
+// discovery is lost, eg shortly after the host network comes up.  Returns
+// common.ErrNotFound if no lights are found after all attempts, or the context
+// error if ctx is done while waiting.
+func (c *Client) GetLightsWithRetry(ctx context.Context, attempts int) ([]common.Light, error) {
+	for attempt := 1; ; attempt++ {
+		lights, err := c.GetLights()
+		if err != common.ErrNotFound || attempt >= attempts {
+			return lights, err
+		}
+
+		common.Log.Debugf("No lights found on attempt %d of %d, retrying discovery", attempt, attempts)
+		if err := c.protocol.Discover(); err != nil {
+			return nil, err
+		}
+
+		wait := c.timeout
+		if wait <= 0 {
+			wait = common.DefaultTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
 // GetLightByID looks up a light by its `id` and returns a common.Light.
 // May return a common.ErrNotFound error if the lookup times out without finding
 // the light, or common.ErrDeviceInvalidType if the device exists but is not a
@@ -359,6 +626,37 @@ func (c *Client) GetLightByID(id uint64) (light common.Light, err error) {
 	return light, nil
 }
 
+// GetLightByMAC looks up a light by its MAC address, in the `aa:bb:cc:dd:ee:ff`
+// form, and returns a common.Light.  The MAC address is the serial number of
+// the device, which unlike the label does not change.  Returns
+// common.ErrInvalidArgument if mac is not a valid MAC address, otherwise
+// behaves as GetLightByID.
+func (c *Client) GetLightByMAC(mac string) (common.Light, error) {
+	id, err := common.MACToID(mac)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetLightByID(id)
+}
+
+// DeviceFromCache returns a light with the MAC address `mac`, in the
+// `aa:bb:cc:dd:ee:ff` form, that sends requests directly to `addr`, in
+// host:port form with the port defaulting to 56700, without waiting for
+// discovery.  This is the fast path for scripts that recorded the address of a
+// light in a previous session.  If the light is already known it is returned
+// as is.  Requests to a light that does not respond at addr return
+// common.ErrTimeout.  Returns common.ErrInvalidArgument if mac or addr is not
+// valid.
+func (c *Client) DeviceFromCache(mac string, addr string) (common.Light, error) {
+	id, err := common.MACToID(mac)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.protocol.DeviceFromCache(id, addr)
+}
+
 // GetLightByLabel looks up a light by its `label` and returns a common.Light.
 // May return a common.ErrNotFound error if the lookup times out without finding
 // the light, or common.ErrDeviceInvalidType if the device exists but is not a
@@ -377,6 +675,19 @@ func (c *Client) GetLightByLabel(label string) (common.Light, error) {
 	return light, nil
 }
 
+// GetLightsByLabelPattern returns the known lights whose label matches the glob
+// pattern, ordered by ID, eg `Office-*`.  Lights are not waited for, see
+// GetLightsContext to wait for discovery first.  Returns
+// common.ErrInvalidArgument if the pattern is malformed, or common.ErrNotFound
+// if no lights match, see common.MatchLabelPattern.
+func (c *Client) GetLightsByLabelPattern(pattern string) ([]common.Light, error) {
+	lights, err := c.GetLights()
+	if err != nil && err != common.ErrNotFound {
+		return nil, err
+	}
+	return common.MatchLabelPattern(lights, pattern)
+}
+
 // SetPower broadcasts a request to change the power state of all devices on
 // the network.  A state of true requests power on, and a state of false
 // requests power off.
@@ -399,6 +710,174 @@ func (c *Client) SetColor(color common.Color, duration time.Duration) error {
 	return c.protocol.SetColor(color, duration)
 }
 
+// CopyColor requests the current color of src once, and applies it to each of
+// dst in parallel over duration, eg to match the color of bulbs.  Every write is
+// attempted, failures are logged and the first is returned once all writes
+// have completed.  Returns common.ErrInvalidArgument if no dst lights are
+// passed, or the error requesting the color of src.
+func (c *Client) CopyColor(src common.Light, duration time.Duration, dst ...common.Light) error {
+	if len(dst) == 0 {
+		return common.ErrInvalidArgument
+	}
+	color, err := src.GetColor()
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, light := range dst {
+		wg.Add(1)
+		go func(light common.Light) {
+			defer wg.Done()
+			if err := light.SetColor(color, duration); err != nil {
+				common.Log.Warnf("Failed copying color to light %d: %v", light.ID(), err)
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(light)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// BroadcastWaveform sends the waveform to all lights at once, so that they run
+// it in unison, eg to flash every light for an alert.  With w.Transient set,
+// the lights return to their own colors once the cycles complete.  Returns
+// common.ErrInvalidArgument if the waveform is invalid (see
+// common.Waveform.Validate).
+func (c *Client) BroadcastWaveform(w common.Waveform) error {
+	return c.protocol.BroadcastWaveform(w)
+}
+
+// SetPort sets the UDP port that discovery requests are sent to, defaulting to
+// the standard LIFX port of 56700.  This is intended for communicating with
+// emulated devices, such as test fixtures running on a non-standard port.
+// Changing the port breaks discovery of real devices, which only respond to
+// discovery on 56700.  Devices report the port they accept commands on during
+// discovery, so no other port configuration is required.
+func (c *Client) SetPort(port int) error {
+	return c.protocol.SetPort(port)
+}
+
+// SetBroadcastAddress sets the address that discovery requests are sent to, in
+// host:port form, bypassing the default broadcast to 255.255.255.255.  The
+// port defaults to 56700 if omitted.  This is useful where the default
+// broadcast does not reach the devices, such as from Docker bridge networks or
+// hosts with multiple interfaces, in which case the subnet broadcast address
+// of the network the devices are on (eg 192.168.1.255) should be used.
+// Returns common.ErrInvalidArgument if the address can not be parsed.
+func (c *Client) SetBroadcastAddress(address string) error {
+	return c.protocol.SetBroadcastAddress(address)
+}
+
+// AddBroadcastAddress adds a further address that discovery requests are sent
+// to, either a broadcast address in host:port form, or a network in CIDR form
+// (eg 10.0.20.0/24).  Discovery fans out to every address, and the devices
+// found are managed together, each recording the network it was found in (see
+// common.Device.Subnet).  Call Discover to find devices at the new address
+// immediately.  Returns common.ErrInvalidArgument if the address is invalid.
+func (c *Client) AddBroadcastAddress(address string) error {
+	return c.protocol.AddBroadcastAddress(address)
+}
+
+// Discover sends a discovery request immediately, in addition to any periodic
+// discovery (see SetDiscoveryInterval).  Responses are processed
+// asynchronously, known devices that respond from a new address are updated,
+// and new devices emit common.EventNewDevice.
+func (c *Client) Discover() error {
+	return c.protocol.Discover()
+}
+
+// DiscoverStream performs a discovery pass, emitting devices on the returned
+// channel as they are found, for populating a UI incrementally.  Devices
+// already known are emitted first, then a discovery request is sent and newly
+// discovered devices are emitted as they respond.  The pass completes after the
+// client timeout (common.DefaultTimeout if the timeout is zero), and the
+// channel is closed once the pass completes or ctx is done.  Each device is
+// emitted at most once per pass.
+func (c *Client) DiscoverStream(ctx context.Context) <-chan common.Device {
+	ch := make(chan common.Device)
+
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = common.DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	// Subscribe before taking the known devices, so that no device discovered
+	// in between is missed
+	sub, err := c.protocol.NewSubscription()
+	if err != nil {
+		common.Log.Warnf("Failed subscribing for discovery stream: %v", err)
+		cancel()
+		close(ch)
+		return ch
+	}
+
+	go func() {
+		defer func() {
+			if err := sub.Close(); err != nil {
+				common.Log.Warnf("Failed closing discovery stream subscription: %v", err)
+			}
+			cancel()
+			close(ch)
+		}()
+
+		seen := make(map[uint64]bool)
+		emit := func(dev common.Device) bool {
+			if seen[dev.ID()] {
+				return true
+			}
+			seen[dev.ID()] = true
+			select {
+			case ch <- dev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		devices, _ := c.protocol.GetDevices()
+		sortDevices(devices)
+		for _, dev := range devices {
+			if !emit(dev) {
+				return
+			}
+		}
+		if err := c.protocol.Discover(); err != nil {
+			common.Log.Warnf("Failed sending discovery for discovery stream: %v", err)
+			return
+		}
+
+		events := sub.Events()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if event, ok := event.(common.EventNewDevice); ok {
+					if !emit(event.Device) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
 // SetDiscoveryInterval causes the client to discover devices and state every
 // interval.  You should set this to a non-zero value for any long-running
 // process, otherwise devices will only be discovered once.
@@ -418,6 +897,9 @@ func (c *Client) SetDiscoveryInterval(interval time.Duration) error {
 // SetTimeout sets the time that client operations wait for results before
 // returning an error.  The special value of 0 may be set to disable timeouts,
 // and all operations will wait indefinitely, but this is not recommended.
+// Writes to a device are sent one at a time, each once the previous write was
+// acknowledged, so without a timeout a write that is never acknowledged holds
+// up later writes to the device for common.DefaultTimeout.
 func (c *Client) SetTimeout(timeout time.Duration) {
 	c.timeout = timeout
 }
@@ -448,6 +930,197 @@ func (c *Client) GetRetryInterval() *time.Duration {
 	return &c.retryInterval
 }
 
+// SetPollInterval sets the interval at which device state is polled, when
+// waiting for a device to reach a desired state, eg Light.WaitUntilColor.
+// Defaults to common.DefaultPollInterval.
+func (c *Client) SetPollInterval(pollInterval time.Duration) {
+	c.Lock()
+	c.pollInterval = pollInterval
+	c.Unlock()
+}
+
+// GetPollInterval returns the currently configured poll interval for
+// operations on this client
+func (c *Client) GetPollInterval() *time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	return &c.pollInterval
+}
+
+// SetCacheTTL sets the period for which device state reported by devices is
+// considered fresh.  Within this period, requests for state that has been
+// reported by the device (eg GetPower, after the power level was reported with
+// the light state) are served from the cache without another round trip.  The
+// special value of 0 (the default) disables caching.
+func (c *Client) SetCacheTTL(ttl time.Duration) {
+	c.Lock()
+	c.cacheTTL = ttl
+	c.Unlock()
+}
+
+// GetCacheTTL returns the currently configured cache TTL for devices on this
+// client
+func (c *Client) GetCacheTTL() *time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	return &c.cacheTTL
+}
+
+// InvalidateAllCaches discards the cached state of all known devices, so that
+// it is requested from each device on next access, see Device.InvalidateCache
+func (c *Client) InvalidateAllCaches() {
+	devices, _ := c.protocol.GetDevices()
+	for _, device := range devices {
+		device.InvalidateCache()
+	}
+}
+
+// SetColorDebounce sets a window within which SetColor requests to each light
+// are coalesced, which is useful when changing color rapidly, eg from a UI
+// slider.  The first request in a window schedules a send when the window
+// elapses, and only the latest color requested within the window is sent.  This
+// introduces up to `debounce` latency to color changes, and as requests are
+// sent asynchronously, errors are logged rather than returned from SetColor.
+// The special value of 0 (the default) disables debouncing.
+func (c *Client) SetColorDebounce(debounce time.Duration) {
+	c.Lock()
+	c.colorDebounce = debounce
+	c.Unlock()
+}
+
+// GetColorDebounce returns the currently configured color debounce window for
+// lights on this client
+func (c *Client) GetColorDebounce() *time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	return &c.colorDebounce
+}
+
+// SetSkipRedundantWrites enables skipping SetColor requests for a color within
+// common.RedundantWriteTolerance of the last color known for the light, which
+// avoids needless traffic (and occasional flicker) when the same color is set
+// repeatedly, eg on a schedule.  The known color comes from the light cache, so
+// it may be stale if the light was changed externally, eg by the LIFX app,
+// without the client observing the change, in which case the request is
+// wrongly skipped.  Discard the cached color with Device.InvalidateCache or
+// InvalidateAllCaches if the light may have been changed externally, so that
+// the next request is sent.  Disabled by default, in which case only requests
+// for exactly the cached color are skipped.
+func (c *Client) SetSkipRedundantWrites(skip bool) {
+	c.Lock()
+	c.skipRedundantWrites = skip
+	c.Unlock()
+}
+
+// GetSkipRedundantWrites returns whether redundant color changes are skipped
+func (c *Client) GetSkipRedundantWrites() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.skipRedundantWrites
+}
+
+// SetRestoreOnPower enables restoring colors on power on.  When enabled, each
+// light remembers its color when the client powers it off, and when the client
+// next powers it on, the remembered color is applied before the light turns
+// on, rather than whatever color the light was left at, eg by another client.
+// Setting a color while the light is off replaces the remembered color.  Lights
+// powered off by other clients are not captured.  Disabled by default, which
+// preserves the standard LIFX behaviour.
+func (c *Client) SetRestoreOnPower(restore bool) {
+	c.Lock()
+	c.restoreOnPower = restore
+	c.Unlock()
+}
+
+// GetRestoreOnPower returns whether colors are restored on power on
+func (c *Client) GetRestoreOnPower() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.restoreOnPower
+}
+
+// SetColorMemory enables remembering colors on power off.  When enabled, each
+// light remembers the color it had when the client powers it off from on,
+// which is returned by Device.LastOnColor until the light is next powered off,
+// eg to restore it later or to build a scene.  Unlike SetRestoreOnPower, the
+// color is not applied when the light is powered on.  Colors are only held in
+// memory, and are lost when the process exits unless persisted by the caller,
+// eg alongside a device cache.  Lights powered off by other clients are not
+// captured.  Disabled by default.
+func (c *Client) SetColorMemory(memory bool) {
+	c.Lock()
+	c.colorMemory = memory
+	c.Unlock()
+}
+
+// GetColorMemory returns whether colors are remembered on power off
+func (c *Client) GetColorMemory() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.colorMemory
+}
+
+// SetMinBrightness sets a brightness floor, any color sent to a light with a
+// lower brightness is raised to the floor before it is sent, eg so that dimming
+// a light never leaves it dark.  This applies to SetColor and the methods built
+// on it, and to the zones of multizone lights, not to waveforms.  Powering a
+// light off is unaffected, the floor only applies to brightness.  The special
+// value of 0 (the default) disables the floor.
+func (c *Client) SetMinBrightness(brightness uint16) {
+	c.Lock()
+	c.minBrightness = brightness
+	c.Unlock()
+}
+
+// GetMinBrightness returns the currently configured brightness floor
+func (c *Client) GetMinBrightness() uint16 {
+	c.RLock()
+	defer c.RUnlock()
+	return c.minBrightness
+}
+
+// SetPrefetchDeviceInfo enables requesting the product and firmware information
+// of each device as soon as it is discovered, and serving it from the cache
+// thereafter, so that GetProductInfo and GetFirmwareVersion return without a
+// round trip, eg when rendering a dashboard of all devices.  This adds a
+// request per device to discovery.  A firmware upgrade is not observed until
+// the device cache is invalidated (see InvalidateAllCaches).  Disabled by
+// default, in which case the firmware version is requested on every call.
+// Only devices discovered after enabling are prefetched.
+func (c *Client) SetPrefetchDeviceInfo(prefetch bool) {
+	c.Lock()
+	c.prefetchDeviceInfo = prefetch
+	c.Unlock()
+}
+
+// GetPrefetchDeviceInfo returns whether device information is prefetched on
+// discovery
+func (c *Client) GetPrefetchDeviceInfo() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.prefetchDeviceInfo
+}
+
+// SetExpectedDeviceCount sets the number of devices that are expected to be
+// present on the network.  When set, GetDevices and GetLights return as soon as
+// this many devices have been discovered, rather than immediately returning
+// whatever is currently known, which avoids waiting out a fixed delay after
+// NewClient on networks where the device count is stable.  If fewer devices
+// respond before the client timeout, the devices discovered so far are
+// returned.  The special value of 0 (the default) disables waiting.
+func (c *Client) SetExpectedDeviceCount(count int) {
+	c.Lock()
+	c.expectedDeviceCount = count
+	c.Unlock()
+}
+
+// GetExpectedDeviceCount returns the currently configured expected device count
+func (c *Client) GetExpectedDeviceCount() int {
+	c.RLock()
+	defer c.RUnlock()
+	return c.expectedDeviceCount
+}
+
 // NewSubscription returns a new *common.Subscription for receiving events from
 // this client.
 func (c *Client) NewSubscription() (*common.Subscription, error) {
@@ -458,6 +1131,18 @@ func (c *Client) NewSubscription() (*common.Subscription, error) {
 	return sub, nil
 }
 
+// Subscribe returns a new *common.Subscription that only receives events from
+// this client matching types, which may be combined, eg
+// common.EventTypeNewDevice|common.EventTypeExpiredDevice
+func (c *Client) Subscribe(types common.EventType) (*common.Subscription, error) {
+	sub, err := c.NewSubscription()
+	if err != nil {
+		return nil, err
+	}
+	sub.SetFilter(types)
+	return sub, nil
+}
+
 // CloseSubscription is a callback for handling the closing of subscriptions.
 func (c *Client) CloseSubscription(sub *common.Subscription) error {
 	c.RLock()
@@ -473,8 +1158,15 @@ func (c *Client) CloseSubscription(sub *common.Subscription) error {
 	return nil
 }
 
-// Close signals the termination of this client, and cleans up resources
+// Close signals the termination of this client, cancels any in-flight
+// animations, and cleans up resources
 func (c *Client) Close() error {
+	c.CancelAnimations()
+	c.SetSignalSampling(0, 0)
+	if err := c.SetEventWebhook(``); err != nil {
+		return err
+	}
+
 	for _, sub := range c.subscriptions {
 		if err := sub.Close(); err != nil {
 			return err
@@ -485,10 +1177,11 @@ func (c *Client) Close() error {
 	defer c.Unlock()
 
 	select {
-	case <-c.quitChan:
+	case <-c.done:
 		common.Log.Warnf(`client already closed`)
 		return common.ErrClosed
 	default:
+		close(c.done)
 		close(c.quitChan)
 	}
 
@@ -532,11 +1225,22 @@ func (c *Client) subscribe() error {
 			case <-c.quitChan:
 				return
 			case event := <-events:
+				switch event := event.(type) {
+				case common.EventNewDevice:
+					c.labels.watch(event.Device, c.done)
+				case common.EventExpiredDevice:
+					// The LAN protocol marks unresponsive devices offline
+					// rather than expiring them, but the fakedevice protocol
+					// still expires devices removed from it
+					c.labels.remove(event.Device.ID())
+				}
 				switch event.(type) {
 				case common.EventNewDevice,
 					common.EventNewGroup,
 					common.EventNewLocation,
 					common.EventExpiredDevice,
+					common.EventOfflineDevice,
+					common.EventOnlineDevice,
 					common.EventExpiredGroup,
 					common.EventExpiredLocation:
 					if err = c.publish(event); err != nil {
@@ -1,7 +1,13 @@
 package golifx_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"time"
 
 	. "github.com/pdf/golifx"
@@ -11,6 +17,7 @@ import (
 	"github.com/onsi/gomega/format"
 
 	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/fakedevice"
 	"github.com/pdf/golifx/mocks"
 	"github.com/stretchr/testify/mock"
 )
@@ -63,6 +70,323 @@ var _ = Describe("Golifx", func() {
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	It("should return lights in a stable order", func() {
+		proto := fakedevice.NewProtocol(
+			fakedevice.NewLight(3, `three`, common.Color{}),
+			fakedevice.NewLight(1, `one`, common.Color{}),
+			fakedevice.NewDevice(4, `four`),
+			fakedevice.NewLight(2, `two`, common.Color{}),
+		)
+		fakeClient, err := NewClient(proto)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		for i := 0; i < 10; i++ {
+			lights, err := fakeClient.GetLights()
+			Expect(err).NotTo(HaveOccurred())
+			ids := make([]uint64, len(lights))
+			for j, light := range lights {
+				ids[j] = light.ID()
+			}
+			Expect(ids).To(Equal([]uint64{1, 2, 3}))
+		}
+	})
+
+	It("should stream discovered devices until cancelled", func(done Done) {
+		proto := fakedevice.NewProtocol(
+			fakedevice.NewLight(2, `two`, common.Color{}),
+			fakedevice.NewLight(1, `one`, common.Color{}),
+		)
+		fakeClient, err := NewClient(proto)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := fakeClient.DiscoverStream(ctx)
+		Expect((<-ch).ID()).To(Equal(uint64(1)))
+		Expect((<-ch).ID()).To(Equal(uint64(2)))
+		Expect(proto.AddDevice(fakedevice.NewLight(3, `three`, common.Color{}))).To(Succeed())
+		Expect((<-ch).ID()).To(Equal(uint64(3)))
+		cancel()
+		Eventually(ch).Should(BeClosed())
+		close(done)
+	})
+
+	It("should report transition progress", func() {
+		light := fakedevice.NewLight(1, `one`, common.Color{})
+		fakeClient, err := NewClient(fakedevice.NewProtocol(light))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		var fractions []float64
+		to := common.Color{Brightness: 65535, Kelvin: 3500}
+		Expect(fakeClient.Transition(context.Background(), light, to, 350*time.Millisecond, OnProgress(func(fraction float64) {
+			fractions = append(fractions, fraction)
+		}))).To(Succeed())
+		Expect(len(fractions)).To(BeNumerically(`>=`, 3))
+		for i := 1; i < len(fractions); i++ {
+			Expect(fractions[i]).To(BeNumerically(`>`, fractions[i-1]))
+		}
+		Expect(fractions[len(fractions)-1]).To(Equal(1.0))
+		Expect(light.CachedColor()).To(Equal(to))
+	})
+
+	It("should copy the color of a light to others", func() {
+		color := common.Color{Hue: 1000, Saturation: 2000, Brightness: 3000, Kelvin: 3500}
+		src := fakedevice.NewLight(1, `one`, color)
+		two := fakedevice.NewLight(2, `two`, common.Color{})
+		three := fakedevice.NewLight(3, `three`, common.Color{})
+		fakeClient, err := NewClient(fakedevice.NewProtocol(src, two, three))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		Expect(fakeClient.CopyColor(src, 0, two, three)).To(Succeed())
+		Expect(two.CachedColor()).To(Equal(color))
+		Expect(three.CachedColor()).To(Equal(color))
+		Expect(fakeClient.CopyColor(src, 0)).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should sample signal strength only when enabled", func() {
+		light := fakedevice.NewLight(1, `one`, common.Color{})
+		light.SetWifiInfo(common.WifiInfo{Signal: 1e-5})
+		fakeClient, err := NewClient(fakedevice.NewProtocol(light))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		Consistently(light.SignalHistory, 50*time.Millisecond).Should(BeEmpty())
+
+		fakeClient.SetSignalSampling(10*time.Millisecond, 0)
+		Expect(fakeClient.GetSignalHistoryLength()).To(Equal(common.DefaultSignalHistoryLength))
+		Eventually(func() int {
+			return len(light.SignalHistory())
+		}).Should(BeNumerically(`>=`, 3))
+		samples := light.SignalHistory()
+		Expect(samples[0].RSSI()).To(Equal(-50))
+		Expect(samples[1].Time).To(BeTemporally(`>`, samples[0].Time))
+
+		fakeClient.SetSignalSampling(0, 0)
+		Expect(fakeClient.GetSignalHistoryLength()).To(BeZero())
+		count := len(light.SignalHistory())
+		Consistently(func() int {
+			return len(light.SignalHistory())
+		}, 50*time.Millisecond).Should(Equal(count))
+	})
+
+	It("should sample transition frames along the easing", func() {
+		light := fakedevice.NewLight(1, `one`, common.Color{Kelvin: 3500})
+		fakeClient, err := NewClient(fakedevice.NewProtocol(light))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		from := light.CachedColor()
+		to := common.Color{Brightness: 65535, Kelvin: 3500}
+		var frames int
+		Expect(fakeClient.Transition(context.Background(), light, to, 350*time.Millisecond, Ease(common.EaseInCubic), OnProgress(func(fraction float64) {
+			if fraction < 1 {
+				frames++
+				expected := common.LerpColor(from, to, common.EaseInCubic(fraction)).Brightness
+				Expect(light.CachedColor().Brightness).To(BeNumerically(`~`, expected, 1))
+				Expect(light.CachedColor().Brightness).To(BeNumerically(`<`, common.LerpColor(from, to, fraction).Brightness))
+			}
+		}))).To(Succeed())
+		Expect(frames).To(BeNumerically(`>=`, 2))
+		Expect(light.CachedColor()).To(Equal(to))
+	})
+
+	It("should stop reporting progress once cancelled", func() {
+		light := fakedevice.NewLight(1, `one`, common.Color{})
+		fakeClient, err := NewClient(fakedevice.NewProtocol(light))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		var fractions []float64
+		ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+		defer cancel()
+		err = fakeClient.Transition(ctx, light, common.Color{Brightness: 65535}, time.Minute, OnProgress(func(fraction float64) {
+			fractions = append(fractions, fraction)
+		}))
+		Expect(err).To(Equal(context.DeadlineExceeded))
+		Expect(fractions).NotTo(BeEmpty())
+		Expect(fractions).NotTo(ContainElement(1.0))
+	})
+
+	It("should apply options on NewClient", func() {
+		proto := fakedevice.NewProtocol()
+		fakeClient, err := NewClient(proto,
+			WithTimeout(time.Second),
+			WithRetryInterval(2*time.Second),
+			WithCacheTTL(time.Minute),
+			WithExpectedDeviceCount(3),
+			WithRestoreOnPower(true),
+			WithSource(42),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		Expect(*fakeClient.GetTimeout()).To(Equal(time.Second))
+		Expect(*fakeClient.GetRetryInterval()).To(Equal(500 * time.Millisecond))
+		Expect(*fakeClient.GetCacheTTL()).To(Equal(time.Minute))
+		Expect(fakeClient.GetExpectedDeviceCount()).To(Equal(3))
+		Expect(fakeClient.GetRestoreOnPower()).To(BeTrue())
+	})
+
+	It("should fail NewClient on invalid options", func() {
+		_, err := NewClient(fakedevice.NewProtocol(), WithSource(0))
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+		_, err = NewClient(fakedevice.NewProtocol(), WithPort(-1))
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+		_, err = NewClient(fakedevice.NewProtocol(), WithInterface(`no-such-interface0`))
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should be ready once a device is discovered", func() {
+		proto := fakedevice.NewProtocol()
+		fakeClient, err := NewClient(proto)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		Expect(fakeClient.DeviceCount()).To(Equal(0))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		Expect(fakeClient.Ready(ctx)).To(Equal(context.DeadlineExceeded))
+
+		ready := make(chan error, 1)
+		go func() { ready <- fakeClient.Ready(context.Background()) }()
+		Expect(proto.AddDevice(fakedevice.NewLight(1, `one`, common.Color{}))).To(Succeed())
+		Eventually(ready).Should(Receive(BeNil()))
+		Expect(fakeClient.DeviceCount()).To(Equal(1))
+		Expect(fakeClient.Ready(context.Background())).To(Succeed())
+	})
+
+	It("should keep waiting for readiness when the discovery interval changes", func() {
+		proto := fakedevice.NewProtocol()
+		fakeClient, err := NewClient(proto)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		ready := make(chan error, 1)
+		go func() { ready <- fakeClient.Ready(context.Background()) }()
+		Expect(fakeClient.SetDiscoveryInterval(time.Hour)).To(Succeed())
+		Expect(fakeClient.SetDiscoveryInterval(time.Hour)).To(Succeed())
+		Consistently(ready, 50*time.Millisecond).ShouldNot(Receive())
+
+		Expect(proto.AddDevice(fakedevice.NewLight(1, `one`, common.Color{}))).To(Succeed())
+		Eventually(ready).Should(Receive(BeNil()))
+	})
+
+	It("should return ErrClosed from Ready when the client closes", func() {
+		fakeClient, err := NewClient(fakedevice.NewProtocol())
+		Expect(err).NotTo(HaveOccurred())
+
+		ready := make(chan error, 1)
+		go func() { ready <- fakeClient.Ready(context.Background()) }()
+		Consistently(ready, 10*time.Millisecond).ShouldNot(Receive())
+		Expect(fakeClient.Close()).To(Succeed())
+		Eventually(ready).Should(Receive(Equal(common.ErrClosed)))
+	})
+
+	It("should find lights by label pattern", func() {
+		proto := fakedevice.NewProtocol(
+			fakedevice.NewLight(3, `Office-2`, common.Color{}),
+			fakedevice.NewLight(1, `Office-1`, common.Color{}),
+			fakedevice.NewLight(2, `Kitchen`, common.Color{}),
+			fakedevice.NewDevice(4, `Office-3`),
+		)
+		fakeClient, err := NewClient(proto)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+
+		lights, err := fakeClient.GetLightsByLabelPattern(`Office-*`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lights).To(HaveLen(2))
+		Expect(lights[0].ID()).To(Equal(uint64(1)))
+		Expect(lights[1].ID()).To(Equal(uint64(3)))
+
+		_, err = fakeClient.GetLightsByLabelPattern(`Garage*`)
+		Expect(err).To(Equal(common.ErrNotFound))
+		_, err = fakeClient.GetLightsByLabelPattern(`Office-[`)
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should index devices by label", func() {
+		proto := fakedevice.NewProtocol()
+		fakeClient, err := NewClient(proto)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = fakeClient.Close() }()
+		fakeClient.SetTimeout(10 * time.Millisecond)
+
+		kitchen := fakedevice.NewLight(2, `kitchen`, common.Color{})
+		Expect(proto.AddDevice(kitchen)).To(Succeed())
+		Expect(proto.AddDevice(fakedevice.NewLight(3, `kitchen`, common.Color{}))).To(Succeed())
+		for i := 0; i < 10; i++ {
+			light, err := fakeClient.GetLightByLabel(`kitchen`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(light.ID()).To(Equal(uint64(2)))
+		}
+
+		Expect(kitchen.SetLabel(`pantry`)).To(Succeed())
+		light, err := fakeClient.GetLightByLabel(`kitchen`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.ID()).To(Equal(uint64(3)))
+		light, err = fakeClient.GetLightByLabel(`pantry`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.ID()).To(Equal(uint64(2)))
+
+		Expect(proto.RemoveDevice(2)).To(Succeed())
+		_, err = fakeClient.GetLightByLabel(`pantry`)
+		Expect(err).To(Equal(common.ErrNotFound))
+	})
+
+	It("should close once after changing the discovery interval", func() {
+		fakeClient, err := NewClient(fakedevice.NewProtocol(fakedevice.NewLight(2, `kitchen`, common.Color{})))
+		Expect(err).NotTo(HaveOccurred())
+
+		// Restarting discovery signals the previous loop to stop, which must
+		// not be mistaken for the client closing
+		Expect(fakeClient.SetDiscoveryInterval(time.Hour)).To(Succeed())
+		Expect(fakeClient.SetDiscoveryInterval(time.Hour)).To(Succeed())
+		Expect(fakeClient.Close()).To(Succeed())
+		Expect(fakeClient.Close()).To(Equal(common.ErrClosed))
+	})
+
+	It("should share one client between handles", func() {
+		created := 0
+		newProtocol := func() common.Protocol {
+			created++
+			return fakedevice.NewProtocol(fakedevice.NewLight(1, `one`, common.Color{}))
+		}
+		first, err := SharedClient(newProtocol)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := SharedClient(newProtocol)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(Equal(1))
+		Expect(second.Client).To(BeIdenticalTo(first.Client))
+
+		sub, err := first.NewSubscription()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.Close()).To(Succeed())
+		Expect(first.Close()).To(Equal(common.ErrClosed))
+		Eventually(sub.Events()).Should(BeClosed())
+
+		lights, err := second.GetLights()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lights).To(HaveLen(1))
+		Expect(second.Close()).To(Succeed())
+
+		third, err := SharedClient(newProtocol)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(Equal(2))
+		Expect(third.Close()).To(Succeed())
+	})
+
+	It("should implement common.Client", func() {
+		var _ common.Client = new(Client)
+	})
+
+	It("should implement common.ClientConfig", func() {
+		var _ common.ClientConfig = new(Client)
+	})
+
 	Describe("Client", func() {
 		BeforeEach(func() {
 			mockProtocol = new(mocks.Protocol)
@@ -101,6 +425,62 @@ var _ = Describe("Golifx", func() {
 			Expect(client.GetRetryInterval()).To(Equal(&interval))
 		})
 
+		It("should update the poll interval", func() {
+			interval := 50 * time.Millisecond
+			client.SetPollInterval(interval)
+			Expect(client.GetPollInterval()).To(Equal(&interval))
+		})
+
+		It("should update the cache TTL", func() {
+			ttl := 5 * time.Second
+			client.SetCacheTTL(ttl)
+			Expect(client.GetCacheTTL()).To(Equal(&ttl))
+		})
+
+		It("should update the color debounce", func() {
+			debounce := 100 * time.Millisecond
+			client.SetColorDebounce(debounce)
+			Expect(client.GetColorDebounce()).To(Equal(&debounce))
+		})
+
+		It("should update skipping of redundant writes", func() {
+			Expect(client.GetSkipRedundantWrites()).To(BeFalse())
+			client.SetSkipRedundantWrites(true)
+			Expect(client.GetSkipRedundantWrites()).To(BeTrue())
+		})
+
+		It("should update restoring colors on power", func() {
+			Expect(client.GetRestoreOnPower()).To(BeFalse())
+			client.SetRestoreOnPower(true)
+			Expect(client.GetRestoreOnPower()).To(BeTrue())
+		})
+
+		It("should update the expected device count", func() {
+			client.SetExpectedDeviceCount(3)
+			Expect(client.GetExpectedDeviceCount()).To(Equal(3))
+		})
+
+		It("should update the tracer", func() {
+			Expect(client.GetTracer()).To(BeNil())
+			buf := new(bytes.Buffer)
+			client.SetTracer(buf)
+			_, err := client.GetTracer().Write([]byte(`trace`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(Equal(`trace`))
+			client.SetTracer(nil)
+			Expect(client.GetTracer()).To(BeNil())
+		})
+
+		It("should update the decode error handler", func() {
+			Expect(client.GetDecodeErrorHandler()).To(BeNil())
+			called := false
+			client.OnDecodeError(func([]byte, error) { called = true })
+			client.GetDecodeErrorHandler()(nil, common.ErrProtocol)
+			Expect(called).To(BeTrue())
+			client.OnDecodeError(nil)
+			Expect(client.GetDecodeErrorHandler()).To(BeNil())
+		})
+
 		It("should set the retry to half the timeout if it's >= the timeout", func() {
 			timeout := 10 * time.Second
 			halfTimeout := timeout / 2
@@ -130,6 +510,21 @@ var _ = Describe("Golifx", func() {
 			mockProtocol.AssertNumberOfCalls(GinkgoT(), `Discover`, 3)
 		})
 
+		It("should send SetPort to the protocol", func() {
+			mockProtocol.On(`SetPort`, 56701).Return(nil).Once()
+			Expect(client.SetPort(56701)).To(Succeed())
+		})
+
+		It("should send SetBroadcastAddress to the protocol", func() {
+			mockProtocol.On(`SetBroadcastAddress`, `192.168.1.255`).Return(nil).Once()
+			Expect(client.SetBroadcastAddress(`192.168.1.255`)).To(Succeed())
+		})
+
+		It("should send AddBroadcastAddress to the protocol", func() {
+			mockProtocol.On(`AddBroadcastAddress`, `10.0.20.0/24`).Return(nil).Once()
+			Expect(client.AddBroadcastAddress(`10.0.20.0/24`)).To(Succeed())
+		})
+
 		It("should send SetPower to the protocol", func() {
 			mockProtocol.On(`SetPower`, true).Return(nil).Once()
 			Expect(client.SetPower(true)).To(Succeed())
@@ -193,6 +588,72 @@ var _ = Describe("Golifx", func() {
 			Expect(client.Close()).NotTo(Succeed())
 		})
 
+		It("should cancel in-flight animations", func(done Done) {
+			mockLight.On(`GetColor`).Return(common.Color{}, nil).Once()
+			mockLight.On(`SetColor`, mock.Anything, mock.Anything).Return(nil)
+			errChan := make(chan error)
+			go func() {
+				errChan <- client.Transition(context.Background(), mockLight, common.Color{Brightness: 65535}, time.Minute)
+			}()
+			time.Sleep(250 * time.Millisecond)
+			client.CancelAnimations()
+			Expect(<-errChan).To(Equal(context.Canceled))
+			close(done)
+		})
+
+		It("should cancel in-flight animations on close", func(done Done) {
+			mockLight.On(`GetColor`).Return(common.Color{}, nil).Once()
+			mockLight.On(`SetColor`, mock.Anything, mock.Anything).Return(nil)
+			mockProtocol.On(`Close`).Return(nil).Once()
+			errChan := make(chan error)
+			go func() {
+				errChan <- client.Transition(context.Background(), mockLight, common.Color{Brightness: 65535}, time.Minute)
+			}()
+			time.Sleep(250 * time.Millisecond)
+			Expect(client.Close()).To(Succeed())
+			Expect(<-errChan).To(Equal(context.Canceled))
+			close(done)
+		})
+
+		It("should cycle hue until cancelled", func(done Done) {
+			base := common.Color{Saturation: 65535, Brightness: 32768, Kelvin: 3500}
+			mockLight.On(`GetColor`).Return(base, nil).Once()
+			mockLight.On(`SetColor`, mock.MatchedBy(func(c common.Color) bool {
+				return c.Saturation == base.Saturation && c.Brightness == base.Brightness && c.Kelvin == base.Kelvin
+			}), mock.Anything).Return(nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			errChan := make(chan error)
+			go func() {
+				errChan <- client.CycleHue(ctx, mockLight, time.Second)
+			}()
+			time.Sleep(250 * time.Millisecond)
+			cancel()
+			Expect(<-errChan).To(Equal(context.Canceled))
+			mockLight.AssertCalled(GinkgoT(), `SetColor`, mock.Anything, mock.Anything)
+			close(done)
+		})
+
+		It("should power a light off after PowerOnFor", func() {
+			light := fakedevice.NewLight(1, `light`, common.Color{})
+			start := time.Now()
+			Expect(client.PowerOnFor(context.Background(), light, 50*time.Millisecond)).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+			Expect(light.CachedPower()).To(BeFalse())
+		})
+
+		It("should leave a light on when PowerOnFor is cancelled", func() {
+			light := fakedevice.NewLight(1, `light`, common.Color{})
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			Expect(client.PowerOnFor(ctx, light, time.Minute)).To(Equal(context.DeadlineExceeded))
+			Expect(light.CachedPower()).To(BeTrue())
+			Expect(client.PowerOnFor(ctx, light, 0)).To(Equal(common.ErrInvalidArgument))
+		})
+
+		It("should reject a non-positive hue cycle period", func() {
+			Expect(client.CycleHue(context.Background(), mockLight, 0)).To(Equal(common.ErrInvalidArgument))
+		})
+
 		It("should return an error on double-close", func() {
 			mockProtocol.On(`Close`).Return(nil).Once()
 			Expect(client.Close()).To(Succeed())
@@ -226,7 +687,10 @@ var _ = Describe("Golifx", func() {
 		})
 
 		It("should publish an EventNewDevice on discovering a device", func(done Done) {
-			mockDevice.On(`ID`).Return(deviceID).Once()
+			mockDevice.On(`ID`).Return(deviceID)
+			// The client follows label changes of new devices
+			mockDevice.SubscriptionTarget.On(`NewSubscription`).Return(common.NewSubscription(mockDevice), nil).Once()
+			mockDevice.On(`GetLabel`).Return(deviceLabel, nil).Once()
 			event := common.EventNewDevice{Device: mockDevice}
 			ch := make(chan interface{})
 			go func() {
@@ -238,6 +702,181 @@ var _ = Describe("Golifx", func() {
 			close(done)
 		})
 
+		It("should publish an EventOfflineDevice when a device goes offline", func(done Done) {
+			event := common.EventOfflineDevice{Device: mockDevice}
+			ch := make(chan interface{})
+			go func() {
+				evt := <-clientSubscription.Events()
+				ch <- evt
+			}()
+			_ = protocolSubscription.Write(event)
+			Expect(<-ch).To(Equal(event))
+			close(done)
+		})
+
+		It("should only deliver events matching a subscription's filter", func(done Done) {
+			sub, err := client.Subscribe(common.EventTypeOfflineDevice)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() { _ = sub.Close() }()
+			Expect(sub.Filter()).To(Equal(common.EventTypeOfflineDevice))
+			_ = protocolSubscription.Write(common.EventOnlineDevice{Device: mockDevice})
+			event := common.EventOfflineDevice{Device: mockDevice}
+			_ = protocolSubscription.Write(event)
+			Expect(<-sub.Events()).To(Equal(event))
+			close(done)
+		})
+
+		It("should reject an invalid event webhook URL", func() {
+			Expect(client.SetEventWebhook(`ftp://example.com`)).To(Equal(common.ErrInvalidArgument))
+		})
+
+		It("should POST events to the event webhook", func(done Done) {
+			bodies := make(chan common.DeviceEvent, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var e common.DeviceEvent
+				Expect(json.NewDecoder(r.Body).Decode(&e)).To(Succeed())
+				bodies <- e
+			}))
+			defer server.Close()
+
+			mockProtocol.On(`GetDevices`).Return(nil, common.ErrNotFound).Once()
+			mockDevice.On(`ID`).Return(deviceID)
+			mockDevice.On(`MAC`).Return(`d0:73:d5:01:02:03`)
+			Expect(client.SetEventWebhook(server.URL)).To(Succeed())
+			_ = protocolSubscription.Write(common.EventOfflineDevice{Device: mockDevice})
+			e := <-bodies
+			Expect(e.Type).To(Equal(common.DeviceEventOffline))
+			Expect(e.DeviceID).To(Equal(deviceID))
+			Expect(e.MAC).To(Equal(`d0:73:d5:01:02:03`))
+			Expect(client.SetEventWebhook(``)).To(Succeed())
+			close(done)
+		})
+
+		It("should resolve the label of new devices when delivering to the event webhook", func(done Done) {
+			bodies := make(chan common.DeviceEvent, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var e common.DeviceEvent
+				Expect(json.NewDecoder(r.Body).Decode(&e)).To(Succeed())
+				bodies <- e
+			}))
+			defer server.Close()
+
+			mockProtocol.On(`GetDevices`).Return(nil, common.ErrNotFound).Once()
+			mockDevice.On(`ID`).Return(deviceID)
+			mockDevice.On(`MAC`).Return(`d0:73:d5:01:02:03`)
+			mockDevice.On(`GetLabel`).Return(deviceLabel, nil)
+			mockDevice.SubscriptionTarget.On(`NewSubscription`).Return(common.NewSubscription(mockDevice), nil)
+			mockDevice.SubscriptionTarget.On(`CloseSubscription`, mock.Anything).Return(nil)
+			Expect(client.SetEventWebhook(server.URL)).To(Succeed())
+			_ = protocolSubscription.Write(common.EventNewDevice{Device: mockDevice})
+			e := <-bodies
+			Expect(e.Type).To(Equal(common.DeviceEventNew))
+			Expect(e.Label).To(Equal(deviceLabel))
+			Expect(client.SetEventWebhook(``)).To(Succeed())
+			close(done)
+		})
+
+		It("should retry failed event webhook deliveries with backoff", func() {
+			defer SetWebhookBackoff(20 * time.Millisecond)()
+			attempts := make(chan time.Time, WebhookMaxAttempts)
+			var count int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts <- time.Now()
+				if atomic.AddInt32(&count, 1) < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			mockProtocol.On(`GetDevices`).Return(nil, common.ErrNotFound).Once()
+			mockDevice.On(`ID`).Return(deviceID)
+			mockDevice.On(`MAC`).Return(`d0:73:d5:01:02:03`)
+			Expect(client.SetEventWebhook(server.URL)).To(Succeed())
+			_ = protocolSubscription.Write(common.EventOfflineDevice{Device: mockDevice})
+			var first, second, third time.Time
+			Eventually(attempts).Should(Receive(&first))
+			Eventually(attempts).Should(Receive(&second))
+			Eventually(attempts).Should(Receive(&third))
+			Expect(second.Sub(first)).To(BeNumerically(`>=`, 20*time.Millisecond))
+			Expect(third.Sub(second)).To(BeNumerically(`>=`, 40*time.Millisecond))
+			Consistently(attempts, 200*time.Millisecond).ShouldNot(Receive())
+			Expect(client.SetEventWebhook(``)).To(Succeed())
+		})
+
+		It("should drop events after WebhookMaxAttempts failed deliveries", func() {
+			defer SetWebhookBackoff(time.Millisecond)()
+			types := make(chan string, WebhookMaxAttempts+1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var e common.DeviceEvent
+				Expect(json.NewDecoder(r.Body).Decode(&e)).To(Succeed())
+				types <- e.Type
+				if e.Type == common.DeviceEventOffline {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			defer server.Close()
+
+			mockProtocol.On(`GetDevices`).Return(nil, common.ErrNotFound).Once()
+			mockDevice.On(`ID`).Return(deviceID)
+			mockDevice.On(`MAC`).Return(`d0:73:d5:01:02:03`)
+			Expect(client.SetEventWebhook(server.URL)).To(Succeed())
+			_ = protocolSubscription.Write(common.EventOfflineDevice{Device: mockDevice})
+			_ = protocolSubscription.Write(common.EventOnlineDevice{Device: mockDevice})
+			for i := 0; i < WebhookMaxAttempts; i++ {
+				Eventually(types).Should(Receive(Equal(common.DeviceEventOffline)))
+			}
+			Eventually(types).Should(Receive(Equal(common.DeviceEventOnline)))
+			Expect(client.SetEventWebhook(``)).To(Succeed())
+		})
+
+		It("should drop the oldest event when the event webhook queue is full", func(done Done) {
+			release := make(chan struct{})
+			ids := make(chan uint64, WebhookQueueSize+2)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var e common.DeviceEvent
+				Expect(json.NewDecoder(r.Body).Decode(&e)).To(Succeed())
+				ids <- e.DeviceID
+				<-release
+			}))
+			defer server.Close()
+
+			// Drain the unused client subscription, so publishing does not stall
+			go func(sub *common.Subscription) {
+				for range sub.Events() {
+				}
+			}(clientSubscription)
+			mockProtocol.On(`GetDevices`).Return(nil, common.ErrNotFound).Once()
+			offline := func(id uint64) common.EventOfflineDevice {
+				dev := new(mocks.Device)
+				dev.On(`ID`).Return(id)
+				dev.On(`MAC`).Return(`d0:73:d5:01:02:03`)
+				return common.EventOfflineDevice{Device: dev}
+			}
+			Expect(client.SetEventWebhook(server.URL)).To(Succeed())
+			// Stall delivery on the first event, then overflow the queue by one
+			_ = protocolSubscription.Write(offline(0))
+			Expect(<-ids).To(Equal(uint64(0)))
+			last := uint64(WebhookQueueSize + 1)
+			for id := uint64(1); id <= last; id++ {
+				_ = protocolSubscription.Write(offline(id))
+			}
+			Eventually(func() uint64 {
+				queue := WebhookQueue(client)
+				if len(queue) == 0 {
+					return 0
+				}
+				return queue[len(queue)-1].DeviceID
+			}).Should(Equal(last))
+			Expect(WebhookQueue(client)).To(HaveLen(WebhookQueueSize))
+
+			close(release)
+			for id := uint64(2); id <= last; id++ {
+				Expect(<-ids).To(Equal(id))
+			}
+			Expect(client.SetEventWebhook(``)).To(Succeed())
+			close(done)
+		}, 10)
+
 		Context("with locations", func() {
 
 			Context("finding a location", func() {
@@ -620,6 +1259,11 @@ var _ = Describe("Golifx", func() {
 			})
 
 			Context("with lights", func() {
+				BeforeEach(func() {
+					// Results are sorted by ID
+					mockDevice.On(`ID`).Return(deviceID)
+					mockLight.Device.On(`ID`).Return(lightID)
+				})
 
 				It("should return only lights", func() {
 					mockProtocol.On(`GetDevices`).Return([]common.Device{mockDevice, mockLight}, nil).Once()
@@ -628,6 +1272,62 @@ var _ = Describe("Golifx", func() {
 					Expect(err).NotTo(HaveOccurred())
 				})
 
+				It("should retry discovery when no lights are found", func() {
+					mockProtocol.On(`GetDevices`).Return(nil, common.ErrNotFound).Once()
+					mockProtocol.On(`Discover`).Return(nil).Once()
+					mockProtocol.On(`GetDevices`).Return([]common.Device{mockLight}, nil).Once()
+					lights, err := client.GetLightsWithRetry(context.Background(), 2)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lights).To(Equal([]common.Light{mockLight}))
+				})
+
+				It("should stop retrying discovery when the context is done", func() {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					mockProtocol.On(`GetDevices`).Return(nil, common.ErrNotFound).Once()
+					mockProtocol.On(`Discover`).Return(nil).Once()
+					_, err := client.GetLightsWithRetry(ctx, 3)
+					Expect(err).To(Equal(context.Canceled))
+				})
+
+				It("should wait for the expected device count", func(done Done) {
+					client.SetExpectedDeviceCount(2)
+					protocolSubscription = common.NewSubscription(mockProtocol)
+					mockProtocol.SubscriptionTarget.On(`NewSubscription`).Return(protocolSubscription, nil).Once()
+					mockProtocol.SubscriptionTarget.On(`CloseSubscription`, protocolSubscription).Return(nil).Once()
+					mockProtocol.On(`GetDevices`).Return([]common.Device{mockDevice}, nil).Once()
+					mockProtocol.On(`GetDevices`).Return([]common.Device{mockDevice, mockLight}, nil).Once()
+					_ = protocolSubscription.Write(common.EventNewDevice{Device: mockLight})
+					lights, err := client.GetLights()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lights).To(Equal([]common.Light{mockLight}))
+					close(done)
+				})
+
+				It("should return known devices when the expected device count is not reached", func() {
+					client.SetExpectedDeviceCount(3)
+					protocolSubscription = common.NewSubscription(mockProtocol)
+					mockProtocol.SubscriptionTarget.On(`NewSubscription`).Return(protocolSubscription, nil).Once()
+					mockProtocol.SubscriptionTarget.On(`CloseSubscription`, protocolSubscription).Return(nil).Once()
+					mockProtocol.On(`GetDevices`).Return([]common.Device{mockDevice, mockLight}, nil).Once()
+					devices, err := client.GetDevices()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(devices).To(HaveLen(2))
+				})
+
+				It("should return lights known when the context is cancelled", func(done Done) {
+					protocolSubscription = common.NewSubscription(mockProtocol)
+					mockProtocol.SubscriptionTarget.On(`NewSubscription`).Return(protocolSubscription, nil).Once()
+					mockProtocol.SubscriptionTarget.On(`CloseSubscription`, protocolSubscription).Return(nil).Once()
+					mockProtocol.On(`GetDevices`).Return([]common.Device{mockDevice, mockLight}, nil).Once()
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					lights, err := client.GetLightsContext(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lights).To(Equal([]common.Light{mockLight}))
+					close(done)
+				})
+
 				It("should return it by ID when known", func() {
 					mockProtocol.On(`GetDevice`, lightID).Return(mockLight, nil).Once()
 					light, err := client.GetLightByID(lightID)
@@ -635,6 +1335,34 @@ var _ = Describe("Golifx", func() {
 					Expect(err).NotTo(HaveOccurred())
 				})
 
+				It("should return it by MAC when known", func() {
+					mockProtocol.On(`GetDevice`, lightID).Return(mockLight, nil).Once()
+					light, err := client.GetLightByMAC(`2e:16:00:00:00:00`)
+					Expect(light).To(Equal(mockLight))
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("should arrange lights into rooms", func() {
+					mockProtocol.On(`GetDevices`).Return([]common.Device{mockDevice, mockLight}, nil).Once()
+					mockProtocol.On(`GetGroups`).Return([]common.Group{mockGroup}, nil).Once()
+					mockProtocol.On(`GetLocations`).Return(nil, common.ErrNotFound).Once()
+					mockGroup.On(`Devices`).Return([]common.Device{mockLight}).Once()
+					mockGroup.On(`GetLabel`).Return(groupLabel)
+					mockLight.Device.On(`ID`).Return(lightID)
+					rooms, err := client.GetRooms()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rooms).To(HaveLen(1))
+					Expect(rooms[0].Label()).To(Equal(groupLabel))
+					Expect(rooms[0].Location).To(BeNil())
+					Expect(rooms[0].Lights).To(Equal([]common.Light{mockLight}))
+				})
+
+				It("should reject an invalid MAC", func() {
+					light, err := client.GetLightByMAC(`invalid`)
+					Expect(light).To(BeNil())
+					Expect(err).To(Equal(common.ErrInvalidArgument))
+				})
+
 				It("should not return a known device by ID if it is not a light", func() {
 					mockProtocol.On(`GetDevice`, deviceID).Return(mockDevice, nil).Once()
 					light, err := client.GetLightByID(deviceID)
@@ -0,0 +1,823 @@
+package cloud
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+const (
+	// DefaultTimeout is the default duration after which API requests time
+	// out.  State changes wait for the light to respond via the cloud, so this
+	// is longer than the LAN default.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Client implements common.Client using the LIFX HTTP API.
+//
+// Lights are discovered by listing all lights associated with the account,
+// only lights that are currently connected to the cloud are reported.  By
+// default, every lookup makes an API request, use SetCacheTTL to serve
+// results from the cache when it is fresh, and avoid exceeding the API rate
+// limit.
+type Client struct {
+	token         string
+	endpoint      string
+	httpClient    *http.Client
+	timeout       time.Duration
+	pollInterval  time.Duration
+	cacheTTL      time.Duration
+	skipRedundant bool
+	colorMemory   bool
+	minBrightness uint16
+	tracer        io.Writer
+	spanHook      common.SpanHook
+	decodeError   common.DecodeErrorHandler
+	lights        map[uint64]*Light
+	groups        map[string]*Group
+	locations     map[string]*Location
+	refreshed     time.Time
+	stopDiscovery chan struct{}
+	quitChan      chan struct{}
+	publisher
+	sync.RWMutex
+}
+
+// NewClient returns a pointer to a new Client that authenticates to the LIFX
+// HTTP API using the personal access token.  Tokens may be generated at
+// https://cloud.lifx.com/settings.  No requests are made until the client is
+// used.
+func NewClient(token string) *Client {
+	return &Client{
+		token:        token,
+		endpoint:     DefaultEndpoint,
+		httpClient:   &http.Client{},
+		timeout:      DefaultTimeout,
+		pollInterval: common.DefaultPollInterval,
+		lights:       make(map[uint64]*Light),
+		groups:       make(map[string]*Group),
+		locations:    make(map[string]*Location),
+		quitChan:     make(chan struct{}),
+	}
+}
+
+// SetEndpoint sets the base URL for API requests, defaulting to
+// DefaultEndpoint.  This is intended for testing, or routing requests via a
+// proxy.
+func (c *Client) SetEndpoint(endpoint string) {
+	c.Lock()
+	c.endpoint = endpoint
+	c.Unlock()
+}
+
+// GetLocations returns a slice of all locations known to the client, or
+// common.ErrNotFound if no locations are currently known.
+func (c *Client) GetLocations() ([]common.Location, error) {
+	if err := c.refresh(false); err != nil {
+		return nil, err
+	}
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.locations) == 0 {
+		return nil, common.ErrNotFound
+	}
+	locations := make([]common.Location, 0, len(c.locations))
+	for _, location := range c.locations {
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
+
+// GetLocationByID looks up a location by its `id` and returns a
+// common.Location, or common.ErrNotFound if the location is not known.
+func (c *Client) GetLocationByID(id string) (common.Location, error) {
+	if err := c.refresh(false); err != nil {
+		return nil, err
+	}
+	c.RLock()
+	defer c.RUnlock()
+	location, ok := c.locations[id]
+	if !ok {
+		return nil, common.ErrNotFound
+	}
+
+	return location, nil
+}
+
+// GetLocationByLabel looks up a location by its `label` and returns a
+// common.Location, or common.ErrNotFound if the location is not known.
+func (c *Client) GetLocationByLabel(label string) (common.Location, error) {
+	locations, err := c.GetLocations()
+	if err != nil {
+		return nil, err
+	}
+	for _, location := range locations {
+		if location.GetLabel() == label {
+			return location, nil
+		}
+	}
+
+	return nil, common.ErrNotFound
+}
+
+// GetGroups returns a slice of all groups known to the client, or
+// common.ErrNotFound if no groups are currently known.
+func (c *Client) GetGroups() ([]common.Group, error) {
+	if err := c.refresh(false); err != nil {
+		return nil, err
+	}
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.groups) == 0 {
+		return nil, common.ErrNotFound
+	}
+	groups := make([]common.Group, 0, len(c.groups))
+	for _, group := range c.groups {
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// GetGroupByID looks up a group by its `id` and returns a common.Group, or
+// common.ErrNotFound if the group is not known.
+func (c *Client) GetGroupByID(id string) (common.Group, error) {
+	if err := c.refresh(false); err != nil {
+		return nil, err
+	}
+	c.RLock()
+	defer c.RUnlock()
+	group, ok := c.groups[id]
+	if !ok {
+		return nil, common.ErrNotFound
+	}
+
+	return group, nil
+}
+
+// GetGroupByLabel looks up a group by its `label` and returns a common.Group,
+// or common.ErrNotFound if the group is not known.
+func (c *Client) GetGroupByLabel(label string) (common.Group, error) {
+	groups, err := c.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.GetLabel() == label {
+			return group, nil
+		}
+	}
+
+	return nil, common.ErrNotFound
+}
+
+// GetDevices returns a slice of all devices known to the client, or
+// common.ErrNotFound if no devices are currently known.
+func (c *Client) GetDevices() ([]common.Device, error) {
+	lights, err := c.GetLights()
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]common.Device, len(lights))
+	for i, light := range lights {
+		devices[i] = light
+	}
+
+	return devices, nil
+}
+
+// DeviceCount returns the number of lights known from the last refresh,
+// without making a request
+func (c *Client) DeviceCount() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.lights)
+}
+
+// Ready blocks until at least one light is known, refreshing the lights from
+// the HTTP API every poll interval until then.  Returns the context error if
+// ctx is done first.
+func (c *Client) Ready(ctx context.Context) error {
+	for {
+		if c.DeviceCount() > 0 {
+			return nil
+		}
+		if err := c.refreshContext(ctx, true); err != nil {
+			return err
+		}
+		if c.DeviceCount() > 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.getPollInterval()):
+		}
+	}
+}
+
+// GetDeviceByID looks up a device by its `id` and returns a common.Device, or
+// common.ErrNotFound if the device is not known.
+func (c *Client) GetDeviceByID(id uint64) (common.Device, error) {
+	return c.GetLightByID(id)
+}
+
+// GetDeviceByLabel looks up a device by its `label` and returns a
+// common.Device, or common.ErrNotFound if the device is not known.
+func (c *Client) GetDeviceByLabel(label string) (common.Device, error) {
+	return c.GetLightByLabel(label)
+}
+
+// GetLights returns a slice of all lights known to the client, ordered by ID,
+// or common.ErrNotFound if no lights are currently known.
+func (c *Client) GetLights() ([]common.Light, error) {
+	return c.GetLightsContext(context.Background())
+}
+
+// GetLightsPaged calls fn with the known lights in batches of up to batchSize,
+// ordered by ascending light ID.  The HTTP API reports the state of all lights
+// in a single response, so batches carry the state from the last refresh
+// rather than querying each light.  If fn returns an error, iteration stops
+// and the error is returned.  Returns common.ErrNotFound if no lights are
+// known, or common.ErrInvalidArgument if batchSize is not positive.
+func (c *Client) GetLightsPaged(batchSize int, fn func([]common.Light) error) error {
+	if batchSize <= 0 {
+		return common.ErrInvalidArgument
+	}
+	lights, err := c.GetLights()
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(lights); start += batchSize {
+		end := start + batchSize
+		if end > len(lights) {
+			end = len(lights)
+		}
+		if err := fn(lights[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetRooms returns the known lights arranged into rooms by the location and
+// group they belong to, see common.Room.  Lights that report no group are
+// placed in a room labelled common.UngroupedRoomLabel.  Rooms are a snapshot,
+// call GetRooms again to include newly discovered lights.  Returns
+// common.ErrNotFound if no lights are currently known.
+func (c *Client) GetRooms() ([]common.Room, error) {
+	lights, err := c.GetLights()
+	if err != nil {
+		return nil, err
+	}
+	// Missing groups or locations just leave lights ungrouped
+	groups, _ := c.GetGroups()
+	locations, _ := c.GetLocations()
+
+	return common.NewRooms(lights, groups, locations), nil
+}
+
+// Discover refreshes the lights from the HTTP API immediately, bypassing the
+// cache
+func (c *Client) Discover() error {
+	return c.refresh(true)
+}
+
+// DiscoverStream refreshes the lights from the HTTP API, and emits each light on
+// the returned channel, which is closed once all lights are emitted or ctx is
+// done
+func (c *Client) DiscoverStream(ctx context.Context) <-chan common.Device {
+	ch := make(chan common.Device)
+	go func() {
+		defer close(ch)
+		if err := c.refresh(true); err != nil {
+			common.Log.Warnf("Failed refreshing lights for discovery stream: %v", err)
+			return
+		}
+		for _, l := range c.lightsWhere(func(*Light) bool { return true }) {
+			select {
+			case ch <- l:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// GetLightsContext behaves as GetLights, the HTTP API reports all lights in a
+// single response so there is nothing further to wait for.  The request is
+// spanned as a child of the span in ctx.  Returns the context error if ctx is
+// already done.
+func (c *Client) GetLightsContext(ctx context.Context) ([]common.Light, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := c.refreshContext(ctx, false); err != nil {
+		return nil, err
+	}
+	lights := c.lightsWhere(func(*Light) bool { return true })
+	if len(lights) == 0 {
+		return nil, common.ErrNotFound
+	}
+
+	return asLights(lights), nil
+}
+
+// GetLightsWithRetry behaves as GetLights, but if no lights are known it waits
+// for the poll interval and requests the lights again, up to a total of
+// `attempts` tries.  Returns common.ErrNotFound if no lights are found after
+// all attempts, or the context error if ctx is done while waiting.
+func (c *Client) GetLightsWithRetry(ctx context.Context, attempts int) ([]common.Light, error) {
+	for attempt := 1; ; attempt++ {
+		lights, err := c.GetLights()
+		if err != common.ErrNotFound || attempt >= attempts {
+			return lights, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.getPollInterval()):
+		}
+		if err := c.refresh(true); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// GetLightByID looks up a light by its `id` and returns a common.Light, or
+// common.ErrNotFound if the light is not known.
+func (c *Client) GetLightByID(id uint64) (common.Light, error) {
+	if err := c.refresh(false); err != nil {
+		return nil, err
+	}
+	c.RLock()
+	defer c.RUnlock()
+	light, ok := c.lights[id]
+	if !ok {
+		return nil, common.ErrNotFound
+	}
+
+	return light, nil
+}
+
+// GetLightByMAC looks up a light by its MAC address, in the `aa:bb:cc:dd:ee:ff`
+// form, and returns a common.Light, or common.ErrNotFound if the light is not
+// known.
+func (c *Client) GetLightByMAC(mac string) (common.Light, error) {
+	id, err := common.MACToID(mac)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetLightByID(id)
+}
+
+// GetLightByLabel looks up a light by its `label` and returns a common.Light,
+// or common.ErrNotFound if the light is not known.
+func (c *Client) GetLightByLabel(label string) (common.Light, error) {
+	if err := c.refresh(false); err != nil {
+		return nil, err
+	}
+	lights := c.lightsWhere(func(l *Light) bool { return l.CachedLabel() == label })
+	if len(lights) == 0 {
+		return nil, common.ErrNotFound
+	}
+
+	return lights[0], nil
+}
+
+// GetLightsByLabelPattern returns the known lights whose label matches the glob
+// pattern, ordered by ID, see common.MatchLabelPattern
+func (c *Client) GetLightsByLabelPattern(pattern string) ([]common.Light, error) {
+	lights, err := c.GetLights()
+	if err != nil && err != common.ErrNotFound {
+		return nil, err
+	}
+	return common.MatchLabelPattern(lights, pattern)
+}
+
+// SetPower requests a change to the power state of all lights.  A state of
+// true requests power on, and a state of false requests power off.
+func (c *Client) SetPower(state bool) error {
+	return c.SetPowerDuration(state, 0)
+}
+
+// SetPowerDuration requests a change to the power state of all lights,
+// transitioning over the specified duration.
+func (c *Client) SetPowerDuration(state bool, duration time.Duration) error {
+	if err := c.setState(context.Background(), selectorAll, powerState(state), duration); err != nil {
+		return err
+	}
+	for _, l := range c.lightsWhere(func(*Light) bool { return true }) {
+		if err := l.updatePower(state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetColor requests a change to the color of all lights, transitioning over
+// the specified duration.
+func (c *Client) SetColor(color common.Color, duration time.Duration) error {
+	if err := c.setState(context.Background(), selectorAll, colorState(color), duration); err != nil {
+		return err
+	}
+	for _, l := range c.lightsWhere(func(*Light) bool { return true }) {
+		if err := l.updateColor(color); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetDiscoveryInterval causes the client to refresh the list of lights and
+// their state every interval, publishing events for any changes.  An interval
+// of 0 refreshes once.
+func (c *Client) SetDiscoveryInterval(interval time.Duration) error {
+	c.Lock()
+	if c.stopDiscovery != nil {
+		close(c.stopDiscovery)
+		c.stopDiscovery = nil
+	}
+	if interval > 0 {
+		c.stopDiscovery = make(chan struct{})
+		go c.discover(interval, c.stopDiscovery)
+	}
+	c.Unlock()
+
+	return c.refresh(true)
+}
+
+// SetTimeout sets the time that API requests wait for a response before
+// returning an error.  The special value of 0 disables timeouts.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.Lock()
+	c.timeout = timeout
+	c.Unlock()
+}
+
+// GetTimeout returns the currently configured timeout period for API requests
+func (c *Client) GetTimeout() *time.Duration {
+	return &c.timeout
+}
+
+// SetPollInterval sets the interval at which lights are polled while waiting
+// for state changes
+func (c *Client) SetPollInterval(pollInterval time.Duration) {
+	c.Lock()
+	c.pollInterval = pollInterval
+	c.Unlock()
+}
+
+// GetPollInterval returns the currently configured poll interval
+func (c *Client) GetPollInterval() *time.Duration {
+	return &c.pollInterval
+}
+
+// SetCacheTTL sets the period for which the list of lights and their state is
+// served from the cache rather than requested from the API.  The default of 0
+// disables caching.
+func (c *Client) SetCacheTTL(ttl time.Duration) {
+	c.Lock()
+	c.cacheTTL = ttl
+	c.Unlock()
+}
+
+// GetCacheTTL returns the currently configured cache TTL
+func (c *Client) GetCacheTTL() *time.Duration {
+	return &c.cacheTTL
+}
+
+// InvalidateAllCaches discards the cached list of lights and their state, so
+// that the next lookup requests them from the API
+func (c *Client) InvalidateAllCaches() {
+	c.Lock()
+	c.refreshed = time.Time{}
+	lights := make([]*Light, 0, len(c.lights))
+	for _, l := range c.lights {
+		lights = append(lights, l)
+	}
+	c.Unlock()
+	for _, l := range lights {
+		l.InvalidateCache()
+	}
+}
+
+// SetSkipRedundantWrites enables skipping SetColor requests for a color within
+// common.RedundantWriteTolerance of the last color reported for the light.  The
+// cache is updated at the poll interval, so changes made elsewhere since the
+// last poll may cause a request to be wrongly skipped.
+func (c *Client) SetSkipRedundantWrites(skip bool) {
+	c.Lock()
+	c.skipRedundant = skip
+	c.Unlock()
+}
+
+// GetSkipRedundantWrites returns whether redundant color changes are skipped
+func (c *Client) GetSkipRedundantWrites() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.skipRedundant
+}
+
+// SetMinBrightness sets a brightness floor, colors set with a lower brightness
+// are raised to the floor before they are sent.  Powering off is unaffected.
+func (c *Client) SetMinBrightness(brightness uint16) {
+	c.Lock()
+	c.minBrightness = brightness
+	c.Unlock()
+}
+
+// GetMinBrightness returns the currently configured brightness floor
+func (c *Client) GetMinBrightness() uint16 {
+	c.RLock()
+	defer c.RUnlock()
+	return c.minBrightness
+}
+
+// SetColorMemory enables remembering the color of each light as the client
+// powers it off, see Light.LastOnColor.  Colors are only held in memory.
+func (c *Client) SetColorMemory(memory bool) {
+	c.Lock()
+	c.colorMemory = memory
+	c.Unlock()
+}
+
+// GetColorMemory returns whether colors are remembered on power off
+func (c *Client) GetColorMemory() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.colorMemory
+}
+
+// SetTracer sets a writer to which every API request is logged, with its
+// request body and response status, for debugging.  Tracing is disabled by
+// default, and may be disabled again by passing nil.
+func (c *Client) SetTracer(w io.Writer) {
+	c.Lock()
+	c.tracer = w
+	c.Unlock()
+}
+
+// GetTracer returns the currently configured tracer, or nil if tracing is
+// disabled
+func (c *Client) GetTracer() io.Writer {
+	c.RLock()
+	defer c.RUnlock()
+	return c.tracer
+}
+
+// SetSpanHook sets a hook that starts a tracing span for each API request,
+// with the request method and path as attributes.  Spans are disabled by
+// default, and may be disabled again by passing nil.
+func (c *Client) SetSpanHook(hook common.SpanHook) {
+	c.Lock()
+	c.spanHook = hook
+	c.Unlock()
+}
+
+// GetSpanHook returns the currently configured span hook, or nil if spans are
+// disabled
+func (c *Client) GetSpanHook() common.SpanHook {
+	c.RLock()
+	defer c.RUnlock()
+	return c.spanHook
+}
+
+// OnDecodeError sets a handler called with the body of each API response that
+// could not be decoded, and the decoding error.  No handler is set by default,
+// and the handler may be removed again by passing nil.
+func (c *Client) OnDecodeError(handler common.DecodeErrorHandler) {
+	c.Lock()
+	c.decodeError = handler
+	c.Unlock()
+}
+
+// GetDecodeErrorHandler returns the currently configured decode error
+// handler, or nil if none is set
+func (c *Client) GetDecodeErrorHandler() common.DecodeErrorHandler {
+	c.RLock()
+	defer c.RUnlock()
+	return c.decodeError
+}
+
+// Close stops discovery and closes all subscriptions
+func (c *Client) Close() error {
+	c.Lock()
+	select {
+	case <-c.quitChan:
+		c.Unlock()
+		return common.ErrClosed
+	default:
+		close(c.quitChan)
+	}
+	lights := make([]*Light, 0, len(c.lights))
+	for _, l := range c.lights {
+		lights = append(lights, l)
+	}
+	c.Unlock()
+
+	for _, l := range lights {
+		if err := l.closeSubscriptions(); err != nil {
+			return err
+		}
+	}
+
+	return c.closeSubscriptions()
+}
+
+func (c *Client) discover(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-c.quitChan:
+			return
+		case <-ticker.C:
+			if err := c.refresh(true); err != nil {
+				common.Log.Warnf("Failed refreshing cloud lights: %v", err)
+			}
+		}
+	}
+}
+
+func (c *Client) getCacheTTL() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	return c.cacheTTL
+}
+
+func (c *Client) getPollInterval() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	return c.pollInterval
+}
+
+func (c *Client) lightsWhere(match func(*Light) bool) []*Light {
+	c.RLock()
+	defer c.RUnlock()
+	var lights []*Light
+	for _, l := range c.lights {
+		if match(l) {
+			lights = append(lights, l)
+		}
+	}
+	// Order by ID, so that results are stable between calls
+	sort.Slice(lights, func(i, j int) bool { return lights[i].id < lights[j].id })
+
+	return lights
+}
+
+func asLights(lights []*Light) []common.Light {
+	res := make([]common.Light, len(lights))
+	for i, l := range lights {
+		res[i] = l
+	}
+	return res
+}
+
+// refresh requests the list of lights from the API, unless force is false and
+// the cache is fresh
+func (c *Client) refresh(force bool) error {
+	return c.refreshContext(context.Background(), force)
+}
+
+// refreshContext refreshes as per refresh, spanning the request as a child of
+// the span in ctx
+func (c *Client) refreshContext(ctx context.Context, force bool) error {
+	c.RLock()
+	fresh := c.cacheTTL > 0 && !c.refreshed.IsZero() && time.Since(c.refreshed) < c.cacheTTL
+	c.RUnlock()
+	if fresh && !force {
+		return nil
+	}
+
+	var res []apiLight
+	if err := c.do(ctx, http.MethodGet, `/lights/`+selectorAll, nil, &res); err != nil {
+		return err
+	}
+
+	return c.update(res)
+}
+
+// update the known lights, groups and locations from the API response,
+// publishing events for changes
+func (c *Client) update(res []apiLight) error {
+	var (
+		events     []interface{}
+		updated    = make(map[*Light]apiLight, len(res))
+		seen       = make(map[uint64]bool, len(res))
+		groups     = make(map[string]bool)
+		locations  = make(map[string]bool)
+		relabelled = make(map[*Group]string)
+	)
+
+	c.Lock()
+	for _, a := range res {
+		if !a.Connected {
+			continue
+		}
+		id, err := serialToID(a.ID)
+		if err != nil {
+			common.Log.Warnf("Invalid light ID from cloud (%s): %v", a.ID, err)
+			continue
+		}
+		seen[id] = true
+		l, ok := c.lights[id]
+		if !ok {
+			l = newLight(c, id, a.ID)
+			c.lights[id] = l
+			events = append(events, common.EventNewDevice{Device: l})
+		}
+		updated[l] = a
+
+		if a.Group.ID != `` && !groups[a.Group.ID] {
+			groups[a.Group.ID] = true
+			if g, ok := c.groups[a.Group.ID]; ok {
+				relabelled[g] = a.Group.Name
+			} else {
+				g = newGroup(c, selectorGroupID, a.Group)
+				c.groups[a.Group.ID] = g
+				events = append(events, common.EventNewGroup{Group: g})
+			}
+		}
+		if a.Location.ID != `` && !locations[a.Location.ID] {
+			locations[a.Location.ID] = true
+			if loc, ok := c.locations[a.Location.ID]; ok {
+				relabelled[loc.Group] = a.Location.Name
+			} else {
+				loc = &Location{Group: newGroup(c, selectorLocationID, a.Location)}
+				c.locations[a.Location.ID] = loc
+				events = append(events, common.EventNewLocation{Location: loc})
+			}
+		}
+	}
+	for id, l := range c.lights {
+		if !seen[id] {
+			delete(c.lights, id)
+			events = append(events, common.EventExpiredDevice{Device: l})
+		}
+	}
+	for id, g := range c.groups {
+		if !groups[id] {
+			delete(c.groups, id)
+			events = append(events, common.EventExpiredGroup{Group: g})
+		}
+	}
+	for id, loc := range c.locations {
+		if !locations[id] {
+			delete(c.locations, id)
+			events = append(events, common.EventExpiredLocation{Location: loc})
+		}
+	}
+	c.refreshed = time.Now()
+	c.Unlock()
+
+	for l, a := range updated {
+		if err := l.update(a); err != nil {
+			return err
+		}
+	}
+	for g, label := range relabelled {
+		if err := g.updateLabel(label); err != nil {
+			return err
+		}
+	}
+	for _, event := range events {
+		if err := c.publish(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func powerState(state bool) *apiState {
+	s := &apiState{Power: powerOff}
+	if state {
+		s.Power = powerOn
+	}
+	return s
+}
+
+func colorState(color common.Color) *apiState {
+	s := &apiState{}
+	s.setColor(color)
+	return s
+}
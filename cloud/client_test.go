@@ -0,0 +1,309 @@
+package cloud_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+
+	. "github.com/pdf/golifx/cloud"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	token     = `test-token`
+	lightsAll = `[
+		{
+			"id": "d073d5000001",
+			"label": "Kitchen",
+			"connected": true,
+			"power": "on",
+			"color": {"hue": 120, "saturation": 1, "kelvin": 3500},
+			"brightness": 0.5,
+			"group": {"id": "g1", "name": "Downstairs"},
+			"location": {"id": "l1", "name": "Home"},
+			"seconds_since_seen": 180
+		},
+		{
+			"id": "d073d5000002",
+			"label": "Garage",
+			"connected": false,
+			"power": "off",
+			"color": {"hue": 0, "saturation": 0, "kelvin": 2700},
+			"brightness": 1,
+			"group": {"id": "g2", "name": "Outside"},
+			"location": {"id": "l1", "name": "Home"}
+		}
+	]`
+)
+
+type stateRequest struct {
+	path string
+	body map[string]interface{}
+}
+
+var _ = Describe("Cloud", func() {
+	var (
+		server   *httptest.Server
+		client   *Client
+		requests []stateRequest
+		gets     int
+		mu       sync.Mutex
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		gets = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(`Authorization`) != `Bearer `+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"Invalid token"}`))
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				mu.Lock()
+				gets++
+				mu.Unlock()
+				_, _ = w.Write([]byte(lightsAll))
+			case http.MethodPut, http.MethodPost:
+				body := make(map[string]interface{})
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				mu.Lock()
+				requests = append(requests, stateRequest{path: r.URL.Path, body: body})
+				mu.Unlock()
+				w.WriteHeader(http.StatusMultiStatus)
+				_, _ = w.Write([]byte(`{"results":[{"id":"d073d5000001","label":"Kitchen","status":"ok"}]}`))
+			}
+		}))
+		client = NewClient(token)
+		client.SetEndpoint(server.URL)
+	})
+
+	AfterEach(func() {
+		_ = client.Close()
+		server.Close()
+	})
+
+	It("should implement common.Client", func() {
+		var _ common.Client = client
+	})
+
+	It("should report connected lights", func() {
+		lights, err := client.GetLights()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lights).To(HaveLen(1))
+		Expect(lights[0].MAC()).To(Equal(`d0:73:d5:00:00:01`))
+		Expect(lights[0].GetLabel()).To(Equal(`Kitchen`))
+		Expect(lights[0].CachedPower()).To(BeTrue())
+		Expect(lights[0].CachedColor()).To(Equal(common.Color{Hue: 21845, Saturation: 65535, Brightness: 32768, Kelvin: 3500}))
+	})
+
+	It("should report when lights were last seen", func() {
+		lights, err := client.GetLights()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lights).To(HaveLen(1))
+		Expect(lights[0].LastSeen()).To(BeTemporally(`~`, time.Now().Add(-3*time.Minute), time.Second))
+	})
+
+	It("should be ready once lights are known", func() {
+		Expect(client.DeviceCount()).To(Equal(0))
+		Expect(client.Ready(context.Background())).To(Succeed())
+		Expect(client.DeviceCount()).To(Equal(1))
+	})
+
+	It("should look up lights by label", func() {
+		light, err := client.GetLightByLabel(`Kitchen`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.ID()).To(Equal(uint64(0x10000d573d0)))
+
+		_, err = client.GetLightByLabel(`Garage`)
+		Expect(err).To(Equal(common.ErrNotFound))
+	})
+
+	It("should look up lights by label pattern", func() {
+		lights, err := client.GetLightsByLabelPattern(`Kit*`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lights).To(HaveLen(1))
+
+		_, err = client.GetLightsByLabelPattern(`Gar*`)
+		Expect(err).To(Equal(common.ErrNotFound))
+	})
+
+	It("should send color changes to the light selector", func() {
+		light, err := client.GetLightByLabel(`Kitchen`)
+		Expect(err).NotTo(HaveOccurred())
+		color := common.Color{Hue: 0, Saturation: 0, Brightness: 65535, Kelvin: 2700}
+		Expect(light.SetColor(color, 2*time.Second)).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].path).To(Equal(`/lights/id:d073d5000001/state`))
+		Expect(requests[0].body).To(Equal(map[string]interface{}{
+			`color`:      `hue:0.00 saturation:0.0000 kelvin:2700`,
+			`brightness`: float64(1),
+			`duration`:   float64(2),
+		}))
+		Expect(light.CachedColor()).To(Equal(color))
+	})
+
+	It("should span API requests when a span hook is set", func() {
+		hook := &spanHook{}
+		client.SetSpanHook(hook)
+		light, err := client.GetLightByLabel(`Kitchen`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.SetColor(common.Color{Kelvin: 2700}, 0)).To(Succeed())
+		Expect(hook.requests).To(ContainElement(`PUT /lights/id:d073d5000001/state`))
+	})
+
+	It("should span API requests with the context of the call", func() {
+		hook := &spanHook{}
+		client.SetSpanHook(hook)
+		type key struct{}
+		ctx := context.WithValue(context.Background(), key{}, `lights`)
+		_, err := client.GetLightsContext(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hook.requests).To(Equal([]string{`GET /lights/all`}))
+		Expect(hook.contexts[0].Value(key{})).To(Equal(`lights`))
+	})
+
+	It("should report responses that can not be decoded", func() {
+		malformed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[{"id":`))
+		}))
+		defer malformed.Close()
+		client.SetEndpoint(malformed.URL)
+		var body []byte
+		client.OnDecodeError(func(buf []byte, err error) {
+			body = buf
+		})
+		_, err := client.GetLights()
+		Expect(err).To(HaveOccurred())
+		Expect(string(body)).To(Equal(`[{"id":`))
+	})
+
+	It("should remember colors on power off when enabled", func() {
+		light, err := client.GetLightByLabel(`Kitchen`)
+		Expect(err).NotTo(HaveOccurred())
+		color := light.CachedColor()
+		Expect(light.SetPower(false)).To(Succeed())
+		_, ok := light.LastOnColor()
+		Expect(ok).To(BeFalse())
+
+		client.SetColorMemory(true)
+		Expect(light.SetPower(true)).To(Succeed())
+		Expect(light.SetPower(false)).To(Succeed())
+		// Powering off again does not replace it
+		Expect(light.SetPower(false)).To(Succeed())
+		remembered, ok := light.LastOnColor()
+		Expect(ok).To(BeTrue())
+		Expect(remembered).To(Equal(color))
+	})
+
+	It("should raise colors to the minimum brightness", func() {
+		client.SetMinBrightness(13107)
+		light, err := client.GetLightByLabel(`Kitchen`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.SetColor(common.Color{Kelvin: 2700}, 0)).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].body).To(HaveKeyWithValue(`brightness`, 0.2))
+		Expect(light.CachedColor().Brightness).To(Equal(uint16(13107)))
+	})
+
+	It("should flash color with the pulse effect", func() {
+		light, err := client.GetLightByLabel(`Kitchen`)
+		Expect(err).NotTo(HaveOccurred())
+		before := light.CachedColor()
+		Expect(light.FlashColor(common.Color{Saturation: 65535, Brightness: 65535, Kelvin: 3500}, 500*time.Millisecond, 3)).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].path).To(Equal(`/lights/id:d073d5000001/effects/pulse`))
+		Expect(requests[0].body).To(Equal(map[string]interface{}{
+			`color`:  `hue:0.00 saturation:1.0000 brightness:1.0000 kelvin:3500`,
+			`period`: 0.5,
+			`cycles`: float64(3),
+		}))
+		Expect(light.CachedColor()).To(Equal(before))
+		Expect(light.FlashColor(common.Color{}, 0, 1)).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should page lights in ID order", func() {
+		var ids []uint64
+		Expect(client.GetLightsPaged(1, func(lights []common.Light) error {
+			Expect(lights).To(HaveLen(1))
+			ids = append(ids, lights[0].ID())
+			return nil
+		})).To(Succeed())
+		Expect(ids).NotTo(BeEmpty())
+		Expect(sort.SliceIsSorted(ids, func(i, j int) bool { return ids[i] < ids[j] })).To(BeTrue())
+	})
+
+	It("should stop paging when the callback fails", func() {
+		calls := 0
+		Expect(client.GetLightsPaged(1, func([]common.Light) error {
+			calls++
+			return common.ErrClosed
+		})).To(MatchError(common.ErrClosed))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should skip redundant color changes when enabled", func() {
+		client.SetSkipRedundantWrites(true)
+		light, err := client.GetLightByLabel(`Kitchen`)
+		Expect(err).NotTo(HaveOccurred())
+		color := light.CachedColor()
+		color.Brightness -= common.RedundantWriteTolerance
+		requests = nil
+		Expect(light.SetColor(color, time.Second)).To(Succeed())
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("should request lights again once caches are invalidated", func() {
+		client.SetCacheTTL(time.Minute)
+		_, err := client.GetLights()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.GetLights()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gets).To(Equal(1))
+
+		client.InvalidateAllCaches()
+		_, err = client.GetLights()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gets).To(Equal(2))
+	})
+
+	It("should send power changes to the group selector", func() {
+		group, err := client.GetGroupByLabel(`Downstairs`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(group.Lights()).To(HaveLen(1))
+		Expect(group.SetPower(false)).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].path).To(Equal(`/lights/group_id:g1/state`))
+		Expect(requests[0].body).To(HaveKeyWithValue(`power`, `off`))
+		Expect(group.Lights()[0].CachedPower()).To(BeFalse())
+	})
+
+	It("should return API errors", func() {
+		client = NewClient(`bad-token`)
+		client.SetEndpoint(server.URL)
+		_, err := client.GetLights()
+		Expect(err).To(Equal(&ErrAPI{StatusCode: http.StatusUnauthorized, Message: `Invalid token`}))
+	})
+})
+
+// spanHook is a common.SpanHook recording the request and context of each span
+// started
+type spanHook struct {
+	requests []string
+	contexts []context.Context
+}
+
+func (h *spanHook) StartSpan(ctx context.Context, name string, attrs common.SpanAttributes) func(error) {
+	h.requests = append(h.requests, attrs.Request)
+	h.contexts = append(h.contexts, ctx)
+	return func(error) {}
+}
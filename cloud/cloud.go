@@ -0,0 +1,319 @@
+// Package cloud implements the common.Client interface against the LIFX HTTP
+// API (https://api.lifx.com/), allowing the same code to control lights when
+// not on the same LAN as them.
+//
+// Only the standard library is used, so the LAN client in the golifx package
+// does not gain any dependencies.  Operations that are not supported by the
+// HTTP API return a *common.ErrNotImplemented.
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+const (
+	// DefaultEndpoint is the base URL of the LIFX HTTP API
+	DefaultEndpoint = `https://api.lifx.com/v1`
+
+	selectorAll        = `all`
+	selectorID         = `id:`
+	selectorGroupID    = `group_id:`
+	selectorLocationID = `location_id:`
+
+	powerOn  = `on`
+	powerOff = `off`
+
+	statusOK = `ok`
+)
+
+type apiRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type apiColor struct {
+	Hue        float64 `json:"hue"`
+	Saturation float64 `json:"saturation"`
+	Kelvin     uint16  `json:"kelvin"`
+}
+
+type apiLight struct {
+	ID         string   `json:"id"`
+	Label      string   `json:"label"`
+	Connected  bool     `json:"connected"`
+	Power      string   `json:"power"`
+	Color      apiColor `json:"color"`
+	Brightness float64  `json:"brightness"`
+	Group      apiRef   `json:"group"`
+	Location   apiRef   `json:"location"`
+	// SecondsSinceSeen is the time since the cloud last heard from the light
+	SecondsSinceSeen float64 `json:"seconds_since_seen"`
+}
+
+func (a *apiLight) color() common.Color {
+	return common.ColorHSV{
+		H:      a.Color.Hue,
+		S:      a.Color.Saturation,
+		V:      a.Brightness,
+		Kelvin: a.Color.Kelvin,
+	}.Color()
+}
+
+type apiState struct {
+	Power      string   `json:"power,omitempty"`
+	Color      string   `json:"color,omitempty"`
+	Brightness *float64 `json:"brightness,omitempty"`
+	Duration   float64  `json:"duration"`
+}
+
+func (s *apiState) setColor(color common.Color) {
+	hsv := color.HSV()
+	s.Color = fmt.Sprintf("hue:%.2f saturation:%.4f kelvin:%d", hsv.H, hsv.S, hsv.Kelvin)
+	s.Brightness = &hsv.V
+}
+
+// apiPulse is the body of a pulse effect request, with persist unset the light
+// reverts to its own color once the cycles complete
+type apiPulse struct {
+	Color  string  `json:"color"`
+	Period float64 `json:"period"`
+	Cycles float64 `json:"cycles"`
+}
+
+type apiResult struct {
+	ID     string `json:"id"`
+	Label  string `json:"label"`
+	Status string `json:"status"`
+}
+
+type apiResults struct {
+	Results []apiResult `json:"results"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// serialToID converts the hex serial number used to identify lights by the
+// HTTP API to the ID used by the LAN protocol
+func serialToID(serial string) (uint64, error) {
+	b, err := hex.DecodeString(serial)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) != 6 {
+		return 0, common.ErrInvalidArgument
+	}
+	target := make([]byte, 8)
+	copy(target, b)
+	return binary.LittleEndian.Uint64(target), nil
+}
+
+// do performs an API request, encoding body as the JSON request body if it is
+// not nil, and decoding the JSON response into result if it is not nil.  The
+// request is spanned as a child of the span in ctx if the client has a span
+// hook, and is cancelled when ctx is done.
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	c.RLock()
+	spanHook := c.spanHook
+	c.RUnlock()
+	if spanHook == nil {
+		return c.request(ctx, method, path, body, result)
+	}
+
+	end := spanHook.StartSpan(ctx, common.SpanCloudRequest, common.SpanAttributes{Request: method + ` ` + path})
+	err := c.request(ctx, method, path, body, result)
+	end(err)
+	return err
+}
+
+func (c *Client) request(ctx context.Context, method, path string, body, result interface{}) error {
+	var (
+		reqBody io.Reader
+		b       []byte
+		err     error
+	)
+	if body != nil {
+		b, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	c.RLock()
+	url := strings.TrimRight(c.endpoint, `/`) + path
+	timeout := c.timeout
+	tracer := c.tracer
+	decodeError := c.decodeError
+	c.RUnlock()
+
+	reqCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(reqCtx)
+	req.Header.Set(`Authorization`, `Bearer `+c.token)
+	if body != nil {
+		req.Header.Set(`Content-Type`, `application/json`)
+	}
+
+	common.Log.Debugf("Cloud request: %s %s", method, path)
+	resp, err := c.httpClient.Do(req)
+	if tracer != nil {
+		status := `error`
+		if err == nil {
+			status = resp.Status
+		}
+		// Write the line in a single call, so lines are not interleaved by
+		// concurrent requests
+		_, _ = io.WriteString(tracer, fmt.Sprintf("%s %s %s %s %s\n", time.Now().Format(`15:04:05.000`), method, path, b, status))
+	}
+	if err != nil {
+		// The caller's context error takes precedence over the client timeout
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if reqCtx.Err() == context.DeadlineExceeded {
+			return common.ErrTimeout
+		}
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			common.Log.Warnf("Failed closing cloud response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		e := apiError{}
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == `` {
+			e.Error = http.StatusText(resp.StatusCode)
+		}
+		return &ErrAPI{StatusCode: resp.StatusCode, Message: e.Error}
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		common.Log.Warnf("Failed decoding cloud response to %s %s: %v", method, path, err)
+		if decodeError != nil {
+			decodeError(data, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// setState applies state to the lights matching selector, transitioning over
+// duration.  Returns common.ErrInvalidArgument if the duration is out of range
+// (see common.ValidateDuration).
+func (c *Client) setState(ctx context.Context, selector string, state *apiState, duration time.Duration) error {
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	state.Duration = duration.Seconds()
+
+	res := apiResults{}
+	if err := c.do(ctx, http.MethodPut, `/lights/`+selector+`/state`, state, &res); err != nil {
+		return err
+	}
+	for _, r := range res.Results {
+		if r.Status != statusOK {
+			common.Log.Warnf("Cloud state change on %s (%s) failed: %s", r.ID, r.Label, r.Status)
+			return &ErrAPI{Message: fmt.Sprintf("%s: %s", r.ID, r.Status)}
+		}
+	}
+
+	return nil
+}
+
+// publisher manages subscriptions for a SubscriptionTarget
+type publisher struct {
+	subscriptions map[string]*common.Subscription
+	mu            sync.RWMutex
+}
+
+// NewSubscription returns a new *common.Subscription for receiving events from
+// this target.
+func (p *publisher) NewSubscription() (*common.Subscription, error) {
+	sub := common.NewSubscription(p)
+	p.mu.Lock()
+	if p.subscriptions == nil {
+		p.subscriptions = make(map[string]*common.Subscription)
+	}
+	p.subscriptions[sub.ID()] = sub
+	p.mu.Unlock()
+	return sub, nil
+}
+
+// CloseSubscription is a callback for handling the closing of subscriptions.
+func (p *publisher) CloseSubscription(sub *common.Subscription) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.subscriptions[sub.ID()]; !ok {
+		return common.ErrNotFound
+	}
+	delete(p.subscriptions, sub.ID())
+
+	return nil
+}
+
+func (p *publisher) closeSubscriptions() error {
+	p.mu.RLock()
+	subs := make([]*common.Subscription, 0, len(p.subscriptions))
+	for _, sub := range p.subscriptions {
+		subs = append(subs, sub)
+	}
+	p.mu.RUnlock()
+
+	for _, sub := range subs {
+		if err := sub.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *publisher) publish(event interface{}) error {
+	p.mu.RLock()
+	subs := make([]*common.Subscription, 0, len(p.subscriptions))
+	for _, sub := range p.subscriptions {
+		subs = append(subs, sub)
+	}
+	p.mu.RUnlock()
+
+	for _, sub := range subs {
+		if err := sub.Write(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
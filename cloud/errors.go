@@ -0,0 +1,20 @@
+package cloud
+
+import "fmt"
+
+// ErrAPI is returned when the LIFX HTTP API reports an error, or a light fails
+// to apply a requested state change
+type ErrAPI struct {
+	// StatusCode is the HTTP status code of the response, or zero if the
+	// request succeeded but a light reported a failure
+	StatusCode int
+	Message    string
+}
+
+// Error satisfies the error interface
+func (e *ErrAPI) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("LIFX API error: %s", e.Message)
+	}
+	return fmt.Sprintf("LIFX API error (%d): %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,155 @@
+package cloud
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// Group implements common.Group via the LIFX HTTP API
+type Group struct {
+	id     string
+	label  string
+	prefix string
+	client *Client
+	publisher
+	sync.RWMutex
+}
+
+// Location implements common.Location via the LIFX HTTP API
+type Location struct {
+	*Group
+}
+
+func newGroup(client *Client, prefix string, ref apiRef) *Group {
+	return &Group{
+		id:     ref.ID,
+		label:  ref.Name,
+		prefix: prefix,
+		client: client,
+	}
+}
+
+// ID returns the ID of the group, as reported by the HTTP API
+func (g *Group) ID() string {
+	return g.id
+}
+
+// GetLabel returns the label for the group
+func (g *Group) GetLabel() string {
+	g.RLock()
+	defer g.RUnlock()
+	return g.label
+}
+
+// Devices returns the devices in the group
+func (g *Group) Devices() []common.Device {
+	lights := g.lights()
+	devices := make([]common.Device, len(lights))
+	for i, l := range lights {
+		devices[i] = l
+	}
+	return devices
+}
+
+// Lights returns the lights in the group
+func (g *Group) Lights() []common.Light {
+	return asLights(g.lights())
+}
+
+// GetPower returns true if any lights in the group are on, or false if all
+// lights are off
+func (g *Group) GetPower() (bool, error) {
+	if err := g.client.refresh(false); err != nil {
+		return false, err
+	}
+	for _, l := range g.lights() {
+		if l.CachedPower() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetColor returns the average color of lights in the group
+func (g *Group) GetColor() (common.Color, error) {
+	if err := g.client.refresh(false); err != nil {
+		return common.Color{}, err
+	}
+	lights := g.lights()
+	colors := make([]common.Color, len(lights))
+	for i, l := range lights {
+		colors[i] = l.CachedColor()
+	}
+
+	return common.AverageColor(colors...), nil
+}
+
+// SetColor requests a change of color for all lights in the group,
+// transitioning over the specified duration
+func (g *Group) SetColor(color common.Color, duration time.Duration) error {
+	if err := g.client.setState(context.Background(), g.selector(), colorState(color), duration); err != nil {
+		return err
+	}
+	for _, l := range g.lights() {
+		if err := l.updateColor(color); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetColorWave changes the color of the lights in the group in order of ID,
+// offset by stagger, see common.ColorWave.  Each light is changed by a separate
+// request, which counts against the rate limit of the HTTP API.
+func (g *Group) SetColorWave(color common.Color, duration, stagger time.Duration) error {
+	return common.ColorWave(g.Lights(), color, duration, stagger)
+}
+
+// SetPower sets the power of all lights in the group
+func (g *Group) SetPower(state bool) error {
+	return g.SetPowerDuration(state, 0)
+}
+
+// SetPowerDuration sets the power of all lights in the group, transitioning
+// over the specified duration
+func (g *Group) SetPowerDuration(state bool, duration time.Duration) error {
+	if err := g.client.setState(context.Background(), g.selector(), powerState(state), duration); err != nil {
+		return err
+	}
+	for _, l := range g.lights() {
+		if err := l.updatePower(state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Group) selector() string {
+	return g.prefix + g.id
+}
+
+func (g *Group) lights() []*Light {
+	if g.prefix == selectorLocationID {
+		return g.client.lightsWhere(func(l *Light) bool { return l.inLocation(g.id) })
+	}
+	return g.client.lightsWhere(func(l *Light) bool { return l.inGroup(g.id) })
+}
+
+func (g *Group) updateLabel(label string) error {
+	g.Lock()
+	changed := g.label != label
+	g.label = label
+	g.Unlock()
+
+	if changed {
+		return g.publish(common.EventUpdateLabel{Label: label})
+	}
+
+	return nil
+}
@@ -0,0 +1,540 @@
+package cloud
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// Light implements common.Light via the LIFX HTTP API
+type Light struct {
+	id         uint64
+	serial     string
+	label      string
+	power      bool
+	color      common.Color
+	groupID    string
+	locationID string
+	updated    time.Time
+	lastSeen   time.Time
+	client     *Client
+
+	transitionEnd time.Time
+	lastOnColor   *common.Color
+
+	updateMutex sync.Mutex
+	publisher
+	sync.RWMutex
+}
+
+func newLight(client *Client, id uint64, serial string) *Light {
+	return &Light{
+		id:     id,
+		serial: serial,
+		client: client,
+	}
+}
+
+// ID returns the ID for the light, which matches the ID reported by the LAN
+// protocol
+func (l *Light) ID() uint64 {
+	return l.id
+}
+
+// MAC returns the MAC address of the light
+func (l *Light) MAC() string {
+	target := make([]byte, 8)
+	binary.LittleEndian.PutUint64(target, l.id)
+	return net.HardwareAddr(target[:6]).String()
+}
+
+// Address always returns nil, lights are not addressed directly via the cloud
+func (l *Light) Address() *net.UDPAddr {
+	return nil
+}
+
+// Subnet returns nil, as cloud lights are not reached over the LAN
+func (l *Light) Subnet() *net.IPNet {
+	return nil
+}
+
+// Services returns nil, as cloud lights are not reached over the LAN
+func (l *Light) Services() []common.Service {
+	return nil
+}
+
+// GetLabel returns the label for the light
+func (l *Light) GetLabel() (string, error) {
+	if err := l.fetch(false); err != nil {
+		return ``, err
+	}
+	return l.CachedLabel(), nil
+}
+
+// CachedLabel returns the last known label for the light
+func (l *Light) CachedLabel() string {
+	l.RLock()
+	defer l.RUnlock()
+	return l.label
+}
+
+// InvalidateCache discards the cached state of the light, so that the next
+// lookup requests it from the API
+func (l *Light) InvalidateCache() {
+	l.Lock()
+	l.updated = time.Time{}
+	l.Unlock()
+}
+
+// SetLabel is not supported by the HTTP API
+func (l *Light) SetLabel(label string) error {
+	return &common.ErrNotImplemented{Method: `SetLabel`}
+}
+
+// GetPower requests the current power state of the light
+func (l *Light) GetPower() (bool, error) {
+	if err := l.fetch(false); err != nil {
+		return false, err
+	}
+	return l.CachedPower(), nil
+}
+
+// GetPowerState returns PowerOn or PowerOff, the HTTP API does not report
+// intermediate power levels
+func (l *Light) GetPowerState() (common.Power, error) {
+	power, err := l.GetPower()
+	if err != nil || !power {
+		return common.PowerOff, err
+	}
+	return common.PowerOn, nil
+}
+
+// CachedPower returns the last known power state of the light
+func (l *Light) CachedPower() bool {
+	l.RLock()
+	defer l.RUnlock()
+	return l.power
+}
+
+// SetPower sets the power state of the light
+func (l *Light) SetPower(state bool) error {
+	return l.SetPowerDuration(state, 0)
+}
+
+// SetPowerDuration sets the power state of the light, transitioning over the
+// specified duration
+func (l *Light) SetPowerDuration(state bool, duration time.Duration) error {
+	// The last known color is remembered, as the API reports it with the
+	// power state
+	if !state && l.CachedPower() && l.client.GetColorMemory() {
+		color := l.CachedColor()
+		l.Lock()
+		l.lastOnColor = &color
+		l.Unlock()
+	}
+	if err := l.client.setState(context.Background(), l.selector(), powerState(state), duration); err != nil {
+		return err
+	}
+	l.startTransition(duration)
+	return l.updatePower(state)
+}
+
+// LastOnColor returns the color the light had when the client last powered it
+// off, if the client remembers colors
+func (l *Light) LastOnColor() (common.Color, bool) {
+	l.RLock()
+	defer l.RUnlock()
+	if l.lastOnColor == nil {
+		return common.Color{}, false
+	}
+	return *l.lastOnColor, true
+}
+
+// GetFirmwareVersion is not supported by the HTTP API
+func (l *Light) GetFirmwareVersion() (string, error) {
+	return ``, &common.ErrNotImplemented{Method: `GetFirmwareVersion`}
+}
+
+// CachedFirmwareVersion always returns an empty string, the firmware version
+// is not reported by the HTTP API
+func (l *Light) CachedFirmwareVersion() string {
+	return ``
+}
+
+// SetGroup is not supported by the HTTP API
+func (l *Light) SetGroup(id [16]byte, label string) error {
+	return &common.ErrNotImplemented{Method: `SetGroup`}
+}
+
+// SetLocation is not supported by the HTTP API
+func (l *Light) SetLocation(id [16]byte, label string) error {
+	return &common.ErrNotImplemented{Method: `SetLocation`}
+}
+
+// IsOnline always returns true, lights that the HTTP API reports as
+// disconnected are expired from the client
+func (l *Light) IsOnline() bool {
+	return true
+}
+
+// LastSeen returns the time the cloud last heard from the light, as of the
+// last refresh
+func (l *Light) LastSeen() time.Time {
+	l.RLock()
+	defer l.RUnlock()
+	return l.lastSeen
+}
+
+// Ping is not supported by this client
+func (l *Light) Ping() (time.Duration, error) {
+	return 0, &common.ErrNotImplemented{Method: `Ping`}
+}
+
+// GetWifiInfo is not supported by this client
+func (l *Light) GetWifiInfo() (common.WifiInfo, error) {
+	return common.WifiInfo{}, &common.ErrNotImplemented{Method: `GetWifiInfo`}
+}
+
+// SignalHistory always returns no samples, signal strength is not available
+// via the API
+func (l *Light) SignalHistory() []common.SignalSample {
+	return nil
+}
+
+// GetProductInfo is not supported by this client
+func (l *Light) GetProductInfo() (common.ProductInfo, error) {
+	return common.ProductInfo{}, &common.ErrNotImplemented{Method: `GetProductInfo`}
+}
+
+// SetColor changes the color of the light, transitioning over the specified
+// duration
+func (l *Light) SetColor(color common.Color, duration time.Duration) error {
+	return l.SetColorContext(context.Background(), color, duration)
+}
+
+// SetColorContext changes the color as per SetColor, spanning the request as a
+// child of the span in ctx, and cancelling it when ctx is done
+func (l *Light) SetColorContext(ctx context.Context, color common.Color, duration time.Duration) error {
+	color = common.FloorBrightness(color, l.client.GetMinBrightness())
+	if l.client.GetSkipRedundantWrites() && common.ColorApproxEqual(color, l.CachedColor(), common.RedundantWriteTolerance) {
+		return nil
+	}
+	if err := l.client.setState(ctx, l.selector(), colorState(color), duration); err != nil {
+		return err
+	}
+	l.startTransition(duration)
+	return l.updateColor(color)
+}
+
+// TransitionRemaining returns the estimated time until the last color or power
+// transition requested via this client completes, measured from when the API
+// accepted it
+func (l *Light) TransitionRemaining() time.Duration {
+	l.RLock()
+	defer l.RUnlock()
+	if remaining := time.Until(l.transitionEnd); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func (l *Light) startTransition(duration time.Duration) {
+	l.Lock()
+	l.transitionEnd = time.Now().Add(duration)
+	l.Unlock()
+}
+
+// Tags returns an empty slice, tags are not exposed by the HTTP API
+func (l *Light) Tags() ([]string, error) {
+	return []string{}, nil
+}
+
+// FlashColor runs the pulse effect on the light, which reverts to its own color
+// once the cycles complete
+func (l *Light) FlashColor(color common.Color, period time.Duration, cycles uint16) error {
+	if period <= 0 || cycles == 0 {
+		return common.ErrInvalidArgument
+	}
+	if err := common.ValidateDuration(period); err != nil {
+		return err
+	}
+	hsv := color.HSV()
+	p := &apiPulse{
+		Color:  fmt.Sprintf("hue:%.2f saturation:%.4f brightness:%.4f kelvin:%d", hsv.H, hsv.S, hsv.V, hsv.Kelvin),
+		Period: period.Seconds(),
+		Cycles: float64(cycles),
+	}
+	return l.client.do(context.Background(), http.MethodPost, `/lights/`+l.selector()+`/effects/pulse`, p, nil)
+}
+
+// LastRebootReason returns common.ErrNotSupported, device info is not exposed
+// by the HTTP API
+func (l *Light) LastRebootReason() (string, error) {
+	return ``, common.ErrNotSupported
+}
+
+// GetAccessPoint returns common.ErrNotSupported, WiFi details are not exposed
+// by the HTTP API
+func (l *Light) GetAccessPoint() (common.AccessPoint, error) {
+	return common.AccessPoint{}, common.ErrNotSupported
+}
+
+// SetColorAck changes the color of the light, the HTTP API responds once the
+// light has applied the change, so success is reported as acknowledged
+func (l *Light) SetColorAck(color common.Color, duration time.Duration) (bool, error) {
+	if err := l.SetColor(color, duration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UpdateColor requests the current color, applies fn to it, and sets the
+// result.  Calls are serialized per light, but changes made by other means
+// between the read and write are overwritten.
+func (l *Light) UpdateColor(fn func(common.Color) common.Color, duration time.Duration) error {
+	l.updateMutex.Lock()
+	defer l.updateMutex.Unlock()
+
+	color, err := l.GetColor()
+	if err != nil {
+		return err
+	}
+
+	return l.SetColor(fn(color), duration)
+}
+
+// SetColorIfOn changes the color of the light if it is currently powered on,
+// returning whether the color was applied
+func (l *Light) SetColorIfOn(color common.Color, duration time.Duration) (bool, error) {
+	power, err := l.GetPower()
+	if err != nil {
+		return false, err
+	}
+	if !power {
+		return false, nil
+	}
+	if err := l.SetColor(color, duration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetColorClamped clamps color into the supported range and sets it, returning
+// the color applied
+func (l *Light) SetColorClamped(color common.Color, duration time.Duration) (common.Color, error) {
+	color = common.ClampColor(color)
+	if err := l.SetColor(color, duration); err != nil {
+		return common.Color{}, err
+	}
+	return color, nil
+}
+
+// SetBrightnessPercent sets the brightness of the light to percent, in the
+// range 0 to 100 (clamped), preserving the other color components
+func (l *Light) SetBrightnessPercent(percent float64, duration time.Duration) error {
+	brightness := common.BrightnessFromPercent(percent)
+	return l.UpdateColor(func(color common.Color) common.Color {
+		color.Brightness = brightness
+		return color
+	}, duration)
+}
+
+// AdjustKelvin adds delta to the color temperature of the light, clamped to the
+// supported range
+func (l *Light) AdjustKelvin(delta int, duration time.Duration) error {
+	return l.UpdateColor(func(color common.Color) common.Color {
+		return common.AdjustKelvin(color, delta)
+	}, duration)
+}
+
+// AdjustBrightness adds delta to the brightness of the light, clamped to the
+// range 0 to 65535
+func (l *Light) AdjustBrightness(delta int, duration time.Duration) error {
+	return l.UpdateColor(func(color common.Color) common.Color {
+		return common.AdjustBrightness(color, delta)
+	}, duration)
+}
+
+// SetColorByName changes the color of the light to the named color (see
+// common.NamedColor), transitioning over the specified duration
+func (l *Light) SetColorByName(name string, duration time.Duration) error {
+	color, err := common.NamedColor(name)
+	if err != nil {
+		return err
+	}
+
+	return l.SetColor(color, duration)
+}
+
+// SetColorVerified changes the color of the light, then waits for the
+// transition and a further delay to elapse before requesting the color.
+// Returns common.ErrVerifyFailed if the color is not within tolerance of
+// color.
+func (l *Light) SetColorVerified(color common.Color, duration time.Duration, tolerance uint16, delay time.Duration) error {
+	if err := l.SetColor(color, duration); err != nil {
+		return err
+	}
+
+	<-time.After(duration + delay)
+
+	if err := l.fetch(true); err != nil {
+		return err
+	}
+	actual := l.CachedColor()
+	if !common.ColorApproxEqual(actual, color, tolerance) {
+		common.Log.Warnf("Color verification failed on %d, requested %+v, got %+v", l.id, color, actual)
+		return common.ErrVerifyFailed
+	}
+
+	return nil
+}
+
+// GetColor requests the current color of the light
+func (l *Light) GetColor() (common.Color, error) {
+	if err := l.fetch(false); err != nil {
+		return common.Color{}, err
+	}
+	return l.CachedColor(), nil
+}
+
+// CachedColor returns the last known color of the light
+func (l *Light) CachedColor() common.Color {
+	l.RLock()
+	defer l.RUnlock()
+	return l.color
+}
+
+// WaitUntilColor blocks until the light reports a color within tolerance of
+// target, or the context is done.  The light is polled at the client poll
+// interval.
+func (l *Light) WaitUntilColor(ctx context.Context, target common.Color, tolerance uint16) error {
+	ticker := time.NewTicker(l.client.getPollInterval())
+	defer ticker.Stop()
+
+	for {
+		if err := l.fetchContext(ctx, true); err != nil {
+			common.Log.Debugf("Failed getting color from %d while waiting: %v", l.id, err)
+		} else if common.ColorApproxEqual(l.CachedColor(), target, tolerance) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *Light) selector() string {
+	return selectorID + l.serial
+}
+
+// fetch requests the state of the light from the API, unless force is false
+// and the cached state is fresh
+func (l *Light) fetch(force bool) error {
+	return l.fetchContext(context.Background(), force)
+}
+
+// fetchContext fetches as per fetch, spanning the request as a child of the
+// span in ctx
+func (l *Light) fetchContext(ctx context.Context, force bool) error {
+	ttl := l.client.getCacheTTL()
+	l.RLock()
+	fresh := ttl > 0 && !l.updated.IsZero() && time.Since(l.updated) < ttl
+	l.RUnlock()
+	if fresh && !force {
+		return nil
+	}
+
+	var res []apiLight
+	if err := l.client.do(ctx, http.MethodGet, `/lights/`+l.selector(), nil, &res); err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		return common.ErrNotFound
+	}
+
+	return l.update(res[0])
+}
+
+// update the cached state of the light from the API response, publishing
+// events for changes
+func (l *Light) update(a apiLight) error {
+	color := a.color()
+	power := a.Power == powerOn
+
+	l.Lock()
+	labelChanged := l.label != a.Label
+	powerChanged := l.power != power
+	colorChanged := !common.ColorEqual(l.color, color)
+	l.label = a.Label
+	l.power = power
+	l.color = color
+	l.groupID = a.Group.ID
+	l.locationID = a.Location.ID
+	l.updated = time.Now()
+	l.lastSeen = l.updated.Add(-time.Duration(a.SecondsSinceSeen * float64(time.Second)))
+	l.Unlock()
+
+	if labelChanged {
+		if err := l.publish(common.EventUpdateLabel{Label: a.Label}); err != nil {
+			return err
+		}
+	}
+	if powerChanged {
+		if err := l.publish(common.EventUpdatePower{Power: power}); err != nil {
+			return err
+		}
+	}
+	if colorChanged {
+		if err := l.publish(common.EventUpdateColor{Color: color}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Light) updatePower(state bool) error {
+	l.Lock()
+	changed := l.power != state
+	l.power = state
+	l.Unlock()
+
+	if changed {
+		return l.publish(common.EventUpdatePower{Power: state})
+	}
+
+	return nil
+}
+
+func (l *Light) updateColor(color common.Color) error {
+	l.Lock()
+	changed := !common.ColorEqual(l.color, color)
+	l.color = color
+	l.Unlock()
+
+	if changed {
+		return l.publish(common.EventUpdateColor{Color: color})
+	}
+
+	return nil
+}
+
+func (l *Light) inGroup(id string) bool {
+	l.RLock()
+	defer l.RUnlock()
+	return l.groupID == id
+}
+
+func (l *Light) inLocation(id string) bool {
+	l.RLock()
+	defer l.RUnlock()
+	return l.locationID == id
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+
+	"github.com/pdf/golifx/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagAdjustKelvinStep     int
+	flagAdjustBrightnessStep float64
+
+	cmdLightWarmer = &cobra.Command{
+		Use:     `warmer`,
+		Short:   `make lights warmer by lowering their color temperature`,
+		PreRun:  setupClient,
+		Run:     func(c *cobra.Command, args []string) { adjustLights(kelvinAdjuster(-flagAdjustKelvinStep)) },
+		PostRun: closeClient,
+	}
+
+	cmdLightCooler = &cobra.Command{
+		Use:     `cooler`,
+		Short:   `make lights cooler by raising their color temperature`,
+		PreRun:  setupClient,
+		Run:     func(c *cobra.Command, args []string) { adjustLights(kelvinAdjuster(flagAdjustKelvinStep)) },
+		PostRun: closeClient,
+	}
+
+	cmdLightBrighter = &cobra.Command{
+		Use:     `brighter`,
+		Short:   `make lights brighter`,
+		PreRun:  setupClient,
+		Run:     func(c *cobra.Command, args []string) { adjustLights(brightnessAdjuster(flagAdjustBrightnessStep)) },
+		PostRun: closeClient,
+	}
+
+	cmdLightDimmer = &cobra.Command{
+		Use:     `dimmer`,
+		Short:   `make lights dimmer`,
+		PreRun:  setupClient,
+		Run:     func(c *cobra.Command, args []string) { adjustLights(brightnessAdjuster(-flagAdjustBrightnessStep)) },
+		PostRun: closeClient,
+	}
+)
+
+func init() {
+	for _, c := range []*cobra.Command{cmdLightWarmer, cmdLightCooler} {
+		c.Flags().IntVar(&flagAdjustKelvinStep, `step`, 500, `color temperature change in kelvin`)
+		cmdLight.AddCommand(c)
+	}
+	for _, c := range []*cobra.Command{cmdLightBrighter, cmdLightDimmer} {
+		c.Flags().Float64Var(&flagAdjustBrightnessStep, `step`, 10, `brightness change as a percentage of full brightness`)
+		cmdLight.AddCommand(c)
+	}
+}
+
+func kelvinAdjuster(delta int) func(common.Light) error {
+	return func(light common.Light) error {
+		return light.AdjustKelvin(delta, flagLightDuration)
+	}
+}
+
+func brightnessAdjuster(percent float64) func(common.Light) error {
+	delta := int(math.Round(percent / 100 * math.MaxUint16))
+	return func(light common.Light) error {
+		return light.AdjustBrightness(delta, flagLightDuration)
+	}
+}
+
+// adjustLights applies adjust to the selected lights, or all lights if none
+// are selected
+func adjustLights(adjust func(common.Light) error) {
+	lights := getLights()
+	if len(lights) == 0 {
+		// Each light is adjusted from its own color, so the global color
+		// can't be used
+		var err error
+		if lights, err = client.GetLights(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Could not find lights`)
+		}
+	}
+
+	forEachLight(lights, `adjusting`, adjust)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pdf/golifx/common"
+)
+
+// deviceCache records the devices seen by previous runs, keyed by MAC
+type deviceCache struct {
+	Devices map[string]cachedDevice `json:"devices"`
+}
+
+type cachedDevice struct {
+	Label     string    `json:"label"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// defaultCacheFile returns the default location of the device cache, in the
+// user cache directory
+func defaultCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, `golifx`, `devices.json`)
+}
+
+// loadDeviceCache reads the device cache at path, a missing file is an empty
+// cache
+func loadDeviceCache(path string) *deviceCache {
+	cache := &deviceCache{Devices: make(map[string]cachedDevice)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			`filename`: path,
+			`error`:    err,
+		}).Fatalln(`Could not read device cache`)
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		logger.WithFields(logrus.Fields{
+			`filename`: path,
+			`error`:    err,
+		}).Fatalln(`Could not parse device cache`)
+	}
+	if cache.Devices == nil {
+		cache.Devices = make(map[string]cachedDevice)
+	}
+
+	return cache
+}
+
+// save writes the cache to path, creating the directory if necessary
+func (c *deviceCache) save(path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logger.WithFields(logrus.Fields{
+			`filename`: path,
+			`error`:    err,
+		}).Fatalln(`Could not create device cache directory`)
+	}
+	data, err := json.MarshalIndent(c, ``, `  `)
+	if err != nil {
+		logger.WithField(`error`, err).Fatalln(`Could not encode device cache`)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		logger.WithFields(logrus.Fields{
+			`filename`: path,
+			`error`:    err,
+		}).Fatalln(`Could not write device cache`)
+	}
+}
+
+// update adds lights missing from the cache, and returns them
+func (c *deviceCache) update(lights []common.Light) []common.Light {
+	var added []common.Light
+	now := time.Now()
+	for _, l := range lights {
+		if _, ok := c.Devices[l.MAC()]; ok {
+			continue
+		}
+		label, _ := l.GetLabel()
+		c.Devices[l.MAC()] = cachedDevice{Label: label, FirstSeen: now}
+		added = append(added, l)
+	}
+
+	return added
+}
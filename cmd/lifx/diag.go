@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pdf/golifx/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLightDiag = &cobra.Command{
+		Use:   `diag`,
+		Short: `diagnose why lights are unreachable`,
+		Long: `Diagnose why lights are unreachable.
+
+For each selected light, diag pings the light directly, and if it does not
+respond, sends a discovery broadcast to check whether it has moved to a new
+address.  For reachable lights the WiFi signal strength is read, along with the
+access point on firmware that reports it, and the reason the light last booted.
+A summary is printed for each light.`,
+		PreRun:  setupClient,
+		Run:     lightDiag,
+		PostRun: closeClient,
+	}
+)
+
+func init() {
+	cmdLight.AddCommand(cmdLightDiag)
+}
+
+func lightDiag(c *cobra.Command, args []string) {
+	lights := getLights()
+	if len(lights) == 0 {
+		if err := c.Usage(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Failed to print usage`)
+		}
+		fmt.Println()
+		logger.Fatalln(`Diagnosis requires selecting lights by ID, label or MAC`)
+	}
+
+	results := newTable(`ID`, `Address`, `Ping`, `Summary`)
+
+	for _, l := range lights {
+		address := l.Address().String()
+		rtt, err := l.Ping()
+		moved := false
+		if err != nil {
+			logger.WithField(`light-id`, l.ID()).Debugf(`Ping failed, rediscovering: %v`, err)
+			rtt, moved, err = rediscover(l, address)
+		}
+		record := diagRecord{ID: l.ID(), Address: address}
+		if err != nil {
+			record.Summary = `not responding`
+			results.add(record, l.ID(), address, `-`, record.Summary)
+			continue
+		}
+
+		record.Reachable = true
+		record.Moved = moved
+		record.Address = l.Address().String()
+		record.PingMillis = float64(rtt) / float64(time.Millisecond)
+		summary := `reachable`
+		if moved {
+			record.PreviousAddress = address
+			summary = fmt.Sprintf("moved to new IP (was %s), reachable", address)
+		}
+		if info, err := l.GetWifiInfo(); err != nil {
+			logger.WithField(`light-id`, l.ID()).Debugf(`Failed reading WiFi info: %v`, err)
+			summary += `, signal unknown`
+		} else {
+			rssi := info.RSSI()
+			record.RSSI = &rssi
+			record.Signal = info.Quality()
+			summary += fmt.Sprintf(", %s signal (%d dBm)", info.Quality(), info.RSSI())
+		}
+		// Only older firmware reports the access point
+		if ap, err := l.GetAccessPoint(); err == nil {
+			record.SSID = ap.SSID
+			summary += fmt.Sprintf(", access point %q (%s)", ap.SSID, ap.Security)
+		}
+		if reason, err := l.LastRebootReason(); err == nil {
+			record.LastReboot = reason
+			summary += fmt.Sprintf(", last booted after %s", reason)
+		}
+		record.Summary = summary
+		results.add(record, l.ID(), record.Address, rtt.Round(time.Millisecond), summary)
+	}
+	results.render()
+}
+
+// diagRecord is the JSON output of light diag
+type diagRecord struct {
+	ID              uint64  `json:"id"`
+	Address         string  `json:"address"`
+	PreviousAddress string  `json:"previous_address,omitempty"`
+	Reachable       bool    `json:"reachable"`
+	Moved           bool    `json:"moved"`
+	PingMillis      float64 `json:"ping_ms,omitempty"`
+	RSSI            *int    `json:"rssi,omitempty"`
+	Signal          string  `json:"signal,omitempty"`
+	SSID            string  `json:"ssid,omitempty"`
+	LastReboot      string  `json:"last_reboot,omitempty"`
+	Summary         string  `json:"summary"`
+}
+
+// rediscover broadcasts discovery and waits up to the timeout for the light to
+// respond, returning the ping round trip and whether the light moved from
+// address
+func rediscover(l common.Light, address string) (time.Duration, bool, error) {
+	if err := client.Discover(); err != nil {
+		return 0, false, err
+	}
+
+	deadline := time.Now().Add(flagTimeout)
+	for {
+		time.Sleep(time.Second)
+		if l.Address().String() != address {
+			rtt, err := l.Ping()
+			return rtt, true, err
+		}
+		if time.Now().After(deadline) {
+			// Try once more at the known address, in case the first ping
+			// was lost
+			rtt, err := l.Ping()
+			return rtt, false, err
+		}
+	}
+}
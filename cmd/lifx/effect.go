@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pdf/golifx"
+	"github.com/pdf/golifx/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagEffectColor  string
+	flagEffectPeriod time.Duration
+	flagEffectFor    time.Duration
+	flagEffectEasing string
+
+	cmdLightWake = &cobra.Command{
+		Use:   `wake`,
+		Short: `gradually wake lights from off to a color over --duration`,
+		Long: `Gradually wake lights from off to a color over --duration.
+
+If interrupted with Ctrl+C, the effect is stopped and the lights are restored to
+the color and power they had before the effect started.`,
+		PreRun:  setupClient,
+		Run:     lightWake,
+		PostRun: closeClient,
+	}
+
+	cmdLightCycle = &cobra.Command{
+		Use:   `cycle`,
+		Short: `cycle the hue of lights around the color wheel, end with Ctrl+C`,
+		Long: `Cycle the hue of lights around the color wheel, completing a rotation every
+--period.  Multizone lights spread the color wheel along their zones.
+
+The effect runs until interrupted with Ctrl+C, or for --for if set, after which
+the lights are restored to the color and power they had before the effect
+started.`,
+		PreRun:  setupClient,
+		Run:     lightCycle,
+		PostRun: closeClient,
+	}
+)
+
+func init() {
+	cmdLightWake.Flags().StringVarP(&flagEffectColor, `color`, `c`, `warm_white`, fmt.Sprintf("named color to wake to, one of: [%s]", strings.Join(common.ColorNames(), `,`)))
+	cmdLightWake.Flags().StringVar(&flagEffectEasing, `easing`, `linear`, fmt.Sprintf("easing curve of the brightness rise, one of: [%s]", strings.Join(common.EasingNames(), `,`)))
+	cmdLightCycle.Flags().DurationVar(&flagEffectPeriod, `period`, 10*time.Second, `time taken for each rotation of the color wheel`)
+	cmdLightCycle.Flags().DurationVar(&flagEffectFor, `for`, 0, `stop the effect after this long, runs until interrupted if zero`)
+	cmdLight.AddCommand(cmdLightWake)
+	cmdLight.AddCommand(cmdLightCycle)
+}
+
+// lightSnapshot is the state of a light before an effect, so that it may be
+// restored afterwards
+type lightSnapshot struct {
+	light common.Light
+	color common.Color
+	power bool
+	zones []common.Color
+}
+
+func snapshotLight(light common.Light) (lightSnapshot, error) {
+	s := lightSnapshot{light: light}
+	var err error
+	if s.color, err = light.GetColor(); err != nil {
+		return s, err
+	}
+	if s.power, err = light.GetPower(); err != nil {
+		return s, err
+	}
+	if mz, ok := light.(common.MultiZoneLight); ok {
+		if s.zones, err = mz.GetZoneColors(); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+func (s lightSnapshot) restore() error {
+	if mz, ok := s.light.(common.MultiZoneLight); ok && len(s.zones) > 0 {
+		if err := mz.SetZoneColors(s.zones, 0); err != nil {
+			return err
+		}
+	} else if err := s.light.SetColor(s.color, 0); err != nil {
+		return err
+	}
+	return s.light.SetPower(s.power)
+}
+
+// runEffect snapshots each light, then runs effect on each light concurrently
+// until they all complete or the command is interrupted.  The snapshots are
+// restored on interrupt, or on completion if restoreOnComplete is set.
+func runEffect(name string, effect func(ctx context.Context, c *golifx.Client, light common.Light) error, restoreOnComplete bool) {
+	lifxClient, ok := client.(*golifx.Client)
+	if !ok {
+		logger.Fatalln(`Effects are not supported by this client`)
+	}
+
+	lights := getLights()
+	if len(lights) == 0 {
+		var err error
+		if lights, err = client.GetLights(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Could not find lights`)
+		}
+	}
+
+	snapshots := make(map[uint64]lightSnapshot, len(lights))
+	for _, light := range lights {
+		s, err := snapshotLight(light)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				`light-id`: light.ID(),
+				`error`:    err,
+			}).Fatalln(`Failed reading light state`)
+		}
+		snapshots[light.ID()] = s
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	effectCtx := ctx
+	if flagEffectFor > 0 {
+		var cancel context.CancelFunc
+		effectCtx, cancel = context.WithTimeout(ctx, flagEffectFor)
+		defer cancel()
+	}
+
+	var wg sync.WaitGroup
+	for _, light := range lights {
+		wg.Add(1)
+		go func(light common.Light) {
+			defer wg.Done()
+			err := effect(effectCtx, lifxClient, light)
+			if err != nil && err != context.Canceled && err != context.DeadlineExceeded {
+				logger.WithFields(logrus.Fields{
+					`light-id`: light.ID(),
+					`error`:    err,
+				}).Errorf("Failed running %s effect", name)
+			}
+		}(light)
+	}
+	wg.Wait()
+
+	interrupted := ctx.Err() != nil
+	// Stop handling signals, so that a second interrupt during restore exits
+	stop()
+	if !interrupted && !restoreOnComplete {
+		return
+	}
+	if interrupted {
+		logger.Infof("Interrupted, restoring lights from before %s effect", name)
+	}
+	forEachLight(lights, `restoring state`, func(light common.Light) error {
+		return snapshots[light.ID()].restore()
+	})
+}
+
+func lightWake(c *cobra.Command, args []string) {
+	color, err := common.NamedColor(flagEffectColor)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			`color`:  flagEffectColor,
+			`colors`: common.ColorNames(),
+		}).Fatalln(`Unknown color name`)
+	}
+	easing, err := common.NamedEasing(flagEffectEasing)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			`easing`:  flagEffectEasing,
+			`easings`: common.EasingNames(),
+		}).Fatalln(`Unknown easing name`)
+	}
+	if flagLightDuration <= 0 {
+		if err := c.Usage(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Failed to print usage`)
+		}
+		fmt.Println()
+		logger.Fatalln(`Wake requires a --duration`)
+	}
+
+	runEffect(`wake`, func(ctx context.Context, c *golifx.Client, light common.Light) error {
+		return c.Wake(ctx, light, color, flagLightDuration, golifx.Ease(easing))
+	}, false)
+}
+
+func lightCycle(c *cobra.Command, args []string) {
+	if flagEffectPeriod <= 0 {
+		logger.WithField(`period`, flagEffectPeriod).Fatalln(`Invalid period, must be positive`)
+	}
+
+	runEffect(`cycle`, func(ctx context.Context, c *golifx.Client, light common.Light) error {
+		if mz, ok := light.(common.MultiZoneLight); ok {
+			return c.CycleZoneHue(ctx, mz, flagEffectPeriod)
+		}
+		return c.CycleHue(ctx, light, flagEffectPeriod)
+	}, true)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pdf/golifx/common"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportFormatHomeAssistant = `homeassistant`
+	exportFormatJSON          = `json`
+)
+
+var (
+	flagExportFormat string
+
+	cmdExport = &cobra.Command{
+		Use:     `export`,
+		Short:   `export discovered lights for use by other tools`,
+		Long:    `Discover lights and export their network details, in a format suitable for importing into other tools.`,
+		PreRun:  setupClient,
+		Run:     export,
+		PostRun: closeClient,
+	}
+)
+
+type exportedLight struct {
+	ID    uint64 `json:"id"`
+	Label string `json:"label"`
+	Host  string `json:"host"`
+	Port  int    `json:"port"`
+}
+
+func init() {
+	cmdExport.Flags().StringVarP(&flagExportFormat, `format`, `f`, exportFormatJSON, `output format, one of: [homeassistant,json]`)
+}
+
+func export(c *cobra.Command, args []string) {
+	if flagTimeout == 0 {
+		logger.Fatalln(`Can not export with a timeout of zero`)
+	}
+
+	if flagJSON {
+		flagExportFormat = exportFormatJSON
+	}
+
+	var write func(io.Writer, []exportedLight) error
+	switch flagExportFormat {
+	case exportFormatHomeAssistant:
+		write = writeExportHomeAssistant
+	case exportFormatJSON:
+		write = writeExportJSON
+	default:
+		if err := c.Usage(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Failed to print usage`)
+		}
+		fmt.Println()
+		logger.WithField(`format`, flagExportFormat).Fatalln(`Invalid export format requested, should be one of [homeassistant,json]`)
+	}
+
+	if flagExpect == 0 {
+		<-time.After(flagTimeout)
+	}
+
+	lights, err := client.GetLights()
+	if err == common.ErrNotFound {
+		logger.Fatalln(`No lights found`)
+	} else if err != nil {
+		logger.WithField(`error`, err).Fatalln(`Could not find lights`)
+	}
+
+	exported := make([]exportedLight, 0, len(lights))
+	for _, l := range lights {
+		label, err := l.GetLabel()
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				`light-id`: l.ID(),
+				`error`:    err,
+			}).Warnln(`Couldn't get label for light`)
+			continue
+		}
+		addr := l.Address()
+		if addr == nil {
+			logger.WithField(`light-id`, l.ID()).Warnln(`Couldn't get address for light`)
+			continue
+		}
+		exported = append(exported, exportedLight{
+			ID:    l.ID(),
+			Label: label,
+			Host:  addr.IP.String(),
+			Port:  addr.Port,
+		})
+	}
+
+	if err := write(os.Stdout, exported); err != nil {
+		logger.WithField(`error`, err).Fatalln(`Failed outputting results`)
+	}
+}
+
+func writeExportJSON(w io.Writer, lights []exportedLight) error {
+	out, err := json.MarshalIndent(lights, ``, `  `)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+// writeExportHomeAssistant writes YAML suitable for pasting into a Home
+// Assistant configuration.yaml.  Labels are emitted as double-quoted scalars,
+// the escapes produced by %q are all valid YAML escapes.
+func writeExportHomeAssistant(w io.Writer, lights []exportedLight) error {
+	if _, err := fmt.Fprintf(w, "# Generated by lifx export\nlifx:\n  light:\n"); err != nil {
+		return err
+	}
+	for _, l := range lights {
+		if _, err := fmt.Fprintf(w, "    - host: %s\n      name: %q\n", l.Host, l.Label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,9 +2,7 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -19,7 +17,10 @@ var (
 	flagGroupSaturation uint16
 	flagGroupBrightness uint16
 	flagGroupKelvin     uint16
+	flagGroupColor      string
+	flagGroupRGB        string
 	flagGroupDuration   time.Duration
+	flagGroupStagger    time.Duration
 
 	cmdGroupList = &cobra.Command{
 		Use:     `list`,
@@ -30,8 +31,13 @@ var (
 	}
 
 	cmdGroupColor = &cobra.Command{
-		Use:     `color`,
-		Short:   `set group color`,
+		Use:   `color`,
+		Short: `set group color`,
+		Long: `Set the color of every light in groups, from exactly one of: all four
+HSBK components, a hex or decimal RGB color, or a named color, as for lifx
+light color, eg:
+
+  lifx group color --label Kitchen --name warm-white`,
 		PreRun:  setupClient,
 		Run:     groupColor,
 		PostRun: closeClient,
@@ -57,22 +63,14 @@ Acts on all groups by default, however you may restrict the groups that a comman
 )
 
 func init() {
-	cmdGroupColor.Flags().Uint16VarP(&flagGroupHue, `hue`, `H`, 0, `hue component of the HSBK color (0-65535)`)
-	cmdGroupColor.Flags().Uint16VarP(&flagGroupSaturation, `saturation`, `S`, 0, `saturation component of the HSBK color (0-65535)`)
-	cmdGroupColor.Flags().Uint16VarP(&flagGroupBrightness, `brightness`, `B`, 0, `brightness component of the HSBK color (0-65535)`)
-	cmdGroupColor.Flags().Uint16VarP(&flagGroupKelvin, `kelvin`, `K`, 0, `kelvin component of the HSBK color, the color temperature of whites (2500-9000)`)
-	if err := cmdGroupColor.MarkFlagRequired(`hue`); err != nil {
-		logger.WithField(`error`, err).Panicln(`Failed initializing application`)
-	}
-	if err := cmdGroupColor.MarkFlagRequired(`saturation`); err != nil {
-		logger.WithField(`error`, err).Panicln(`Failed initializing application`)
-	}
-	if err := cmdGroupColor.MarkFlagRequired(`brightness`); err != nil {
-		logger.WithField(`error`, err).Panicln(`Failed initializing application`)
-	}
-	if err := cmdGroupColor.MarkFlagRequired(`kelvin`); err != nil {
-		logger.WithField(`error`, err).Panicln(`Failed initializing application`)
-	}
+	cmdGroupColor.Flags().Uint16VarP(&flagGroupHue, `hue`, `H`, 0, fmt.Sprintf("hue component of the HSBK color (0-%d)", common.MaxUint16Component))
+	cmdGroupColor.Flags().Uint16VarP(&flagGroupSaturation, `saturation`, `S`, 0, fmt.Sprintf("saturation component of the HSBK color (0-%d)", common.MaxUint16Component))
+	cmdGroupColor.Flags().Uint16VarP(&flagGroupBrightness, `brightness`, `B`, 0, fmt.Sprintf("brightness component of the HSBK color (0-%d)", common.MaxUint16Component))
+	cmdGroupColor.Flags().Uint16VarP(&flagGroupKelvin, `kelvin`, `K`, 0, fmt.Sprintf("kelvin component of the HSBK color, the color temperature of whites (%d-%d)", common.MinKelvin, common.MaxKelvin))
+	cmdGroupColor.Flags().StringVarP(&flagGroupColor, `color`, `c`, ``, fmt.Sprintf("named color to apply, instead of specifying HSBK components, one of: [%s]", strings.Join(common.ColorNames(), `,`)))
+	cmdGroupColor.Flags().StringVar(&flagGroupColor, `name`, ``, `alias of --color`)
+	cmdGroupColor.Flags().StringVar(&flagGroupRGB, `rgb`, ``, `RGB color to apply, instead of specifying HSBK components, as hex #RRGGBB or RRGGBB, or decimal r,g,b (0-255)`)
+	cmdGroupColor.Flags().DurationVar(&flagGroupStagger, `stagger`, 0, `start the transition of each light in the group this long after the previous light, in order of ID, sweeping the color across the group`)
 	cmdGroup.AddCommand(cmdGroupList)
 	cmdGroup.AddCommand(cmdGroupColor)
 	cmdGroup.AddCommand(cmdGroupPower)
@@ -109,9 +107,7 @@ func groupList(c *cobra.Command, args []string) {
 		}
 	}
 
-	table := new(tabwriter.Writer)
-	table.Init(os.Stdout, 0, 4, 4, ' ', 0)
-	fmt.Fprintf(table, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\n", `ID`, `Label`, `Power`, `Color`, `Devices`))
+	results := newTable(`ID`, `Label`, `Power`, `Color`, `Devices`)
 
 	for _, g := range groups {
 		var deviceLabels []string
@@ -147,12 +143,19 @@ func groupList(c *cobra.Command, args []string) {
 			}
 			deviceLabels = append(deviceLabels, l)
 		}
-		fmt.Fprintf(table, "%v\t%s\t%v\t%+v\t%+v\n", g.ID(), label, power, color, fmt.Sprintf("[%v]", strings.Join(deviceLabels, `, `)))
-	}
-	fmt.Fprintln(table)
-	if err := table.Flush(); err != nil {
-		logger.WithField(`error`, err).Fatalln(`Failed outputting results`)
+		record := groupRecord{ID: g.ID(), Label: label, Power: power, Color: common.ColorJSON(color), Devices: deviceLabels}
+		results.add(record, g.ID(), label, power, color, fmt.Sprintf("[%v]", strings.Join(deviceLabels, `, `)))
 	}
+	results.render()
+}
+
+// groupRecord is the JSON output of group list
+type groupRecord struct {
+	ID      string           `json:"id"`
+	Label   string           `json:"label"`
+	Power   bool             `json:"power"`
+	Color   common.ColorJSON `json:"color"`
+	Devices []string         `json:"devices"`
 }
 
 func getGroups() []common.Group {
@@ -224,51 +227,74 @@ func groupPower(c *cobra.Command, args []string) {
 	groups := getGroups()
 
 	if len(groups) > 0 {
-		for _, group := range groups {
-			if err := group.SetPowerDuration(state, flagGroupDuration); err != nil {
-				logger.WithFields(logrus.Fields{
-					`group-id`: group.ID(),
-					`error`:    err,
-				}).Fatalln(`Failed setting power for group`)
-			}
-		}
+		forEachGroup(groups, `setting power`, func(group common.Group) error {
+			return group.SetPowerDuration(state, flagGroupDuration)
+		})
 	} else {
-		if err := client.SetPowerDuration(state, flagGroupDuration); err != nil {
-			logger.WithField(`error`, err).Fatalln(`Failed setting power for groups`)
-		}
+		renderBroadcast(`setting power`, client.SetPowerDuration(state, flagGroupDuration))
 	}
 }
 
 func groupColor(c *cobra.Command, args []string) {
-	if flagGroupHue == 0 && flagGroupSaturation == 0 && flagGroupBrightness == 0 && flagGroupKelvin == 0 {
+	validateKelvinFlag(c, flagGroupKelvin)
+
+	hsbk := common.Color{
+		Hue:        flagGroupHue,
+		Saturation: flagGroupSaturation,
+		Brightness: flagGroupBrightness,
+		Kelvin:     flagGroupKelvin,
+	}
+	color, err := parseColor(c, hsbk, flagGroupColor, flagGroupRGB)
+	if err != nil {
 		if err := c.Usage(); err != nil {
 			logger.WithField(`error`, err).Fatalln(`Failed to print usage`)
 		}
 		fmt.Println()
-		logger.Fatalln(`Missing color definition`)
+		logger.Fatalln(err)
 	}
 
 	groups := getGroups()
 
-	color := common.Color{
-		Hue:        flagGroupHue,
-		Saturation: flagGroupSaturation,
-		Brightness: flagGroupBrightness,
-		Kelvin:     flagGroupKelvin,
-	}
-
-	if len(groups) > 0 {
-		for _, group := range groups {
-			if err := group.SetColor(color, flagGroupDuration); err != nil {
-				logger.WithFields(logrus.Fields{
-					`group-id`: group.ID(),
-					`error`:    err,
-				}).Fatalln(`Failed setting color for group`)
-			}
+	if flagGroupStagger > 0 {
+		if len(groups) == 0 {
+			logger.Fatalln(`Staggering color changes requires selecting groups by ID or label`)
 		}
+		forEachGroup(groups, `setting color wave`, func(group common.Group) error {
+			return group.SetColorWave(color, flagGroupDuration, flagGroupStagger)
+		})
+	} else if len(groups) > 0 {
+		forEachGroup(groups, `setting color`, func(group common.Group) error {
+			return group.SetColor(color, flagGroupDuration)
+		})
 	} else {
-		if err := client.SetColor(color, flagGroupDuration); err != nil {
-			logger.WithField(`error`, err).Fatalln(`Failed setting color for groups`)
+		renderBroadcast(`setting color`, client.SetColor(color, flagGroupDuration))
+	}
+}
+
+// forEachGroup applies fn to every group, as forEachLight does for lights
+func forEachGroup(groups []common.Group, action string, fn func(common.Group) error) {
+	result := operationResult{Action: action}
+	for _, group := range groups {
+		target := targetResult{ID: group.ID(), Label: group.GetLabel()}
+		if err := fn(group); err != nil {
+			result.Failed++
+			target.Error = err.Error()
+			logger.WithFields(logrus.Fields{
+				`group-id`:    group.ID(),
+				`group-label`: target.Label,
+				`error`:       err,
+			}).Errorf("Failed %s for group", action)
+		} else {
+			result.Succeeded++
 		}
+		result.Targets = append(result.Targets, target)
+	}
+	render(result, ``)
+
+	if result.Failed > 0 {
+		logger.WithFields(logrus.Fields{
+			`succeeded`: result.Succeeded,
+			`failed`:    result.Failed,
+		}).Fatalf("Failed %s for %d of %d groups", action, result.Failed, len(groups))
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -19,11 +20,15 @@ import (
 )
 
 var (
-	client *golifx.Client
+	client common.Client
 
 	flagTimeout  time.Duration
 	flagLogLevel string
 	flagPort     int
+	flagTrace    bool
+	flagExpect   int
+	flagBcast    []string
+	flagJSON     bool
 
 	logger = logrus.New()
 	app    = &cobra.Command{
@@ -66,9 +71,15 @@ func init() {
 	app.PersistentFlags().DurationVarP(&flagTimeout, `timeout`, `t`, common.DefaultTimeout, `timeout for all operations`)
 	app.PersistentFlags().StringVarP(&flagLogLevel, `log-level`, `L`, `info`, `log level, one of: [debug,info,warn,error]`)
 	app.PersistentFlags().IntVarP(&flagPort, `port`, `p`, 56700, `UDP listen port`)
+	app.PersistentFlags().BoolVar(&flagTrace, `trace`, false, `trace all protocol traffic to stderr`)
+	app.PersistentFlags().StringSliceVar(&flagBcast, `broadcast`, nil, `broadcast address(es) for discovery, eg 192.168.1.255, or networks in CIDR form, eg 10.0.20.0/24, comma-separated (defaults to 255.255.255.255:56700)`)
+	app.PersistentFlags().BoolVar(&flagJSON, `json`, false, `output results as JSON, and errors to stderr as JSON objects, for use in scripts`)
+	app.PersistentFlags().IntVar(&flagExpect, `expect`, 0, `number of devices expected on the network, listing returns as soon as this many are discovered rather than waiting for the timeout`)
 
 	app.AddCommand(cmdLight)
 	app.AddCommand(cmdGroup)
+	app.AddCommand(cmdExport)
+	app.AddCommand(cmdSchedule)
 	app.AddCommand(cmdGenerateBashComp)
 	app.AddCommand(cmdGenerateDocs)
 	app.AddCommand(cmdVersion)
@@ -84,7 +95,32 @@ func main() {
 func setupClient(c *cobra.Command, args []string) {
 	var err error
 
-	client, err = golifx.NewClient(&protocol.V2{Reliable: true, Port: flagPort})
+	proto := &protocol.V2{Reliable: true, Port: flagPort}
+	// Set before creating the client, so that the initial discovery uses the
+	// requested addresses
+	for i, address := range flagBcast {
+		if i == 0 && !strings.Contains(address, `/`) {
+			err = proto.SetBroadcastAddress(address)
+		} else {
+			err = proto.AddBroadcastAddress(address)
+		}
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				`address`: address,
+				`error`:   err,
+			}).Fatalln(`Invalid broadcast address`)
+		}
+	}
+	// Commands are short-lived, so state reported during the command may be
+	// considered fresh for its duration
+	opts := []golifx.Option{golifx.WithCacheTTL(flagTimeout)}
+	if flagTrace {
+		opts = append(opts, golifx.WithTracer(os.Stderr))
+	}
+	if flagExpect > 0 {
+		opts = append(opts, golifx.WithTimeout(flagTimeout), golifx.WithExpectedDeviceCount(flagExpect))
+	}
+	client, err = golifx.NewClient(proto, opts...)
 	if err != nil {
 		logger.WithField(`error`, err).Fatalln(`Failed initializing client`)
 	}
@@ -95,6 +131,9 @@ func closeClient(c *cobra.Command, args []string) {
 	if err != nil {
 		logger.WithField(`error`, err).Fatalln(`Failed closing client`)
 	}
+	if missingLights > 0 {
+		logger.Fatalf("%d of the requested lights were not found", missingLights)
+	}
 }
 
 func generateBashComp(c *cobra.Command, args []string) {
@@ -148,7 +187,7 @@ func generateDocs(c *cobra.Command, args []string) {
 }
 
 func version(c *cobra.Command, args []string) {
-	fmt.Printf("lifx version %s\n", golifx.VERSION)
+	render(map[string]string{`version`: golifx.VERSION}, fmt.Sprintf("lifx version %s", golifx.VERSION))
 }
 
 func usage(c *cobra.Command, args []string) {
@@ -177,4 +216,7 @@ func setLogger() {
 	default:
 		logger.Level = logrus.InfoLevel
 	}
+	if flagJSON {
+		logger.Formatter = &jsonFormatter{}
+	}
 }
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/rand"
 	"os"
-	"text/tabwriter"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/pdf/golifx"
 	"github.com/pdf/golifx/common"
 	"github.com/spf13/cobra"
 )
@@ -14,11 +24,33 @@ import (
 var (
 	flagLightIDs        []int
 	flagLightLabels     []string
+	flagLightMACs       []string
 	flagLightHue        uint16
 	flagLightSaturation uint16
 	flagLightBrightness uint16
 	flagLightKelvin     uint16
 	flagLightDuration   time.Duration
+	flagLightImage      string
+	flagLightColor      string
+	flagLightRGB        string
+	flagLightRetries    int
+	flagLightNewOnly    bool
+	flagLightZones      string
+	flagLightIfOn       bool
+	flagLightPowerFor   time.Duration
+	flagLightCacheFile  string
+	flagLightHueDeg     float64
+	flagLightSat        float64
+	flagLightVal        float64
+	flagLightStrict     bool
+	flagLightLabelGlob  string
+	flagLightSame       bool
+	flagLightCopyFrom   string
+	flagLightCopyTo     []string
+	flagLightBlink      bool
+
+	// missingLights counts requested lights that were not found
+	missingLights int
 
 	cmdLightList = &cobra.Command{
 		Use:     `list`,
@@ -29,8 +61,20 @@ var (
 	}
 
 	cmdLightColor = &cobra.Command{
-		Use:     `color`,
-		Short:   `set light color`,
+		Use:   `color`,
+		Short: `set light color`,
+		Long: `Set the color of lights, from exactly one of: all four HSBK components,
+the HSV alternatives, a hex or decimal RGB color, a named color, or an image,
+eg:
+
+  lifx light color --hue 21845 --saturation 65535 --brightness 65535 --kelvin 3500
+  lifx light color --rgb '#FF8800'
+  lifx light color --name warm-white
+
+RGB colors that appear white, including grays, are set as a white of the
+matching color temperature.  --kelvin may be combined with the other forms to
+override their color temperature, the HSV alternatives otherwise use a neutral
+3500K.`,
 		PreRun:  setupClient,
 		Run:     lightColor,
 		PostRun: closeClient,
@@ -46,6 +90,38 @@ var (
 		PostRun:   closeClient,
 	}
 
+	cmdLightBrightness = &cobra.Command{
+		Use:     `brightness <percent>`,
+		Short:   `set light brightness as a percentage, eg 75 or 75%`,
+		Long:    `Set the brightness of lights as a percentage (0-100, out of range values are clamped), preserving their hue, saturation and kelvin.`,
+		PreRun:  setupClient,
+		Run:     lightBrightness,
+		PostRun: closeClient,
+	}
+
+	cmdLightRandom = &cobra.Command{
+		Use:   `random`,
+		Short: `set lights to random colors`,
+		Long: `Set each light to a color of random hue at full saturation and brightness, or all lights to the same random color with --same.
+Lights are changed one at a time, so that requests are paced by the rate limit of each light.`,
+		PreRun:  setupClient,
+		Run:     lightRandom,
+		PostRun: closeClient,
+	}
+
+	cmdLightCopy = &cobra.Command{
+		Use:   `copy`,
+		Short: `copy the color of one light to others`,
+		Long: `Copy the current color of the light labelled --from to the lights labelled --to, eg:
+
+  lifx light copy --from Desk --to Shelf,Lamp --duration 1s
+
+The color is read once, then applied to every target at once.`,
+		PreRun:  setupClient,
+		Run:     lightCopy,
+		PostRun: closeClient,
+	}
+
 	cmdLight = &cobra.Command{
 		Use:   `light`,
 		Short: `interact with lights`,
@@ -56,87 +132,154 @@ Acts on all lights by default, however you may restrict the lights that a comman
 )
 
 func init() {
-	cmdLightColor.Flags().Uint16VarP(&flagLightHue, `hue`, `H`, 0, `hue component of the HSBK color (0-65535)`)
-	cmdLightColor.Flags().Uint16VarP(&flagLightSaturation, `saturation`, `S`, 0, `saturation component of the HSBK color (0-65535)`)
-	cmdLightColor.Flags().Uint16VarP(&flagLightBrightness, `brightness`, `B`, 0, `brightness component of the HSBK color (0-65535)`)
-	cmdLightColor.Flags().Uint16VarP(&flagLightKelvin, `kelvin`, `K`, 0, `kelvin component of the HSBK color, the color temperature of whites (2500-9000)`)
-	if err := cmdLightColor.MarkFlagRequired(`hue`); err != nil {
-		logger.WithField(`error`, err).Panicln(`Failed initializing application`)
-	}
-	if err := cmdLightColor.MarkFlagRequired(`saturation`); err != nil {
-		logger.WithField(`error`, err).Panicln(`Failed initializing application`)
-	}
-	if err := cmdLightColor.MarkFlagRequired(`brightness`); err != nil {
-		logger.WithField(`error`, err).Panicln(`Failed initializing application`)
-	}
-	if err := cmdLightColor.MarkFlagRequired(`kelvin`); err != nil {
-		logger.WithField(`error`, err).Panicln(`Failed initializing application`)
-	}
+	cmdLightColor.Flags().Uint16VarP(&flagLightHue, `hue`, `H`, 0, fmt.Sprintf("hue component of the HSBK color (0-%d)", common.MaxUint16Component))
+	cmdLightColor.Flags().Uint16VarP(&flagLightSaturation, `saturation`, `S`, 0, fmt.Sprintf("saturation component of the HSBK color (0-%d)", common.MaxUint16Component))
+	cmdLightColor.Flags().Uint16VarP(&flagLightBrightness, `brightness`, `B`, 0, fmt.Sprintf("brightness component of the HSBK color (0-%d)", common.MaxUint16Component))
+	cmdLightColor.Flags().Uint16VarP(&flagLightKelvin, `kelvin`, `K`, 0, fmt.Sprintf("kelvin component of the HSBK color, the color temperature of whites (%d-%d)", common.MinKelvin, common.MaxKelvin))
+	cmdLightColor.Flags().Float64Var(&flagLightHueDeg, `hue-deg`, 0, `hue in degrees (0-360), alternative to --hue`)
+	cmdLightColor.Flags().Float64Var(&flagLightSat, `sat`, 0, `saturation as a fraction (0-1), alternative to --saturation`)
+	cmdLightColor.Flags().Float64Var(&flagLightVal, `val`, 0, `value (brightness) as a fraction (0-1), alternative to --brightness`)
+	cmdLightColor.Flags().StringVarP(&flagLightColor, `color`, `c`, ``, fmt.Sprintf("named color to apply, instead of specifying HSBK components, one of: [%s]", strings.Join(common.ColorNames(), `,`)))
+	cmdLightColor.Flags().StringVar(&flagLightColor, `name`, ``, `alias of --color`)
+	cmdLightColor.Flags().StringVar(&flagLightRGB, `rgb`, ``, `RGB color to apply, instead of specifying HSBK components, as hex #RRGGBB or RRGGBB, or decimal r,g,b (0-255)`)
+	cmdLightColor.Flags().StringVar(&flagLightZones, `zones`, ``, `range of zones to color on multizone lights, eg 0-7 or 3, requires selecting lights`)
+	cmdLightColor.Flags().BoolVar(&flagLightIfOn, `if-on`, false, `only change the color of lights that are currently on`)
+	cmdLightColor.Flags().StringVar(&flagLightImage, `image`, ``, `path to an image (png, jpeg, gif) whose dominant color will be applied, instead of specifying HSBK components`)
 	cmdLight.AddCommand(cmdLightList)
 	cmdLight.AddCommand(cmdLightColor)
+	cmdLightPower.Flags().DurationVar(&flagLightPowerFor, `for`, 0, `power lights off again after this long, the command runs until then`)
 	cmdLight.AddCommand(cmdLightPower)
+	cmdLight.AddCommand(cmdLightBrightness)
+	cmdLightRandom.Flags().BoolVar(&flagLightSame, `same`, false, `set all lights to the same random color`)
+	cmdLight.AddCommand(cmdLightRandom)
+	cmdLightCopy.Flags().StringVar(&flagLightCopyFrom, `from`, ``, `label of the light to copy the color from`)
+	cmdLightCopy.Flags().StringSliceVar(&flagLightCopyTo, `to`, make([]string, 0), `label of the light(s) to copy the color to, comma-separated`)
+	cmdLight.AddCommand(cmdLightCopy)
+
+	cmdLightList.Flags().IntVar(&flagLightRetries, `retries`, 0, `number of times to retry discovery if no lights are found`)
+	cmdLightList.Flags().BoolVar(&flagLightNewOnly, `new-only`, false, `only list lights not seen by a previous --new-only run, recording them in the device cache`)
+	cmdLightList.Flags().StringVar(&flagLightCacheFile, `cache-file`, defaultCacheFile(), `path to the device cache used by --new-only`)
 
 	cmdLight.PersistentFlags().IntSliceVarP(&flagLightIDs, `id`, `i`, make([]int, 0), `ID of the light(s) to manage, comma-seprated.  Defaults to all lights`)
 	cmdLight.PersistentFlags().StringSliceVarP(&flagLightLabels, `label`, `l`, make([]string, 0), `label of the light(s) to manage, comma-separated.  Defaults to all lights.`)
+	cmdLight.PersistentFlags().StringVar(&flagLightLabelGlob, `label-glob`, ``, `glob pattern matching the labels of the light(s) to manage, eg 'Office-*', where * matches any characters, ? a single character, and [12] a set of characters`)
+	cmdLight.PersistentFlags().StringSliceVarP(&flagLightMACs, `mac`, `m`, make([]string, 0), `MAC address (serial) of the light(s) to manage in the form aa:bb:cc:dd:ee:ff, comma-separated.  Defaults to all lights.`)
+	cmdLight.PersistentFlags().BoolVar(&flagLightStrict, `strict`, false, `exit immediately if any requested light is not found, rather than continuing with the lights found`)
 	cmdLight.PersistentFlags().DurationVarP(&flagLightDuration, `duration`, `d`, 0*time.Second, `duration of the power/color transition`)
+	cmdLight.PersistentFlags().BoolVar(&flagLightBlink, `blink-on-success`, false, `briefly blink each light that was updated successfully once the command completes, to show which lights responded`)
 }
 
 func lightList(c *cobra.Command, args []string) {
-	var (
-		err     error
-		lights  []common.Light
-		timeout <-chan time.Time
-	)
-
 	if flagTimeout == 0 {
 		logger.Fatalln(`Can not list with a timeout of zero`)
 	}
 
-	if len(flagLightIDs) > 0 || len(flagLightLabels) > 0 {
+	var lights []common.Light
+	if len(flagLightIDs) > 0 || len(flagLightLabels) > 0 || len(flagLightMACs) > 0 || flagLightLabelGlob != `` {
 		lights = getLights()
 	} else {
-		timeout = time.After(flagTimeout)
-		<-timeout
+		lights = discoverLights(flagLightRetries)
+	}
 
-		lights, err = client.GetLights()
-		if err == common.ErrNotFound {
-			logger.Fatalln(`No lights found`)
-		} else if err != nil {
-			logger.WithField(`error`, err).Fatalln(`Could not find lights`)
+	if flagLightNewOnly {
+		cache := loadDeviceCache(flagLightCacheFile)
+		lights = cache.update(lights)
+		cache.save(flagLightCacheFile)
+		if len(lights) == 0 && !flagJSON {
+			logger.Infoln(`No new lights found`)
+			return
 		}
 	}
 
-	table := new(tabwriter.Writer)
-	table.Init(os.Stdout, 0, 4, 4, ' ', 0)
-	fmt.Fprintf(table, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\n", `ID`, `Label`, `Power`, `Color`, `Firmware`))
-
+	results := newTable(`ID`, `Label`, `Power`, `Color`, `Firmware`, `Note`)
 	for _, l := range lights {
+		record := lightRecord{ID: l.ID(), MAC: l.MAC()}
+		if !l.IsOnline() {
+			// Offline lights will not respond, so only cached state is shown
+			record.Label, _ = l.GetLabel()
+			record.Firmware = l.CachedFirmwareVersion()
+			results.add(record, l.ID(), record.Label, `offline`, `-`, record.Firmware, ``)
+			continue
+		}
+		record.Online = true
+		// Lights that answered discovery but not subsequent requests are
+		// still listed, as they may respond to commands, with the details
+		// that could not be read left blank
 		label, err := l.GetLabel()
 		if err != nil {
-			logger.WithField(`light-id`, l.ID()).Warnln(`Couldn't get color for light`)
-			continue
+			logger.WithField(`light-id`, l.ID()).Warnln(`Couldn't get label for light`)
+			record.Note = noteIncomplete
 		}
-		power, err := l.GetPower()
+		record.Label = label
+		// Color is requested first, as the light state also carries the power
+		// level, which is then served from the cache
+		color, err := l.GetColor()
 		if err != nil {
 			logger.WithField(`light-id`, l.ID()).Warnln(`Couldn't get color for light`)
+			record.Note = noteIncomplete
+			results.add(record, l.ID(), label, `-`, `-`, `-`, record.Note)
 			continue
 		}
-		color, err := l.GetColor()
+		power, err := l.GetPower()
 		if err != nil {
-			logger.WithField(`light-id`, l.ID()).Warnln(`Couldn't get color for light`)
+			logger.WithField(`light-id`, l.ID()).Warnln(`Couldn't get power for light`)
+			record.Note = noteIncomplete
+			results.add(record, l.ID(), label, `-`, `-`, `-`, record.Note)
 			continue
 		}
+		record.Power = &power
+		colorJSON := common.ColorJSON(color)
+		record.Color = &colorJSON
 		firmwareVersion, err := l.GetFirmwareVersion()
 		if err != nil {
 			logger.WithField(`light-id`, l.ID()).Warnln(`Couldn't get firmware version for light`)
-			continue
+			record.Note = noteIncomplete
+			firmwareVersion = `-`
+		} else {
+			record.Firmware = firmwareVersion
 		}
-		fmt.Fprintf(table, "%v\t%s\t%v\t%+v\t%s\n", l.ID(), label, power, color, firmwareVersion)
+		results.add(record, l.ID(), label, power, color, firmwareVersion, record.Note)
+	}
+	results.render()
+}
+
+// noteIncomplete notes a light listed without some of its details, as it did
+// not respond to every request
+const noteIncomplete = `incomplete, did not respond to every request`
+
+// lightRecord is the JSON output of light list, power and color are omitted
+// for offline lights, and lights that did not report them, which are noted
+type lightRecord struct {
+	ID       uint64            `json:"id"`
+	MAC      string            `json:"mac"`
+	Label    string            `json:"label"`
+	Online   bool              `json:"online"`
+	Power    *bool             `json:"power,omitempty"`
+	Color    *common.ColorJSON `json:"color,omitempty"`
+	Firmware string            `json:"firmware"`
+	Note     string            `json:"note,omitempty"`
+}
+
+// discoverLights waits for discovery until the timeout, the expected number of
+// lights respond, or Ctrl+C, returning the lights found so far.  If no lights
+// are found, discovery is retried up to retries times.
+func discoverLights(retries int) []common.Light {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	discoverCtx, cancel := context.WithTimeout(ctx, flagTimeout)
+	lights, err := client.GetLightsContext(discoverCtx)
+	cancel()
+	if err == common.ErrNotFound && retries > 0 && ctx.Err() == nil {
+		// The first attempt of GetLightsWithRetry only checks the lights
+		// found above, so allow one more for the requested retries
+		lights, err = client.GetLightsWithRetry(ctx, retries+1)
 	}
-	fmt.Fprintln(table)
-	if err := table.Flush(); err != nil {
-		logger.WithField(`error`, err).Fatalln(`Failed outputting results`)
+	if err == common.ErrNotFound || err == context.Canceled {
+		logger.Fatalln(`No lights found`)
+	} else if err != nil {
+		logger.WithField(`error`, err).Fatalln(`Could not find lights`)
 	}
+	return lights
 }
 
 func getLights() []common.Light {
@@ -144,15 +287,18 @@ func getLights() []common.Light {
 
 	logger.WithField(`ids`, flagLightIDs).Debug(`Requested IDs`)
 	logger.WithField(`labels`, flagLightLabels).Debug(`Requested labels`)
+	logger.WithField(`macs`, flagLightMACs).Debug(`Requested MACs`)
+	logger.WithField(`pattern`, flagLightLabelGlob).Debug(`Requested label pattern`)
 
 	if len(flagLightIDs) > 0 {
 		for _, id := range flagLightIDs {
 			light, err := client.GetLightByID(uint64(id))
 			if err != nil {
-				logger.WithFields(logrus.Fields{
+				missingLight(logrus.Fields{
 					`error`: err,
 					`ID`:    id,
-				}).Fatalln(`Could not find light with requested ID`)
+				}, `Could not find light with requested ID`)
+				continue
 			}
 			lights = append(lights, light)
 		}
@@ -161,18 +307,66 @@ func getLights() []common.Light {
 		for _, label := range flagLightLabels {
 			light, err := client.GetLightByLabel(label)
 			if err != nil {
-				logger.WithFields(logrus.Fields{
+				missingLight(logrus.Fields{
 					`error`: err,
 					`label`: label,
-				}).Fatalln(`Could not find light with requested label`)
+				}, `Could not find light with requested label`)
+				continue
 			}
 			lights = append(lights, light)
 		}
 	}
+	if len(flagLightMACs) > 0 {
+		for _, mac := range flagLightMACs {
+			light, err := client.GetLightByMAC(mac)
+			if err != nil {
+				missingLight(logrus.Fields{
+					`error`: err,
+					`MAC`:   mac,
+				}, `Could not find light with requested MAC`)
+				continue
+			}
+			lights = append(lights, light)
+		}
+	}
+	if flagLightLabelGlob != `` {
+		// Patterns are matched against the lights already known, so wait
+		// for discovery first
+		ctx, cancel := context.WithTimeout(context.Background(), flagTimeout)
+		_, _ = client.GetLightsContext(ctx)
+		cancel()
+		matched, err := client.GetLightsByLabelPattern(flagLightLabelGlob)
+		if err == common.ErrInvalidArgument {
+			logger.WithField(`pattern`, flagLightLabelGlob).Fatalln(`Invalid label pattern`)
+		} else if err != nil {
+			missingLight(logrus.Fields{
+				`error`:   err,
+				`pattern`: flagLightLabelGlob,
+			}, `Could not find lights matching requested label pattern`)
+		}
+		lights = append(lights, matched...)
+	}
+
+	if len(lights) == 0 && missingLights > 0 {
+		// Carrying on would act on all lights, rather than none of those
+		// requested
+		logger.Fatalln(`None of the requested lights were found`)
+	}
 
 	return lights
 }
 
+// missingLight reports a requested light that was not found, which is fatal
+// with --strict, otherwise the command continues with the lights found and
+// exits non-zero once complete (see closeClient)
+func missingLight(fields logrus.Fields, msg string) {
+	if flagLightStrict {
+		logger.WithFields(fields).Fatalln(msg)
+	}
+	missingLights++
+	logger.WithFields(fields).Warnln(msg)
+}
+
 func lightPower(c *cobra.Command, args []string) {
 	if len(args) < 1 {
 		if err := c.Usage(); err != nil {
@@ -199,52 +393,442 @@ func lightPower(c *cobra.Command, args []string) {
 
 	lights := getLights()
 
+	if flagLightPowerFor > 0 {
+		if !state {
+			logger.Fatalln(`--for may only be used to power lights on`)
+		}
+		lightPowerOnFor(lights)
+		return
+	}
+
 	if len(lights) > 0 {
-		for _, light := range lights {
-			if err := light.SetPowerDuration(state, flagLightDuration); err != nil {
+		forEachLight(lights, `setting power`, func(light common.Light) error {
+			return light.SetPowerDuration(state, flagLightDuration)
+		})
+	} else {
+		renderBroadcast(`setting power`, client.SetPowerDuration(state, flagLightDuration))
+	}
+}
+
+// lightPowerOnFor powers on each of lights (or all lights if none are
+// selected), then off again after --for, or on Ctrl+C
+func lightPowerOnFor(lights []common.Light) {
+	lifxClient, ok := client.(*golifx.Client)
+	if !ok {
+		logger.Fatalln(`Timed power is not supported by this client`)
+	}
+	if len(lights) == 0 {
+		var err error
+		if lights, err = client.GetLights(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Could not find lights`)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var wg sync.WaitGroup
+	for _, light := range lights {
+		wg.Add(1)
+		go func(light common.Light) {
+			defer wg.Done()
+			if err := lifxClient.PowerOnFor(ctx, light, flagLightPowerFor); err == context.Canceled {
+				// Interrupted, power off now rather than leaving the light on
+				if err := light.SetPower(false); err != nil {
+					logger.WithFields(logrus.Fields{
+						`light-id`: light.ID(),
+						`error`:    err,
+					}).Errorln(`Failed powering off light`)
+				}
+			} else if err != nil {
 				logger.WithFields(logrus.Fields{
 					`light-id`: light.ID(),
 					`error`:    err,
-				}).Fatalln(`Failed setting power for light`)
+				}).Errorln(`Failed timed power for light`)
 			}
+		}(light)
+	}
+	wg.Wait()
+}
+
+func lightCopy(c *cobra.Command, args []string) {
+	if flagLightCopyFrom == `` || len(flagLightCopyTo) == 0 {
+		if err := c.Usage(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Failed to print usage`)
 		}
-	} else {
-		if err := client.SetPowerDuration(state, flagLightDuration); err != nil {
-			logger.WithField(`error`, err).Fatalln(`Failed setting power for lights`)
+		fmt.Println()
+		logger.Fatalln(`Both --from and --to are required`)
+	}
+	lifxClient, ok := client.(*golifx.Client)
+	if !ok {
+		logger.Fatalln(`Copying colors is not supported by this client`)
+	}
+
+	src, err := client.GetLightByLabel(flagLightCopyFrom)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			`error`: err,
+			`label`: flagLightCopyFrom,
+		}).Fatalln(`Could not find light to copy from`)
+	}
+	var dst []common.Light
+	for _, label := range flagLightCopyTo {
+		light, err := client.GetLightByLabel(label)
+		if err != nil {
+			missingLight(logrus.Fields{
+				`error`: err,
+				`label`: label,
+			}, `Could not find light with requested label`)
+			continue
 		}
+		dst = append(dst, light)
+	}
+	if len(dst) == 0 {
+		logger.Fatalln(`Could not find lights to copy to`)
 	}
+
+	if err := lifxClient.CopyColor(src, flagLightDuration, dst...); err != nil {
+		logger.WithField(`error`, err).Fatalln(`Failed copying color`)
+	}
+	render(operationResult{Action: `copying color`, Succeeded: len(dst)}, ``)
 }
 
-func lightColor(c *cobra.Command, args []string) {
-	if flagLightHue == 0 && flagLightSaturation == 0 && flagLightBrightness == 0 && flagLightKelvin == 0 {
+func lightBrightness(c *cobra.Command, args []string) {
+	if len(args) < 1 {
 		if err := c.Usage(); err != nil {
 			logger.WithField(`error`, err).Fatalln(`Failed to print usage`)
 		}
 		fmt.Println()
-		logger.Fatalln(`Missing color definition`)
+		logger.Fatalln(`Missing brightness percentage`)
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(args[0]), `%`), 64)
+	if err != nil {
+		logger.WithField(`brightness`, args[0]).Fatalln(`Invalid brightness percentage, should be a number from 0 to 100`)
 	}
 
 	lights := getLights()
+	if len(lights) == 0 {
+		// Each light keeps its own color, so the global color can't be used
+		if lights, err = client.GetLights(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Could not find lights`)
+		}
+	}
+
+	forEachLight(lights, `setting brightness`, func(light common.Light) error {
+		return light.SetBrightnessPercent(percent, flagLightDuration)
+	})
+}
+
+func lightRandom(c *cobra.Command, args []string) {
+	lights := getLights()
+	if len(lights) == 0 {
+		var err error
+		if lights, err = client.GetLights(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Could not find lights`)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	color := common.RandomColor(rng)
+	forEachLight(lights, `setting random color`, func(light common.Light) error {
+		if !flagLightSame {
+			color = common.RandomColor(rng)
+		}
+		return light.SetColor(color, flagLightDuration)
+	})
+}
+
+func lightColor(c *cobra.Command, args []string) {
+	validateKelvinFlag(c, flagLightKelvin)
 
-	color := common.Color{
+	hsbk := common.Color{
 		Hue:        flagLightHue,
 		Saturation: flagLightSaturation,
 		Brightness: flagLightBrightness,
 		Kelvin:     flagLightKelvin,
 	}
+	color, err := parseColor(c, hsbk, flagLightColor, flagLightRGB)
+	if err != nil {
+		if err := c.Usage(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Failed to print usage`)
+		}
+		fmt.Println()
+		logger.Fatalln(err)
+	}
+
+	lights := getLights()
+
+	if flagLightZones != `` {
+		lightColorZones(lights, color)
+		return
+	}
+
+	if flagLightIfOn {
+		lightColorIfOn(lights, color)
+		return
+	}
 
 	if len(lights) > 0 {
-		for _, light := range lights {
-			if err := light.SetColor(color, flagLightDuration); err != nil {
+		forEachLight(lights, `setting color`, func(light common.Light) error {
+			return light.SetColor(color, flagLightDuration)
+		})
+	} else {
+		renderBroadcast(`setting color`, client.SetColor(color, flagLightDuration))
+	}
+}
+
+// parseColor returns the color defined by the flags of c, which must define it
+// in exactly one form, with --kelvin overriding the color temperature of forms
+// other than HSBK.  The HSBK form requires all four components, so that a
+// missing brightness or kelvin is not silently sent as zero.  hsbk, name and
+// rgb are the values of the HSBK, --color and --rgb flags, forms without flags
+// on c are never selected.  Color parsing itself is in common.NamedColor and
+// common.ParseRGB.
+func parseColor(c *cobra.Command, hsbk common.Color, name, rgb string) (common.Color, error) {
+	changed := c.Flags().Changed
+	var forms []string
+	if changed(`color`) || changed(`name`) {
+		forms = append(forms, `--color/--name`)
+	}
+	if changed(`rgb`) {
+		forms = append(forms, `--rgb`)
+	}
+	if changed(`image`) {
+		forms = append(forms, `--image`)
+	}
+	if changed(`hue-deg`) || changed(`sat`) || changed(`val`) {
+		forms = append(forms, `--hue-deg/--sat/--val`)
+	}
+	if changed(`hue`) || changed(`saturation`) || changed(`brightness`) || (len(forms) == 0 && changed(`kelvin`)) {
+		forms = append(forms, `--hue/--saturation/--brightness/--kelvin`)
+	}
+	switch len(forms) {
+	case 0:
+		return common.Color{}, fmt.Errorf("Missing color definition")
+	case 1:
+	default:
+		return common.Color{}, fmt.Errorf("Conflicting color definitions, specify only one of: %s", strings.Join(forms, `, `))
+	}
+
+	var color common.Color
+	switch {
+	case changed(`color`) || changed(`name`):
+		var err error
+		if color, err = common.NamedColor(name); err != nil {
+			return color, fmt.Errorf("Unknown color name %q, should be one of: %s", name, strings.Join(common.ColorNames(), `, `))
+		}
+	case changed(`rgb`):
+		var err error
+		if color, err = common.ParseRGB(rgb); err != nil {
+			return color, fmt.Errorf("Invalid RGB color %q, should be #RRGGBB, RRGGBB or r,g,b (0-255)", rgb)
+		}
+	case changed(`image`):
+		color = imageColor(flagLightImage)
+	case changed(`hue-deg`) || changed(`sat`) || changed(`val`):
+		for _, flag := range []string{`hue-deg`, `sat`, `val`} {
+			if !changed(flag) {
+				return common.Color{}, fmt.Errorf("Incomplete HSV color, missing --%s, all of --hue-deg, --sat and --val are required", flag)
+			}
+		}
+		kelvin := uint16(common.DefaultKelvin)
+		if changed(`kelvin`) {
+			kelvin = hsbk.Kelvin
+		}
+		return common.ColorHSV{
+			H:      flagLightHueDeg,
+			S:      flagLightSat,
+			V:      flagLightVal,
+			Kelvin: kelvin,
+		}.Color(), nil
+	default:
+		for _, flag := range []string{`hue`, `saturation`, `brightness`, `kelvin`} {
+			if !changed(flag) {
+				return common.Color{}, fmt.Errorf("Incomplete HSBK color, missing --%s, all of --hue, --saturation, --brightness and --kelvin are required", flag)
+			}
+		}
+		return hsbk, nil
+	}
+	if changed(`kelvin`) {
+		color.Kelvin = hsbk.Kelvin
+	}
+
+	return color, nil
+}
+
+// validateKelvinFlag exits if --kelvin was set outside the range supported by
+// lights
+func validateKelvinFlag(c *cobra.Command, kelvin uint16) {
+	if c.Flags().Changed(`kelvin`) && (kelvin < common.MinKelvin || kelvin > common.MaxKelvin) {
+		logger.WithField(`kelvin`, kelvin).Fatalf("Invalid kelvin, should be from %d to %d", common.MinKelvin, common.MaxKelvin)
+	}
+}
+
+// lightColorIfOn sets color on each of lights (or all lights if none are
+// selected) that is currently powered on
+func lightColorIfOn(lights []common.Light, color common.Color) {
+	if len(lights) == 0 {
+		var err error
+		if lights, err = client.GetLights(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Could not find lights`)
+		}
+	}
+
+	forEachLight(lights, `setting color`, func(light common.Light) error {
+		applied, err := light.SetColorIfOn(color, flagLightDuration)
+		if err == nil && !applied {
+			logger.WithField(`light-id`, light.ID()).Debugln(`Skipped light that is off`)
+		}
+		return err
+	})
+}
+
+// forEachLight applies fn to every light, logging each failure rather than
+// stopping at the first, then logs a summary.  Exits non-zero if fn failed for
+// any light, so that scripts can rely on the exit code.
+func forEachLight(lights []common.Light, action string, fn func(common.Light) error) {
+	result := operationResult{Action: action}
+	var succeeded []common.Light
+	for _, light := range lights {
+		label, _ := light.GetLabel()
+		target := targetResult{ID: light.ID(), Label: label}
+		if err := fn(light); err != nil {
+			result.Failed++
+			target.Error = err.Error()
+			logger.WithFields(logrus.Fields{
+				`light-id`:    light.ID(),
+				`light-label`: label,
+				`error`:       err,
+			}).Errorf("Failed %s for light", action)
+		} else {
+			result.Succeeded++
+			succeeded = append(succeeded, light)
+		}
+		result.Targets = append(result.Targets, target)
+	}
+	render(result, ``)
+	if flagLightBlink {
+		blinkLights(succeeded)
+	}
+
+	failed := result.Failed
+	fields := logrus.Fields{
+		`succeeded`: result.Succeeded,
+		`failed`:    failed,
+	}
+	if failed > 0 {
+		logger.WithFields(fields).Fatalf("Failed %s for %d of %d lights", action, failed, len(lights))
+	}
+	logger.WithFields(fields).Debugf("Finished %s for %d lights", action, len(lights))
+}
+
+const (
+	// blinkPeriod and blinkCycles are the flash of --blink-on-success
+	blinkPeriod        = 400 * time.Millisecond
+	blinkCycles uint16 = 2
+)
+
+// blinkLights briefly flashes each light to a contrasting color once any
+// transition of the command has finished, so that the flash does not interrupt
+// it.  The flash is transient, lights revert to their color by themselves, and
+// lights that are powered off show no flash.  Failures are only logged, as the
+// command itself has completed.
+func blinkLights(lights []common.Light) {
+	if len(lights) == 0 {
+		return
+	}
+	time.Sleep(flagLightDuration)
+	var wg sync.WaitGroup
+	for _, light := range lights {
+		wg.Add(1)
+		go func(light common.Light) {
+			defer wg.Done()
+			if err := light.FlashColor(common.BlinkColor(light.CachedColor()), blinkPeriod, blinkCycles); err != nil {
 				logger.WithFields(logrus.Fields{
 					`light-id`: light.ID(),
 					`error`:    err,
-				}).Fatalln(`Failed setting color for light`)
+				}).Warnln(`Failed blinking light`)
 			}
+		}(light)
+	}
+	wg.Wait()
+}
+
+// lightColorZones sets the range of zones given by --zones to color on each
+// light, failing for lights that are not multizone.  With --if-on, lights that
+// are off are skipped, as per lightColorIfOn.
+func lightColorZones(lights []common.Light, color common.Color) {
+	start, end, err := parseZones(flagLightZones)
+	if err != nil {
+		logger.WithField(`zones`, flagLightZones).Fatalln(`Invalid zone range, should be a zone index or range, eg 3 or 0-7`)
+	}
+	if len(lights) == 0 {
+		logger.Fatalln(`Setting zones requires selecting lights by ID, label or MAC`)
+	}
+
+	forEachLight(lights, `setting zone colors`, func(light common.Light) error {
+		mz, ok := light.(common.MultiZoneLight)
+		if !ok {
+			return fmt.Errorf("Not a multizone light, zones can not be set")
 		}
-	} else {
-		if err := client.SetColor(color, flagLightDuration); err != nil {
-			logger.WithField(`error`, err).Fatalln(`Failed setting color for lights`)
+		if flagLightIfOn {
+			power, err := light.GetPower()
+			if err != nil {
+				return err
+			}
+			if !power {
+				logger.WithField(`light-id`, light.ID()).Debugln(`Skipped light that is off`)
+				return nil
+			}
 		}
+		return mz.SetColorZones(start, end, color, flagLightDuration)
+	})
+}
+
+// parseZones parses a zone index (`3`) or inclusive range (`0-7`)
+func parseZones(zones string) (start, end uint8, err error) {
+	parts := strings.SplitN(zones, `-`, 2)
+	s, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 8)
+	if err != nil {
+		return 0, 0, err
 	}
+	e := s
+	if len(parts) == 2 {
+		if e, err = strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 8); err != nil {
+			return 0, 0, err
+		}
+	}
+	if e < s {
+		return 0, 0, common.ErrInvalidArgument
+	}
+
+	return uint8(s), uint8(e), nil
+}
+
+// imageColor returns the dominant color of the image at path
+func imageColor(path string) common.Color {
+	f, err := os.Open(path)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			`filename`: path,
+			`error`:    err,
+		}).Fatalln(`Could not open image`)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logger.WithField(`error`, err).Warnln(`Failed closing image`)
+		}
+	}()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			`filename`: path,
+			`error`:    err,
+		}).Fatalln(`Could not decode image`)
+	}
+
+	color := common.DominantColor(img)
+	logger.WithField(`color`, color).Debug(`Dominant image color`)
+
+	return color
 }
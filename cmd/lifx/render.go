@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// table collects the results of a command, rendered as aligned columns, or as
+// a JSON array of records with --json
+type table struct {
+	columns []string
+	rows    [][]interface{}
+	records []interface{}
+}
+
+func newTable(columns ...string) *table {
+	return &table{columns: columns, records: make([]interface{}, 0)}
+}
+
+// add appends a row of cells for text output, and the equivalent record for
+// JSON output
+func (t *table) add(record interface{}, cells ...interface{}) {
+	t.rows = append(t.rows, cells)
+	t.records = append(t.records, record)
+}
+
+func (t *table) render() {
+	if flagJSON {
+		renderJSON(t.records)
+		return
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 4, 4, ' ', 0)
+	for i, column := range t.columns {
+		fmt.Fprint(w, column)
+		if i < len(t.columns)-1 {
+			fmt.Fprint(w, "\t")
+		}
+	}
+	fmt.Fprintln(w)
+	for _, row := range t.rows {
+		for i, cell := range row {
+			fmt.Fprintf(w, "%+v", cell)
+			if i < len(row)-1 {
+				fmt.Fprint(w, "\t")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w)
+	if err := w.Flush(); err != nil {
+		logger.WithField(`error`, err).Fatalln(`Failed outputting results`)
+	}
+}
+
+// render outputs v as JSON with --json, otherwise prints text, if any
+func render(v interface{}, text string) {
+	if flagJSON {
+		renderJSON(v)
+		return
+	}
+	if text != `` {
+		fmt.Println(text)
+	}
+}
+
+func renderJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent(``, `  `)
+	if err := enc.Encode(v); err != nil {
+		logger.WithField(`error`, err).Fatalln(`Failed outputting results`)
+	}
+}
+
+// operationResult is the JSON output of a command that changes lights or
+// groups
+type operationResult struct {
+	Action    string         `json:"action"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Targets   []targetResult `json:"targets,omitempty"`
+}
+
+type targetResult struct {
+	ID    interface{} `json:"id"`
+	Label string      `json:"label"`
+	Error string      `json:"error,omitempty"`
+}
+
+// renderBroadcast reports the result of an operation sent to all devices at
+// once, exiting non-zero on failure
+func renderBroadcast(action string, err error) {
+	if err != nil {
+		logger.WithField(`error`, err).Fatalf("Failed %s for all devices", action)
+	}
+	render(operationResult{Action: action}, ``)
+}
+
+// jsonFormatter formats log entries as JSON objects for --json, with the
+// message of warnings and errors under the `error` key, eg:
+//
+//	{"error": "Could not find lights", "cause": "Timed out"}
+type jsonFormatter struct{}
+
+func (f *jsonFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(map[string]interface{}, len(entry.Data)+2)
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	if cause, ok := data[`error`]; ok {
+		delete(data, `error`)
+		data[`cause`] = cause
+	}
+	if entry.Level <= logrus.WarnLevel {
+		data[`error`] = entry.Message
+	} else {
+		data[`message`] = entry.Message
+	}
+	data[`level`] = entry.Level.String()
+
+	out, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed encoding log entry: %v", err)
+	}
+	return append(out, '\n'), nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pdf/golifx/common"
+	"github.com/spf13/cobra"
+)
+
+// replHelp describes the commands available at the prompt
+const replHelp = `  list                  list the selected lights
+  select [pattern|all]  select lights by label pattern, eg Office-*, or show the selection
+  color <name>          set the selected lights to a named color, eg red
+  power <on|off>        power the selected lights on or off
+  brightness <percent>  set the brightness of the selected lights
+  help                  show the available commands
+  exit                  end the session, as does Ctrl+D`
+
+var (
+	flagReplDuration time.Duration
+
+	cmdRepl = &cobra.Command{
+		Use:   `repl`,
+		Short: `interactive prompt for controlling lights, end with Ctrl+D`,
+		Long: `Open an interactive prompt for controlling lights, discovering them once and
+reusing the client for every command.  Commands act on the current selection,
+which is all lights until narrowed with select.
+
+` + replHelp,
+		PreRun:  setupClient,
+		Run:     repl,
+		PostRun: closeClient,
+	}
+)
+
+func init() {
+	cmdRepl.Flags().DurationVarP(&flagReplDuration, `duration`, `d`, 0, `duration of each color, power or brightness transition`)
+	app.AddCommand(cmdRepl)
+}
+
+// replSession is the state of an interactive session
+type replSession struct {
+	// pattern is the label pattern of the selection, empty for all lights
+	pattern string
+	out     io.Writer
+}
+
+func repl(c *cobra.Command, args []string) {
+	s := &replSession{out: os.Stdout}
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(s.out, s.prompt())
+		if !scanner.Scan() {
+			// End of input, end the prompt line before closing
+			fmt.Fprintln(s.out)
+			break
+		}
+		if !s.exec(scanner.Text()) {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logger.WithField(`error`, err).Errorln(`Failed reading input`)
+	}
+}
+
+func (s *replSession) prompt() string {
+	if s.pattern == `` {
+		return `lifx> `
+	}
+	return fmt.Sprintf("lifx [%s]> ", s.pattern)
+}
+
+// exec runs a line of input, reporting any error inline, and returns false if
+// the session should end
+func (s *replSession) exec(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+
+	var err error
+	switch cmd, args := strings.ToLower(fields[0]), fields[1:]; cmd {
+	case `exit`, `quit`:
+		return false
+	case `help`:
+		fmt.Fprintln(s.out, replHelp)
+	case `list`:
+		err = s.list()
+	case `select`:
+		err = s.selectLights(args)
+	case `color`:
+		err = s.color(args)
+	case `power`:
+		err = s.power(args)
+	case `brightness`:
+		err = s.brightness(args)
+	default:
+		err = fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	if err != nil {
+		fmt.Fprintf(s.out, "Error: %v\n", err)
+	}
+	return true
+}
+
+// lights returns the selected lights
+func (s *replSession) lights() ([]common.Light, error) {
+	lights, err := client.GetLights()
+	if err != nil {
+		return nil, err
+	}
+	if s.pattern == `` {
+		return lights, nil
+	}
+	return common.MatchLabelPattern(lights, s.pattern)
+}
+
+func (s *replSession) list() error {
+	lights, err := s.lights()
+	if err != nil {
+		return err
+	}
+	results := newTable(`ID`, `Label`, `Power`, `Color`)
+	for _, l := range lights {
+		label, _ := l.GetLabel()
+		record := lightRecord{ID: l.ID(), MAC: l.MAC(), Label: label}
+		if !l.IsOnline() {
+			results.add(record, l.ID(), label, `offline`, `-`)
+			continue
+		}
+		color, err := l.GetColor()
+		if err != nil {
+			results.add(record, l.ID(), label, `-`, `-`)
+			continue
+		}
+		power := l.CachedPower()
+		record.Online = true
+		record.Power = &power
+		colorJSON := common.ColorJSON(color)
+		record.Color = &colorJSON
+		results.add(record, l.ID(), label, power, color)
+	}
+	results.render()
+	return nil
+}
+
+func (s *replSession) selectLights(args []string) error {
+	if len(args) == 0 {
+		lights, err := s.lights()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.out, "%d lights selected\n", len(lights))
+		return nil
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("expected a single pattern, match spaces in labels with ?")
+	}
+	if strings.ToLower(args[0]) == `all` {
+		s.pattern = ``
+		return s.selectLights(nil)
+	}
+
+	lights, err := client.GetLights()
+	if err != nil {
+		return err
+	}
+	matched, err := common.MatchLabelPattern(lights, args[0])
+	if err == common.ErrInvalidArgument {
+		return fmt.Errorf("invalid pattern %q", args[0])
+	} else if err != nil {
+		return fmt.Errorf("no lights match %q", args[0])
+	}
+	s.pattern = args[0]
+	fmt.Fprintf(s.out, "%d lights selected\n", len(matched))
+	return nil
+}
+
+func (s *replSession) color(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a color name, one of: %s", strings.Join(common.ColorNames(), `, `))
+	}
+	color, err := common.NamedColor(args[0])
+	if err != nil {
+		return fmt.Errorf("unknown color %q, expected one of: %s", args[0], strings.Join(common.ColorNames(), `, `))
+	}
+	return s.forEach(`setting color`, func(light common.Light) error {
+		return light.SetColor(color, flagReplDuration)
+	})
+}
+
+func (s *replSession) power(args []string) error {
+	if len(args) != 1 || (args[0] != `on` && args[0] != `off`) {
+		return fmt.Errorf("expected on or off")
+	}
+	state := args[0] == `on`
+	return s.forEach(`setting power`, func(light common.Light) error {
+		return light.SetPowerDuration(state, flagReplDuration)
+	})
+}
+
+func (s *replSession) brightness(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a brightness percentage")
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(args[0], `%`), 64)
+	if err != nil {
+		return fmt.Errorf("invalid brightness percentage %q, should be a number from 0 to 100", args[0])
+	}
+	return s.forEach(`setting brightness`, func(light common.Light) error {
+		return light.SetBrightnessPercent(percent, flagReplDuration)
+	})
+}
+
+// forEach applies fn to every selected light, reporting each failure inline
+// rather than ending the session as forEachLight would
+func (s *replSession) forEach(action string, fn func(common.Light) error) error {
+	lights, err := s.lights()
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, light := range lights {
+		if err := fn(light); err != nil {
+			failed++
+			label, _ := light.GetLabel()
+			fmt.Fprintf(s.out, "Failed %s for %s (%d): %v\n", action, label, light.ID(), err)
+		}
+	}
+	fmt.Fprintf(s.out, "Finished %s for %d of %d lights\n", action, len(lights)-failed, len(lights))
+	return nil
+}
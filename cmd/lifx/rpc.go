@@ -0,0 +1,54 @@
+//go:build rpc
+
+package main
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pdf/golifx/rpc"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+var (
+	flagRPCListen string
+
+	cmdRPC = &cobra.Command{
+		Use:   `rpc`,
+		Short: `serve the client API over gRPC, end with Ctrl+C`,
+		Long: `Serve the client API over gRPC on --listen, see lifx.proto in the rpc package
+for the service definition.  Only built with the 'rpc' build tag.`,
+		PreRun:  setupClient,
+		Run:     serveRPC,
+		PostRun: closeClient,
+	}
+)
+
+func init() {
+	cmdRPC.Flags().StringVar(&flagRPCListen, `listen`, `127.0.0.1:56780`, `TCP address to listen on`)
+	app.AddCommand(cmdRPC)
+}
+
+func serveRPC(c *cobra.Command, args []string) {
+	l, err := net.Listen(`tcp`, flagRPCListen)
+	if err != nil {
+		logger.WithField(`error`, err).Fatalln(`Failed listening for RPC`)
+	}
+	server := grpc.NewServer()
+	rpc.Register(server, rpc.NewServer(client))
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		server.Stop()
+	}()
+
+	logger.WithField(`address`, l.Addr().String()).Infoln(`Serving RPC`)
+	// Serve returns nil once stopped on interrupt
+	if err := server.Serve(l); err != nil {
+		logger.WithField(`error`, err).Errorln(`Failed serving RPC`)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pdf/golifx"
+	"github.com/pdf/golifx/common"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	flagScheduleConfig string
+
+	cmdSchedule = &cobra.Command{
+		Use:   `schedule`,
+		Short: `run a daily color schedule, end with Ctrl+C`,
+		Long: `Run a daily color schedule on all lights, transitioning smoothly between the scheduled colors.
+
+The schedule is read from a YAML config file, for example:
+
+  interval: 1m
+  entries:
+    - time: "07:00"
+      color: warm_white
+      brightness: 0.3
+    - time: "12:00"
+      color: cool_white
+    - time: "21:00"
+      hue: 30
+      saturation: 0.5
+      brightness: 0.2
+      kelvin: 2700
+
+Each entry sets either a named color, or HSV components (hue in degrees,
+saturation and brightness as fractions), and components override the named
+color when both are given.`,
+		PreRun:  setupClient,
+		Run:     schedule,
+		PostRun: closeClient,
+	}
+)
+
+type scheduleConfig struct {
+	Interval string                `yaml:"interval"`
+	Entries  []scheduleConfigEntry `yaml:"entries"`
+}
+
+type scheduleConfigEntry struct {
+	Time       string   `yaml:"time"`
+	Color      string   `yaml:"color"`
+	Hue        *float64 `yaml:"hue"`
+	Saturation *float64 `yaml:"saturation"`
+	Brightness *float64 `yaml:"brightness"`
+	Kelvin     *uint16  `yaml:"kelvin"`
+}
+
+func init() {
+	cmdSchedule.Flags().StringVarP(&flagScheduleConfig, `config`, `c`, ``, `path to the schedule YAML config`)
+}
+
+// color resolves the entry color, from the named color if set, with any HSV
+// components applied on top
+func (e scheduleConfigEntry) color() (common.Color, error) {
+	hsv := common.ColorHSV{V: 1, Kelvin: 3500}
+	if e.Color != `` {
+		color, err := common.NamedColor(e.Color)
+		if err != nil {
+			return common.Color{}, err
+		}
+		hsv = color.HSV()
+	}
+	if e.Hue != nil {
+		hsv.H = *e.Hue
+	}
+	if e.Saturation != nil {
+		hsv.S = *e.Saturation
+	}
+	if e.Brightness != nil {
+		hsv.V = *e.Brightness
+	}
+	if e.Kelvin != nil {
+		hsv.Kelvin = *e.Kelvin
+	}
+	return hsv.Color(), nil
+}
+
+func loadSchedule(path string) *golifx.Schedule {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			`filename`: path,
+			`error`:    err,
+		}).Fatalln(`Could not read schedule config`)
+	}
+	config := scheduleConfig{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		logger.WithFields(logrus.Fields{
+			`filename`: path,
+			`error`:    err,
+		}).Fatalln(`Could not parse schedule config`)
+	}
+
+	entries := make([]golifx.ScheduleEntry, len(config.Entries))
+	for i, e := range config.Entries {
+		t, err := time.Parse(`15:04`, e.Time)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				`time`:  e.Time,
+				`error`: err,
+			}).Fatalln(`Invalid schedule time, should be HH:MM`)
+		}
+		color, err := e.color()
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				`color`:  e.Color,
+				`colors`: common.ColorNames(),
+			}).Fatalln(`Unknown color name`)
+		}
+		entries[i] = golifx.ScheduleEntry{
+			At:    time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute,
+			Color: color,
+		}
+	}
+
+	s, err := golifx.NewSchedule(entries...)
+	if err != nil {
+		logger.WithField(`error`, err).Fatalln(`Invalid schedule, at least one entry is required`)
+	}
+	if config.Interval != `` {
+		if s.Interval, err = time.ParseDuration(config.Interval); err != nil {
+			logger.WithFields(logrus.Fields{
+				`interval`: config.Interval,
+				`error`:    err,
+			}).Fatalln(`Invalid schedule interval`)
+		}
+	}
+
+	return s
+}
+
+func schedule(c *cobra.Command, args []string) {
+	if flagScheduleConfig == `` {
+		if err := c.Usage(); err != nil {
+			logger.WithField(`error`, err).Fatalln(`Failed to print usage`)
+		}
+		logger.Fatalln(`Missing schedule config`)
+	}
+	s := loadSchedule(flagScheduleConfig)
+
+	lifxClient, ok := client.(*golifx.Client)
+	if !ok {
+		logger.Fatalln(`Schedules are not supported by this client`)
+	}
+	// The schedule runs indefinitely, so keep discovering lights as they join
+	// the network
+	if err := client.SetDiscoveryInterval(time.Minute); err != nil {
+		logger.WithField(`error`, err).Fatalln(`Failed setting discovery interval`)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	if err := lifxClient.RunSchedule(ctx, s); err != nil && err != context.Canceled {
+		logger.WithField(`error`, err).Fatalln(`Schedule failed`)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pdf/golifx/common"
+	"github.com/spf13/cobra"
+)
+
+// noLocation is the location reported for lights not belonging to a known
+// location
+const noLocation = `(none)`
+
+var (
+	cmdStatus = &cobra.Command{
+		Use:   `status`,
+		Short: `report the status of all lights, grouped by location`,
+		Long: `Report the status of all lights, grouped by location.
+
+For each light the power, color, brightness, firmware version and WiFi signal
+are shown, followed by a count of lights online and offline.  Attributes that
+can not be read are shown as '-', and offline lights show only their cached
+state.`,
+		PreRun:  setupClient,
+		Run:     status,
+		PostRun: closeClient,
+	}
+)
+
+func init() {
+	app.AddCommand(cmdStatus)
+}
+
+// statusReport is the JSON output of status
+type statusReport struct {
+	Online    int              `json:"online"`
+	Offline   int              `json:"offline"`
+	Locations []locationStatus `json:"locations"`
+}
+
+type locationStatus struct {
+	Label   string         `json:"label"`
+	Online  int            `json:"online"`
+	Offline int            `json:"offline"`
+	Lights  []statusRecord `json:"lights"`
+}
+
+// statusRecord is the status of a light, attributes that could not be read are
+// omitted
+type statusRecord struct {
+	ID         uint64            `json:"id"`
+	MAC        string            `json:"mac"`
+	Label      string            `json:"label"`
+	Online     bool              `json:"online"`
+	Power      *bool             `json:"power,omitempty"`
+	Color      *common.ColorJSON `json:"color,omitempty"`
+	Brightness *float64          `json:"brightness,omitempty"`
+	Firmware   string            `json:"firmware,omitempty"`
+	RSSI       *int              `json:"rssi,omitempty"`
+	Signal     string            `json:"signal,omitempty"`
+}
+
+func status(c *cobra.Command, args []string) {
+	if flagTimeout == 0 {
+		logger.Fatalln(`Can not report status with a timeout of zero`)
+	}
+
+	lights := discoverLights(0)
+
+	locationLabels := make(map[uint64]string, len(lights))
+	locations, err := client.GetLocations()
+	if err != nil {
+		logger.WithField(`error`, err).Debugln(`Couldn't get locations`)
+	}
+	for _, location := range locations {
+		for _, l := range location.Lights() {
+			locationLabels[l.ID()] = location.GetLabel()
+		}
+	}
+
+	byLocation := make(map[string]*locationStatus)
+	report := statusReport{Locations: make([]locationStatus, 0)}
+	for _, l := range lights {
+		label, ok := locationLabels[l.ID()]
+		if !ok {
+			label = noLocation
+		}
+		location, ok := byLocation[label]
+		if !ok {
+			location = &locationStatus{Label: label}
+			byLocation[label] = location
+		}
+		record := lightStatus(l)
+		if record.Online {
+			location.Online++
+			report.Online++
+		} else {
+			location.Offline++
+			report.Offline++
+		}
+		location.Lights = append(location.Lights, record)
+	}
+
+	for _, location := range byLocation {
+		sort.Slice(location.Lights, func(i, j int) bool {
+			return location.Lights[i].Label < location.Lights[j].Label
+		})
+		report.Locations = append(report.Locations, *location)
+	}
+	sort.Slice(report.Locations, func(i, j int) bool {
+		// Lights without a location are listed last
+		if report.Locations[i].Label == noLocation {
+			return false
+		}
+		if report.Locations[j].Label == noLocation {
+			return true
+		}
+		return report.Locations[i].Label < report.Locations[j].Label
+	})
+
+	if flagJSON {
+		renderJSON(report)
+		return
+	}
+
+	results := newTable(`Location`, `ID`, `Label`, `Power`, `Color`, `Brightness`, `Firmware`, `Signal`)
+	for _, location := range report.Locations {
+		for _, r := range location.Lights {
+			power, color, brightness, firmware, signal := `offline`, `-`, `-`, `-`, `-`
+			if r.Online {
+				power = `-`
+			}
+			if r.Power != nil {
+				power = fmt.Sprint(*r.Power)
+			}
+			if r.Color != nil {
+				color = fmt.Sprintf("%+v", common.Color(*r.Color))
+			}
+			if r.Brightness != nil {
+				brightness = fmt.Sprintf("%.0f%%", *r.Brightness*100)
+			}
+			if r.Firmware != `` {
+				firmware = r.Firmware
+			}
+			if r.RSSI != nil {
+				signal = fmt.Sprintf("%s (%d dBm)", r.Signal, *r.RSSI)
+			}
+			results.add(r, location.Label, r.ID, r.Label, power, color, brightness, firmware, signal)
+		}
+	}
+	results.render()
+	for _, location := range report.Locations {
+		fmt.Printf("%s: %d online, %d offline\n", location.Label, location.Online, location.Offline)
+	}
+	fmt.Printf("Total: %d online, %d offline\n", report.Online, report.Offline)
+}
+
+// lightStatus reads what it can of the state of l, logging attributes that
+// could not be read at debug level
+func lightStatus(l common.Light) statusRecord {
+	record := statusRecord{ID: l.ID(), MAC: l.MAC()}
+	log := logger.WithField(`light-id`, l.ID())
+	if label, err := l.GetLabel(); err != nil {
+		log.Debugf(`Couldn't get label: %v`, err)
+	} else {
+		record.Label = label
+	}
+	if !l.IsOnline() {
+		// Offline lights will not respond, so only cached state is shown
+		record.Firmware = l.CachedFirmwareVersion()
+		return record
+	}
+	record.Online = true
+
+	// Color is requested first, as the light state also carries the power
+	// level, which is then served from the cache
+	if color, err := l.GetColor(); err != nil {
+		log.Debugf(`Couldn't get color: %v`, err)
+	} else {
+		colorJSON := common.ColorJSON(color)
+		record.Color = &colorJSON
+		brightness := float64(color.Brightness) / common.MaxUint16Component
+		record.Brightness = &brightness
+	}
+	if power, err := l.GetPower(); err != nil {
+		log.Debugf(`Couldn't get power: %v`, err)
+	} else {
+		record.Power = &power
+	}
+	if firmware, err := l.GetFirmwareVersion(); err != nil {
+		log.Debugf(`Couldn't get firmware version: %v`, err)
+	} else {
+		record.Firmware = firmware
+	}
+	if info, err := l.GetWifiInfo(); err != nil {
+		log.Debugf(`Couldn't get WiFi info: %v`, err)
+	} else {
+		rssi := info.RSSI()
+		record.RSSI = &rssi
+		record.Signal = info.Quality()
+	}
+	return record
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/pdf/golifx/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagWaitCount int
+
+	cmdWait = &cobra.Command{
+		Use:   `wait`,
+		Short: `wait until lights are online, eg in boot scripts`,
+		Long: `Wait until at least --count lights are discovered and online, printing each
+light as it comes online.  Exits non-zero if the --timeout expires first, a
+timeout of zero waits indefinitely.  For example, to wait up to a minute for
+four lights before starting a service:
+
+  lifx wait --count 4 --timeout 60s`,
+		PreRun:  setupClient,
+		Run:     wait,
+		PostRun: closeClient,
+	}
+)
+
+func init() {
+	cmdWait.Flags().IntVarP(&flagWaitCount, `count`, `n`, 1, `number of lights to wait for`)
+	app.AddCommand(cmdWait)
+}
+
+func wait(c *cobra.Command, args []string) {
+	if flagWaitCount < 1 {
+		logger.WithField(`count`, flagWaitCount).Fatalln(`Invalid count, must be at least one`)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if flagTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flagTimeout)
+		defer cancel()
+	}
+
+	// Subscribe before checking the known devices, so that no light coming
+	// online in between is missed
+	sub, err := client.NewSubscription()
+	if err != nil {
+		logger.WithField(`error`, err).Fatalln(`Could not subscribe to events`)
+	}
+	defer func() {
+		if err := sub.Close(); err != nil {
+			logger.WithField(`error`, err).Debugln(`Failed closing subscription`)
+		}
+	}()
+	sub.SetFilter(common.EventTypeNewDevice | common.EventTypeOnlineDevice | common.EventTypeOfflineDevice)
+	events := sub.Events()
+
+	waited := newLightWait(flagWaitCount)
+	if err := client.Ready(ctx); err == nil {
+		devices, _ := client.GetDevices()
+		for _, dev := range devices {
+			waited.online(dev)
+		}
+	}
+
+	for !waited.done() {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				logger.Fatalln(`Client closed while waiting for lights`)
+			}
+			switch event := event.(type) {
+			case common.EventNewDevice:
+				waited.online(event.Device)
+			case common.EventOnlineDevice:
+				waited.online(event.Device)
+			case common.EventOfflineDevice:
+				waited.offline(event.Device)
+			}
+		case <-ctx.Done():
+			if flagJSON {
+				renderJSON(waited.records)
+			}
+			logger.Fatalf("Timed out with %d of %d lights online", len(waited.records), flagWaitCount)
+		}
+	}
+
+	render(waited.records, fmt.Sprintf("%d lights online", len(waited.records)))
+}
+
+// lightWait tracks the lights online while waiting for a count of lights
+type lightWait struct {
+	count   int
+	ids     map[uint64]bool
+	records []lightRecord
+}
+
+func newLightWait(count int) *lightWait {
+	return &lightWait{count: count, ids: make(map[uint64]bool), records: make([]lightRecord, 0)}
+}
+
+func (w *lightWait) done() bool {
+	return len(w.records) >= w.count
+}
+
+// online records dev if it is a light that is online and not yet recorded,
+// printing progress
+func (w *lightWait) online(dev common.Device) {
+	if _, ok := dev.(common.Light); !ok || !dev.IsOnline() || w.ids[dev.ID()] {
+		return
+	}
+	label, _ := dev.GetLabel()
+	w.ids[dev.ID()] = true
+	w.records = append(w.records, lightRecord{ID: dev.ID(), MAC: dev.MAC(), Label: label, Online: true})
+	if !flagJSON {
+		fmt.Printf("Online: %s (%d), %d of %d\n", label, dev.ID(), len(w.records), w.count)
+	}
+}
+
+// offline removes dev from the recorded lights, if present
+func (w *lightWait) offline(dev common.Device) {
+	if !w.ids[dev.ID()] {
+		return
+	}
+	delete(w.ids, dev.ID())
+	for i, record := range w.records {
+		if record.ID == dev.ID() {
+			w.records = append(w.records[:i], w.records[i+1:]...)
+			break
+		}
+	}
+	if !flagJSON {
+		label, _ := dev.GetLabel()
+		fmt.Printf("Offline: %s (%d), %d of %d\n", label, dev.ID(), len(w.records), w.count)
+	}
+}
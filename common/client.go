@@ -1,9 +1,116 @@
 package common
 
-import "time"
+import (
+	"context"
+	"io"
+	"time"
+)
 
-// Client defines the interface required by protocols
+// Client defines the interface for interacting with LIFX devices, implemented
+// by golifx.Client and cloud.Client.  Consumers may depend on this interface
+// rather than a concrete type, to allow substitution in tests or alternative
+// backends.  Configuration is backend specific, and is set on the concrete
+// type.
 type Client interface {
+	// GetLocations returns a slice of all locations known to the client, or
+	// ErrNotFound if no locations are currently known.
+	GetLocations() ([]Location, error)
+	// GetLocationByID looks up a location by its `id`
+	GetLocationByID(id string) (Location, error)
+	// GetLocationByLabel looks up a location by its `label`
+	GetLocationByLabel(label string) (Location, error)
+	// GetGroups returns a slice of all groups known to the client, or
+	// ErrNotFound if no groups are currently known.
+	GetGroups() ([]Group, error)
+	// GetGroupByID looks up a group by its `id`
+	GetGroupByID(id string) (Group, error)
+	// GetGroupByLabel looks up a group by its `label`
+	GetGroupByLabel(label string) (Group, error)
+	// GetDevices returns a slice of all devices known to the client, or
+	// ErrNotFound if no devices are currently known.
+	GetDevices() ([]Device, error)
+	// GetLights returns a slice of all lights known to the client, or
+	// ErrNotFound if no lights are currently known.
+	GetLights() ([]Light, error)
+	// GetLightsContext waits for lights to respond to discovery until the
+	// client timeout or ctx is done, and returns the lights that responded
+	GetLightsContext(ctx context.Context) ([]Light, error)
+	// GetLightsWithRetry behaves as GetLights, but retries discovery up to
+	// `attempts` times before returning ErrNotFound
+	GetLightsWithRetry(ctx context.Context, attempts int) ([]Light, error)
+	// GetLightByID looks up a light by its `id`
+	GetLightByID(id uint64) (Light, error)
+	// GetLightByLabel looks up a light by its `label`
+	GetLightByLabel(label string) (Light, error)
+	// GetLightsByLabelPattern returns the known lights whose label matches the
+	// glob pattern, see MatchLabelPattern
+	GetLightsByLabelPattern(pattern string) ([]Light, error)
+	// GetLightByMAC looks up a light by its MAC address, in the
+	// `aa:bb:cc:dd:ee:ff` form
+	GetLightByMAC(mac string) (Light, error)
+
+	// SetPower sets the power state globally, on all devices
+	SetPower(state bool) error
+	// SetPowerDuration sets the power state globally, on all lights, over the
+	// specified duration
+	SetPowerDuration(state bool, duration time.Duration) error
+	// SetColor changes the color globally, on all lights, over the specified
+	// duration
+	SetColor(color Color, duration time.Duration) error
+
+	// Ready blocks until at least one device is known to the client, or ctx
+	// is done, in which case the context error is returned
+	Ready(ctx context.Context) error
+	// DiscoverStream performs a discovery pass, emitting each device on the
+	// returned channel, which is closed when the pass completes or ctx is done
+	DiscoverStream(ctx context.Context) <-chan Device
+	// Discover sends a discovery request immediately, in addition to any
+	// periodic discovery
+	Discover() error
+	// SetDiscoveryInterval causes the client to discover devices and state
+	// every interval
+	SetDiscoveryInterval(interval time.Duration) error
+
+	// Close terminates the client and cleans up resources
+	Close() error
+
+	// Client is a SubscriptionTarget
+	SubscriptionTarget
+}
+
+// ClientConfig defines the client configuration that protocols and their
+// devices read, see Protocol.SetClient.  It is implemented by golifx.Client.
+type ClientConfig interface {
+	// GetTimeout returns the client timeout
 	GetTimeout() *time.Duration
+	// GetRetryInterval returns the client retry interval
 	GetRetryInterval() *time.Duration
+	// GetPollInterval returns the client poll interval
+	GetPollInterval() *time.Duration
+	// GetCacheTTL returns the client cache TTL
+	GetCacheTTL() *time.Duration
+	// GetColorDebounce returns the client color debounce window
+	GetColorDebounce() *time.Duration
+	// GetSkipRedundantWrites returns whether color changes within
+	// RedundantWriteTolerance of the cached color are skipped
+	GetSkipRedundantWrites() bool
+	// GetRestoreOnPower returns whether colors are restored on power on
+	GetRestoreOnPower() bool
+	// GetColorMemory returns whether colors are remembered on power off
+	GetColorMemory() bool
+	// GetMinBrightness returns the client minimum brightness
+	GetMinBrightness() uint16
+	// GetPrefetchDeviceInfo returns whether device information is prefetched
+	// on discovery
+	GetPrefetchDeviceInfo() bool
+	// GetSignalHistoryLength returns the number of signal samples kept per
+	// device, or 0 if sampling is disabled
+	GetSignalHistoryLength() int
+	// GetTracer returns the client tracer, or nil if tracing is disabled
+	GetTracer() io.Writer
+	// GetSpanHook returns the client span hook, or nil if disabled
+	GetSpanHook() SpanHook
+	// GetDecodeErrorHandler returns the client decode error handler, or nil
+	// if none is set
+	GetDecodeErrorHandler() DecodeErrorHandler
 }
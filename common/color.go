@@ -1,6 +1,30 @@
 package common
 
-import "math"
+import (
+	"encoding/json"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+const (
+	// rgbDefaultKelvin is the color temperature assigned to colors converted
+	// from RGB, which carries no color temperature information
+	rgbDefaultKelvin = DefaultKelvin
+)
+
+const (
+	// MaxUint16Component is the maximum value of the Hue, Saturation and
+	// Brightness components of a Color, the minimum is zero
+	MaxUint16Component = math.MaxUint16
+	// MinKelvin is the minimum (warmest) color temperature of a Color
+	MinKelvin = 2500
+	// MaxKelvin is the maximum (coolest) color temperature of a Color
+	MaxKelvin = 9000
+	// DefaultKelvin is a neutral color temperature, for colors defined
+	// without one
+	DefaultKelvin = 3500
+)
 
 // Color is used to represent the color and color temperature of a light.
 // The color is represented as a 48-bit HSB (Hue, Saturation, Brightness) value.
@@ -51,6 +75,84 @@ func AverageColor(colors ...Color) (color Color) {
 	return color
 }
 
+// LerpColor linearly interpolates between the colors from and to, where t is in
+// the range 0 (from) to 1 (to).  Hue is interpolated along the shorter arc of
+// the color wheel, so interpolating between red hues either side of zero does
+// not pass through green and blue.
+func LerpColor(from, to Color, t float64) Color {
+	if t <= 0 {
+		return from
+	}
+	if t >= 1 {
+		return to
+	}
+
+	hueDelta := int(to.Hue) - int(from.Hue)
+	if hueDelta > math.MaxUint16/2 {
+		hueDelta -= math.MaxUint16 + 1
+	} else if hueDelta < -math.MaxUint16/2 {
+		hueDelta += math.MaxUint16 + 1
+	}
+	hue := int(from.Hue) + int(math.Round(float64(hueDelta)*t))
+
+	return Color{
+		Hue:        uint16(hue & math.MaxUint16),
+		Saturation: lerpUint16(from.Saturation, to.Saturation, t),
+		Brightness: lerpUint16(from.Brightness, to.Brightness, t),
+		Kelvin:     lerpUint16(from.Kelvin, to.Kelvin, t),
+	}
+}
+
+// Gradient returns steps colors evenly interpolated from `from` to `to`
+// inclusive, via LerpColor.  Hue travels along the shorter arc of the color
+// wheel, in whichever direction that is, and when the hues are exactly opposite
+// it increases from `from`.  Returns nil if steps is less than one, and only
+// `from` if steps is one.
+func Gradient(from, to Color, steps int) []Color {
+	if steps < 1 {
+		return nil
+	}
+	colors := make([]Color, steps)
+	if steps == 1 {
+		colors[0] = from
+		return colors
+	}
+	for i := range colors {
+		colors[i] = LerpColor(from, to, float64(i)/float64(steps-1))
+	}
+	return colors
+}
+
+func lerpUint16(a, b uint16, t float64) uint16 {
+	return uint16(math.Round(float64(a) + (float64(b)-float64(a))*t))
+}
+
+// RedundantWriteTolerance is the per-component tolerance within which a
+// requested color is considered unchanged when redundant writes are skipped,
+// see Client.SetSkipRedundantWrites
+const RedundantWriteTolerance uint16 = 64
+
+// ColorApproxEqual tests whether each component of two Colors differs by no
+// more than tolerance.  Hue is compared as an angle, so values either side of
+// zero are considered close.
+func ColorApproxEqual(a, b Color, tolerance uint16) bool {
+	hue := absDiff(a.Hue, b.Hue)
+	if hue > math.MaxUint16/2 {
+		hue = math.MaxUint16 - hue + 1
+	}
+	return hue <= tolerance &&
+		absDiff(a.Saturation, b.Saturation) <= tolerance &&
+		absDiff(a.Brightness, b.Brightness) <= tolerance &&
+		absDiff(a.Kelvin, b.Kelvin) <= tolerance
+}
+
+func absDiff(a, b uint16) uint16 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
 // ColorEqual tests whether two Colors are equal
 func ColorEqual(a, b Color) bool {
 	return a.Hue == b.Hue &&
@@ -58,3 +160,262 @@ func ColorEqual(a, b Color) bool {
 		a.Brightness == b.Brightness &&
 		a.Kelvin == b.Kelvin
 }
+
+// ColorHSV is a friendlier representation of a Color, using hue in degrees and
+// saturation/value as fractions.  Use ColorHSV.Color to obtain a Color for
+// sending to devices.
+type ColorHSV struct {
+	H      float64 `json:"h"`      // hue in degrees, range 0 to 360
+	S      float64 `json:"s"`      // saturation, range 0 to 1
+	V      float64 `json:"v"`      // value (brightness), range 0 to 1
+	Kelvin uint16  `json:"kelvin"` // range 2500° (warm) to 9000° (cool)
+}
+
+// Color converts the ColorHSV to a Color.  Hue is wrapped to the range
+// [0, 360), so 360° and -90° are equivalent to 0° and 270° respectively.
+// Saturation and value are clamped to the range [0, 1].  NaN components are
+// treated as zero.  Kelvin is passed through untouched.
+func (c ColorHSV) Color() Color {
+	h := c.H
+	if math.IsNaN(h) || math.IsInf(h, 0) {
+		h = 0
+	}
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+
+	return Color{
+		Hue:        degreesToHue(h),
+		Saturation: fractionToUint16(c.S),
+		Brightness: fractionToUint16(c.V),
+		Kelvin:     c.Kelvin,
+	}
+}
+
+// HSV converts the Color to a ColorHSV
+func (c Color) HSV() ColorHSV {
+	return ColorHSV{
+		H:      float64(c.Hue) / (math.MaxUint16 + 1) * 360,
+		S:      float64(c.Saturation) / math.MaxUint16,
+		V:      float64(c.Brightness) / math.MaxUint16,
+		Kelvin: c.Kelvin,
+	}
+}
+
+// ColorJSON is a Color with a friendlier JSON encoding, for APIs and user
+// facing output.  Color itself encodes its raw components, which is preferred
+// for storage and internal use.  The JSON schema is:
+//
+//	{
+//	  "hue": 120,          // degrees, range 0 to 360
+//	  "saturation": 0.5,   // fraction, range 0 to 1
+//	  "brightness": 1,     // fraction, range 0 to 1
+//	  "kelvin": 3500       // range 2500 to 9000
+//	}
+//
+// Values are encoded at full precision, so a Color survives a round trip
+// through ColorJSON unchanged.  When decoding, hue is wrapped and the
+// fractions clamped as per ColorHSV.Color.
+type ColorJSON Color
+
+type colorJSON struct {
+	Hue        float64 `json:"hue"`
+	Saturation float64 `json:"saturation"`
+	Brightness float64 `json:"brightness"`
+	Kelvin     uint16  `json:"kelvin"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface
+func (c ColorJSON) MarshalJSON() ([]byte, error) {
+	hsv := Color(c).HSV()
+	return json.Marshal(colorJSON{
+		Hue:        hsv.H,
+		Saturation: hsv.S,
+		Brightness: hsv.V,
+		Kelvin:     hsv.Kelvin,
+	})
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface
+func (c *ColorJSON) UnmarshalJSON(data []byte) error {
+	var v colorJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = ColorJSON(ColorHSV{H: v.Hue, S: v.Saturation, V: v.Brightness, Kelvin: v.Kelvin}.Color())
+	return nil
+}
+
+// Validate checks that the components of the color are within the range
+// supported by lights, returning an ErrInvalidColor naming the first offending
+// field.  Hue, Saturation and Brightness span the full uint16 range, so only
+// Kelvin can be out of range.
+func (c Color) Validate() error {
+	if c.Kelvin < MinKelvin || c.Kelvin > MaxKelvin {
+		return &ErrInvalidColor{Field: `Kelvin`, Value: c.Kelvin, Min: MinKelvin, Max: MaxKelvin}
+	}
+	return nil
+}
+
+// RandomColor returns a color of random hue at full saturation and brightness,
+// drawn from rng, or the global source if rng is nil.  Pass a seeded rng for a
+// reproducible sequence of colors.
+func RandomColor(rng *rand.Rand) Color {
+	var hue int
+	if rng == nil {
+		hue = rand.Intn(MaxUint16Component + 1)
+	} else {
+		hue = rng.Intn(MaxUint16Component + 1)
+	}
+	return Color{
+		Hue:        uint16(hue),
+		Saturation: MaxUint16Component,
+		Brightness: MaxUint16Component,
+		Kelvin:     rgbDefaultKelvin,
+	}
+}
+
+// ClampColor returns c with any components outside the range supported by
+// lights clamped into range, see Validate
+func ClampColor(c Color) Color {
+	c.Kelvin = uint16(clampInt(int(c.Kelvin), MinKelvin, MaxKelvin))
+	return c
+}
+
+// BrightnessFromPercent maps percent from the range [0, 100] to a Brightness
+// value in the range [0, 65535], clamping out of range values
+func BrightnessFromPercent(percent float64) uint16 {
+	return fractionToUint16(percent / 100)
+}
+
+// AdjustKelvin returns c with delta added to the Kelvin component, clamped to
+// the range MinKelvin to MaxKelvin.  Negative deltas are warmer, positive cooler.
+func AdjustKelvin(c Color, delta int) Color {
+	c.Kelvin = uint16(clampInt(int(c.Kelvin)+delta, MinKelvin, MaxKelvin))
+	return c
+}
+
+// ColorFromMired returns a white (unsaturated) Color of the color temperature
+// mired, in micro reciprocal degrees, and brightness.  The Kelvin component is
+// 1,000,000 / mired rounded to the nearest degree, clamped to the range
+// MinKelvin to MaxKelvin, so mired values outside roughly 111 to 400 map to the
+// coolest or warmest white a light supports.  A mired of 0 maps to MaxKelvin.
+func ColorFromMired(mired uint16, brightness uint16) Color {
+	kelvin := MaxKelvin
+	if mired > 0 {
+		kelvin = clampInt(int(math.Round(1e6/float64(mired))), MinKelvin, MaxKelvin)
+	}
+	return Color{Brightness: brightness, Kelvin: uint16(kelvin)}
+}
+
+// Mired returns the color temperature of c in mired (micro reciprocal
+// degrees), 1,000,000 / Kelvin rounded to the nearest mired.  As both are
+// rounded, converting to mired and back may differ from Kelvin by a few
+// degrees at the cool end of the range.  Returns 0 if Kelvin is 0.
+func (c Color) Mired() uint16 {
+	if c.Kelvin == 0 {
+		return 0
+	}
+	return uint16(math.Round(1e6 / float64(c.Kelvin)))
+}
+
+// AdjustBrightness returns c with delta added to the Brightness component,
+// clamped to the range 0 to MaxUint16Component
+func AdjustBrightness(c Color, delta int) Color {
+	c.Brightness = uint16(clampInt(int(c.Brightness)+delta, 0, MaxUint16Component))
+	return c
+}
+
+// FloorBrightness returns c with the Brightness component raised to floor if it
+// is lower
+func FloorBrightness(c Color, floor uint16) Color {
+	if c.Brightness < floor {
+		c.Brightness = floor
+	}
+	return c
+}
+
+// BlinkColor returns a color contrasting with c for a brief flash, eg to
+// identify a light: c with its brightness halved if it is above half, or
+// raised to full otherwise
+func BlinkColor(c Color) Color {
+	if c.Brightness > MaxUint16Component/2 {
+		c.Brightness /= 2
+	} else {
+		c.Brightness = MaxUint16Component
+	}
+	return c
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// degreesToHue maps h from the range [0, 360) to [0, 65535], where a full
+// rotation of the color wheel is 65536 steps
+func degreesToHue(h float64) uint16 {
+	return uint16(int(math.Round(h/360*(math.MaxUint16+1))) & math.MaxUint16)
+}
+
+// fractionToUint16 maps f from the range [0, 1] to [0, 65535], clamping out of
+// range values
+func fractionToUint16(f float64) uint16 {
+	if math.IsNaN(f) || f <= 0 {
+		return 0
+	}
+	if f >= 1 {
+		return MaxUint16Component
+	}
+	return uint16(math.Round(f * MaxUint16Component))
+}
+
+// ColorFromRGB converts a standard library color.Color (RGB) to a HSBK Color.
+// As RGB carries no color temperature, Kelvin is set to a neutral 3500°.
+func ColorFromRGB(c color.Color) Color {
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return Color{Kelvin: rgbDefaultKelvin}
+	}
+
+	// Un-premultiply alpha
+	rf := float64(r) / float64(a)
+	gf := float64(g) / float64(a)
+	bf := float64(b) / float64(a)
+
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	delta := max - min
+
+	var h, s float64
+	if delta > 0 {
+		switch max {
+		case rf:
+			h = math.Mod((gf-bf)/delta, 6)
+		case gf:
+			h = (bf-rf)/delta + 2
+		default:
+			h = (rf-gf)/delta + 4
+		}
+		h *= 60
+		if h < 0 {
+			h += 360
+		}
+	}
+	if max > 0 {
+		s = delta / max
+	}
+
+	return Color{
+		Hue:        degreesToHue(h),
+		Saturation: uint16(math.Round(s * math.MaxUint16)),
+		Brightness: uint16(math.Round(max * math.MaxUint16)),
+		Kelvin:     rgbDefaultKelvin,
+	}
+}
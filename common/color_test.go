@@ -0,0 +1,218 @@
+package common_test
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+	"math"
+	"math/rand"
+
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Color", func() {
+
+	DescribeTable("converting brightness percentages",
+		func(percent float64, expected uint16) {
+			Expect(BrightnessFromPercent(percent)).To(Equal(expected))
+		},
+		Entry("zero", 0.0, uint16(0)),
+		Entry("half", 50.0, uint16(32768)),
+		Entry("full", 100.0, uint16(65535)),
+		Entry("clamped below zero", -10.0, uint16(0)),
+		Entry("clamped above full", 150.0, uint16(65535)),
+	)
+
+	It("should adjust kelvin within range", func() {
+		c := Color{Kelvin: 3500}
+		Expect(AdjustKelvin(c, 500).Kelvin).To(Equal(uint16(4000)))
+		Expect(AdjustKelvin(c, -2000).Kelvin).To(Equal(uint16(2500)))
+		Expect(AdjustKelvin(c, 10000).Kelvin).To(Equal(uint16(9000)))
+	})
+
+	DescribeTable("converting from mired",
+		func(mired uint16, kelvin uint16) {
+			Expect(ColorFromMired(mired, 1000)).To(Equal(Color{Brightness: 1000, Kelvin: kelvin}))
+		},
+		Entry("warm white", uint16(370), uint16(2703)),
+		Entry("daylight", uint16(154), uint16(6494)),
+		Entry("beyond the warmest", uint16(500), uint16(MinKelvin)),
+		Entry("beyond the coolest", uint16(100), uint16(MaxKelvin)),
+		Entry("zero", uint16(0), uint16(MaxKelvin)),
+	)
+
+	DescribeTable("tinting whites by color temperature",
+		func(kelvin uint16, r, g, b uint8) {
+			Expect(Color{Brightness: 65535, Kelvin: kelvin}.RGB()).To(Equal(color.RGBA{R: r, G: g, B: b, A: 255}))
+			tr, tg, tb := KelvinToRGB(kelvin)
+			Expect([]uint8{tr, tg, tb}).To(Equal([]uint8{r, g, b}))
+		},
+		Entry("candlelight", uint16(1500), uint8(255), uint8(108), uint8(0)),
+		Entry("warm white", uint16(2700), uint8(255), uint8(166), uint8(86)),
+		Entry("neutral", uint16(3500), uint8(255), uint8(193), uint8(141)),
+		Entry("daylight", uint16(6500), uint8(255), uint8(254), uint8(250)),
+		Entry("blue sky", uint16(9000), uint8(210), uint8(223), uint8(255)),
+		Entry("beyond the warmest", uint16(0), uint8(255), uint8(108), uint8(0)),
+		Entry("beyond the coolest", uint16(12000), uint8(210), uint8(223), uint8(255)),
+	)
+
+	DescribeTable("converting to RGB",
+		func(c Color, expected color.RGBA) {
+			Expect(c.RGB()).To(Equal(expected))
+		},
+		Entry("red", Color{Saturation: 65535, Brightness: 65535, Kelvin: 3500}, color.RGBA{R: 255, A: 255}),
+		Entry("green at half brightness", Color{Hue: 21845, Saturation: 65535, Brightness: 32768, Kelvin: 3500}, color.RGBA{G: 128, A: 255}),
+		Entry("pastel blue", Color{Hue: 43690, Saturation: 32768, Brightness: 65535, Kelvin: 6500}, color.RGBA{R: 127, G: 127, B: 253, A: 255}),
+		Entry("black", Color{Kelvin: 2700}, color.RGBA{A: 255}),
+	)
+
+	It("should convert kelvin to mired", func() {
+		Expect(Color{Kelvin: 2700}.Mired()).To(Equal(uint16(370)))
+		Expect(Color{Kelvin: 6500}.Mired()).To(Equal(uint16(154)))
+		Expect(Color{}.Mired()).To(BeZero())
+		Expect(ColorFromMired(250, 0).Mired()).To(Equal(uint16(250)))
+	})
+
+	It("should contrast brightness for blinking", func() {
+		c := Color{Hue: 120, Saturation: 65535, Brightness: 60000, Kelvin: 3500}
+		Expect(BlinkColor(c)).To(Equal(Color{Hue: 120, Saturation: 65535, Brightness: 30000, Kelvin: 3500}))
+		Expect(BlinkColor(Color{Brightness: 1000}).Brightness).To(Equal(uint16(65535)))
+	})
+
+	It("should adjust brightness within range", func() {
+		c := Color{Brightness: 1000}
+		Expect(AdjustBrightness(c, 500).Brightness).To(Equal(uint16(1500)))
+		Expect(AdjustBrightness(c, -2000).Brightness).To(Equal(uint16(0)))
+		Expect(AdjustBrightness(c, 100000).Brightness).To(Equal(uint16(65535)))
+	})
+
+	It("should raise brightness to a floor", func() {
+		c := Color{Hue: 1, Brightness: 1000, Kelvin: 3500}
+		Expect(FloorBrightness(c, 2000)).To(Equal(Color{Hue: 1, Brightness: 2000, Kelvin: 3500}))
+		Expect(FloorBrightness(c, 500)).To(Equal(c))
+	})
+
+	It("should validate kelvin", func() {
+		Expect(Color{Kelvin: 3500}.Validate()).To(Succeed())
+		Expect(Color{Hue: 65535, Saturation: 65535, Brightness: 65535, Kelvin: 9000}.Validate()).To(Succeed())
+
+		err := Color{Kelvin: 1000}.Validate()
+		Expect(err).To(MatchError(ContainSubstring(`Kelvin 1000`)))
+		Expect(errors.Is(err, ErrInvalidArgument)).To(BeTrue())
+		Expect(Color{Kelvin: 9001}.Validate()).To(BeAssignableToTypeOf(&ErrInvalidColor{}))
+	})
+
+	It("should clamp kelvin", func() {
+		Expect(ClampColor(Color{Hue: 1, Kelvin: 1000})).To(Equal(Color{Hue: 1, Kelvin: MinKelvin}))
+		Expect(ClampColor(Color{Kelvin: 9001})).To(Equal(Color{Kelvin: MaxKelvin}))
+		Expect(ClampColor(Color{Kelvin: 3500}).Validate()).To(Succeed())
+	})
+
+	Context("encoding ColorJSON", func() {
+		It("should use degrees and fractions", func() {
+			data, err := json.Marshal(ColorJSON{Hue: 16384, Saturation: 65535, Brightness: 0, Kelvin: 3500})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(MatchJSON(`{"hue": 90, "saturation": 1, "brightness": 0, "kelvin": 3500}`))
+		})
+
+		It("should round trip colors unchanged", func() {
+			for _, c := range []Color{{Hue: 1, Saturation: 2, Brightness: 3, Kelvin: 2500}, {Hue: 65535, Saturation: 32768, Brightness: 65534, Kelvin: 9000}} {
+				data, err := json.Marshal(ColorJSON(c))
+				Expect(err).NotTo(HaveOccurred())
+				var decoded ColorJSON
+				Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+				Expect(Color(decoded)).To(Equal(c))
+			}
+		})
+
+		It("should leave the raw Color encoding unchanged", func() {
+			data, err := json.Marshal(Color{Hue: 1, Kelvin: 3500})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(MatchJSON(`{"hue": 1, "saturation": 0, "brightness": 0, "kelvin": 3500}`))
+		})
+	})
+
+	Context("converting from ColorHSV", func() {
+		DescribeTable("should map to the wire Color",
+			func(hsv ColorHSV, expected Color) {
+				Expect(hsv.Color()).To(Equal(expected))
+			},
+			Entry("black", ColorHSV{H: 0, S: 0, V: 0, Kelvin: 3500}, Color{Hue: 0, Saturation: 0, Brightness: 0, Kelvin: 3500}),
+			Entry("full red", ColorHSV{H: 0, S: 1, V: 1, Kelvin: 3500}, Color{Hue: 0, Saturation: 65535, Brightness: 65535, Kelvin: 3500}),
+			Entry("green at half value", ColorHSV{H: 120, S: 1, V: 0.5, Kelvin: 3500}, Color{Hue: 21845, Saturation: 65535, Brightness: 32768, Kelvin: 3500}),
+			Entry("blue", ColorHSV{H: 240, S: 1, V: 1, Kelvin: 9000}, Color{Hue: 43691, Saturation: 65535, Brightness: 65535, Kelvin: 9000}),
+			Entry("warm white", ColorHSV{H: 0, S: 0, V: 1, Kelvin: 2500}, Color{Hue: 0, Saturation: 0, Brightness: 65535, Kelvin: 2500}),
+			Entry("hue of 360 wraps to zero", ColorHSV{H: 360, S: 1, V: 1}, Color{Hue: 0, Saturation: 65535, Brightness: 65535}),
+			Entry("hue above 360 wraps", ColorHSV{H: 480, S: 1, V: 1}, Color{Hue: 21845, Saturation: 65535, Brightness: 65535}),
+			Entry("negative hue wraps", ColorHSV{H: -120, S: 1, V: 1}, Color{Hue: 43691, Saturation: 65535, Brightness: 65535}),
+			Entry("saturation above 1 clamps", ColorHSV{S: 1.5, V: 1}, Color{Saturation: 65535, Brightness: 65535}),
+			Entry("negative saturation clamps", ColorHSV{S: -0.5, V: 1}, Color{Saturation: 0, Brightness: 65535}),
+			Entry("value above 1 clamps", ColorHSV{S: 1, V: 2}, Color{Saturation: 65535, Brightness: 65535}),
+			Entry("negative value clamps", ColorHSV{S: 1, V: -1}, Color{Saturation: 65535, Brightness: 0}),
+			Entry("NaN components are zero", ColorHSV{H: math.NaN(), S: math.NaN(), V: math.NaN()}, Color{}),
+		)
+	})
+
+	Context("converting to ColorHSV", func() {
+		DescribeTable("should round-trip",
+			func(color Color) {
+				Expect(color.HSV().Color()).To(Equal(color))
+			},
+			Entry("zero", Color{}),
+			Entry("max", Color{Hue: 65535, Saturation: 65535, Brightness: 65535, Kelvin: 9000}),
+			Entry("mid", Color{Hue: 32768, Saturation: 32768, Brightness: 32768, Kelvin: 5000}),
+			Entry("arbitrary", Color{Hue: 12345, Saturation: 54321, Brightness: 1, Kelvin: 2500}),
+		)
+	})
+
+	Context("generating a RandomColor", func() {
+		It("should be fully saturated and bright", func() {
+			c := RandomColor(nil)
+			Expect(c.Saturation).To(Equal(uint16(MaxUint16Component)))
+			Expect(c.Brightness).To(Equal(uint16(MaxUint16Component)))
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("should be reproducible with a seeded source", func() {
+			a, b := rand.New(rand.NewSource(42)), rand.New(rand.NewSource(42))
+			for i := 0; i < 5; i++ {
+				Expect(RandomColor(a)).To(Equal(RandomColor(b)))
+			}
+			Expect(RandomColor(a).Hue).NotTo(Equal(RandomColor(a).Hue))
+		})
+	})
+
+	Context("generating a Gradient", func() {
+		var (
+			from = Color{Hue: 0, Saturation: 65535, Brightness: 0, Kelvin: 2500}
+			to   = Color{Hue: 21845, Saturation: 65535, Brightness: 65535, Kelvin: 9000}
+		)
+
+		It("should include both endpoints", func() {
+			colors := Gradient(from, to, 5)
+			Expect(colors).To(HaveLen(5))
+			Expect(colors[0]).To(Equal(from))
+			Expect(colors[4]).To(Equal(to))
+			Expect(colors[2]).To(Equal(LerpColor(from, to, 0.5)))
+		})
+
+		It("should interpolate hue along the shorter arc", func() {
+			colors := Gradient(Color{Hue: 60000}, Color{Hue: 5000}, 3)
+			Expect(colors[1].Hue).To(Equal(uint16(65268)))
+		})
+
+		It("should return only from for a single step", func() {
+			Expect(Gradient(from, to, 1)).To(Equal([]Color{from}))
+		})
+
+		It("should return nil for fewer than one step", func() {
+			Expect(Gradient(from, to, 0)).To(BeNil())
+			Expect(Gradient(from, to, -1)).To(BeNil())
+		})
+	})
+
+})
@@ -1,17 +1,55 @@
 package common
 
+import (
+	"net"
+	"time"
+)
+
+// Reasons for a device booting, returned by Device.LastRebootReason.  The LAN
+// protocol reports how long a device was without power before it last booted,
+// to an accuracy of five seconds, so a device that was without power for longer
+// is reported as RebootReasonPowerLoss.  Otherwise the device restarted without
+// losing power, eg after a firmware update, a crash or a reset, which can not
+// be told apart, and RebootReasonRestart is reported.
+const (
+	RebootReasonPowerLoss = `power loss`
+	RebootReasonRestart   = `restart`
+)
+
 // Device represents a generic LIFX device
 type Device interface {
-	// Returns the ID for the device
+	// Returns the ID for the device.  The ID is the full 8-byte target field of
+	// the protocol, decoded as a little-endian uint64.  The lower six bytes
+	// hold the device MAC address (first octet in the least significant byte),
+	// and the upper two bytes are zero.
 	ID() uint64
+	// MAC returns the MAC address of the device in the canonical
+	// aa:bb:cc:dd:ee:ff form, which matches the serial number displayed by the
+	// LIFX app
+	MAC() string
+	// Address returns the network address of the device
+	Address() *net.UDPAddr
+	// Services returns the services advertised by the device during
+	// discovery, the address port is that of the UDP service
+	Services() []Service
+	// Subnet returns the network the device was discovered in, or nil if
+	// unknown, to distinguish devices discovered via several broadcast
+	// addresses (see Client.AddBroadcastAddress)
+	Subnet() *net.IPNet
 
-	// GetLabel gets the label for the device
+	// GetLabel gets the label for the device.  The label is cached once known,
+	// and the cache is updated, emitting EventUpdateLabel, whenever the device
+	// reports its label, including when it is renamed by another client such as
+	// the LIFX app.
 	GetLabel() (string, error)
 	// SetLabel sets the label for the device
 	SetLabel(label string) error
 	// GetPower requests the current power state of the device, true for on,
-	// false for off
+	// false for off.  A device transitioning power is on, see Power.
 	GetPower() (bool, error)
+	// GetPowerState requests the current power level of the device, which is
+	// between PowerOff and PowerOn while transitioning
+	GetPowerState() (Power, error)
 	// CachedPower returns the last known power state of the device, true for
 	// on, false for off
 	CachedPower() bool
@@ -22,6 +60,45 @@ type Device interface {
 	// CachedFirmwareVersion returns the last known firmware version of the
 	// device
 	CachedFirmwareVersion() string
+	// Ping sends an echo request to the device, and returns the round trip
+	// time of the response
+	Ping() (time.Duration, error)
+	// GetWifiInfo requests the state of the WiFi connection of the device
+	GetWifiInfo() (WifiInfo, error)
+	// SignalHistory returns the most recent WiFi signal strength samples,
+	// oldest first, recorded while the client samples signal strength
+	SignalHistory() []SignalSample
+	// LastOnColor returns the color the device had when the client last
+	// powered it off, and true, if the client remembers colors (see
+	// Client.SetColorMemory), or false if no color has been remembered
+	LastOnColor() (Color, bool)
+	// SetGroup moves the device to the group with the specified ID and label,
+	// use NewGroupID to create a new group
+	SetGroup(id [16]byte, label string) error
+	// SetLocation moves the device to the location with the specified ID and
+	// label, use NewGroupID to create a new location
+	SetLocation(id [16]byte, label string) error
+	// IsOnline returns false if the device has stopped responding to
+	// discovery, it is no longer reachable but remains known in case it
+	// returns, at which point it is marked online again
+	IsOnline() bool
+	// GetProductInfo returns the capabilities and specifications of the
+	// device, from the product table.  Returns ErrNotFound if the product is
+	// not known.
+	GetProductInfo() (ProductInfo, error)
+	// LastRebootReason returns why the device last booted, one of the
+	// RebootReason constants.  LIFX firmware does not report the reason
+	// directly, so it is inferred on a best-effort basis, see
+	// RebootReasonPowerLoss.  Returns ErrNotSupported if the device does not
+	// report the information required.
+	LastRebootReason() (string, error)
+	// LastSeen returns the time a message was last received from the device,
+	// or the zero time if none has been received
+	LastSeen() time.Time
+	// InvalidateCache discards cached state, so that it is requested from the
+	// device on next access, for when the device is known to have changed by
+	// other means.  Safe to call concurrently with other methods.
+	InvalidateCache()
 
 	// Device is a SubscriptionTarget
 	SubscriptionTarget
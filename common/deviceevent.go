@@ -0,0 +1,62 @@
+package common
+
+import "time"
+
+// DeviceEvent types, see DeviceEvent
+const (
+	DeviceEventNew     = `device_new`
+	DeviceEventExpired = `device_expired`
+	DeviceEventOffline = `device_offline`
+	DeviceEventOnline  = `device_online`
+	DeviceEventLabel   = `label_update`
+	DeviceEventPower   = `power_update`
+	DeviceEventColor   = `color_update`
+)
+
+// DeviceEvent is a serializable record of a device event, for delivery outside
+// the process, such as by the event webhook or RPC.  Fields not relevant to
+// the event type are omitted.
+type DeviceEvent struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	DeviceID uint64    `json:"device_id"`
+	MAC      string    `json:"mac"`
+	Label    string    `json:"label,omitempty"`
+	Power    *bool     `json:"power,omitempty"`
+	Color    *Color    `json:"color,omitempty"`
+}
+
+// NewDeviceEvent returns the record of event, which is either a device event
+// from a Client, or an update from the subscription to dev, and whether event
+// is one of the events recorded.  The record of an EventNewDevice carries no
+// Label, as getting it may require a request to the device.
+func NewDeviceEvent(event interface{}, dev Device) (DeviceEvent, bool) {
+	var e DeviceEvent
+	switch event := event.(type) {
+	case EventNewDevice:
+		e.Type, dev = DeviceEventNew, event.Device
+	case EventExpiredDevice:
+		e.Type, dev = DeviceEventExpired, event.Device
+	case EventOfflineDevice:
+		e.Type, dev = DeviceEventOffline, event.Device
+	case EventOnlineDevice:
+		e.Type, dev = DeviceEventOnline, event.Device
+	case EventUpdateLabel:
+		e.Type, e.Label = DeviceEventLabel, event.Label
+	case EventUpdatePower:
+		power := event.Power
+		e.Type, e.Power = DeviceEventPower, &power
+	case EventUpdateColor:
+		color := event.Color
+		e.Type, e.Color = DeviceEventColor, &color
+	default:
+		return e, false
+	}
+	if dev == nil {
+		return e, false
+	}
+	e.Time = time.Now()
+	e.DeviceID = dev.ID()
+	e.MAC = dev.MAC()
+	return e, true
+}
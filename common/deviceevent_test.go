@@ -0,0 +1,57 @@
+package common_test
+
+import (
+	"github.com/pdf/golifx/mocks"
+
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeviceEvent", func() {
+	var (
+		dev   *mocks.Device
+		power = true
+		color = Color{Hue: 1, Saturation: 2, Brightness: 3, Kelvin: 4}
+	)
+
+	BeforeEach(func() {
+		dev = new(mocks.Device)
+		dev.On(`ID`).Return(uint64(1))
+		dev.On(`MAC`).Return(`00:00:00:00:00:01`)
+	})
+
+	DescribeTable("recording events",
+		func(event func() interface{}, subscribed bool, expected DeviceEvent) {
+			var from Device
+			if subscribed {
+				from = dev
+			}
+			e, ok := NewDeviceEvent(event(), from)
+			Expect(ok).To(BeTrue())
+			Expect(e.Time).NotTo(BeZero())
+			e.Time = expected.Time
+			expected.DeviceID, expected.MAC = 1, `00:00:00:00:00:01`
+			Expect(e).To(Equal(expected))
+		},
+		Entry("new device", func() interface{} { return EventNewDevice{Device: dev} }, false, DeviceEvent{Type: DeviceEventNew}),
+		Entry("expired device", func() interface{} { return EventExpiredDevice{Device: dev} }, false, DeviceEvent{Type: DeviceEventExpired}),
+		Entry("offline device", func() interface{} { return EventOfflineDevice{Device: dev} }, false, DeviceEvent{Type: DeviceEventOffline}),
+		Entry("online device", func() interface{} { return EventOnlineDevice{Device: dev} }, false, DeviceEvent{Type: DeviceEventOnline}),
+		Entry("label update", func() interface{} { return EventUpdateLabel{Label: `one`} }, true, DeviceEvent{Type: DeviceEventLabel, Label: `one`}),
+		Entry("power update", func() interface{} { return EventUpdatePower{Power: power} }, true, DeviceEvent{Type: DeviceEventPower, Power: &power}),
+		Entry("color update", func() interface{} { return EventUpdateColor{Color: color} }, true, DeviceEvent{Type: DeviceEventColor, Color: &color}),
+	)
+
+	It("should not record other events", func() {
+		_, ok := NewDeviceEvent(EventNewGroup{}, dev)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should not record updates without a device", func() {
+		_, ok := NewDeviceEvent(EventUpdateLabel{Label: `one`}, nil)
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,22 @@
+package common
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// MaxDuration is the longest transition duration supported by devices,
+	// which encode durations as 32-bit milliseconds (approximately 49.7 days)
+	MaxDuration = time.Duration(math.MaxUint32) * time.Millisecond
+)
+
+// ValidateDuration returns ErrInvalidArgument if the transition duration d is
+// negative or exceeds MaxDuration, rather than allowing it to silently wrap to
+// an unexpected value on the wire
+func ValidateDuration(d time.Duration) error {
+	if d < 0 || d > MaxDuration {
+		return ErrInvalidArgument
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package common_test
+
+import (
+	"time"
+
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Duration", func() {
+
+	DescribeTable("validating transition durations",
+		func(d time.Duration, expected error) {
+			if expected == nil {
+				Expect(ValidateDuration(d)).To(Succeed())
+			} else {
+				Expect(ValidateDuration(d)).To(Equal(expected))
+			}
+		},
+		Entry("zero", time.Duration(0), nil),
+		Entry("typical", 2*time.Second, nil),
+		Entry("maximum", MaxDuration, nil),
+		Entry("negative", -time.Millisecond, ErrInvalidArgument),
+		Entry("beyond maximum", MaxDuration+time.Millisecond, ErrInvalidArgument),
+	)
+
+})
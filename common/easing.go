@@ -0,0 +1,104 @@
+package common
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Easing maps the fraction of time elapsed in a transition, from 0 to 1, to the
+// fraction of the change applied, where 0 is the start and 1 the end.
+// Fractions outside the range are clamped by each of the easing functions
+// provided.
+type Easing func(t float64) float64
+
+// EaseLinear applies the change at a constant rate, matching the transitions
+// performed by the device itself
+func EaseLinear(t float64) float64 {
+	return clampFraction(t)
+}
+
+// EaseInQuad starts slowly and accelerates
+func EaseInQuad(t float64) float64 {
+	t = clampFraction(t)
+	return t * t
+}
+
+// EaseOutQuad starts quickly and decelerates
+func EaseOutQuad(t float64) float64 {
+	t = clampFraction(t)
+	return t * (2 - t)
+}
+
+// EaseInOutQuad accelerates until half way, then decelerates
+func EaseInOutQuad(t float64) float64 {
+	t = clampFraction(t)
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return 1 - 2*(1-t)*(1-t)
+}
+
+// EaseInCubic starts slowly and accelerates, more sharply than EaseInQuad
+func EaseInCubic(t float64) float64 {
+	t = clampFraction(t)
+	return t * t * t
+}
+
+// EaseOutCubic starts quickly and decelerates, more sharply than EaseOutQuad
+func EaseOutCubic(t float64) float64 {
+	t = 1 - clampFraction(t)
+	return 1 - t*t*t
+}
+
+// EaseInOutCubic accelerates until half way, then decelerates, more sharply
+// than EaseInOutQuad
+func EaseInOutCubic(t float64) float64 {
+	t = clampFraction(t)
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	return 1 - 4*(1-t)*(1-t)*(1-t)
+}
+
+// EaseInOut accelerates gently until half way, then decelerates, following a
+// half cosine wave, which suits gradual changes such as waking
+func EaseInOut(t float64) float64 {
+	return (1 - math.Cos(math.Pi*clampFraction(t))) / 2
+}
+
+// NamedEasings are the easing functions available by name, see NamedEasing
+var NamedEasings = map[string]Easing{
+	`linear`:       EaseLinear,
+	`in_quad`:      EaseInQuad,
+	`out_quad`:     EaseOutQuad,
+	`in_out_quad`:  EaseInOutQuad,
+	`in_cubic`:     EaseInCubic,
+	`out_cubic`:    EaseOutCubic,
+	`in_out_cubic`: EaseInOutCubic,
+	`in_out`:       EaseInOut,
+}
+
+// NamedEasing returns the easing function from NamedEasings matching name,
+// ignoring case.  Returns ErrInvalidArgument if the name is unknown.
+func NamedEasing(name string) (Easing, error) {
+	easing, ok := NamedEasings[strings.ToLower(name)]
+	if !ok {
+		return nil, ErrInvalidArgument
+	}
+	return easing, nil
+}
+
+// EasingNames returns the names in NamedEasings, sorted alphabetically
+func EasingNames() []string {
+	names := make([]string, 0, len(NamedEasings))
+	for name := range NamedEasings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func clampFraction(t float64) float64 {
+	return math.Max(0, math.Min(1, t))
+}
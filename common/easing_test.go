@@ -0,0 +1,41 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Easing", func() {
+
+	DescribeTable("matching reference values",
+		func(easing Easing, quarter, half, threeQuarters float64) {
+			Expect(easing(0)).To(BeNumerically(`~`, 0, 1e-9))
+			Expect(easing(0.25)).To(BeNumerically(`~`, quarter, 1e-9))
+			Expect(easing(0.5)).To(BeNumerically(`~`, half, 1e-9))
+			Expect(easing(0.75)).To(BeNumerically(`~`, threeQuarters, 1e-9))
+			Expect(easing(1)).To(BeNumerically(`~`, 1, 1e-9))
+			Expect(easing(-1)).To(BeNumerically(`~`, 0, 1e-9))
+			Expect(easing(2)).To(BeNumerically(`~`, 1, 1e-9))
+		},
+		Entry("linear", Easing(EaseLinear), 0.25, 0.5, 0.75),
+		Entry("in quad", Easing(EaseInQuad), 0.0625, 0.25, 0.5625),
+		Entry("out quad", Easing(EaseOutQuad), 0.4375, 0.75, 0.9375),
+		Entry("in out quad", Easing(EaseInOutQuad), 0.125, 0.5, 0.875),
+		Entry("in cubic", Easing(EaseInCubic), 0.015625, 0.125, 0.421875),
+		Entry("out cubic", Easing(EaseOutCubic), 0.578125, 0.875, 0.984375),
+		Entry("in out cubic", Easing(EaseInOutCubic), 0.0625, 0.5, 0.9375),
+		Entry("in out", Easing(EaseInOut), 0.1464466094067262, 0.5, 0.8535533905932737),
+	)
+
+	It("should look up easings by name", func() {
+		easing, err := NamedEasing(`Out_Cubic`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(easing(0.5)).To(Equal(EaseOutCubic(0.5)))
+		_, err = NamedEasing(`bounce`)
+		Expect(err).To(Equal(ErrInvalidArgument))
+		Expect(EasingNames()).To(HaveLen(len(NamedEasings)))
+	})
+})
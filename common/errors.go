@@ -20,6 +20,10 @@ var (
 	ErrTimeout = errors.New(`Timed out`)
 	// ErrDeviceInvalidType invalid device type
 	ErrDeviceInvalidType = errors.New(`Invalid device type`)
+	// ErrVerifyFailed device state did not match the requested state
+	ErrVerifyFailed = errors.New(`Verification failed`)
+	// ErrNotSupported not supported by the device
+	ErrNotSupported = errors.New(`Not supported by device`)
 )
 
 // ErrNotImplemented not implemented
@@ -31,3 +35,22 @@ type ErrNotImplemented struct {
 func (e *ErrNotImplemented) Error() string {
 	return fmt.Sprintf("Method '%s' not implemented for this protocol", e.Method)
 }
+
+// ErrInvalidColor color component out of range, matches ErrInvalidArgument via
+// errors.Is
+type ErrInvalidColor struct {
+	Field string
+	Value uint16
+	Min   uint16
+	Max   uint16
+}
+
+// Error satisfies the error interface
+func (e *ErrInvalidColor) Error() string {
+	return fmt.Sprintf("Invalid color: %s %d outside range %d to %d", e.Field, e.Value, e.Min, e.Max)
+}
+
+// Is reports whether target is ErrInvalidArgument
+func (e *ErrInvalidColor) Is(target error) bool {
+	return target == ErrInvalidArgument
+}
@@ -11,6 +11,18 @@ type EventExpiredDevice struct {
 	Device Device
 }
 
+// EventOfflineDevice is emitted by a Client when a known Device stops
+// responding, see Device.IsOnline
+type EventOfflineDevice struct {
+	Device Device
+}
+
+// EventOnlineDevice is emitted by a Client when an offline Device responds
+// again
+type EventOnlineDevice struct {
+	Device Device
+}
+
 // EventUpdateLabel is emitted by a Device or Group when its label is updated
 type EventUpdateLabel struct {
 	Label string
@@ -48,3 +60,58 @@ type EventNewGroup struct {
 type EventExpiredGroup struct {
 	Group Group
 }
+
+// EventType is a bitmask of event types, used to filter the events delivered
+// to a Subscription, see Subscription.SetFilter
+type EventType uint32
+
+const (
+	EventTypeNewDevice EventType = 1 << iota
+	EventTypeExpiredDevice
+	EventTypeOfflineDevice
+	EventTypeOnlineDevice
+	EventTypeUpdateLabel
+	EventTypeUpdatePower
+	EventTypeUpdateColor
+	EventTypeNewLocation
+	EventTypeExpiredLocation
+	EventTypeNewGroup
+	EventTypeExpiredGroup
+	// EventTypeOther matches events not covered by the other types, such as
+	// those internal to a protocol
+	EventTypeOther
+
+	// EventTypeAll matches every event
+	EventTypeAll EventType = 1<<iota - 1
+)
+
+// EventTypeOf returns the EventType of event, or EventTypeOther if it is not
+// one of the events defined here
+func EventTypeOf(event interface{}) EventType {
+	switch event.(type) {
+	case EventNewDevice:
+		return EventTypeNewDevice
+	case EventExpiredDevice:
+		return EventTypeExpiredDevice
+	case EventOfflineDevice:
+		return EventTypeOfflineDevice
+	case EventOnlineDevice:
+		return EventTypeOnlineDevice
+	case EventUpdateLabel:
+		return EventTypeUpdateLabel
+	case EventUpdatePower:
+		return EventTypeUpdatePower
+	case EventUpdateColor:
+		return EventTypeUpdateColor
+	case EventNewLocation:
+		return EventTypeNewLocation
+	case EventExpiredLocation:
+		return EventTypeExpiredLocation
+	case EventNewGroup:
+		return EventTypeNewGroup
+	case EventExpiredGroup:
+		return EventTypeExpiredGroup
+	default:
+		return EventTypeOther
+	}
+}
@@ -0,0 +1,23 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event", func() {
+
+	DescribeTable("classifying events",
+		func(event interface{}, eventType EventType) {
+			Expect(EventTypeOf(event)).To(Equal(eventType))
+			Expect(EventTypeAll & eventType).To(Equal(eventType))
+		},
+		Entry("new device", EventNewDevice{}, EventTypeNewDevice),
+		Entry("power update", EventUpdatePower{Power: true}, EventTypeUpdatePower),
+		Entry("expired group", EventExpiredGroup{}, EventTypeExpiredGroup),
+		Entry("unknown", struct{}{}, EventTypeOther),
+	)
+})
@@ -0,0 +1,207 @@
+//go:build ignore
+
+// gen_products regenerates products.json from the product definitions
+// published by LIFX.  Details that LIFX does not publish (the form factor,
+// lumens and watts) are preserved from the existing table, so only need to be
+// filled in by hand for new products.
+//
+// Usage: go run gen_products.go [-upstream file-or-url] [-out products.json]
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+const upstreamURL = `https://raw.githubusercontent.com/LIFX/products/master/products.json`
+
+type features struct {
+	HEV              *bool    `json:"hev"`
+	Relays           *bool    `json:"relays"`
+	Buttons          *bool    `json:"buttons"`
+	Color            *bool    `json:"color"`
+	Matrix           *bool    `json:"matrix"`
+	Infrared         *bool    `json:"infrared"`
+	MultiZone        *bool    `json:"multizone"`
+	TemperatureRange []uint16 `json:"temperature_range"`
+}
+
+type upstreamVendor struct {
+	VID      uint32   `json:"vid"`
+	Defaults features `json:"defaults"`
+	Products []struct {
+		PID      uint32   `json:"pid"`
+		Name     string   `json:"name"`
+		Features features `json:"features"`
+	} `json:"products"`
+}
+
+// product mirrors common.ProductInfo, which can't be imported by an ignored
+// file in the same directory
+type product struct {
+	Vendor    uint32  `json:"vendor"`
+	Product   uint32  `json:"product"`
+	Name      string  `json:"name"`
+	Kind      string  `json:"kind"`
+	Color     bool    `json:"color"`
+	Infrared  bool    `json:"infrared"`
+	MultiZone bool    `json:"multizone"`
+	Matrix    bool    `json:"matrix"`
+	HEV       bool    `json:"hev"`
+	Relays    bool    `json:"relays"`
+	Buttons   bool    `json:"buttons"`
+	MinKelvin uint16  `json:"min_kelvin"`
+	MaxKelvin uint16  `json:"max_kelvin"`
+	MaxLumens uint32  `json:"max_lumens"`
+	MaxWatts  float64 `json:"max_watts"`
+}
+
+func main() {
+	upstream := flag.String(`upstream`, upstreamURL, `file or URL of the LIFX products.json`)
+	out := flag.String(`out`, `products.json`, `product table to update`)
+	flag.Parse()
+
+	data, err := read(*upstream)
+	if err != nil {
+		log.Fatalf("Failed reading upstream products: %v", err)
+	}
+	var vendors []upstreamVendor
+	if err := json.Unmarshal(data, &vendors); err != nil {
+		log.Fatalf("Failed decoding upstream products: %v", err)
+	}
+
+	existing := make(map[[2]uint32]product)
+	if data, err := os.ReadFile(*out); err == nil {
+		var list []product
+		if err := json.Unmarshal(data, &list); err != nil {
+			log.Fatalf("Failed decoding %s: %v", *out, err)
+		}
+		for _, p := range list {
+			existing[[2]uint32{p.Vendor, p.Product}] = p
+		}
+	}
+
+	var list []product
+	for _, v := range vendors {
+		for _, up := range v.Products {
+			f := merge(v.Defaults, up.Features)
+			p := product{
+				Vendor:    v.VID,
+				Product:   up.PID,
+				Name:      up.Name,
+				Kind:      `bulb`,
+				Color:     isSet(f.Color),
+				Infrared:  isSet(f.Infrared),
+				MultiZone: isSet(f.MultiZone),
+				Matrix:    isSet(f.Matrix),
+				HEV:       isSet(f.HEV),
+				Relays:    isSet(f.Relays),
+				Buttons:   isSet(f.Buttons),
+			}
+			switch {
+			case p.Relays:
+				p.Kind = `switch`
+			case p.Matrix:
+				p.Kind = `tile`
+			case p.MultiZone:
+				p.Kind = `strip`
+			}
+			if len(f.TemperatureRange) == 2 {
+				p.MinKelvin, p.MaxKelvin = f.TemperatureRange[0], f.TemperatureRange[1]
+			}
+			if old, ok := existing[[2]uint32{p.Vendor, p.Product}]; ok {
+				p.Kind, p.MaxLumens, p.MaxWatts = old.Kind, old.MaxLumens, old.MaxWatts
+			}
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Vendor != list[j].Vendor {
+			return list[i].Vendor < list[j].Vendor
+		}
+		return list[i].Product < list[j].Product
+	})
+
+	if err := os.WriteFile(*out, format(list), 0644); err != nil {
+		log.Fatalf("Failed writing %s: %v", *out, err)
+	}
+}
+
+func read(src string) ([]byte, error) {
+	if !strings.HasPrefix(src, `http://`) && !strings.HasPrefix(src, `https://`) {
+		return os.ReadFile(src)
+	}
+	res, err := http.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with status %d", src, res.StatusCode)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// merge returns the vendor defaults overridden by any features set for the
+// product
+func merge(defaults, f features) features {
+	if f.HEV != nil {
+		defaults.HEV = f.HEV
+	}
+	if f.Relays != nil {
+		defaults.Relays = f.Relays
+	}
+	if f.Buttons != nil {
+		defaults.Buttons = f.Buttons
+	}
+	if f.Color != nil {
+		defaults.Color = f.Color
+	}
+	if f.Matrix != nil {
+		defaults.Matrix = f.Matrix
+	}
+	if f.Infrared != nil {
+		defaults.Infrared = f.Infrared
+	}
+	if f.MultiZone != nil {
+		defaults.MultiZone = f.MultiZone
+	}
+	if f.TemperatureRange != nil {
+		defaults.TemperatureRange = f.TemperatureRange
+	}
+	return defaults
+}
+
+func isSet(b *bool) bool {
+	return b != nil && *b
+}
+
+// format writes one product per line, matching the hand-maintained layout
+func format(list []product) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, p := range list {
+		line, err := json.Marshal(p)
+		if err != nil {
+			log.Fatalf("Failed encoding product: %v", err)
+		}
+		line = bytes.ReplaceAll(line, []byte(`,"`), []byte(`, "`))
+		line = bytes.ReplaceAll(line, []byte(`":`), []byte(`": `))
+		buf.WriteString("  ")
+		buf.Write(line)
+		if i < len(list)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	return buf.Bytes()
+}
@@ -1,6 +1,19 @@
 package common
 
-import "time"
+import (
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+// NewGroupID returns a new random ID for creating a group or location via
+// Device.SetGroup or Device.SetLocation.  IDs are 16-byte version 4 (random)
+// UUIDs, so they are unique without coordination.  To move a device into an
+// existing group, use the ID already reported by the group's devices instead,
+// otherwise a new group with the same label is created.
+func NewGroupID() [16]byte {
+	return uuid.NewV4()
+}
 
 // Group represents a group of LIFX devices
 type Group interface {
@@ -27,6 +40,9 @@ type Group interface {
 	// SetColor requests a change of color for all devices in the group that
 	// support color changes, transitioning over the specified duration
 	SetColor(color Color, duration time.Duration) error
+	// SetColorWave changes the color of the lights in the group in order of
+	// ID, starting each light stagger after the previous, see ColorWave
+	SetColorWave(color Color, duration, stagger time.Duration) error
 	// SetPower sets the power of devices in the group that support power
 	// changes, state is true for on, false for off.
 	SetPower(state bool) error
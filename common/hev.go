@@ -0,0 +1,45 @@
+package common
+
+import (
+	"math"
+	"time"
+)
+
+// HevLight represents a LIFX light with a germicidal HEV (high energy visible)
+// clean cycle, such as the LIFX Clean
+type HevLight interface {
+	// StartHevCycle starts a clean cycle running for the specified duration,
+	// which is rounded down to whole seconds.  A duration of zero uses the
+	// default duration configured on the light.  Returns ErrInvalidArgument if
+	// the duration is negative or exceeds MaxHevCycleDuration.
+	StartHevCycle(duration time.Duration) error
+	// StopHevCycle stops the running clean cycle, if any
+	StopHevCycle() error
+	// GetHevCycle requests the state of the clean cycle from the light
+	GetHevCycle() (HevStatus, error)
+
+	// HevLight is a superset of the Light interface
+	Light
+}
+
+// MaxHevCycleDuration is the longest clean cycle supported by lights, which
+// encode durations as 32-bit seconds
+const MaxHevCycleDuration = time.Duration(math.MaxUint32) * time.Second
+
+// HevStatus is the state of the clean cycle of a HevLight
+type HevStatus struct {
+	// Duration is the total duration of the running cycle, or the last cycle
+	// if none is running
+	Duration time.Duration `json:"duration"`
+	// Remaining is the time remaining in the running cycle, zero if none is
+	// running
+	Remaining time.Duration `json:"remaining"`
+	// LastPower is whether the light was powered on before the cycle started,
+	// the light returns to this power state when the cycle ends
+	LastPower bool `json:"last_power"`
+}
+
+// Active returns whether a clean cycle is running
+func (s HevStatus) Active() bool {
+	return s.Remaining > 0
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"image"
+	"image/color"
+)
+
+const (
+	// dominantSampleSize is the maximum number of pixels sampled along each
+	// axis when determining the dominant color of an image
+	dominantSampleSize = 64
+	// dominantBucketBits is the number of most significant bits per RGB
+	// channel used to bucket similar colors together
+	dominantBucketBits = 4
+)
+
+type colorBucket struct {
+	r, g, b, weight uint64
+}
+
+// DominantColor returns the dominant color of the image img.  The image is
+// downsampled to at most 64x64 pixels, and the sampled pixels are bucketed by
+// similarity, weighted by their opacity.  The average of the most heavily
+// weighted bucket is converted to a HSBK Color via ColorFromRGB.  Fully
+// transparent pixels are ignored, and if the image contains no visible pixels,
+// a zero Color is returned.
+func DominantColor(img image.Image) Color {
+	var (
+		bounds  = img.Bounds()
+		buckets = make(map[uint32]*colorBucket)
+		best    *colorBucket
+		stepX   = bounds.Dx()/dominantSampleSize + 1
+		stepY   = bounds.Dy()/dominantSampleSize + 1
+		shift   = uint(16 - dominantBucketBits)
+	)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			if c.A == 0 {
+				continue
+			}
+			key := uint32(c.R>>shift)<<(2*dominantBucketBits) |
+				uint32(c.G>>shift)<<dominantBucketBits |
+				uint32(c.B>>shift)
+			bucket, ok := buckets[key]
+			if !ok {
+				bucket = new(colorBucket)
+				buckets[key] = bucket
+			}
+			weight := uint64(c.A)
+			bucket.r += uint64(c.R) * weight
+			bucket.g += uint64(c.G) * weight
+			bucket.b += uint64(c.B) * weight
+			bucket.weight += weight
+			if best == nil || bucket.weight > best.weight {
+				best = bucket
+			}
+		}
+	}
+
+	if best == nil {
+		return Color{}
+	}
+
+	return ColorFromRGB(color.RGBA64{
+		R: uint16(best.r / best.weight),
+		G: uint16(best.g / best.weight),
+		B: uint16(best.b / best.weight),
+		A: 0xffff,
+	})
+}
@@ -0,0 +1,51 @@
+package common_test
+
+import (
+	"image"
+	"image/color"
+
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Image", func() {
+	var (
+		red         = color.NRGBA{R: 255, A: 255}
+		blue        = color.NRGBA{B: 255, A: 255}
+		transparent = color.NRGBA{G: 255}
+	)
+
+	newImage := func(size int, pixel func(x, y int) color.Color) image.Image {
+		img := image.NewNRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				img.Set(x, y, pixel(x, y))
+			}
+		}
+		return img
+	}
+
+	DescribeTable("finding the dominant color",
+		func(size int, pixel func(x, y int) color.Color, expected Color) {
+			Expect(DominantColor(newImage(size, pixel))).To(Equal(expected))
+		},
+		Entry("solid", 10, func(x, y int) color.Color { return red }, ColorFromRGB(red)),
+		Entry("solid, downsampled", 200, func(x, y int) color.Color { return blue }, ColorFromRGB(blue)),
+		Entry("majority among noise", 10, func(x, y int) color.Color {
+			if (x+y)%4 == 0 {
+				return red
+			}
+			return blue
+		}, ColorFromRGB(blue)),
+		Entry("transparent majority ignored", 10, func(x, y int) color.Color {
+			if x < 3 {
+				return red
+			}
+			return transparent
+		}, ColorFromRGB(red)),
+		Entry("fully transparent", 10, func(x, y int) color.Color { return transparent }, Color{}),
+	)
+})
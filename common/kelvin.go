@@ -0,0 +1,106 @@
+package common
+
+import (
+	"image/color"
+	"math"
+)
+
+// kelvinTint is the RGB appearance of a white at a color temperature
+type kelvinTint struct {
+	kelvin  uint16
+	r, g, b uint8
+}
+
+// kelvinTints are the RGB appearance of whites from the warmest to the coolest
+// color temperature supported by any light, approximating the black body
+// curve, where 6500° is close to neutral (sRGB) white
+var kelvinTints = []kelvinTint{
+	{1500, 255, 108, 0},
+	{2000, 255, 137, 14},
+	{2500, 255, 159, 70},
+	{3000, 255, 177, 110},
+	{3500, 255, 193, 141},
+	{4000, 255, 206, 166},
+	{4500, 255, 218, 187},
+	{5000, 255, 228, 206},
+	{5500, 255, 237, 222},
+	{6000, 255, 246, 237},
+	{6500, 255, 254, 250},
+	{7000, 243, 242, 255},
+	{7500, 230, 235, 255},
+	{8000, 221, 230, 255},
+	{8500, 215, 226, 255},
+	{9000, 210, 223, 255},
+}
+
+// KelvinToRGB returns the RGB appearance of a white at the color temperature
+// kelvin, eg for tinting a UI swatch, interpolated from a table of tints every
+// 500°.  Temperatures beyond the table, 1500° to 9000°, are clamped to it.
+func KelvinToRGB(kelvin uint16) (r, g, b uint8) {
+	first, last := kelvinTints[0], kelvinTints[len(kelvinTints)-1]
+	if kelvin <= first.kelvin {
+		return first.r, first.g, first.b
+	}
+	if kelvin >= last.kelvin {
+		return last.r, last.g, last.b
+	}
+	for i := 1; i < len(kelvinTints); i++ {
+		hi := kelvinTints[i]
+		if kelvin > hi.kelvin {
+			continue
+		}
+		lo := kelvinTints[i-1]
+		t := float64(kelvin-lo.kelvin) / float64(hi.kelvin-lo.kelvin)
+		return lerpUint8(lo.r, hi.r, t), lerpUint8(lo.g, hi.g, t), lerpUint8(lo.b, hi.b, t)
+	}
+	return last.r, last.g, last.b
+}
+
+// RGB converts the Color to its approximate RGB appearance, eg for a UI
+// swatch.  The hue at full saturation is blended with the tint of the color
+// temperature (see KelvinToRGB) as saturation falls, so that whites show as
+// warm or cool rather than grey, and the result is scaled by brightness.
+func (c Color) RGB() color.RGBA {
+	r, g, b := c.rgbFraction()
+	channel := func(f float64) uint8 {
+		return uint8(math.Round(f * math.MaxUint8))
+	}
+	return color.RGBA{R: channel(r), G: channel(g), B: channel(b), A: math.MaxUint8}
+}
+
+// rgbFraction returns the RGB appearance of the Color as in RGB, with
+// components from 0 to 1
+func (c Color) rgbFraction() (r, g, b float64) {
+	tr, tg, tb := KelvinToRGB(c.Kelvin)
+	hr, hg, hb := hueToRGB(c.HSV().H)
+	s := float64(c.Saturation) / MaxUint16Component
+	v := float64(c.Brightness) / MaxUint16Component
+	channel := func(tint uint8, hue float64) float64 {
+		return v * ((1-s)*float64(tint)/math.MaxUint8 + s*hue)
+	}
+	return channel(tr, hr), channel(tg, hg), channel(tb, hb)
+}
+
+// hueToRGB returns the RGB components, from 0 to 1, of the hue h in degrees at
+// full saturation and value
+func hueToRGB(h float64) (r, g, b float64) {
+	x := 1 - math.Abs(math.Mod(h/60, 2)-1)
+	switch {
+	case h < 60:
+		return 1, x, 0
+	case h < 120:
+		return x, 1, 0
+	case h < 180:
+		return 0, 1, x
+	case h < 240:
+		return 0, x, 1
+	case h < 300:
+		return x, 0, 1
+	default:
+		return 1, 0, x
+	}
+}
+
+func lerpUint8(a, b uint8, t float64) uint8 {
+	return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+}
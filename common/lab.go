@@ -0,0 +1,69 @@
+package common
+
+import "math"
+
+// D65 reference white, the white point of sRGB, in CIE XYZ
+const (
+	d65X = 0.95047
+	d65Y = 1.0
+	d65Z = 1.08883
+)
+
+// ColorLab is a color in the CIE L*a*b* color space, in which distances
+// approximate perceived differences, see DeltaE
+type ColorLab struct {
+	L float64 `json:"l"` // lightness, range 0 (black) to 100 (white)
+	A float64 `json:"a"` // green (negative) to red (positive)
+	B float64 `json:"b"` // blue (negative) to yellow (positive)
+}
+
+// Lab converts the Color to CIE L*a*b*, from its RGB appearance (see
+// Color.RGB) as sRGB under the D65 white point
+func (c Color) Lab() ColorLab {
+	return labFromRGB(c.rgbFraction())
+}
+
+// labFromRGB converts sRGB components, from 0 to 1, to CIE L*a*b*
+func labFromRGB(r, g, b float64) ColorLab {
+	r, g, b = srgbToLinear(r), srgbToLinear(g), srgbToLinear(b)
+
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / d65X
+	y := (0.2126729*r + 0.7151522*g + 0.0721750*b) / d65Y
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / d65Z
+	fx, fy, fz := labF(x), labF(y), labF(z)
+
+	return ColorLab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+// DeltaE returns the CIE76 color difference between a and b, the distance
+// between them in CIE L*a*b* (see Color.Lab).  A difference of about 2.3 is
+// just noticeable, and identical colors have a difference of zero.
+func DeltaE(a, b Color) float64 {
+	return DeltaELab(a.Lab(), b.Lab())
+}
+
+// DeltaELab returns the CIE76 color difference between a and b
+func DeltaELab(a, b ColorLab) float64 {
+	return math.Sqrt((a.L-b.L)*(a.L-b.L) + (a.A-b.A)*(a.A-b.A) + (a.B-b.B)*(a.B-b.B))
+}
+
+// srgbToLinear removes the sRGB gamma from the component c, from 0 to 1
+func srgbToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// labF is the nonlinear compression of CIE L*a*b*, linear near black
+func labF(t float64) float64 {
+	const delta = 6.0 / 29
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29
+}
@@ -0,0 +1,53 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lab", func() {
+	var (
+		red   = Color{Saturation: 65535, Brightness: 65535, Kelvin: 3500}
+		green = Color{Hue: 21845, Saturation: 65535, Brightness: 65535, Kelvin: 3500}
+		blue  = Color{Hue: 43690, Saturation: 65535, Brightness: 65535, Kelvin: 3500}
+		black = Color{Kelvin: 6500}
+	)
+
+	// Reference values for the sRGB primaries under D65
+	DescribeTable("converting to CIE L*a*b*",
+		func(c Color, expected ColorLab) {
+			lab := c.Lab()
+			Expect(lab.L).To(BeNumerically("~", expected.L, 0.01))
+			Expect(lab.A).To(BeNumerically("~", expected.A, 0.01))
+			Expect(lab.B).To(BeNumerically("~", expected.B, 0.01))
+		},
+		Entry("red", red, ColorLab{L: 53.2408, A: 80.0925, B: 67.2032}),
+		Entry("green", green, ColorLab{L: 87.7347, A: -86.1827, B: 83.1793}),
+		Entry("blue", blue, ColorLab{L: 32.2970, A: 79.1875, B: -107.8602}),
+		Entry("black", black, ColorLab{}),
+	)
+
+	DescribeTable("calculating the color difference",
+		func(a, b Color, expected float64) {
+			Expect(DeltaE(a, b)).To(BeNumerically("~", expected, 0.01))
+			Expect(DeltaE(b, a)).To(BeNumerically("~", expected, 0.01))
+		},
+		Entry("identical colors", red, red, 0.0),
+		Entry("red and green", red, green, 170.5650),
+		Entry("red and blue", red, blue, 176.3141),
+		Entry("green and blue", green, blue, 258.6826),
+	)
+
+	It("should treat neighbouring whites as barely noticeably different", func() {
+		warm := Color{Brightness: 65535, Kelvin: 2700}
+		Expect(DeltaE(warm, Color{Brightness: 65535, Kelvin: 2750})).To(BeNumerically("<", 2.3))
+		Expect(DeltaE(warm, Color{Brightness: 65535, Kelvin: 6500})).To(BeNumerically(">", 2.3))
+	})
+
+	It("should place black and white at opposite ends of lightness", func() {
+		Expect(DeltaE(black, Color{Brightness: 65535, Kelvin: 6500})).To(BeNumerically("~", 100, 0.5))
+	})
+})
@@ -1,20 +1,157 @@
 package common
 
-import "time"
+import (
+	"context"
+	"path"
+	"sync"
+	"time"
+)
 
 // Light represents a LIFX light device
 type Light interface {
 	// SetColor changes the color of the light, transitioning over the specified
-	// duration
+	// duration.  Returns ErrInvalidArgument if the duration is negative or
+	// exceeds MaxDuration.
 	SetColor(color Color, duration time.Duration) error
+	// SetColorIfOn changes the color of the light as per SetColor, but only if
+	// the light is currently powered on, so that lights deliberately switched
+	// off are left alone.  Returns whether the color was applied.
+	SetColorIfOn(color Color, duration time.Duration) (bool, error)
+	// SetColorClamped changes the color of the light as per SetColor, after
+	// clamping out of range components (see ClampColor), and returns the
+	// color that was applied.
+	SetColorClamped(color Color, duration time.Duration) (Color, error)
+	// SetColorByName changes the color of the light to the color from
+	// NamedColors matching name (see NamedColor), transitioning over the
+	// specified duration.  Returns ErrInvalidArgument if the name is unknown.
+	SetColorByName(name string, duration time.Duration) error
+	// SetColorVerified changes the color of the light, transitioning over the
+	// specified duration, then waits for the transition and a further delay to
+	// elapse before reading back the color.  Returns ErrVerifyFailed if the
+	// color read back is not within tolerance of color (see
+	// ColorApproxEqual).  The request is always sent, even if the cached color
+	// matches.
+	SetColorVerified(color Color, duration time.Duration, tolerance uint16, delay time.Duration) error
+	// SetColorAck changes the color of the light, transitioning over the
+	// specified duration, and waits for the light to acknowledge the change,
+	// regardless of the client reliability setting.  Returns true if
+	// acknowledged, or false and ErrTimeout if no acknowledgement arrived
+	// before the timeout.
+	SetColorAck(color Color, duration time.Duration) (bool, error)
+	// UpdateColor requests the current color of the light, applies fn to it,
+	// and sets the returned color, transitioning over the specified duration.
+	// Concurrent calls to UpdateColor on the same light are serialized, but
+	// changes made by other means between the read and write are overwritten.
+	UpdateColor(fn func(Color) Color, duration time.Duration) error
+	// SetBrightnessPercent sets the brightness of the light to percent, in the
+	// range 0 to 100 (clamped), preserving hue, saturation and kelvin, see
+	// UpdateColor
+	SetBrightnessPercent(percent float64, duration time.Duration) error
+	// AdjustKelvin adds delta to the color temperature of the light, clamped
+	// to the supported range (see AdjustKelvin), see UpdateColor
+	AdjustKelvin(delta int, duration time.Duration) error
+	// AdjustBrightness adds delta to the brightness of the light, clamped to
+	// the range 0 to 65535, see UpdateColor
+	AdjustBrightness(delta int, duration time.Duration) error
+	// FlashColor pulses the light to color and back for cycles cycles, each
+	// lasting period, after which the light itself reverts to its color
+	// beforehand, so no state is kept by the client and the revert happens
+	// even if the client exits.  The cached color is left unchanged.  Returns
+	// ErrInvalidArgument if period or cycles is not positive, or period
+	// exceeds MaxDuration.
+	FlashColor(color Color, period time.Duration, cycles uint16) error
 	// GetColor requests the current color of the light
 	GetColor() (Color, error)
 	// CachedColor returns the last known color of the light
 	CachedColor() Color
+	// WaitUntilColor blocks until the light reports a color within tolerance
+	// of target (see ColorApproxEqual), or the context is done.  The light is
+	// polled at the client poll interval.
+	WaitUntilColor(ctx context.Context, target Color, tolerance uint16) error
+	// Tags returns the labels of any legacy (v1 protocol) tags reported by the
+	// light.  Returns an empty slice and no error if tags are not supported.
+	Tags() ([]string, error)
+	// GetAccessPoint requests the WiFi access point the light is associated
+	// with.  Access point messages are from the legacy (v1) protocol, returns
+	// ErrNotSupported if the light does not answer them.
+	GetAccessPoint() (AccessPoint, error)
 	// SetPowerDuration sets the power of the light, transitioning over the
-	// speficied duration, state is true for on, false for off.
+	// speficied duration, state is true for on, false for off.  Returns
+	// ErrInvalidArgument if the duration is negative or exceeds MaxDuration.
 	SetPowerDuration(state bool, duration time.Duration) error
+	// TransitionRemaining returns the estimated time until the last color or
+	// power transition sent by this client completes, or zero if none is in
+	// flight.  The protocol does not report transitions, so this is tracked
+	// by the client from the time each change was sent, and is invalidated by
+	// changes made by other clients or at the device.
+	TransitionRemaining() time.Duration
 
 	// Light is a superset of the Device interface
 	Device
 }
+
+// MatchLabelPattern returns the lights whose label matches the glob pattern,
+// preserving their order.  Patterns use the syntax of path.Match, eg
+// `Office-*` or `Bedroom-[12]`, and must match the whole label.  Lights whose
+// label can not be read are skipped.  Returns ErrInvalidArgument if the pattern
+// is malformed, or ErrNotFound if no lights match.
+func MatchLabelPattern(lights []Light, pattern string) ([]Light, error) {
+	// Match only reports a malformed pattern when it is reached, so check the
+	// whole pattern before matching any labels
+	if _, err := path.Match(pattern, ``); err != nil {
+		return nil, ErrInvalidArgument
+	}
+
+	var matched []Light
+	for _, light := range lights {
+		label, err := light.GetLabel()
+		if err != nil {
+			continue
+		}
+		if ok, _ := path.Match(pattern, label); ok {
+			matched = append(matched, light)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, ErrNotFound
+	}
+	return matched, nil
+}
+
+// ColorWave changes the color of each light in turn, in the order given,
+// starting the transition of each light stagger after the previous light, so
+// that the change sweeps across the lights.  Lights transition over duration,
+// and may overlap if the stagger is shorter.  This blocks until every light
+// has been sent its change, and returns the first error encountered.  Returns
+// ErrInvalidArgument if either duration is negative or exceeds MaxDuration.
+func ColorWave(lights []Light, color Color, duration, stagger time.Duration) error {
+	if err := ValidateDuration(duration); err != nil {
+		return err
+	}
+	if err := ValidateDuration(stagger); err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		err      error
+		errMutex sync.Mutex
+	)
+	start := time.Now()
+	for i, light := range lights {
+		wg.Add(1)
+		go func(light Light, offset time.Duration) {
+			defer wg.Done()
+			time.Sleep(time.Until(start.Add(offset)))
+			e := light.SetColor(color, duration)
+			errMutex.Lock()
+			if err == nil && e != nil {
+				err = e
+			}
+			errMutex.Unlock()
+		}(light, time.Duration(i)*stagger)
+	}
+
+	wg.Wait()
+	return err
+}
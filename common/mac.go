@@ -0,0 +1,19 @@
+package common
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// MACToID converts a MAC address in the `aa:bb:cc:dd:ee:ff` form (as returned
+// by Device.MAC) to the corresponding device ID.  Returns ErrInvalidArgument
+// if mac is not a valid 6-byte MAC address.
+func MACToID(mac string) (uint64, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return 0, ErrInvalidArgument
+	}
+	target := make([]byte, 8)
+	copy(target, hw)
+	return binary.LittleEndian.Uint64(target), nil
+}
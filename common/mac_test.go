@@ -0,0 +1,31 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MAC", func() {
+
+	It("should convert a MAC address to a device ID", func() {
+		id, err := MACToID(`d0:73:d5:01:02:03`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(uint64(0x030201d573d0)))
+	})
+
+	It("should accept upper-case MAC addresses", func() {
+		id, err := MACToID(`D0:73:D5:01:02:03`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(uint64(0x030201d573d0)))
+	})
+
+	It("should return ErrInvalidArgument for invalid MAC addresses", func() {
+		_, err := MACToID(`d0:73:d5`)
+		Expect(err).To(Equal(ErrInvalidArgument))
+		_, err = MACToID(`00:00:00:00:fe:80:00:00`)
+		Expect(err).To(Equal(ErrInvalidArgument))
+	})
+
+})
@@ -0,0 +1,91 @@
+package common
+
+import (
+	"math"
+	"time"
+)
+
+// MultiZoneLight represents a LIFX light with multiple independently colored
+// zones, such as the LIFX Z strip or LIFX Beam
+type MultiZoneLight interface {
+	// ZoneCount returns the number of zones on the light.  The count is
+	// requested from the light on first use and cached, as it does not change.
+	ZoneCount() (uint8, error)
+	// GetZoneColors requests the current color of each zone on the light
+	GetZoneColors() ([]Color, error)
+	// SetZoneColors changes the color of each zone on the light, transitioning
+	// over the specified duration.  The length of colors must match the zone
+	// count.
+	SetZoneColors(colors []Color, duration time.Duration) error
+	// SetColorZones changes the color of the zones from start to end
+	// (inclusive) on the light, transitioning over the specified duration.
+	// Returns ErrInvalidArgument if the range is outside the zone count.
+	SetColorZones(start, end uint8, color Color, duration time.Duration) error
+	// SetColorFraction changes the color of the portion of the light from
+	// start to end, expressed as fractions (0 to 1) of its length, so that
+	// the same call covers the same proportion of strips with different zone
+	// counts.  See ZoneRange for how fractions are rounded to zones.
+	SetColorFraction(start, end float64, color Color, duration time.Duration) error
+	// SetColorZonesStaged stages a change of the zones from start to end
+	// (inclusive) to color, without displaying it.  The light buffers staged
+	// changes until the next ApplyZones, or the next SetColorZones or
+	// SetZoneColors, which display all staged changes together with their
+	// own.  Several staged writes may be used to build a complete frame across
+	// many requests, which is then displayed at once to avoid tearing on long
+	// strips.  Staged changes are held by the light and lost if it restarts,
+	// and are not reported by GetZoneColors until applied.  Returns
+	// ErrInvalidArgument if the range is outside the zone count.
+	SetColorZonesStaged(start, end uint8, color Color) error
+	// ApplyZones displays all changes staged with SetColorZonesStaged,
+	// transitioning over the specified duration
+	ApplyZones(duration time.Duration) error
+	// SetGradient fills the zones of the light with a gradient from `from` to
+	// `to` (see Gradient), transitioning over the specified duration
+	SetGradient(from, to Color, duration time.Duration) error
+	// VerifyZones requests the current color of each zone, and returns the
+	// indexes of the zones that do not match expected within tolerance (see
+	// ColorApproxEqual), eg to resend zones whose changes were dropped.
+	// Returns ErrInvalidArgument if the length of expected does not match the
+	// zone count.
+	VerifyZones(expected []Color, tolerance uint16) ([]int, error)
+
+	// MultiZoneLight is a superset of the Light interface
+	Light
+}
+
+// MismatchedZones returns the indexes of the zones in actual that do not match
+// the zone in expected within tolerance, see ColorApproxEqual.  Returns
+// ErrInvalidArgument if the lengths differ.
+func MismatchedZones(actual, expected []Color, tolerance uint16) ([]int, error) {
+	if len(actual) != len(expected) {
+		return nil, ErrInvalidArgument
+	}
+	var mismatched []int
+	for i := range actual {
+		if !ColorApproxEqual(actual[i], expected[i], tolerance) {
+			mismatched = append(mismatched, i)
+		}
+	}
+	return mismatched, nil
+}
+
+// ZoneRange maps the fractions start and end (0 to 1) of a light with count
+// zones to the inclusive range of zone indexes they cover.  Any zone partially
+// covered is included, so start rounds down and end rounds up to a zone
+// boundary, eg the first third (0 to 1/3) of a 16 zone strip is zones 0 to 5.
+// Returns ErrInvalidArgument if the fractions are outside 0 to 1, start is not
+// less than end, or count is zero.
+func ZoneRange(count uint8, start, end float64) (uint8, uint8, error) {
+	if count == 0 || start < 0 || end > 1 || start >= end {
+		return 0, 0, ErrInvalidArgument
+	}
+	first := math.Floor(start * float64(count))
+	last := math.Ceil(end*float64(count)) - 1
+	if first >= float64(count) {
+		first = float64(count) - 1
+	}
+	if last < first {
+		last = first
+	}
+	return uint8(first), uint8(last), nil
+}
@@ -0,0 +1,36 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MultiZone", func() {
+
+	DescribeTable("mapping fractions to zones",
+		func(count uint8, start, end float64, first, last uint8) {
+			f, l, err := ZoneRange(count, start, end)
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]uint8{f, l}).To(Equal([]uint8{first, last}))
+		},
+		Entry("whole strip", uint8(16), 0.0, 1.0, uint8(0), uint8(15)),
+		Entry("first third of 16", uint8(16), 0.0, 1.0/3, uint8(0), uint8(5)),
+		Entry("first third of 82", uint8(82), 0.0, 1.0/3, uint8(0), uint8(27)),
+		Entry("second half of 16", uint8(16), 0.5, 1.0, uint8(8), uint8(15)),
+		Entry("sliver within a zone", uint8(8), 0.51, 0.52, uint8(4), uint8(4)),
+	)
+
+	DescribeTable("rejecting invalid fractions",
+		func(count uint8, start, end float64) {
+			_, _, err := ZoneRange(count, start, end)
+			Expect(err).To(Equal(ErrInvalidArgument))
+		},
+		Entry("no zones", uint8(0), 0.0, 1.0),
+		Entry("negative start", uint8(8), -0.1, 1.0),
+		Entry("end past 1", uint8(8), 0.0, 1.1),
+		Entry("empty range", uint8(8), 0.5, 0.5),
+	)
+})
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// NamedColors maps simple color names to colors at full brightness, for
+// convenience when scripting.  Names are lower case, see NamedColor for
+// case-insensitive lookup.  The CLI resolves its --color flag from this map,
+// so additions here are available to both.
+var NamedColors = map[string]Color{
+	`red`:        {Hue: 0, Saturation: math.MaxUint16, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`orange`:     {Hue: 6372, Saturation: math.MaxUint16, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`yellow`:     {Hue: 10923, Saturation: math.MaxUint16, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`green`:      {Hue: 21845, Saturation: math.MaxUint16, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`cyan`:       {Hue: 32768, Saturation: math.MaxUint16, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`blue`:       {Hue: 43690, Saturation: math.MaxUint16, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`purple`:     {Hue: 50062, Saturation: math.MaxUint16, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`pink`:       {Hue: 59164, Saturation: 16384, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`white`:      {Hue: 0, Saturation: 0, Brightness: math.MaxUint16, Kelvin: rgbDefaultKelvin},
+	`warm_white`: {Hue: 0, Saturation: 0, Brightness: math.MaxUint16, Kelvin: 2700},
+	`cool_white`: {Hue: 0, Saturation: 0, Brightness: math.MaxUint16, Kelvin: 6500},
+	`daylight`:   {Hue: 0, Saturation: 0, Brightness: math.MaxUint16, Kelvin: 5600},
+}
+
+// NamedColor returns the color from NamedColors matching name, ignoring case
+// and treating hyphens as underscores, eg `Warm-White`.  Returns
+// ErrInvalidArgument if the name is unknown.
+func NamedColor(name string) (Color, error) {
+	color, ok := NamedColors[strings.ReplaceAll(strings.ToLower(name), `-`, `_`)]
+	if !ok {
+		return Color{}, ErrInvalidArgument
+	}
+	return color, nil
+}
+
+// ColorNames returns the names in NamedColors, sorted alphabetically
+func ColorNames() []string {
+	names := make([]string, 0, len(NamedColors))
+	for name := range NamedColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
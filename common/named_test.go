@@ -0,0 +1,39 @@
+package common_test
+
+import (
+	"math"
+
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NamedColor", func() {
+
+	It("should resolve names ignoring case", func() {
+		color, err := NamedColor(`Red`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(color).To(Equal(NamedColors[`red`]))
+		Expect(color.Saturation).To(Equal(uint16(math.MaxUint16)))
+	})
+
+	It("should resolve hyphenated names", func() {
+		color, err := NamedColor(`Warm-White`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(color).To(Equal(NamedColors[`warm_white`]))
+	})
+
+	It("should return ErrInvalidArgument for unknown names", func() {
+		_, err := NamedColor(`octarine`)
+		Expect(err).To(Equal(ErrInvalidArgument))
+	})
+
+	It("should list every name in sorted order", func() {
+		names := ColorNames()
+		Expect(names).To(HaveLen(len(NamedColors)))
+		Expect(names).To(ContainElement(`warm_white`))
+		Expect(names[0]).To(Equal(`blue`))
+	})
+
+})
@@ -0,0 +1,37 @@
+package common
+
+import "image/color"
+
+// accessiblePalette is the Okabe-Ito palette (Okabe & Ito, "Color Universal
+// Design", 2008), designed to remain distinguishable under the common red-green
+// color vision deficiencies, deuteranopia and protanopia.  Its black is
+// omitted, as a light set to black is indistinguishable from one switched off.
+var accessiblePalette = []color.RGBA{
+	{R: 0xe6, G: 0x9f, B: 0x00, A: 0xff}, // orange
+	{R: 0x56, G: 0xb4, B: 0xe9, A: 0xff}, // sky blue
+	{R: 0x00, G: 0x9e, B: 0x73, A: 0xff}, // bluish green
+	{R: 0xf0, G: 0xe4, B: 0x42, A: 0xff}, // yellow
+	{R: 0x00, G: 0x72, B: 0xb2, A: 0xff}, // blue
+	{R: 0xd5, G: 0x5e, B: 0x00, A: 0xff}, // vermillion
+	{R: 0xcc, G: 0x79, B: 0xa7, A: 0xff}, // reddish purple
+}
+
+// AccessiblePalette returns up to n colors that are distinguishable by people
+// with deuteranopia or protanopia, for status indicators and the like.  Colors
+// are from the Okabe-Ito palette, converted via ColorFromRGB, so they differ in
+// brightness as well as hue, which aids distinction.  At most seven colors are
+// available, the first colors are the most distinct, so prefer small n.
+// Returns an empty slice if n is not positive.
+func AccessiblePalette(n int) []Color {
+	if n < 0 {
+		n = 0
+	}
+	if n > len(accessiblePalette) {
+		n = len(accessiblePalette)
+	}
+	colors := make([]Color, n)
+	for i := range colors {
+		colors[i] = ColorFromRGB(accessiblePalette[i])
+	}
+	return colors
+}
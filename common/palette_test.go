@@ -0,0 +1,30 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AccessiblePalette", func() {
+
+	It("should return the Okabe-Ito hues in order", func() {
+		hues := []float64{41.5, 201.6, 163.7, 55.9, 201.6, 26.5, 326.7}
+		palette := AccessiblePalette(len(hues))
+		Expect(palette).To(HaveLen(len(hues)))
+		for i, color := range palette {
+			Expect(color.HSV().H).To(BeNumerically(`~`, hues[i], 0.1))
+			Expect(color.Brightness).NotTo(BeZero())
+			Expect(color.Validate()).To(Succeed())
+		}
+	})
+
+	It("should limit the number of colors", func() {
+		Expect(AccessiblePalette(3)).To(Equal(AccessiblePalette(7)[:3]))
+		Expect(AccessiblePalette(20)).To(HaveLen(7))
+		Expect(AccessiblePalette(0)).To(BeEmpty())
+		Expect(AccessiblePalette(-1)).To(BeEmpty())
+	})
+
+})
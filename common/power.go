@@ -0,0 +1,43 @@
+package common
+
+import "strconv"
+
+// Power is the power level of a device, which the protocol reports as a level
+// from 0 (off) to 65535 (on).  Devices report intermediate levels while
+// transitioning power, eg via Light.SetPowerDuration.  The bool power methods,
+// such as Device.GetPower, treat any non-zero level as on.
+type Power uint16
+
+const (
+	// PowerOff is the power level of a device that is off
+	PowerOff Power = 0
+	// PowerOn is the power level of a device that is fully on
+	PowerOn Power = MaxUint16Component
+)
+
+// On returns true for any non-zero power level, matching Device.GetPower
+func (p Power) On() bool {
+	return p != PowerOff
+}
+
+// Level returns the power level, from 0 (off) to 65535 (on)
+func (p Power) Level() uint16 {
+	return uint16(p)
+}
+
+// Fraction returns the power level as a fraction, from 0 (off) to 1 (on)
+func (p Power) Fraction() float64 {
+	return float64(p) / MaxUint16Component
+}
+
+// String returns on, off, or the level as a percentage while transitioning
+func (p Power) String() string {
+	switch p {
+	case PowerOff:
+		return `off`
+	case PowerOn:
+		return `on`
+	default:
+		return strconv.FormatFloat(p.Fraction()*100, 'f', 0, 64) + `%`
+	}
+}
@@ -0,0 +1,23 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Power", func() {
+
+	DescribeTable("mapping power levels",
+		func(power Power, on bool, description string) {
+			Expect(power.On()).To(Equal(on))
+			Expect(power.String()).To(Equal(description))
+		},
+		Entry("off", PowerOff, false, `off`),
+		Entry("on", PowerOn, true, `on`),
+		Entry("half way through a fade", Power(32768), true, `50%`),
+		Entry("barely on", Power(1), true, `0%`),
+	)
+})
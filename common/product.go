@@ -0,0 +1,88 @@
+package common
+
+import (
+	_ "embed" // required for go:embed
+	"encoding/json"
+)
+
+// ProductKind describes the form factor of a product
+type ProductKind string
+
+const (
+	// ProductKindBulb is a single-zone bulb, downlight or similar
+	ProductKindBulb ProductKind = `bulb`
+	// ProductKindStrip is a linear multi-zone light, such as the LIFX Z or
+	// Beam
+	ProductKindStrip ProductKind = `strip`
+	// ProductKindTile is a two-dimensional matrix light, such as the LIFX
+	// Tile
+	ProductKindTile ProductKind = `tile`
+	// ProductKindSwitch is a wall switch controlling loads via relays, such
+	// as the LIFX Switch, rather than a light
+	ProductKindSwitch ProductKind = `switch`
+)
+
+// ProductInfo describes the capabilities and nominal specifications of a
+// product, identified by its vendor and product IDs as reported by the device.
+// Numeric specifications are zero when unknown, or when they depend on the
+// installation (eg the length of a strip).
+type ProductInfo struct {
+	Vendor    uint32      `json:"vendor"`
+	Product   uint32      `json:"product"`
+	Name      string      `json:"name"`
+	Kind      ProductKind `json:"kind"`
+	Color     bool        `json:"color"`
+	Infrared  bool        `json:"infrared"`
+	MultiZone bool        `json:"multizone"`
+	Matrix    bool        `json:"matrix"`
+	HEV       bool        `json:"hev"`
+	Relays    bool        `json:"relays"`
+	Buttons   bool        `json:"buttons"`
+	MinKelvin uint16      `json:"min_kelvin"`
+	MaxKelvin uint16      `json:"max_kelvin"`
+	MaxLumens uint32      `json:"max_lumens"`
+	MaxWatts  float64     `json:"max_watts"`
+}
+
+// productsJSON is the product table, regenerated from the LIFX products
+// repository by `go generate`, see gen_products.go
+//
+//go:generate go run gen_products.go
+//go:embed products.json
+var productsJSON []byte
+
+type productKey struct {
+	vendor  uint32
+	product uint32
+}
+
+var products, productList = loadProducts(productsJSON)
+
+func loadProducts(data []byte) (map[productKey]ProductInfo, []ProductInfo) {
+	var list []ProductInfo
+	if err := json.Unmarshal(data, &list); err != nil {
+		panic(`Failed decoding embedded product table: ` + err.Error())
+	}
+	m := make(map[productKey]ProductInfo, len(list))
+	for _, info := range list {
+		m[productKey{vendor: info.Vendor, product: info.Product}] = info
+	}
+	return m, list
+}
+
+// LookupProduct returns the product table entry for the vendor and product
+// IDs, as reported by a device's hardware version, and whether the product is
+// known.  This is the table used by Device.GetProductInfo, but requires no
+// device.
+func LookupProduct(vendor, product uint32) (ProductInfo, bool) {
+	info, ok := products[productKey{vendor: vendor, product: product}]
+	return info, ok
+}
+
+// Products returns every entry in the product table, ordered by vendor and
+// product ID
+func Products() []ProductInfo {
+	list := make([]ProductInfo, len(productList))
+	copy(list, productList)
+	return list
+}
@@ -0,0 +1,41 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Product", func() {
+
+	It("should look up known products", func() {
+		info, ok := LookupProduct(1, 90)
+		Expect(ok).To(BeTrue())
+		Expect(info.Name).To(Equal(`LIFX Clean`))
+		Expect(info.HEV).To(BeTrue())
+	})
+
+	It("should identify switches", func() {
+		info, ok := LookupProduct(1, 70)
+		Expect(ok).To(BeTrue())
+		Expect(info.Kind).To(Equal(ProductKindSwitch))
+		Expect(info.Relays).To(BeTrue())
+		Expect(info.Buttons).To(BeTrue())
+	})
+
+	It("should report unknown products", func() {
+		_, ok := LookupProduct(1, 65535)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should list every product in order", func() {
+		list := Products()
+		Expect(list).NotTo(BeEmpty())
+		for i := 1; i < len(list); i++ {
+			Expect(list[i-1].Product).To(BeNumerically("<", list[i].Product))
+		}
+		list[0].Name = `modified`
+		Expect(Products()[0].Name).NotTo(Equal(`modified`))
+	})
+})
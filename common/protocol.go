@@ -4,6 +4,11 @@ import (
 	"time"
 )
 
+// DecodeErrorHandler is called with the raw bytes of a message received by a
+// client that could not be decoded, and the decoding error, see
+// Client.OnDecodeError
+type DecodeErrorHandler func(buf []byte, err error)
+
 // Protocol defines the interface between the Client and a protocol
 // implementation
 type Protocol interface {
@@ -24,6 +29,10 @@ type Protocol interface {
 	GetDevices() (devices []Device, err error)
 	// GetDevice looks up a device by its `id`
 	GetDevice(id uint64) (Device, error)
+	// DeviceFromCache returns a light with the known `id`, addressed directly
+	// at `address` (eg as recorded by a previous session), without waiting
+	// for discovery.  Requests to a light that does not respond time out.
+	DeviceFromCache(id uint64, address string) (Light, error)
 	// Discover initiates device discovery, this may be a noop in some future
 	// protocol versions.  This is called immediately when the client connects
 	// to the protocol
@@ -32,6 +41,19 @@ type Protocol interface {
 	SetTimeout(timeout *time.Duration)
 	// SetRetryInterval attaches the client retry interval to the protocol
 	SetRetryInterval(retryInterval *time.Duration)
+	// SetClient attaches the client to the protocol, allowing the protocol to
+	// access client configuration
+	SetClient(client ClientConfig)
+	// SetPort sets the port that discovery requests are sent to
+	SetPort(port int) error
+	// SetBroadcastAddress sets the address that discovery requests are sent
+	// to, in host:port form
+	SetBroadcastAddress(address string) error
+	// AddBroadcastAddress adds a further address or CIDR network that
+	// discovery requests are broadcast to
+	AddBroadcastAddress(address string) error
+	// SetSource sets the source identifier that requests are tagged with
+	SetSource(source uint32) error
 	// Close closes the protocol driver, no further communication with the
 	// protocol is possible
 	Close() error
@@ -46,4 +68,6 @@ type Protocol interface {
 	// SetColor changes the color globally, on all lights, over the specified
 	// duration
 	SetColor(color Color, duration time.Duration) error
+	// BroadcastWaveform sends the waveform to all lights at once
+	BroadcastWaveform(w Waveform) error
 }
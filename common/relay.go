@@ -0,0 +1,13 @@
+package common
+
+// RelayDevice represents a LIFX device that switches loads via relays rather
+// than emitting light, such as the LIFX Switch
+type RelayDevice interface {
+	// SetIndicatorColor sets the color of the button backlight shown while the
+	// load is on, preserving the rest of the button configuration.  Returns
+	// ErrNotSupported if the product has no configurable indicator.
+	SetIndicatorColor(color Color) error
+
+	// RelayDevice is a superset of the Device interface
+	Device
+}
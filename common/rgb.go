@@ -0,0 +1,94 @@
+package common
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// whiteKelvinStep is the resolution of the search by WhiteFromRGB for the
+	// white matching a color
+	whiteKelvinStep = 50
+	// justNoticeableDeltaE is the smallest DeltaE generally perceived as a
+	// difference between two colors
+	justNoticeableDeltaE = 2.3
+)
+
+// ParseRGB parses an RGB color, given in hex as `#RRGGBB` or `RRGGBB`, or as
+// decimal components from 0 to 255 as `r,g,b`, and converts it to a HSBK
+// Color.  Colors that appear white are converted to a white of the matching
+// color temperature, see WhiteFromRGB, others via ColorFromRGB.  Returns
+// ErrInvalidArgument if s is malformed.
+func ParseRGB(s string) (Color, error) {
+	c, err := parseRGB(strings.TrimSpace(s))
+	if err != nil {
+		return Color{}, err
+	}
+	if white, ok := WhiteFromRGB(c); ok {
+		return white, nil
+	}
+	return ColorFromRGB(c), nil
+}
+
+// parseRGB parses the hex or decimal forms accepted by ParseRGB
+func parseRGB(s string) (color.RGBA, error) {
+	var parts []string
+	base := 10
+	if strings.Contains(s, `,`) {
+		parts = strings.Split(s, `,`)
+	} else {
+		s = strings.TrimPrefix(s, `#`)
+		if len(s) != 6 {
+			return color.RGBA{}, ErrInvalidArgument
+		}
+		parts = []string{s[0:2], s[2:4], s[4:6]}
+		base = 16
+	}
+	if len(parts) != 3 {
+		return color.RGBA{}, ErrInvalidArgument
+	}
+
+	var components [3]uint8
+	for i, part := range parts {
+		v, err := strconv.ParseUint(strings.TrimSpace(part), base, 8)
+		if err != nil {
+			return color.RGBA{}, ErrInvalidArgument
+		}
+		components[i] = uint8(v)
+	}
+
+	return color.RGBA{R: components[0], G: components[1], B: components[2], A: math.MaxUint8}, nil
+}
+
+// WhiteFromRGB returns the white, with zero saturation, whose appearance (see
+// Color.RGB) is closest to c, and whether it is close enough to be
+// indistinguishable, so that grays and tinted whites may be set as a color
+// temperature rather than a faint hue.  Black is always matched, as a white of
+// zero brightness.
+func WhiteFromRGB(c color.Color) (Color, bool) {
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return Color{Kelvin: rgbDefaultKelvin}, true
+	}
+	rf := float64(r) / float64(a)
+	gf := float64(g) / float64(a)
+	bf := float64(b) / float64(a)
+	brightness := fractionToUint16(math.Max(rf, math.Max(gf, bf)))
+	if brightness == 0 {
+		return Color{Kelvin: rgbDefaultKelvin}, true
+	}
+
+	target := labFromRGB(rf, gf, bf)
+	best := Color{Brightness: brightness, Kelvin: rgbDefaultKelvin}
+	bestDelta := math.Inf(1)
+	for kelvin := MinKelvin; kelvin <= MaxKelvin; kelvin += whiteKelvinStep {
+		white := Color{Brightness: brightness, Kelvin: uint16(kelvin)}
+		if delta := DeltaELab(white.Lab(), target); delta < bestDelta {
+			best, bestDelta = white, delta
+		}
+	}
+
+	return best, bestDelta < justNoticeableDeltaE
+}
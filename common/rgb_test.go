@@ -0,0 +1,74 @@
+package common_test
+
+import (
+	"image/color"
+
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RGB", func() {
+
+	DescribeTable("parsing saturated colors",
+		func(s string, expected Color) {
+			c, err := ParseRGB(s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c).To(Equal(expected))
+		},
+		Entry("hex with hash", `#FF0000`, Color{Saturation: 65535, Brightness: 65535, Kelvin: 3500}),
+		Entry("hex without hash", `00ff00`, Color{Hue: 21845, Saturation: 65535, Brightness: 65535, Kelvin: 3500}),
+		Entry("decimal", `0, 0, 255`, Color{Hue: 43691, Saturation: 65535, Brightness: 65535, Kelvin: 3500}),
+		Entry("orange", `#FF8800`, ColorFromRGB(color.RGBA{R: 255, G: 136, A: 255})),
+	)
+
+	DescribeTable("parsing whites",
+		func(s string, brightness uint16, minKelvin, maxKelvin int) {
+			c, err := ParseRGB(s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Saturation).To(BeZero())
+			Expect(c.Brightness).To(Equal(brightness))
+			Expect(int(c.Kelvin)).To(BeNumerically(">=", minKelvin))
+			Expect(int(c.Kelvin)).To(BeNumerically("<=", maxKelvin))
+		},
+		Entry("white", `#FFFFFF`, uint16(65535), 6000, 7000),
+		Entry("gray", `128,128,128`, uint16(32896), 6000, 7000),
+		Entry("warm white", `#FFA757`, uint16(65535), 2500, 3000),
+	)
+
+	It("should parse black with zero brightness", func() {
+		c, err := ParseRGB(`#000000`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c).To(Equal(Color{Kelvin: 3500}))
+	})
+
+	DescribeTable("rejecting malformed colors",
+		func(s string) {
+			_, err := ParseRGB(s)
+			Expect(err).To(Equal(ErrInvalidArgument))
+		},
+		Entry("empty", ``),
+		Entry("invalid hex digits", `#GGGGGG`),
+		Entry("short hex", `#GGG`),
+		Entry("long hex", `#FF00000`),
+		Entry("decimal out of range", `256,0,0`),
+		Entry("negative decimal", `-1,0,0`),
+		Entry("too few components", `255,0`),
+		Entry("empty component", `255,,0`),
+	)
+
+	It("should not match saturated colors as whites", func() {
+		_, ok := WhiteFromRGB(color.RGBA{R: 255, G: 136, A: 255})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should match the tint of each color temperature", func() {
+		r, g, b := KelvinToRGB(4000)
+		white, ok := WhiteFromRGB(color.RGBA{R: r, G: g, B: b, A: 255})
+		Expect(ok).To(BeTrue())
+		Expect(int(white.Kelvin)).To(BeNumerically("~", 4000, 100))
+	})
+
+})
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// UngroupedRoomLabel is the label of the Room holding lights that do not
+// report a group
+const UngroupedRoomLabel = `Ungrouped`
+
+// Room is the set of lights that share both a location and a group, which
+// mirrors how homes are arranged in the LIFX app, eg the "Kitchen" group in
+// the "Home" location.  Rooms are a snapshot of the known lights, obtain them
+// again to include newly discovered lights.
+type Room struct {
+	// Location is the location of the room, nil if the lights report no
+	// location
+	Location Location
+	// Group is the group of the room, nil for lights that report no group, see
+	// UngroupedRoomLabel
+	Group Group
+	// Lights are the members of the room
+	Lights []Light
+}
+
+// Label returns the label of the room group, or UngroupedRoomLabel
+func (r Room) Label() string {
+	if r.Group == nil {
+		return UngroupedRoomLabel
+	}
+	return r.Group.GetLabel()
+}
+
+// SetColor requests a change of color for all lights in the room,
+// transitioning over the specified duration.  Returns the first error
+// encountered, after attempting all lights.
+func (r Room) SetColor(color Color, duration time.Duration) error {
+	return r.each(func(light Light) error {
+		return light.SetColor(color, duration)
+	})
+}
+
+// SetPower sets the power of all lights in the room, state is true for on,
+// false for off.  Returns the first error encountered, after attempting all
+// lights.
+func (r Room) SetPower(state bool) error {
+	return r.each(func(light Light) error {
+		return light.SetPower(state)
+	})
+}
+
+// SetPowerDuration sets the power of all lights in the room, transitioning
+// over the specified duration, state is true for on, false for off.
+func (r Room) SetPowerDuration(state bool, duration time.Duration) error {
+	return r.each(func(light Light) error {
+		return light.SetPowerDuration(state, duration)
+	})
+}
+
+func (r Room) each(fn func(light Light) error) error {
+	var (
+		wg       sync.WaitGroup
+		err      error
+		errMutex sync.Mutex
+	)
+
+	for _, light := range r.Lights {
+		wg.Add(1)
+		go func(light Light) {
+			e := fn(light)
+			errMutex.Lock()
+			if err == nil && e != nil {
+				err = e
+			}
+			errMutex.Unlock()
+			wg.Done()
+		}(light)
+	}
+
+	wg.Wait()
+	return err
+}
+
+// NewRooms arranges lights into rooms by the groups and locations that contain
+// them.  Lights missing from every group are placed in an ungrouped room for
+// their location.  Rooms are sorted by location label then room label.
+func NewRooms(lights []Light, groups []Group, locations []Location) []Room {
+	groupOf := make(map[uint64]Group)
+	for _, group := range groups {
+		for _, dev := range group.Devices() {
+			groupOf[dev.ID()] = group
+		}
+	}
+	locationOf := make(map[uint64]Location)
+	for _, location := range locations {
+		for _, dev := range location.Devices() {
+			locationOf[dev.ID()] = location
+		}
+	}
+
+	type roomKey struct {
+		location Location
+		group    Group
+	}
+	index := make(map[roomKey]int)
+	var rooms []Room
+	for _, light := range lights {
+		key := roomKey{location: locationOf[light.ID()], group: groupOf[light.ID()]}
+		i, ok := index[key]
+		if !ok {
+			i = len(rooms)
+			index[key] = i
+			rooms = append(rooms, Room{Location: key.location, Group: key.group})
+		}
+		rooms[i].Lights = append(rooms[i].Lights, light)
+	}
+
+	sort.SliceStable(rooms, func(i, j int) bool {
+		li, lj := locationLabel(rooms[i].Location), locationLabel(rooms[j].Location)
+		if li != lj {
+			return li < lj
+		}
+		return rooms[i].Label() < rooms[j].Label()
+	})
+
+	return rooms
+}
+
+func locationLabel(location Location) string {
+	if location == nil {
+		return ``
+	}
+	return location.GetLabel()
+}
@@ -0,0 +1,27 @@
+package common
+
+import "fmt"
+
+// ServiceType identifies a transport advertised by a device
+type ServiceType uint8
+
+const (
+	// ServiceUDP is the LAN protocol over UDP, the only service currently used
+	ServiceUDP ServiceType = 1
+)
+
+// String returns `udp` for the UDP service, or the numeric type of services
+// reserved by the protocol
+func (t ServiceType) String() string {
+	if t == ServiceUDP {
+		return `udp`
+	}
+	return fmt.Sprintf("reserved(%d)", uint8(t))
+}
+
+// Service is a transport and port advertised by a device in response to
+// discovery, a device may advertise several
+type Service struct {
+	Type ServiceType `json:"type"`
+	Port uint32      `json:"port"`
+}
@@ -0,0 +1,36 @@
+package common
+
+import "context"
+
+// Span names used by the clients
+const (
+	// SpanSend is the name of the span of each request sent to a device on the
+	// LAN, from sending until the response, acknowledgement or error
+	SpanSend = `golifx.send`
+	// SpanCloudRequest is the name of the span of each cloud API request
+	SpanCloudRequest = `golifx.cloud_request`
+)
+
+// SpanHook starts a tracing span for each request sent by a client, so that
+// requests may be traced by an observability system without the core library
+// depending on it.  See the otelspan package for OpenTelemetry.
+type SpanHook interface {
+	// StartSpan is called as a request is sent, and returns the func to call
+	// once with the outcome of the request, nil on success.  ctx is the
+	// context of the call that sent the request, eg WaitUntilColor, or
+	// context.Background() for calls that take no context.
+	StartSpan(ctx context.Context, name string, attrs SpanAttributes) (end func(err error))
+}
+
+// SpanAttributes describe the request a span was started for
+type SpanAttributes struct {
+	// DeviceID is the ID of the target device, or 0 for broadcasts and cloud
+	// requests
+	DeviceID uint64
+	// Message is the LAN protocol message type, eg 102 for SetColor, 0 for
+	// cloud requests
+	Message uint16
+	// Request is the method and path of a cloud request, eg `PUT
+	// /lights/all/state`, empty on the LAN
+	Request string
+}
@@ -24,6 +24,8 @@ type Subscription struct {
 	wg       sync.WaitGroup
 	id       uuid.UUID
 	target   SubscriptionTarget
+	filter   EventType
+	mu       sync.RWMutex
 }
 
 // ID returns the unique ID for this subscription
@@ -37,8 +39,28 @@ func (s *Subscription) Events() <-chan interface{} {
 	return s.events
 }
 
-// Write pushes an event onto the events channel
+// SetFilter restricts the events delivered to this subscription to those
+// matching types, events that do not match are dropped before being queued.
+// Subscriptions receive EventTypeAll by default.
+func (s *Subscription) SetFilter(types EventType) {
+	s.mu.Lock()
+	s.filter = types
+	s.mu.Unlock()
+}
+
+// Filter returns the event types delivered to this subscription
+func (s *Subscription) Filter() EventType {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.filter
+}
+
+// Write pushes an event onto the events channel, events not matching the
+// filter are discarded without error
 func (s *Subscription) Write(event interface{}) error {
+	if s.Filter()&EventTypeOf(event) == 0 {
+		return nil
+	}
 	s.wg.Add(1)
 	defer s.wg.Done()
 	timeout := time.After(DefaultTimeout)
@@ -81,5 +103,6 @@ func NewSubscription(target SubscriptionTarget) *Subscription {
 		quitChan: make(chan struct{}),
 		id:       uuid.NewV4(),
 		target:   target,
+		filter:   EventTypeAll,
 	}
 }
@@ -0,0 +1,269 @@
+package common
+
+import (
+	"image"
+	"math"
+	"sync"
+	"time"
+)
+
+// MaxTilePixels is the largest number of pixels on a single tile, which is
+// also the number of colors sent to a tile in each SetTileState64 request
+const MaxTilePixels = 64
+
+// TileLight represents a LIFX light made up of a chain of two-dimensional
+// matrix tiles, such as the LIFX Tile.  Pixels of each tile are addressed in
+// rows from the top left corner of the tile, in its own frame of reference.
+type TileLight interface {
+	// GetTileChain requests the position, size and orientation of each tile in
+	// the chain
+	GetTileChain() ([]Tile, error)
+	// GetTileState64 requests the color of each pixel of the tile at index
+	GetTileState64(index uint8) ([]Color, error)
+	// SetTileState64 changes the color of each pixel of the tile at index, from
+	// colors in rows of width pixels, transitioning over the specified
+	// duration.  Returns ErrInvalidArgument if width is zero or there are more
+	// than MaxTilePixels colors.
+	SetTileState64(index, width uint8, colors []Color, duration time.Duration) error
+
+	// TileLight is a superset of the Light interface
+	Light
+}
+
+// TileOrientation is the orientation of a tile, as determined from its
+// accelerometer by Tile.Orientation
+type TileOrientation int
+
+const (
+	// TileUpright is a tile hanging the right way up, also assumed when no
+	// accelerometer measurement is available
+	TileUpright TileOrientation = iota
+	// TileRotatedLeft is a tile turned a quarter counter-clockwise
+	TileRotatedLeft
+	// TileRotatedRight is a tile turned a quarter clockwise
+	TileRotatedRight
+	// TileUpsideDown is a tile turned half way around
+	TileUpsideDown
+	// TileFaceUp is a tile lying flat, facing up
+	TileFaceUp
+	// TileFaceDown is a tile lying flat, facing down
+	TileFaceDown
+)
+
+// Tile describes a single tile in the chain of a TileLight
+type Tile struct {
+	// Index is the position of the tile in the chain
+	Index uint8 `json:"index"`
+	// UserX and UserY are the position of the center of the tile, in tile
+	// widths and heights respectively, as configured in the LIFX app.  UserY
+	// increases upwards.
+	UserX float32 `json:"user_x"`
+	UserY float32 `json:"user_y"`
+	// Width and Height are the number of pixels along each side of the tile
+	Width  uint8 `json:"width"`
+	Height uint8 `json:"height"`
+	// AccelX, AccelY and AccelZ are the accelerometer measurements of the
+	// tile, from which its orientation is determined
+	AccelX int16 `json:"accel_x"`
+	AccelY int16 `json:"accel_y"`
+	AccelZ int16 `json:"accel_z"`
+}
+
+// Orientation returns the orientation of the tile from its accelerometer
+// measurements, following the axis of gravity with the largest magnitude.
+// Tiles without a measurement are assumed to be upright.
+func (t Tile) Orientation() TileOrientation {
+	if t.AccelX == -1 && t.AccelY == -1 && t.AccelZ == -1 {
+		return TileUpright
+	}
+	x := math.Abs(float64(t.AccelX))
+	y := math.Abs(float64(t.AccelY))
+	z := math.Abs(float64(t.AccelZ))
+	switch {
+	case x > y && x > z:
+		if t.AccelX > 0 {
+			return TileRotatedRight
+		}
+		return TileRotatedLeft
+	case z > x && z > y:
+		if t.AccelZ > 0 {
+			return TileFaceDown
+		}
+		return TileFaceUp
+	case t.AccelY > 0:
+		return TileUpsideDown
+	default:
+		return TileUpright
+	}
+}
+
+// TileCanvas maps a single two-dimensional buffer of colors across the tiles of
+// a TileLight, according to the position and orientation of each tile in the
+// chain, so that the whole arrangement may be drawn as one image.  The canvas
+// covers the bounding box of the tiles, pixels in gaps between tiles are not
+// displayed, and tiles that overlap in the layout display the same pixels.
+// Changes are held by the canvas until pushed to the light with Flush.
+type TileCanvas struct {
+	light   TileLight
+	tiles   []Tile
+	origins []image.Point
+	width   int
+	height  int
+	pixels  []Color
+	sync.RWMutex
+}
+
+// NewTileCanvas requests the tile chain of light and returns a *TileCanvas
+// covering its layout.  Returns ErrNotFound if the chain contains no tiles.
+func NewTileCanvas(light TileLight) (*TileCanvas, error) {
+	tiles, err := light.GetTileChain()
+	if err != nil {
+		return nil, err
+	}
+	if len(tiles) == 0 {
+		return nil, ErrNotFound
+	}
+
+	c := &TileCanvas{
+		light:   light,
+		tiles:   tiles,
+		origins: make([]image.Point, len(tiles)),
+	}
+	var bounds image.Rectangle
+	for i, tile := range tiles {
+		w, h := tile.footprint()
+		// The layout is relative to tile centers, with y increasing upwards
+		origin := image.Pt(
+			int(math.Round((float64(tile.UserX)-0.5)*float64(w))),
+			int(math.Round(-(float64(tile.UserY)+0.5)*float64(h))),
+		)
+		rect := image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))}
+		c.origins[i] = origin
+		if i == 0 {
+			bounds = rect
+		} else {
+			bounds = bounds.Union(rect)
+		}
+	}
+	for i := range c.origins {
+		c.origins[i] = c.origins[i].Sub(bounds.Min)
+	}
+	c.width, c.height = bounds.Dx(), bounds.Dy()
+	c.pixels = make([]Color, c.width*c.height)
+
+	return c, nil
+}
+
+// footprint returns the size of the tile as it appears in the layout, which is
+// transposed for tiles turned on their side
+func (t Tile) footprint() (int, int) {
+	switch t.Orientation() {
+	case TileRotatedLeft, TileRotatedRight:
+		return int(t.Height), int(t.Width)
+	default:
+		return int(t.Width), int(t.Height)
+	}
+}
+
+// Size returns the width and height of the canvas in pixels
+func (c *TileCanvas) Size() (int, int) {
+	return c.width, c.height
+}
+
+// Tiles returns the tiles covered by the canvas
+func (c *TileCanvas) Tiles() []Tile {
+	tiles := make([]Tile, len(c.tiles))
+	copy(tiles, c.tiles)
+	return tiles
+}
+
+// At returns the color of the pixel at x, y, from the top left of the
+// canvas.  Pixels outside the canvas are a zero Color.
+func (c *TileCanvas) At(x, y int) Color {
+	c.RLock()
+	defer c.RUnlock()
+	if x < 0 || y < 0 || x >= c.width || y >= c.height {
+		return Color{}
+	}
+	return c.pixels[y*c.width+x]
+}
+
+// Set changes the color of the pixel at x, y, from the top left of the
+// canvas.  Pixels outside the canvas are ignored.
+func (c *TileCanvas) Set(x, y int, color Color) {
+	c.Lock()
+	defer c.Unlock()
+	if x < 0 || y < 0 || x >= c.width || y >= c.height {
+		return
+	}
+	c.pixels[y*c.width+x] = color
+}
+
+// Fill changes the color of every pixel of the canvas
+func (c *TileCanvas) Fill(color Color) {
+	c.Lock()
+	defer c.Unlock()
+	for i := range c.pixels {
+		c.pixels[i] = color
+	}
+}
+
+// DrawImage scales img to the size of the canvas, by sampling the nearest
+// pixel, and converts each pixel to a Color via ColorFromRGB
+func (c *TileCanvas) DrawImage(img image.Image) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	for y := 0; y < c.height; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/c.height
+		for x := 0; x < c.width; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/c.width
+			c.pixels[y*c.width+x] = ColorFromRGB(img.At(srcX, srcY))
+		}
+	}
+}
+
+// Flush pushes the canvas to the light, with a SetTileState64 request for each
+// tile, transitioning over the specified duration.  All tiles are attempted,
+// and the first error encountered is returned.
+func (c *TileCanvas) Flush(duration time.Duration) error {
+	var firstErr error
+	for i, tile := range c.tiles {
+		if err := c.light.SetTileState64(tile.Index, tile.Width, c.tileColors(i), duration); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// tileColors returns the colors for the pixels of the tile at i, in rows from
+// the top left of the tile in its own frame of reference
+func (c *TileCanvas) tileColors(i int) []Color {
+	c.RLock()
+	defer c.RUnlock()
+	tile, origin := c.tiles[i], c.origins[i]
+	w, h := int(tile.Width), int(tile.Height)
+	if w*h > MaxTilePixels {
+		h = MaxTilePixels / w
+	}
+	colors := make([]Color, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Rotate the tile pixel into the frame of the canvas
+			cx, cy := x, y
+			switch tile.Orientation() {
+			case TileRotatedLeft:
+				cx, cy = y, w-1-x
+			case TileRotatedRight:
+				cx, cy = h-1-y, x
+			case TileUpsideDown:
+				cx, cy = w-1-x, h-1-y
+			}
+			colors[y*w+x] = c.pixels[(origin.Y+cy)*c.width+origin.X+cx]
+		}
+	}
+	return colors
+}
@@ -0,0 +1,25 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tile", func() {
+
+	DescribeTable("determining orientation",
+		func(x, y, z int16, orientation TileOrientation) {
+			Expect(Tile{AccelX: x, AccelY: y, AccelZ: z}.Orientation()).To(Equal(orientation))
+		},
+		Entry("upright", int16(0), int16(-100), int16(0), TileUpright),
+		Entry("upside down", int16(0), int16(100), int16(0), TileUpsideDown),
+		Entry("rotated left", int16(-100), int16(0), int16(0), TileRotatedLeft),
+		Entry("rotated right", int16(100), int16(0), int16(0), TileRotatedRight),
+		Entry("face up", int16(0), int16(0), int16(-100), TileFaceUp),
+		Entry("face down", int16(0), int16(0), int16(100), TileFaceDown),
+		Entry("unmeasured", int16(-1), int16(-1), int16(-1), TileUpright),
+	)
+})
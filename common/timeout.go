@@ -8,4 +8,7 @@ const (
 	// DefaultRetryInterval is the default interval at which operations are
 	// retried
 	DefaultRetryInterval = 100 * time.Millisecond
+	// DefaultPollInterval is the default interval at which device state is
+	// polled when waiting for the device to reach a desired state
+	DefaultPollInterval = 250 * time.Millisecond
 )
@@ -0,0 +1,114 @@
+package common
+
+import "time"
+
+// WaveformType is the shape of a Waveform
+type WaveformType uint8
+
+// Waveform types, as defined by the LIFX protocol
+const (
+	// WaveformSaw ramps from the light's color to the waveform color, then
+	// jumps back
+	WaveformSaw WaveformType = iota
+	// WaveformSine transitions smoothly to the waveform color and back
+	WaveformSine
+	// WaveformHalfSine transitions smoothly to the waveform color, then jumps
+	// back
+	WaveformHalfSine
+	// WaveformTriangle transitions linearly to the waveform color and back
+	WaveformTriangle
+	// WaveformPulse switches between the waveform color and the light's color,
+	// with the time spent on each set by the skew ratio
+	WaveformPulse
+)
+
+// Waveform describes a periodic change of a light's color, such as a flash or
+// a breathing effect, which the light performs itself
+type Waveform struct {
+	Type  WaveformType
+	Color Color
+	// Period is the duration of each cycle
+	Period time.Duration
+	// Cycles is the number of periods to run, and may be fractional
+	Cycles float32
+	// SkewRatio (0 to 1) shifts the peak of each cycle for WaveformSine and
+	// WaveformTriangle, or sets the fraction of each WaveformPulse period
+	// spent on the light's own color.  Zero is treated as 0.5, the symmetric
+	// waveform.
+	SkewRatio float32
+	// Transient returns the light to its own color once the cycles complete,
+	// otherwise the light is left at the waveform color
+	Transient bool
+}
+
+// Validate returns ErrInvalidArgument if the type is unknown, the period or
+// cycles are not positive, the period exceeds MaxDuration, or the skew ratio is
+// outside 0 to 1
+func (w Waveform) Validate() error {
+	if w.Type > WaveformPulse || w.Period <= 0 || w.Cycles <= 0 || w.SkewRatio < 0 || w.SkewRatio > 1 {
+		return ErrInvalidArgument
+	}
+	return ValidateDuration(w.Period)
+}
+
+// WaveformInfo describes a WaveformType for presenting to users, eg in a
+// waveform picker
+type WaveformInfo struct {
+	Type WaveformType `json:"type"`
+	// Name is a short snake_case name for the type
+	Name string `json:"name"`
+	// Description describes the shape of the waveform and how its parameters
+	// apply
+	Description string `json:"description"`
+	// UsesSkew is whether Waveform.SkewRatio affects the waveform
+	UsesSkew bool `json:"uses_skew"`
+}
+
+// waveforms are the supported waveform types, in protocol order
+var waveforms = []WaveformInfo{
+	{
+		Type:        WaveformSaw,
+		Name:        `saw`,
+		Description: `ramps from the light's color to the waveform color each period, then jumps back`,
+	},
+	{
+		Type:        WaveformSine,
+		Name:        `sine`,
+		Description: `transitions smoothly to the waveform color and back each period, the skew ratio shifts the peak within the period`,
+		UsesSkew:    true,
+	},
+	{
+		Type:        WaveformHalfSine,
+		Name:        `half_sine`,
+		Description: `transitions smoothly to the waveform color each period, then jumps back`,
+	},
+	{
+		Type:        WaveformTriangle,
+		Name:        `triangle`,
+		Description: `transitions linearly to the waveform color and back each period, the skew ratio shifts the peak within the period`,
+		UsesSkew:    true,
+	},
+	{
+		Type:        WaveformPulse,
+		Name:        `pulse`,
+		Description: `switches between the waveform color and the light's color each period, the skew ratio sets the fraction of the period spent on the light's color`,
+		UsesSkew:    true,
+	},
+}
+
+// Waveforms returns a description of each supported waveform type, in
+// protocol order.  Every type uses the period, cycles, color and transient
+// flag of a Waveform.
+func Waveforms() []WaveformInfo {
+	infos := make([]WaveformInfo, len(waveforms))
+	copy(infos, waveforms)
+	return infos
+}
+
+// String returns the name of the waveform type, see WaveformInfo
+func (t WaveformType) String() string {
+	if int(t) < len(waveforms) {
+		return waveforms[t].Name
+	}
+	return `unknown`
+}
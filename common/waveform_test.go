@@ -0,0 +1,30 @@
+package common_test
+
+import (
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Waveform", func() {
+
+	It("should describe every waveform type in order", func() {
+		infos := Waveforms()
+		Expect(infos).To(HaveLen(int(WaveformPulse) + 1))
+		for i, info := range infos {
+			Expect(info.Type).To(Equal(WaveformType(i)))
+			Expect(info.Type.String()).To(Equal(info.Name))
+			Expect(info.Description).NotTo(BeEmpty())
+			Expect(Waveform{Type: info.Type, Period: 1, Cycles: 1}.Validate()).To(Succeed())
+		}
+		Expect(infos[WaveformSaw].UsesSkew).To(BeFalse())
+		Expect(infos[WaveformPulse].UsesSkew).To(BeTrue())
+		Expect(WaveformType(9).String()).To(Equal(`unknown`))
+	})
+
+	It("should not expose the table for modification", func() {
+		Waveforms()[0].Name = `changed`
+		Expect(Waveforms()[0].Name).To(Equal(`saw`))
+	})
+})
@@ -0,0 +1,113 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultSignalHistoryLength is the number of signal samples kept for each
+// device when sampling is enabled without specifying a length
+const DefaultSignalHistoryLength = 60
+
+// WifiInfo is the state of the WiFi connection of a device
+type WifiInfo struct {
+	// Signal is the raw signal strength reported by the device, in mW
+	Signal float32 `json:"signal"`
+	// Tx is the number of bytes transmitted since power on
+	Tx uint32 `json:"tx"`
+	// Rx is the number of bytes received since power on
+	Rx uint32 `json:"rx"`
+}
+
+// RSSI returns the signal strength in dBm
+func (w WifiInfo) RSSI() int {
+	if w.Signal <= 0 {
+		return math.MinInt16
+	}
+	return int(math.Floor(10*math.Log10(float64(w.Signal)) + 0.5))
+}
+
+// Quality returns a description of the signal strength, one of `none`, `very
+// bad`, `somewhat bad`, `alright` or `good`, following the thresholds used by
+// the LIFX app
+func (w WifiInfo) Quality() string {
+	rssi := w.RSSI()
+	switch {
+	case w.Signal <= 0:
+		return `none`
+	case rssi <= -80:
+		return `very bad`
+	case rssi <= -70:
+		return `somewhat bad`
+	case rssi <= -60:
+		return `alright`
+	default:
+		return `good`
+	}
+}
+
+// SignalSample is a WiFi signal strength reading taken at Time
+type SignalSample struct {
+	Time time.Time `json:"time"`
+	// Signal is the raw signal strength reported by the device, in mW
+	Signal float32 `json:"signal"`
+}
+
+// RSSI returns the signal strength in dBm, see WifiInfo.RSSI
+func (s SignalSample) RSSI() int {
+	return WifiInfo{Signal: s.Signal}.RSSI()
+}
+
+// AppendSignalSample appends sample to history, dropping the oldest samples so
+// that no more than length remain
+func AppendSignalSample(history []SignalSample, sample SignalSample, length int) []SignalSample {
+	history = append(history, sample)
+	if length > 0 && len(history) > length {
+		history = append([]SignalSample(nil), history[len(history)-length:]...)
+	}
+	return history
+}
+
+// WifiSecurity is the security protocol of a WiFi access point
+type WifiSecurity uint8
+
+// WiFi security protocols, as reported by the device
+const (
+	WifiSecurityUnknown WifiSecurity = iota + 1
+	WifiSecurityOpen
+	WifiSecurityWEPPSK
+	WifiSecurityWPATKIPPSK
+	WifiSecurityWPAAESPSK
+	WifiSecurityWPA2AESPSK
+	WifiSecurityWPA2TKIPPSK
+	WifiSecurityWPA2MixedPSK
+)
+
+var wifiSecurityNames = map[WifiSecurity]string{
+	WifiSecurityUnknown:      `unknown`,
+	WifiSecurityOpen:         `open`,
+	WifiSecurityWEPPSK:       `WEP-PSK`,
+	WifiSecurityWPATKIPPSK:   `WPA-TKIP-PSK`,
+	WifiSecurityWPAAESPSK:    `WPA-AES-PSK`,
+	WifiSecurityWPA2AESPSK:   `WPA2-AES-PSK`,
+	WifiSecurityWPA2TKIPPSK:  `WPA2-TKIP-PSK`,
+	WifiSecurityWPA2MixedPSK: `WPA2-mixed-PSK`,
+}
+
+// String returns the name of the security protocol
+func (s WifiSecurity) String() string {
+	if name, ok := wifiSecurityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(s))
+}
+
+// AccessPoint is a WiFi access point reported by a device
+type AccessPoint struct {
+	SSID     string       `json:"ssid"`
+	Security WifiSecurity `json:"security"`
+	// Strength is the raw signal strength reported by the device
+	Strength uint16 `json:"strength"`
+	Channel  uint16 `json:"channel"`
+}
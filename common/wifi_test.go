@@ -0,0 +1,40 @@
+package common_test
+
+import (
+	"time"
+
+	. "github.com/pdf/golifx/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WifiInfo", func() {
+
+	DescribeTable("describing signal quality",
+		func(signal float32, rssi int, quality string) {
+			info := WifiInfo{Signal: signal}
+			if signal > 0 {
+				Expect(info.RSSI()).To(Equal(rssi))
+			}
+			Expect(info.Quality()).To(Equal(quality))
+		},
+		Entry("no signal", float32(0), 0, `none`),
+		Entry("very bad", float32(1e-9), -90, `very bad`),
+		Entry("somewhat bad", float32(1e-7), -70, `somewhat bad`),
+		Entry("alright", float32(3.2e-7), -65, `alright`),
+		Entry("good", float32(1e-5), -50, `good`),
+	)
+
+	It("should keep the most recent signal samples", func() {
+		var history []SignalSample
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			history = AppendSignalSample(history, SignalSample{Time: start.Add(time.Duration(i) * time.Second), Signal: float32(i)}, 3)
+		}
+		Expect(history).To(HaveLen(3))
+		Expect(history[0].Signal).To(Equal(float32(2)))
+		Expect(history[2].Signal).To(Equal(float32(4)))
+	})
+})
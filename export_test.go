@@ -0,0 +1,31 @@
+package golifx
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// SetWebhookBackoff overrides WebhookBackoff for webhooks set after the call,
+// returning a func that restores it
+func SetWebhookBackoff(backoff time.Duration) func() {
+	webhookBackoff = backoff
+	return func() { webhookBackoff = WebhookBackoff }
+}
+
+// WebhookQueue returns the events queued for delivery to the event webhook of c
+func WebhookQueue(c *Client) []common.DeviceEvent {
+	c.RLock()
+	w := c.webhook
+	c.RUnlock()
+	if w == nil {
+		return nil
+	}
+	w.Lock()
+	defer w.Unlock()
+	events := make([]common.DeviceEvent, len(w.queue))
+	for i, queued := range w.queue {
+		events[i] = queued.event
+	}
+	return events
+}
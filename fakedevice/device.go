@@ -0,0 +1,364 @@
+package fakedevice
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// Device is an in-memory implementation of common.Device
+type Device struct {
+	id              uint64
+	address         *net.UDPAddr
+	label           string
+	power           uint16
+	firmwareVersion string
+	productInfo     *common.ProductInfo
+	rebootReason    string
+	lastSeen        time.Time
+	offline         bool
+	wifiInfo        common.WifiInfo
+	signalHistory   []common.SignalSample
+	services        []common.Service
+	subnet          *net.IPNet
+	subscriptions   map[string]*common.Subscription
+	sync.RWMutex
+}
+
+// NewDevice returns a new *Device with the specified id and label
+func NewDevice(id uint64, label string) *Device {
+	d := &Device{}
+	d.init(id, label)
+	return d
+}
+
+func (d *Device) init(id uint64, label string) {
+	d.id = id
+	d.label = label
+	d.subscriptions = make(map[string]*common.Subscription)
+}
+
+// ID returns the ID for the device
+func (d *Device) ID() uint64 {
+	return d.id
+}
+
+// MAC returns the MAC address derived from the device ID
+func (d *Device) MAC() string {
+	target := make([]byte, 8)
+	binary.LittleEndian.PutUint64(target, d.id)
+	return net.HardwareAddr(target[:6]).String()
+}
+
+// Address returns the address assigned via SetAddress, or nil if none has been
+// assigned
+func (d *Device) Address() *net.UDPAddr {
+	d.RLock()
+	defer d.RUnlock()
+	return d.address
+}
+
+// SetAddress assigns the network address reported by the device
+func (d *Device) SetAddress(addr *net.UDPAddr) {
+	d.Lock()
+	d.address = addr
+	d.Unlock()
+}
+
+// Subnet returns the network assigned via SetSubnet, or nil if none has been
+// assigned
+func (d *Device) Subnet() *net.IPNet {
+	d.RLock()
+	defer d.RUnlock()
+	return d.subnet
+}
+
+// SetSubnet assigns the network the device was discovered in
+func (d *Device) SetSubnet(subnet *net.IPNet) {
+	d.Lock()
+	d.subnet = subnet
+	d.Unlock()
+}
+
+// Services returns the services assigned via SetServices, or none if none
+// have been assigned
+func (d *Device) Services() []common.Service {
+	d.RLock()
+	defer d.RUnlock()
+	services := make([]common.Service, len(d.services))
+	copy(services, d.services)
+	return services
+}
+
+// SetServices assigns the services advertised by the device
+func (d *Device) SetServices(services ...common.Service) {
+	d.Lock()
+	d.services = services
+	d.Unlock()
+}
+
+// GetLabel returns the label for the device
+func (d *Device) GetLabel() (string, error) {
+	return d.CachedLabel(), nil
+}
+
+// CachedLabel returns the label for the device
+func (d *Device) CachedLabel() string {
+	d.RLock()
+	defer d.RUnlock()
+	return d.label
+}
+
+// SetLabel sets the label for the device, publishing common.EventUpdateLabel
+// if the label changed
+func (d *Device) SetLabel(label string) error {
+	d.Lock()
+	changed := d.label != label
+	d.label = label
+	d.Unlock()
+
+	if changed {
+		return d.publish(common.EventUpdateLabel{Label: label})
+	}
+
+	return nil
+}
+
+// GetPower returns the power state of the device
+func (d *Device) GetPower() (bool, error) {
+	return d.CachedPower(), nil
+}
+
+// CachedPower returns the power state of the device
+func (d *Device) CachedPower() bool {
+	d.RLock()
+	defer d.RUnlock()
+	return d.power > 0
+}
+
+// GetPowerState returns the power level of the device
+func (d *Device) GetPowerState() (common.Power, error) {
+	d.RLock()
+	defer d.RUnlock()
+	return common.Power(d.power), nil
+}
+
+// SetPowerLevel sets the power level of the device, eg to simulate a device
+// part way through a power transition, publishing common.EventUpdatePower if
+// the device was turned on or off
+func (d *Device) SetPowerLevel(power common.Power) error {
+	d.Lock()
+	changed := d.power > 0 != power.On()
+	d.power = power.Level()
+	d.Unlock()
+
+	if changed {
+		return d.publish(common.EventUpdatePower{Power: power.On()})
+	}
+
+	return nil
+}
+
+// SetPower sets the power state of the device, publishing
+// common.EventUpdatePower if the state changed
+func (d *Device) SetPower(state bool) error {
+	var level uint16
+	if state {
+		level = math.MaxUint16
+	}
+
+	d.Lock()
+	changed := d.power > 0 != state
+	d.power = level
+	d.Unlock()
+
+	if changed {
+		return d.publish(common.EventUpdatePower{Power: state})
+	}
+
+	return nil
+}
+
+// LastOnColor always returns false, only lights remember their color
+func (d *Device) LastOnColor() (common.Color, bool) {
+	return common.Color{}, false
+}
+
+// GetFirmwareVersion returns the firmware version of the device
+func (d *Device) GetFirmwareVersion() (string, error) {
+	return d.CachedFirmwareVersion(), nil
+}
+
+// CachedFirmwareVersion returns the firmware version of the device
+func (d *Device) CachedFirmwareVersion() string {
+	d.RLock()
+	defer d.RUnlock()
+	return d.firmwareVersion
+}
+
+// SetFirmwareVersion sets the firmware version reported by the device
+func (d *Device) SetFirmwareVersion(version string) {
+	d.Lock()
+	d.firmwareVersion = version
+	d.Unlock()
+}
+
+// SetGroup is accepted and ignored, groups are not modelled by fakes
+func (d *Device) SetGroup(id [16]byte, label string) error {
+	return nil
+}
+
+// SetLocation is accepted and ignored, locations are not modelled by fakes
+func (d *Device) SetLocation(id [16]byte, label string) error {
+	return nil
+}
+
+// IsOnline returns false if the device has been marked offline via SetOnline
+func (d *Device) IsOnline() bool {
+	d.RLock()
+	defer d.RUnlock()
+	return !d.offline
+}
+
+// SetOnline marks the device online or offline
+func (d *Device) SetOnline(online bool) {
+	d.Lock()
+	d.offline = !online
+	d.Unlock()
+}
+
+// Ping returns immediately, or common.ErrTimeout if the device is offline
+func (d *Device) Ping() (time.Duration, error) {
+	if !d.IsOnline() {
+		return 0, common.ErrTimeout
+	}
+	return 0, nil
+}
+
+// GetWifiInfo returns the WiFi info set via SetWifiInfo, or common.ErrTimeout
+// if the device is offline.  The signal strength is recorded in the signal
+// history, which keeps common.DefaultSignalHistoryLength samples.
+func (d *Device) GetWifiInfo() (common.WifiInfo, error) {
+	if !d.IsOnline() {
+		return common.WifiInfo{}, common.ErrTimeout
+	}
+	d.Lock()
+	defer d.Unlock()
+	d.signalHistory = common.AppendSignalSample(d.signalHistory, common.SignalSample{
+		Time:   time.Now(),
+		Signal: d.wifiInfo.Signal,
+	}, common.DefaultSignalHistoryLength)
+	return d.wifiInfo, nil
+}
+
+// SignalHistory returns the signal strength recorded by GetWifiInfo, oldest
+// first
+func (d *Device) SignalHistory() []common.SignalSample {
+	d.RLock()
+	defer d.RUnlock()
+	return append([]common.SignalSample(nil), d.signalHistory...)
+}
+
+// SetWifiInfo sets the WiFi info reported by the device
+func (d *Device) SetWifiInfo(info common.WifiInfo) {
+	d.Lock()
+	d.wifiInfo = info
+	d.Unlock()
+}
+
+// GetProductInfo returns the product info assigned via SetProductInfo, or
+// common.ErrNotFound if none has been assigned
+func (d *Device) GetProductInfo() (common.ProductInfo, error) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.productInfo == nil {
+		return common.ProductInfo{}, common.ErrNotFound
+	}
+	return *d.productInfo, nil
+}
+
+// SetProductInfo sets the product info reported by the device
+func (d *Device) SetProductInfo(info common.ProductInfo) {
+	d.Lock()
+	d.productInfo = &info
+	d.Unlock()
+}
+
+// LastRebootReason returns the reason assigned via SetLastRebootReason, or
+// common.ErrNotSupported if none has been assigned
+func (d *Device) LastRebootReason() (string, error) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.rebootReason == `` {
+		return ``, common.ErrNotSupported
+	}
+	return d.rebootReason, nil
+}
+
+// SetLastRebootReason sets the reboot reason reported by the device
+func (d *Device) SetLastRebootReason(reason string) {
+	d.Lock()
+	d.rebootReason = reason
+	d.Unlock()
+}
+
+// LastSeen returns the time assigned via SetLastSeen, or the zero time if none
+// has been assigned
+func (d *Device) LastSeen() time.Time {
+	d.RLock()
+	defer d.RUnlock()
+	return d.lastSeen
+}
+
+// InvalidateCache does nothing, the fake device state is never stale
+func (d *Device) InvalidateCache() {}
+
+// SetLastSeen sets the time the device was last seen
+func (d *Device) SetLastSeen(seen time.Time) {
+	d.Lock()
+	d.lastSeen = seen
+	d.Unlock()
+}
+
+// NewSubscription returns a new *common.Subscription for receiving events from
+// this device.
+func (d *Device) NewSubscription() (*common.Subscription, error) {
+	sub := common.NewSubscription(d)
+	d.Lock()
+	d.subscriptions[sub.ID()] = sub
+	d.Unlock()
+	return sub, nil
+}
+
+// CloseSubscription is a callback for handling the closing of subscriptions.
+func (d *Device) CloseSubscription(sub *common.Subscription) error {
+	d.Lock()
+	defer d.Unlock()
+	if _, ok := d.subscriptions[sub.ID()]; !ok {
+		return common.ErrNotFound
+	}
+	delete(d.subscriptions, sub.ID())
+
+	return nil
+}
+
+func (d *Device) publish(event interface{}) error {
+	d.RLock()
+	subs := make([]*common.Subscription, 0, len(d.subscriptions))
+	for _, sub := range d.subscriptions {
+		subs = append(subs, sub)
+	}
+	d.RUnlock()
+
+	for _, sub := range subs {
+		if err := sub.Write(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
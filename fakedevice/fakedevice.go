@@ -0,0 +1,27 @@
+// Package fakedevice provides in-memory implementations of the golifx device
+// interfaces, for testing code that uses golifx without LIFX hardware.
+//
+// Devices and lights hold their state in memory, and operations apply
+// immediately.  State may be set and inspected via the standard
+// common.Device and common.Light methods.  A Protocol holding fake devices may
+// be passed to golifx.NewClient to obtain a fully functional Client, or use
+// NewClient as a shortcut.
+package fakedevice
+
+import (
+	"github.com/pdf/golifx"
+	"github.com/pdf/golifx/common"
+)
+
+// NewClient returns a *golifx.Client backed by a new Protocol holding the
+// provided devices, along with the Protocol so that devices may be added or
+// removed.
+func NewClient(devices ...common.Device) (*golifx.Client, *Protocol, error) {
+	p := NewProtocol(devices...)
+	c, err := golifx.NewClient(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c, p, nil
+}
@@ -0,0 +1,13 @@
+package fakedevice_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFakedevice(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fakedevice Suite")
+}
@@ -0,0 +1,320 @@
+package fakedevice_test
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+	. "github.com/pdf/golifx/fakedevice"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// timedLight records when its color was set
+type timedLight struct {
+	set time.Time
+	*Light
+}
+
+func (l *timedLight) SetColor(color common.Color, duration time.Duration) error {
+	l.set = time.Now()
+	return l.Light.SetColor(color, duration)
+}
+
+var _ = Describe("Fakedevice", func() {
+	var (
+		light  *Light
+		device *Device
+		color  = common.Color{Hue: 1, Saturation: 2, Brightness: 3, Kelvin: 3500}
+	)
+
+	BeforeEach(func() {
+		light = NewLight(1, `light`, common.Color{})
+		device = NewDevice(2, `device`)
+	})
+
+	It("should implement common.Light", func() {
+		var _ common.Light = light
+	})
+
+	It("should implement common.MultiZoneLight", func() {
+		var _ common.MultiZoneLight = NewMultiZoneLight(3, `strip`, make([]common.Color, 8))
+	})
+
+	It("should implement common.HevLight", func() {
+		var _ common.HevLight = NewHevLight(4, `clean`, common.Color{})
+	})
+
+	It("should implement common.TileLight", func() {
+		var _ common.TileLight = NewTileLight(6, `tile`, nil)
+	})
+
+	It("should implement common.RelayDevice", func() {
+		var _ common.RelayDevice = NewRelayDevice(5, `switch`)
+	})
+
+	It("should implement common.Protocol", func() {
+		var _ common.Protocol = NewProtocol()
+	})
+
+	It("should apply color and power immediately", func() {
+		Expect(light.SetColor(color, 0)).To(Succeed())
+		Expect(light.SetPower(true)).To(Succeed())
+		Expect(light.CachedColor()).To(Equal(color))
+		Expect(light.CachedPower()).To(BeTrue())
+	})
+
+	It("should remember the color when powered off", func() {
+		_, ok := light.LastOnColor()
+		Expect(ok).To(BeFalse())
+		Expect(light.SetColor(color, 0)).To(Succeed())
+		Expect(light.SetPower(true)).To(Succeed())
+		Expect(light.SetPowerDuration(false, 0)).To(Succeed())
+		Expect(light.SetColor(common.Color{Kelvin: 3500}, 0)).To(Succeed())
+		remembered, ok := light.LastOnColor()
+		Expect(ok).To(BeTrue())
+		Expect(remembered).To(Equal(color))
+	})
+
+	It("should apply named colors", func() {
+		Expect(light.SetColorByName(`Blue`, 0)).To(Succeed())
+		Expect(light.CachedColor()).To(Equal(common.NamedColors[`blue`]))
+		Expect(light.SetColorByName(`octarine`, 0)).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should acknowledge color changes", func() {
+		acked, err := light.SetColorAck(color, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acked).To(BeTrue())
+		Expect(light.CachedColor()).To(Equal(color))
+	})
+
+	It("should only apply color to lights that are on", func() {
+		applied, err := light.SetColorIfOn(color, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applied).To(BeFalse())
+		Expect(light.CachedColor()).To(Equal(common.Color{}))
+
+		Expect(light.SetPower(true)).To(Succeed())
+		applied, err = light.SetColorIfOn(color, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applied).To(BeTrue())
+		Expect(light.CachedColor()).To(Equal(color))
+	})
+
+	It("should return the clamped color applied", func() {
+		applied, err := light.SetColorClamped(common.Color{Hue: 1, Kelvin: 12000}, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applied).To(Equal(common.Color{Hue: 1, Kelvin: common.MaxKelvin}))
+		Expect(light.CachedColor()).To(Equal(applied))
+	})
+
+	It("should update the color from the current color", func() {
+		Expect(light.SetColor(color, 0)).To(Succeed())
+		Expect(light.UpdateColor(func(c common.Color) common.Color {
+			c.Brightness = 1000
+			return c
+		}, 0)).To(Succeed())
+		expected := color
+		expected.Brightness = 1000
+		Expect(light.CachedColor()).To(Equal(expected))
+	})
+
+	It("should set a range of zones", func() {
+		strip := NewMultiZoneLight(3, `strip`, make([]common.Color, 4))
+		Expect(strip.SetColorZones(1, 2, color, 0)).To(Succeed())
+		Expect(strip.GetZoneColors()).To(Equal([]common.Color{{}, color, color, {}}))
+		Expect(strip.SetColorZones(2, 4, color, 0)).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should apply staged zones together", func() {
+		strip := NewMultiZoneLight(3, `strip`, make([]common.Color, 4))
+		other := common.Color{Hue: 2, Kelvin: 3500}
+		Expect(strip.SetColorZonesStaged(0, 1, color)).To(Succeed())
+		Expect(strip.SetColorZonesStaged(3, 3, other)).To(Succeed())
+		Expect(strip.GetZoneColors()).To(Equal(make([]common.Color, 4)))
+		Expect(strip.ApplyZones(0)).To(Succeed())
+		Expect(strip.GetZoneColors()).To(Equal([]common.Color{color, color, {}, other}))
+
+		Expect(strip.SetColorZonesStaged(0, 0, other)).To(Succeed())
+		Expect(strip.SetColorZones(2, 2, other, 0)).To(Succeed())
+		Expect(strip.GetZoneColors()).To(Equal([]common.Color{other, color, other, other}))
+		Expect(strip.SetColorZonesStaged(3, 4, color)).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should report zones that do not match", func() {
+		strip := NewMultiZoneLight(3, `strip`, make([]common.Color, 4))
+		Expect(strip.SetColorZones(1, 1, color, 0)).To(Succeed())
+		near := color
+		near.Brightness += 5
+		Expect(strip.VerifyZones([]common.Color{{}, near, {}, {}}, 10)).To(BeEmpty())
+		Expect(strip.VerifyZones([]common.Color{color, color, color, {}}, 10)).To(Equal([]int{0, 2}))
+		_, err := strip.VerifyZones([]common.Color{color}, 10)
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should set a fraction of zones", func() {
+		strip := NewMultiZoneLight(3, `strip`, make([]common.Color, 6))
+		Expect(strip.SetColorFraction(0, 1.0/3, color, 0)).To(Succeed())
+		Expect(strip.GetZoneColors()).To(Equal([]common.Color{color, color, {}, {}, {}, {}}))
+		Expect(strip.SetColorFraction(0.5, 0.25, color, 0)).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should run and stop clean cycles", func() {
+		clean := NewHevLight(4, `clean`, color)
+		Expect(clean.StartHevCycle(-time.Second)).To(Equal(common.ErrInvalidArgument))
+		Expect(clean.StartHevCycle(0)).To(Succeed())
+		status, err := clean.GetHevCycle()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Active()).To(BeTrue())
+		Expect(status.Duration).To(Equal(DefaultHevCycleDuration))
+		Expect(status.Remaining).To(BeNumerically(`<=`, DefaultHevCycleDuration))
+
+		Expect(clean.StopHevCycle()).To(Succeed())
+		status, err = clean.GetHevCycle()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Active()).To(BeFalse())
+		Expect(status.Duration).To(Equal(DefaultHevCycleDuration))
+	})
+
+	It("should map a canvas across a chain of tiles", func() {
+		chain := NewTileLight(6, `tile`, []common.Tile{
+			{UserX: 0, UserY: 0, Width: 8, Height: 8},
+			// Upside down, with a gap of one tile
+			{UserX: 2, UserY: 0, Width: 8, Height: 8, AccelY: 100},
+		})
+		canvas, err := common.NewTileCanvas(chain)
+		Expect(err).NotTo(HaveOccurred())
+		width, height := canvas.Size()
+		Expect(width).To(Equal(24))
+		Expect(height).To(Equal(8))
+
+		canvas.Set(0, 0, color)
+		canvas.Set(8, 0, color)
+		canvas.Set(16, 0, color)
+		Expect(canvas.Flush(0)).To(Succeed())
+		first, err := chain.GetTileState64(0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first[0]).To(Equal(color))
+		Expect(first[1]).To(Equal(common.Color{}))
+		second, err := chain.GetTileState64(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second[0]).To(Equal(common.Color{}))
+		Expect(second[63]).To(Equal(color))
+
+		_, err = common.NewTileCanvas(NewTileLight(7, `empty`, nil))
+		Expect(err).To(Equal(common.ErrNotFound))
+	})
+
+	It("should report intermediate power levels", func() {
+		Expect(light.SetPowerLevel(common.Power(32768))).To(Succeed())
+		Expect(light.GetPowerState()).To(Equal(common.Power(32768)))
+		Expect(light.GetPower()).To(BeTrue())
+		Expect(light.SetPowerLevel(common.PowerOff)).To(Succeed())
+		Expect(light.CachedPower()).To(BeFalse())
+	})
+
+	It("should stagger a color wave across lights in order", func() {
+		lights := []*timedLight{
+			{Light: NewLight(3, `three`, common.Color{})},
+			{Light: NewLight(1, `one`, common.Color{})},
+			{Light: NewLight(2, `two`, common.Color{})},
+		}
+		wave := make([]common.Light, len(lights))
+		for i, l := range lights {
+			wave[i] = l
+		}
+		start := time.Now()
+		Expect(common.ColorWave(wave, color, 0, 50*time.Millisecond)).To(Succeed())
+		for i, l := range lights {
+			Expect(l.CachedColor()).To(Equal(color))
+			Expect(l.set.Sub(start)).To(BeNumerically(`>=`, time.Duration(i)*50*time.Millisecond))
+		}
+		Expect(lights[1].set).To(BeTemporally(`>`, lights[0].set))
+		Expect(lights[2].set).To(BeTemporally(`>`, lights[1].set))
+
+		Expect(common.ColorWave(wave, color, 0, -time.Second)).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should track the remaining transition time", func() {
+		Expect(light.TransitionRemaining()).To(BeZero())
+		Expect(light.SetColor(color, time.Minute)).To(Succeed())
+		Expect(light.TransitionRemaining()).To(BeNumerically(`~`, time.Minute, time.Second))
+		Expect(light.SetPowerDuration(true, 0)).To(Succeed())
+		Expect(light.TransitionRemaining()).To(BeZero())
+	})
+
+	It("should set the indicator color", func() {
+		relay := NewRelayDevice(5, `switch`)
+		Expect(relay.SetIndicatorColor(color)).To(Succeed())
+		Expect(relay.IndicatorColor()).To(Equal(color))
+	})
+
+	It("should report assigned services", func() {
+		Expect(device.Services()).To(BeEmpty())
+		device.SetServices(common.Service{Type: common.ServiceUDP, Port: 56701})
+		Expect(device.Services()).To(Equal([]common.Service{{Type: common.ServiceUDP, Port: 56701}}))
+		Expect(device.Services()[0].Type.String()).To(Equal(`udp`))
+	})
+
+	It("should report the reboot reason once set", func() {
+		_, err := device.LastRebootReason()
+		Expect(err).To(Equal(common.ErrNotSupported))
+		device.SetLastRebootReason(common.RebootReasonPowerLoss)
+		Expect(device.LastRebootReason()).To(Equal(common.RebootReasonPowerLoss))
+	})
+
+	It("should report the access point once set", func() {
+		_, err := light.GetAccessPoint()
+		Expect(err).To(Equal(common.ErrNotSupported))
+		ap := common.AccessPoint{SSID: `home`, Security: common.WifiSecurityWPA2AESPSK, Channel: 6}
+		light.SetAccessPoint(ap)
+		Expect(light.GetAccessPoint()).To(Equal(ap))
+		Expect(ap.Security.String()).To(Equal(`WPA2-AES-PSK`))
+	})
+
+	It("should only respond to pings while online", func() {
+		_, err := device.Ping()
+		Expect(err).NotTo(HaveOccurred())
+		device.SetOnline(false)
+		_, err = device.Ping()
+		Expect(err).To(Equal(common.ErrTimeout))
+	})
+
+	It("should report tags", func() {
+		Expect(light.Tags()).To(BeEmpty())
+		light.SetTags(`Bedroom`, `Upstairs`)
+		Expect(light.Tags()).To(Equal([]string{`Bedroom`, `Upstairs`}))
+	})
+
+	It("should publish updates to subscribers", func() {
+		sub, err := light.NewSubscription()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.SetLabel(`renamed`)).To(Succeed())
+		Expect(sub.Events()).To(Receive(Equal(common.EventUpdateLabel{Label: `renamed`})))
+		Expect(sub.Close()).To(Succeed())
+	})
+
+	Context("with a client", func() {
+		It("should serve the configured devices", func() {
+			client, protocol, err := NewClient(light, device)
+			Expect(err).NotTo(HaveOccurred())
+			defer client.Close()
+
+			l, err := client.GetLightByLabel(`light`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(l).To(Equal(light))
+
+			lights, err := client.GetLights()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lights).To(HaveLen(1))
+
+			Expect(client.SetColor(color, 0)).To(Succeed())
+			Expect(light.CachedColor()).To(Equal(color))
+
+			Expect(protocol.RemoveDevice(light.ID())).To(Succeed())
+			_, err = client.GetLights()
+			Expect(err).To(Equal(common.ErrNotFound))
+		})
+	})
+})
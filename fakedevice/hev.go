@@ -0,0 +1,69 @@
+package fakedevice
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// DefaultHevCycleDuration is the duration of clean cycles started with a
+// duration of zero, matching the default configuration of a LIFX Clean
+const DefaultHevCycleDuration = 2 * time.Hour
+
+// HevLight is an in-memory implementation of common.HevLight.  The clean cycle
+// runs against the wall clock, the power of the light is left unchanged.
+type HevLight struct {
+	hevDuration  time.Duration
+	hevStarted   time.Time
+	hevLastPower bool
+	hevActive    bool
+	Light
+}
+
+// NewHevLight returns a new *HevLight with the specified id, label and color
+func NewHevLight(id uint64, label string, color common.Color) *HevLight {
+	l := &HevLight{}
+	l.color = color
+	l.init(id, label)
+	return l
+}
+
+// StartHevCycle starts a clean cycle for duration, or DefaultHevCycleDuration
+// if zero.  Returns common.ErrInvalidArgument if the duration is out of range.
+func (l *HevLight) StartHevCycle(duration time.Duration) error {
+	if duration < 0 || duration > common.MaxHevCycleDuration {
+		return common.ErrInvalidArgument
+	}
+	if duration == 0 {
+		duration = DefaultHevCycleDuration
+	}
+	power := l.CachedPower()
+	l.Lock()
+	defer l.Unlock()
+	l.hevDuration = duration.Truncate(time.Second)
+	l.hevStarted = time.Now()
+	l.hevLastPower = power
+	l.hevActive = true
+	return nil
+}
+
+// StopHevCycle stops the running clean cycle, if any
+func (l *HevLight) StopHevCycle() error {
+	l.Lock()
+	defer l.Unlock()
+	l.hevActive = false
+	return nil
+}
+
+// GetHevCycle returns the state of the clean cycle
+func (l *HevLight) GetHevCycle() (common.HevStatus, error) {
+	l.RLock()
+	defer l.RUnlock()
+	s := common.HevStatus{Duration: l.hevDuration, LastPower: l.hevLastPower}
+	if l.hevActive {
+		if remaining := l.hevDuration - time.Since(l.hevStarted); remaining > 0 {
+			s.Remaining = remaining
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,263 @@
+package fakedevice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// Light is an in-memory implementation of common.Light.  Color and power
+// changes are applied immediately, transition durations are only tracked for
+// TransitionRemaining.
+type Light struct {
+	color         common.Color
+	tags          []string
+	ap            *common.AccessPoint
+	transitionEnd time.Time
+	lastOnColor   *common.Color
+
+	updateMutex sync.Mutex
+	Device
+}
+
+// NewLight returns a new *Light with the specified id, label and color
+func NewLight(id uint64, label string, color common.Color) *Light {
+	l := &Light{color: color}
+	l.init(id, label)
+	return l
+}
+
+// SetColor sets the color of the light, publishing common.EventUpdateColor if
+// the color changed.  Returns common.ErrInvalidArgument if the duration is out
+// of range (see common.ValidateDuration).
+func (l *Light) SetColor(color common.Color, duration time.Duration) error {
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	l.Lock()
+	changed := !common.ColorEqual(l.color, color)
+	l.color = color
+	l.transitionEnd = time.Now().Add(duration)
+	l.Unlock()
+
+	if changed {
+		return l.publish(common.EventUpdateColor{Color: color})
+	}
+
+	return nil
+}
+
+// FlashColor validates the arguments, the light reverts to its own color once
+// flashed, so the color is left unchanged
+func (l *Light) FlashColor(color common.Color, period time.Duration, cycles uint16) error {
+	if period <= 0 || cycles == 0 {
+		return common.ErrInvalidArgument
+	}
+	return common.ValidateDuration(period)
+}
+
+// Tags returns the tags set via SetTags, or an empty slice
+func (l *Light) Tags() ([]string, error) {
+	l.RLock()
+	defer l.RUnlock()
+	tags := make([]string, len(l.tags))
+	copy(tags, l.tags)
+	return tags, nil
+}
+
+// SetTags sets the tags reported by the light
+func (l *Light) SetTags(tags ...string) {
+	l.Lock()
+	l.tags = tags
+	l.Unlock()
+}
+
+// GetAccessPoint returns the access point set via SetAccessPoint, or
+// common.ErrNotSupported if none has been set
+func (l *Light) GetAccessPoint() (common.AccessPoint, error) {
+	l.RLock()
+	defer l.RUnlock()
+	if l.ap == nil {
+		return common.AccessPoint{}, common.ErrNotSupported
+	}
+	return *l.ap, nil
+}
+
+// SetAccessPoint sets the access point reported by the light
+func (l *Light) SetAccessPoint(ap common.AccessPoint) {
+	l.Lock()
+	l.ap = &ap
+	l.Unlock()
+}
+
+// SetColorAck sets the color of the light, which is always acknowledged
+func (l *Light) SetColorAck(color common.Color, duration time.Duration) (bool, error) {
+	if err := l.SetColor(color, duration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UpdateColor sets the color of the light to the result of applying fn to the
+// current color
+func (l *Light) UpdateColor(fn func(common.Color) common.Color, duration time.Duration) error {
+	l.updateMutex.Lock()
+	defer l.updateMutex.Unlock()
+
+	return l.SetColor(fn(l.CachedColor()), duration)
+}
+
+// SetColorIfOn changes the color of the light if it is currently powered on,
+// returning whether the color was applied
+func (l *Light) SetColorIfOn(color common.Color, duration time.Duration) (bool, error) {
+	power, err := l.GetPower()
+	if err != nil {
+		return false, err
+	}
+	if !power {
+		return false, nil
+	}
+	if err := l.SetColor(color, duration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetColorClamped clamps color into the supported range and sets it, returning
+// the color applied
+func (l *Light) SetColorClamped(color common.Color, duration time.Duration) (common.Color, error) {
+	color = common.ClampColor(color)
+	if err := l.SetColor(color, duration); err != nil {
+		return common.Color{}, err
+	}
+	return color, nil
+}
+
+// SetBrightnessPercent sets the brightness of the light to percent, in the
+// range 0 to 100 (clamped), preserving the other color components
+func (l *Light) SetBrightnessPercent(percent float64, duration time.Duration) error {
+	brightness := common.BrightnessFromPercent(percent)
+	return l.UpdateColor(func(color common.Color) common.Color {
+		color.Brightness = brightness
+		return color
+	}, duration)
+}
+
+// AdjustKelvin adds delta to the color temperature of the light, clamped to the
+// supported range
+func (l *Light) AdjustKelvin(delta int, duration time.Duration) error {
+	return l.UpdateColor(func(color common.Color) common.Color {
+		return common.AdjustKelvin(color, delta)
+	}, duration)
+}
+
+// AdjustBrightness adds delta to the brightness of the light, clamped to the
+// range 0 to 65535
+func (l *Light) AdjustBrightness(delta int, duration time.Duration) error {
+	return l.UpdateColor(func(color common.Color) common.Color {
+		return common.AdjustBrightness(color, delta)
+	}, duration)
+}
+
+// SetColorByName sets the color of the light to the named color (see
+// common.NamedColor)
+func (l *Light) SetColorByName(name string, duration time.Duration) error {
+	color, err := common.NamedColor(name)
+	if err != nil {
+		return err
+	}
+
+	return l.SetColor(color, duration)
+}
+
+// SetColorVerified sets the color of the light, and checks it was applied
+// within tolerance.  No delay is necessary, so none is applied.
+func (l *Light) SetColorVerified(color common.Color, duration time.Duration, tolerance uint16, delay time.Duration) error {
+	if err := l.SetColor(color, duration); err != nil {
+		return err
+	}
+	if !common.ColorApproxEqual(l.CachedColor(), color, tolerance) {
+		return common.ErrVerifyFailed
+	}
+
+	return nil
+}
+
+// GetColor returns the color of the light
+func (l *Light) GetColor() (common.Color, error) {
+	return l.CachedColor(), nil
+}
+
+// CachedColor returns the color of the light
+func (l *Light) CachedColor() common.Color {
+	l.RLock()
+	defer l.RUnlock()
+	return l.color
+}
+
+// WaitUntilColor blocks until the light color is within tolerance of target,
+// or the context is done
+func (l *Light) WaitUntilColor(ctx context.Context, target common.Color, tolerance uint16) error {
+	ticker := time.NewTicker(common.DefaultPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if common.ColorApproxEqual(l.CachedColor(), target, tolerance) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetPower sets the power state of the light, remembering the color when
+// powering off from on, for LastOnColor
+func (l *Light) SetPower(state bool) error {
+	if !state && l.CachedPower() {
+		l.Lock()
+		color := l.color
+		l.lastOnColor = &color
+		l.Unlock()
+	}
+	return l.Device.SetPower(state)
+}
+
+// LastOnColor returns the color the light had when it was last powered off
+// from on.  Unlike the LAN and cloud lights, which remember colors only if the
+// client does, it is always remembered.
+func (l *Light) LastOnColor() (common.Color, bool) {
+	l.RLock()
+	defer l.RUnlock()
+	if l.lastOnColor == nil {
+		return common.Color{}, false
+	}
+	return *l.lastOnColor, true
+}
+
+// SetPowerDuration sets the power state of the light immediately, the
+// duration is only tracked for TransitionRemaining
+func (l *Light) SetPowerDuration(state bool, duration time.Duration) error {
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	l.Lock()
+	l.transitionEnd = time.Now().Add(duration)
+	l.Unlock()
+	return l.SetPower(state)
+}
+
+// TransitionRemaining returns the time until the duration of the last color
+// or power change elapses, or zero
+func (l *Light) TransitionRemaining() time.Duration {
+	l.RLock()
+	defer l.RUnlock()
+	if remaining := time.Until(l.transitionEnd); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
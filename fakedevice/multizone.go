@@ -0,0 +1,151 @@
+package fakedevice
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// MultiZoneLight is an in-memory implementation of common.MultiZoneLight
+type MultiZoneLight struct {
+	zones  []common.Color
+	staged []common.Color
+	Light
+}
+
+// NewMultiZoneLight returns a new *MultiZoneLight with the specified id, label
+// and zone colors, the zone count is the length of zones
+func NewMultiZoneLight(id uint64, label string, zones []common.Color) *MultiZoneLight {
+	l := &MultiZoneLight{zones: make([]common.Color, len(zones))}
+	copy(l.zones, zones)
+	l.init(id, label)
+	if len(zones) > 0 {
+		l.color = zones[0]
+	}
+	return l
+}
+
+// ZoneCount returns the number of zones on the light
+func (l *MultiZoneLight) ZoneCount() (uint8, error) {
+	l.RLock()
+	defer l.RUnlock()
+	return uint8(len(l.zones)), nil
+}
+
+// GetZoneColors returns the color of each zone on the light
+func (l *MultiZoneLight) GetZoneColors() ([]common.Color, error) {
+	l.RLock()
+	defer l.RUnlock()
+	colors := make([]common.Color, len(l.zones))
+	copy(colors, l.zones)
+	return colors, nil
+}
+
+// VerifyZones returns the indexes of the zones that do not match expected
+// within tolerance, or common.ErrInvalidArgument if the length of expected
+// does not match the zone count
+func (l *MultiZoneLight) VerifyZones(expected []common.Color, tolerance uint16) ([]int, error) {
+	colors, err := l.GetZoneColors()
+	if err != nil {
+		return nil, err
+	}
+	return common.MismatchedZones(colors, expected, tolerance)
+}
+
+// SetZoneColors sets the color of each zone on the light, returns
+// common.ErrInvalidArgument if the length of colors does not match the zone
+// count
+func (l *MultiZoneLight) SetZoneColors(colors []common.Color, duration time.Duration) error {
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	l.Lock()
+	defer l.Unlock()
+	if len(colors) != len(l.zones) {
+		return common.ErrInvalidArgument
+	}
+	copy(l.zones, colors)
+	l.staged = nil
+	return nil
+}
+
+// SetColorZones sets the zones from start to end (inclusive) to color, returns
+// common.ErrInvalidArgument if the range is outside the zones of the light
+func (l *MultiZoneLight) SetColorZones(start, end uint8, color common.Color, duration time.Duration) error {
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	l.Lock()
+	defer l.Unlock()
+	if start > end || int(end) >= len(l.zones) {
+		return common.ErrInvalidArgument
+	}
+	l.applyStaged()
+	for i := start; i <= end; i++ {
+		l.zones[i] = color
+	}
+	return nil
+}
+
+// SetColorZonesStaged stages the zones from start to end (inclusive) to be set
+// to color on the next ApplyZones, SetColorZones or SetZoneColors, returns
+// common.ErrInvalidArgument if the range is outside the zones of the light
+func (l *MultiZoneLight) SetColorZonesStaged(start, end uint8, color common.Color) error {
+	l.Lock()
+	defer l.Unlock()
+	if start > end || int(end) >= len(l.zones) {
+		return common.ErrInvalidArgument
+	}
+	if l.staged == nil {
+		l.staged = make([]common.Color, len(l.zones))
+		copy(l.staged, l.zones)
+	}
+	for i := start; i <= end; i++ {
+		l.staged[i] = color
+	}
+	return nil
+}
+
+// ApplyZones applies the zones staged by SetColorZonesStaged
+func (l *MultiZoneLight) ApplyZones(duration time.Duration) error {
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	l.Lock()
+	defer l.Unlock()
+	l.applyStaged()
+	return nil
+}
+
+// applyStaged copies any staged zones to the light, the lock must be held
+func (l *MultiZoneLight) applyStaged() {
+	if l.staged != nil {
+		copy(l.zones, l.staged)
+		l.staged = nil
+	}
+}
+
+// SetColorFraction changes the color of the portion of the light from start
+// to end, as fractions of its length, see common.ZoneRange
+func (l *MultiZoneLight) SetColorFraction(start, end float64, color common.Color, duration time.Duration) error {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return err
+	}
+	first, last, err := common.ZoneRange(count, start, end)
+	if err != nil {
+		return err
+	}
+	return l.SetColorZones(first, last, color, duration)
+}
+
+// SetGradient sets a gradient across all zones of the light, returns
+// common.ErrInvalidArgument if the light has no zones
+func (l *MultiZoneLight) SetGradient(from, to common.Color, duration time.Duration) error {
+	count, _ := l.ZoneCount()
+	if count == 0 {
+		return common.ErrInvalidArgument
+	}
+
+	return l.SetZoneColors(common.Gradient(from, to, int(count)), duration)
+}
@@ -0,0 +1,310 @@
+package fakedevice
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// Protocol is an in-memory implementation of common.Protocol, serving a fixed
+// set of devices that may be modified via AddDevice and RemoveDevice.  Groups
+// and locations are not supported.
+type Protocol struct {
+	devices                 map[uint64]common.Device
+	subscriptions           map[string]*common.Subscription
+	timeout                 *time.Duration
+	retryInterval           *time.Duration
+	client                  common.ClientConfig
+	port                    int
+	source                  uint32
+	broadcastAddress        string
+	extraBroadcastAddresses []string
+	quitChan                chan struct{}
+	sync.RWMutex
+}
+
+// NewProtocol returns a new *Protocol holding the provided devices
+func NewProtocol(devices ...common.Device) *Protocol {
+	p := &Protocol{
+		devices:       make(map[uint64]common.Device, len(devices)),
+		subscriptions: make(map[string]*common.Subscription),
+		quitChan:      make(chan struct{}),
+	}
+	for _, dev := range devices {
+		p.devices[dev.ID()] = dev
+	}
+
+	return p
+}
+
+// AddDevice adds dev to the protocol, publishing common.EventNewDevice
+func (p *Protocol) AddDevice(dev common.Device) error {
+	p.Lock()
+	p.devices[dev.ID()] = dev
+	p.Unlock()
+
+	return p.publish(common.EventNewDevice{Device: dev})
+}
+
+// RemoveDevice removes the device with the specified id from the protocol,
+// publishing common.EventExpiredDevice.  Returns common.ErrNotFound if the
+// device is not known.
+func (p *Protocol) RemoveDevice(id uint64) error {
+	p.Lock()
+	dev, ok := p.devices[id]
+	delete(p.devices, id)
+	p.Unlock()
+	if !ok {
+		return common.ErrNotFound
+	}
+
+	return p.publish(common.EventExpiredDevice{Device: dev})
+}
+
+// NewSubscription returns a new *common.Subscription for receiving events from
+// this protocol.
+func (p *Protocol) NewSubscription() (*common.Subscription, error) {
+	sub := common.NewSubscription(p)
+	p.Lock()
+	p.subscriptions[sub.ID()] = sub
+	p.Unlock()
+	return sub, nil
+}
+
+// CloseSubscription is a callback for handling the closing of subscriptions.
+func (p *Protocol) CloseSubscription(sub *common.Subscription) error {
+	p.Lock()
+	defer p.Unlock()
+	if _, ok := p.subscriptions[sub.ID()]; !ok {
+		return common.ErrNotFound
+	}
+	delete(p.subscriptions, sub.ID())
+
+	return nil
+}
+
+// GetLocations always returns common.ErrNotFound
+func (p *Protocol) GetLocations() ([]common.Location, error) {
+	return nil, common.ErrNotFound
+}
+
+// GetLocation always returns common.ErrNotFound
+func (p *Protocol) GetLocation(id string) (common.Location, error) {
+	return nil, common.ErrNotFound
+}
+
+// GetGroups always returns common.ErrNotFound
+func (p *Protocol) GetGroups() ([]common.Group, error) {
+	return nil, common.ErrNotFound
+}
+
+// GetGroup always returns common.ErrNotFound
+func (p *Protocol) GetGroup(id string) (common.Group, error) {
+	return nil, common.ErrNotFound
+}
+
+// GetDevices returns a slice of all devices held by the protocol, or
+// common.ErrNotFound if there are none.
+func (p *Protocol) GetDevices() ([]common.Device, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.devices) == 0 {
+		return nil, common.ErrNotFound
+	}
+	devices := make([]common.Device, 0, len(p.devices))
+	for _, dev := range p.devices {
+		devices = append(devices, dev)
+	}
+
+	return devices, nil
+}
+
+// GetDevice looks up a device by its `id`
+func (p *Protocol) GetDevice(id uint64) (common.Device, error) {
+	p.RLock()
+	defer p.RUnlock()
+	dev, ok := p.devices[id]
+	if !ok {
+		return nil, common.ErrNotFound
+	}
+
+	return dev, nil
+}
+
+// DeviceFromCache returns the light held by the protocol with the specified
+// id, the address is ignored.  Returns common.ErrNotFound if the light is not
+// held, as there is no network to address it on, or
+// common.ErrDeviceInvalidType if the device is not a light.
+func (p *Protocol) DeviceFromCache(id uint64, address string) (common.Light, error) {
+	dev, err := p.GetDevice(id)
+	if err != nil {
+		return nil, err
+	}
+	light, ok := dev.(common.Light)
+	if !ok {
+		return nil, common.ErrDeviceInvalidType
+	}
+	return light, nil
+}
+
+// Discover is a noop, all devices are known in advance
+func (p *Protocol) Discover() error {
+	return nil
+}
+
+// SetTimeout attaches the client timeout to the protocol
+func (p *Protocol) SetTimeout(timeout *time.Duration) {
+	p.Lock()
+	p.timeout = timeout
+	p.Unlock()
+}
+
+// SetRetryInterval attaches the client retry interval to the protocol
+func (p *Protocol) SetRetryInterval(retryInterval *time.Duration) {
+	p.Lock()
+	p.retryInterval = retryInterval
+	p.Unlock()
+}
+
+// SetClient attaches the client to the protocol
+func (p *Protocol) SetClient(client common.ClientConfig) {
+	p.Lock()
+	p.client = client
+	p.Unlock()
+}
+
+// SetBroadcastAddress records the discovery broadcast address, which is
+// otherwise unused
+func (p *Protocol) SetBroadcastAddress(address string) error {
+	p.Lock()
+	p.broadcastAddress = address
+	p.Unlock()
+	return nil
+}
+
+// AddBroadcastAddress records an additional discovery broadcast address, which
+// is otherwise unused
+func (p *Protocol) AddBroadcastAddress(address string) error {
+	p.Lock()
+	p.extraBroadcastAddresses = append(p.extraBroadcastAddresses, address)
+	p.Unlock()
+	return nil
+}
+
+// SetSource records the request source, which is otherwise unused.  Returns
+// common.ErrInvalidArgument if the source is zero.
+func (p *Protocol) SetSource(source uint32) error {
+	if source == 0 {
+		return common.ErrInvalidArgument
+	}
+	p.Lock()
+	p.source = source
+	p.Unlock()
+	return nil
+}
+
+// SetPort records the discovery port, which is otherwise unused
+func (p *Protocol) SetPort(port int) error {
+	if port <= 0 || port > 65535 {
+		return common.ErrInvalidArgument
+	}
+	p.Lock()
+	p.port = port
+	p.Unlock()
+	return nil
+}
+
+// BroadcastWaveform validates the waveform, and sets the color of all lights
+// to the waveform color unless the waveform is transient
+func (p *Protocol) BroadcastWaveform(w common.Waveform) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	if w.Transient {
+		return nil
+	}
+	return p.SetColor(w.Color, 0)
+}
+
+// SetPower sets the power state on all devices
+func (p *Protocol) SetPower(state bool) error {
+	devices, _ := p.GetDevices()
+	for _, dev := range devices {
+		if err := dev.SetPower(state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetPowerDuration sets the power state on all lights
+func (p *Protocol) SetPowerDuration(state bool, duration time.Duration) error {
+	devices, _ := p.GetDevices()
+	for _, dev := range devices {
+		if l, ok := dev.(common.Light); ok {
+			if err := l.SetPowerDuration(state, duration); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetColor sets the color on all lights
+func (p *Protocol) SetColor(color common.Color, duration time.Duration) error {
+	devices, _ := p.GetDevices()
+	for _, dev := range devices {
+		if l, ok := dev.(common.Light); ok {
+			if err := l.SetColor(color, duration); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close closes all subscriptions to the protocol
+func (p *Protocol) Close() error {
+	p.Lock()
+	select {
+	case <-p.quitChan:
+		p.Unlock()
+		return common.ErrClosed
+	default:
+		close(p.quitChan)
+	}
+	subs := make([]*common.Subscription, 0, len(p.subscriptions))
+	for _, sub := range p.subscriptions {
+		subs = append(subs, sub)
+	}
+	p.Unlock()
+
+	for _, sub := range subs {
+		if err := sub.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Protocol) publish(event interface{}) error {
+	p.RLock()
+	subs := make([]*common.Subscription, 0, len(p.subscriptions))
+	for _, sub := range p.subscriptions {
+		subs = append(subs, sub)
+	}
+	p.RUnlock()
+
+	for _, sub := range subs {
+		if err := sub.Write(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
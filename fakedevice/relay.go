@@ -0,0 +1,31 @@
+package fakedevice
+
+import "github.com/pdf/golifx/common"
+
+// RelayDevice is an in-memory implementation of common.RelayDevice
+type RelayDevice struct {
+	indicator common.Color
+	Device
+}
+
+// NewRelayDevice returns a new *RelayDevice with the specified id and label
+func NewRelayDevice(id uint64, label string) *RelayDevice {
+	d := &RelayDevice{}
+	d.init(id, label)
+	return d
+}
+
+// SetIndicatorColor sets the color returned by IndicatorColor
+func (d *RelayDevice) SetIndicatorColor(color common.Color) error {
+	d.Lock()
+	defer d.Unlock()
+	d.indicator = color
+	return nil
+}
+
+// IndicatorColor returns the color assigned via SetIndicatorColor
+func (d *RelayDevice) IndicatorColor() common.Color {
+	d.RLock()
+	defer d.RUnlock()
+	return d.indicator
+}
@@ -0,0 +1,71 @@
+package fakedevice
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// TileLight is an in-memory implementation of common.TileLight
+type TileLight struct {
+	tiles  []common.Tile
+	pixels [][]common.Color
+	Light
+}
+
+// NewTileLight returns a new *TileLight with the specified id, label and tile
+// chain, the index of each tile is its position in tiles
+func NewTileLight(id uint64, label string, tiles []common.Tile) *TileLight {
+	l := &TileLight{
+		tiles:  make([]common.Tile, len(tiles)),
+		pixels: make([][]common.Color, len(tiles)),
+	}
+	for i, tile := range tiles {
+		tile.Index = uint8(i)
+		l.tiles[i] = tile
+		l.pixels[i] = make([]common.Color, common.MaxTilePixels)
+	}
+	l.init(id, label)
+	return l
+}
+
+// GetTileChain returns the tiles in the chain
+func (l *TileLight) GetTileChain() ([]common.Tile, error) {
+	l.RLock()
+	defer l.RUnlock()
+	tiles := make([]common.Tile, len(l.tiles))
+	copy(tiles, l.tiles)
+	return tiles, nil
+}
+
+// GetTileState64 returns the color of each pixel of the tile at index, returns
+// common.ErrInvalidArgument if there is no tile at index
+func (l *TileLight) GetTileState64(index uint8) ([]common.Color, error) {
+	l.RLock()
+	defer l.RUnlock()
+	if int(index) >= len(l.pixels) {
+		return nil, common.ErrInvalidArgument
+	}
+	colors := make([]common.Color, common.MaxTilePixels)
+	copy(colors, l.pixels[index])
+	return colors, nil
+}
+
+// SetTileState64 sets the color of each pixel of the tile at index, returns
+// common.ErrInvalidArgument if there is no tile at index, width is zero or
+// there are more than common.MaxTilePixels colors
+func (l *TileLight) SetTileState64(index, width uint8, colors []common.Color, duration time.Duration) error {
+	if width == 0 || len(colors) > common.MaxTilePixels {
+		return common.ErrInvalidArgument
+	}
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	l.Lock()
+	defer l.Unlock()
+	if int(index) >= len(l.pixels) {
+		return common.ErrInvalidArgument
+	}
+	copy(l.pixels[index], colors)
+	return nil
+}
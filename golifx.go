@@ -29,22 +29,38 @@ const (
 )
 
 // NewClient returns a pointer to a new Client and any error that occurred
-// initializing the client, using the protocol p.  It also kicks off a discovery
-// run.
-func NewClient(p common.Protocol) (*Client, error) {
+// initializing the client, using the protocol p, configured by opts in order.
+// It also kicks off a discovery run.
+func NewClient(p common.Protocol, opts ...Option) (*Client, error) {
 	c := &Client{
 		protocol:              p,
 		subscriptions:         make(map[string]*common.Subscription),
+		labels:                newLabelIndex(),
+		animations:            make(map[uint64]*animation),
 		timeout:               common.DefaultTimeout,
 		retryInterval:         common.DefaultRetryInterval,
+		pollInterval:          common.DefaultPollInterval,
 		internalRetryInterval: 10 * time.Millisecond,
 		quitChan:              make(chan struct{}, 2),
+		done:                  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
 	}
 	c.protocol.SetTimeout(&c.timeout)
 	c.protocol.SetRetryInterval(&c.retryInterval)
+	c.protocol.SetClient(c)
 	if err := c.subscribe(); err != nil {
 		return nil, err
 	}
+	if c.discoveryInterval > 0 {
+		// The discovery loop waits out the first interval
+		if err := c.protocol.Discover(); err != nil {
+			return c, err
+		}
+	}
 	err := c.discover()
 	return c, err
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/pdf/golifx"
+	"github.com/pdf/golifx/common"
 	"github.com/pdf/golifx/protocol"
 )
 
@@ -55,3 +56,22 @@ func ExampleNewClient_v2reliable() {
 
 	fmt.Println(light.ID())
 }
+
+// Subsystems that each need a client can share one UDP socket by taking a
+// handle from SharedClient, rather than each binding the client port.
+func ExampleSharedClient() {
+	client, err := golifx.SharedClient(func() common.Protocol {
+		return &protocol.V2{Reliable: true}
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	light, err := client.GetLightByLabel(`lightLabel`)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(light.ID())
+}
@@ -0,0 +1,124 @@
+package golifx
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pdf/golifx/common"
+)
+
+// labelIndex maps device labels to device IDs, so that looking up a device by
+// label does not scan every device.  The index follows label changes reported
+// by each device, including renames by other clients.  Where several devices
+// share a label, the device with the lowest ID is returned.
+type labelIndex struct {
+	labels   map[uint64]string
+	ids      map[string][]uint64
+	watching map[uint64]*common.Subscription
+	sync.RWMutex
+}
+
+func newLabelIndex() *labelIndex {
+	return &labelIndex{
+		labels:   make(map[uint64]string),
+		ids:      make(map[string][]uint64),
+		watching: make(map[uint64]*common.Subscription),
+	}
+}
+
+// lookup returns the ID of the device with label, if any
+func (i *labelIndex) lookup(label string) (uint64, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	ids := i.ids[label]
+	if len(ids) == 0 {
+		return 0, false
+	}
+	return ids[0], true
+}
+
+// set records label for the device with id, replacing any previous label
+func (i *labelIndex) set(id uint64, label string) {
+	i.Lock()
+	defer i.Unlock()
+	i.unset(id)
+	i.labels[id] = label
+	ids := append(i.ids[label], id)
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+	i.ids[label] = ids
+}
+
+// unset removes the label for the device with id, the lock must be held
+func (i *labelIndex) unset(id uint64) {
+	label, ok := i.labels[id]
+	if !ok {
+		return
+	}
+	delete(i.labels, id)
+	ids := i.ids[label]
+	for n, v := range ids {
+		if v == id {
+			ids = append(ids[:n], ids[n+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(i.ids, label)
+	} else {
+		i.ids[label] = ids
+	}
+}
+
+// watch indexes the label of dev, and follows changes to it until the device
+// is removed or quit is closed.  Devices already watched are ignored.
+func (i *labelIndex) watch(dev common.Device, quit <-chan struct{}) {
+	i.Lock()
+	if _, ok := i.watching[dev.ID()]; ok {
+		i.Unlock()
+		return
+	}
+	sub, err := dev.NewSubscription()
+	if err != nil {
+		i.Unlock()
+		common.Log.Warnf("Failed subscribing to device %d for label changes: %v", dev.ID(), err)
+		return
+	}
+	i.watching[dev.ID()] = sub
+	i.Unlock()
+
+	go func() {
+		// The label may require a request if not reported during discovery,
+		// changes made in the meantime are queued on the subscription
+		if label, err := dev.GetLabel(); err == nil {
+			i.set(dev.ID(), label)
+		}
+		events := sub.Events()
+		for {
+			select {
+			case <-quit:
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if event, ok := event.(common.EventUpdateLabel); ok {
+					i.set(dev.ID(), event.Label)
+				}
+			}
+		}
+	}()
+}
+
+// remove drops the device with id from the index, and stops watching it
+func (i *labelIndex) remove(id uint64) {
+	i.Lock()
+	sub, ok := i.watching[id]
+	delete(i.watching, id)
+	i.unset(id)
+	i.Unlock()
+	if ok {
+		if err := sub.Close(); err != nil {
+			common.Log.Debugf("Failed closing label subscription for device %d: %v", id, err)
+		}
+	}
+}
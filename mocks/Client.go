@@ -1,41 +1,432 @@
 package mocks
 
+import "github.com/pdf/golifx/common"
 import "github.com/stretchr/testify/mock"
 
+import "context"
 import "time"
 
 type Client struct {
+	SubscriptionTarget
 	mock.Mock
 }
 
-// GetTimeout provides a mock function with given fields:
-func (_m *Client) GetTimeout() *time.Duration {
+// GetLocations provides a mock function with given fields:
+func (_m *Client) GetLocations() ([]common.Location, error) {
 	ret := _m.Called()
 
-	var r0 *time.Duration
-	if rf, ok := ret.Get(0).(func() *time.Duration); ok {
+	var r0 []common.Location
+	if rf, ok := ret.Get(0).(func() []common.Location); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*time.Duration)
+			r0 = ret.Get(0).([]common.Location)
 		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLocationByID provides a mock function with given fields: id
+func (_m *Client) GetLocationByID(id string) (common.Location, error) {
+	ret := _m.Called(id)
+
+	var r0 common.Location
+	if rf, ok := ret.Get(0).(func(string) common.Location); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(common.Location)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLocationByLabel provides a mock function with given fields: label
+func (_m *Client) GetLocationByLabel(label string) (common.Location, error) {
+	ret := _m.Called(label)
+
+	var r0 common.Location
+	if rf, ok := ret.Get(0).(func(string) common.Location); ok {
+		r0 = rf(label)
+	} else {
+		r0 = ret.Get(0).(common.Location)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(label)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGroups provides a mock function with given fields:
+func (_m *Client) GetGroups() ([]common.Group, error) {
+	ret := _m.Called()
+
+	var r0 []common.Group
+	if rf, ok := ret.Get(0).(func() []common.Group); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Group)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGroupByID provides a mock function with given fields: id
+func (_m *Client) GetGroupByID(id string) (common.Group, error) {
+	ret := _m.Called(id)
+
+	var r0 common.Group
+	if rf, ok := ret.Get(0).(func(string) common.Group); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(common.Group)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGroupByLabel provides a mock function with given fields: label
+func (_m *Client) GetGroupByLabel(label string) (common.Group, error) {
+	ret := _m.Called(label)
+
+	var r0 common.Group
+	if rf, ok := ret.Get(0).(func(string) common.Group); ok {
+		r0 = rf(label)
+	} else {
+		r0 = ret.Get(0).(common.Group)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(label)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDevices provides a mock function with given fields:
+func (_m *Client) GetDevices() ([]common.Device, error) {
+	ret := _m.Called()
+
+	var r0 []common.Device
+	if rf, ok := ret.Get(0).(func() []common.Device); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Device)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetRetryInterval provides a mock function with given fields:
-func (_m *Client) GetRetryInterval() *time.Duration {
+// GetLights provides a mock function with given fields:
+func (_m *Client) GetLights() ([]common.Light, error) {
 	ret := _m.Called()
 
-	var r0 *time.Duration
-	if rf, ok := ret.Get(0).(func() *time.Duration); ok {
+	var r0 []common.Light
+	if rf, ok := ret.Get(0).(func() []common.Light); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*time.Duration)
+			r0 = ret.Get(0).([]common.Light)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLightsContext provides a mock function with given fields: ctx
+func (_m *Client) GetLightsContext(ctx context.Context) ([]common.Light, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []common.Light
+	if rf, ok := ret.Get(0).(func(context.Context) []common.Light); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Light)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLightsWithRetry provides a mock function with given fields: ctx, attempts
+func (_m *Client) GetLightsWithRetry(ctx context.Context, attempts int) ([]common.Light, error) {
+	ret := _m.Called(ctx, attempts)
+
+	var r0 []common.Light
+	if rf, ok := ret.Get(0).(func(context.Context, int) []common.Light); ok {
+		r0 = rf(ctx, attempts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Light)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, attempts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLightByID provides a mock function with given fields: id
+func (_m *Client) GetLightByID(id uint64) (common.Light, error) {
+	ret := _m.Called(id)
+
+	var r0 common.Light
+	if rf, ok := ret.Get(0).(func(uint64) common.Light); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(common.Light)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLightByLabel provides a mock function with given fields: label
+func (_m *Client) GetLightByLabel(label string) (common.Light, error) {
+	ret := _m.Called(label)
+
+	var r0 common.Light
+	if rf, ok := ret.Get(0).(func(string) common.Light); ok {
+		r0 = rf(label)
+	} else {
+		r0 = ret.Get(0).(common.Light)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(label)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLightsByLabelPattern provides a mock function with given fields: pattern
+func (_m *Client) GetLightsByLabelPattern(pattern string) ([]common.Light, error) {
+	ret := _m.Called(pattern)
+
+	var r0 []common.Light
+	if rf, ok := ret.Get(0).(func(string) []common.Light); ok {
+		r0 = rf(pattern)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Light)
 		}
 	}
 
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(pattern)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLightByMAC provides a mock function with given fields: mac
+func (_m *Client) GetLightByMAC(mac string) (common.Light, error) {
+	ret := _m.Called(mac)
+
+	var r0 common.Light
+	if rf, ok := ret.Get(0).(func(string) common.Light); ok {
+		r0 = rf(mac)
+	} else {
+		r0 = ret.Get(0).(common.Light)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(mac)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPower provides a mock function with given fields: state
+func (_m *Client) SetPower(state bool) error {
+	ret := _m.Called(state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(bool) error); ok {
+		r0 = rf(state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPowerDuration provides a mock function with given fields: state, duration
+func (_m *Client) SetPowerDuration(state bool, duration time.Duration) error {
+	ret := _m.Called(state, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(bool, time.Duration) error); ok {
+		r0 = rf(state, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetColor provides a mock function with given fields: color, duration
+func (_m *Client) SetColor(color common.Color, duration time.Duration) error {
+	ret := _m.Called(color, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Color, time.Duration) error); ok {
+		r0 = rf(color, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Ready provides a mock function with given fields: ctx
+func (_m *Client) Ready(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DiscoverStream provides a mock function with given fields: ctx
+func (_m *Client) DiscoverStream(ctx context.Context) <-chan common.Device {
+	ret := _m.Called(ctx)
+
+	var r0 <-chan common.Device
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan common.Device); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(<-chan common.Device)
+	}
+
+	return r0
+}
+
+// Discover provides a mock function with given fields:
+func (_m *Client) Discover() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetDiscoveryInterval provides a mock function with given fields: interval
+func (_m *Client) SetDiscoveryInterval(interval time.Duration) error {
+	ret := _m.Called(interval)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Duration) error); ok {
+		r0 = rf(interval)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Close provides a mock function with given fields:
+func (_m *Client) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
 	return r0
 }
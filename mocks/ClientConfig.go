@@ -0,0 +1,217 @@
+package mocks
+
+import "github.com/pdf/golifx/common"
+import "github.com/stretchr/testify/mock"
+
+import "io"
+import "time"
+
+type ClientConfig struct {
+	mock.Mock
+}
+
+// GetTimeout provides a mock function with given fields:
+func (_m *ClientConfig) GetTimeout() *time.Duration {
+	ret := _m.Called()
+
+	var r0 *time.Duration
+	if rf, ok := ret.Get(0).(func() *time.Duration); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Duration)
+		}
+	}
+
+	return r0
+}
+
+// GetRetryInterval provides a mock function with given fields:
+func (_m *ClientConfig) GetRetryInterval() *time.Duration {
+	ret := _m.Called()
+
+	var r0 *time.Duration
+	if rf, ok := ret.Get(0).(func() *time.Duration); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Duration)
+		}
+	}
+
+	return r0
+}
+
+// GetPollInterval provides a mock function with given fields:
+func (_m *ClientConfig) GetPollInterval() *time.Duration {
+	ret := _m.Called()
+
+	var r0 *time.Duration
+	if rf, ok := ret.Get(0).(func() *time.Duration); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Duration)
+		}
+	}
+
+	return r0
+}
+
+// GetCacheTTL provides a mock function with given fields:
+func (_m *ClientConfig) GetCacheTTL() *time.Duration {
+	ret := _m.Called()
+
+	var r0 *time.Duration
+	if rf, ok := ret.Get(0).(func() *time.Duration); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Duration)
+		}
+	}
+
+	return r0
+}
+
+// GetColorDebounce provides a mock function with given fields:
+func (_m *ClientConfig) GetColorDebounce() *time.Duration {
+	ret := _m.Called()
+
+	var r0 *time.Duration
+	if rf, ok := ret.Get(0).(func() *time.Duration); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Duration)
+		}
+	}
+
+	return r0
+}
+
+// GetSkipRedundantWrites provides a mock function with given fields:
+func (_m *ClientConfig) GetSkipRedundantWrites() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// GetRestoreOnPower provides a mock function with given fields:
+func (_m *ClientConfig) GetRestoreOnPower() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// GetColorMemory provides a mock function with given fields:
+func (_m *ClientConfig) GetColorMemory() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// GetMinBrightness provides a mock function with given fields:
+func (_m *ClientConfig) GetMinBrightness() uint16 {
+	ret := _m.Called()
+
+	var r0 uint16
+	if rf, ok := ret.Get(0).(func() uint16); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint16)
+	}
+
+	return r0
+}
+
+// GetPrefetchDeviceInfo provides a mock function with given fields:
+func (_m *ClientConfig) GetPrefetchDeviceInfo() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// GetSignalHistoryLength provides a mock function with given fields:
+func (_m *ClientConfig) GetSignalHistoryLength() int {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// GetTracer provides a mock function with given fields:
+func (_m *ClientConfig) GetTracer() io.Writer {
+	ret := _m.Called()
+
+	var r0 io.Writer
+	if rf, ok := ret.Get(0).(func() io.Writer); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(io.Writer)
+	}
+
+	return r0
+}
+
+// GetSpanHook provides a mock function with given fields:
+func (_m *ClientConfig) GetSpanHook() common.SpanHook {
+	ret := _m.Called()
+
+	var r0 common.SpanHook
+	if rf, ok := ret.Get(0).(func() common.SpanHook); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(common.SpanHook)
+	}
+
+	return r0
+}
+
+// GetDecodeErrorHandler provides a mock function with given fields:
+func (_m *ClientConfig) GetDecodeErrorHandler() common.DecodeErrorHandler {
+	ret := _m.Called()
+
+	var r0 common.DecodeErrorHandler
+	if rf, ok := ret.Get(0).(func() common.DecodeErrorHandler); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(common.DecodeErrorHandler)
+	}
+
+	return r0
+}
@@ -1,7 +1,11 @@
 package mocks
 
+import "github.com/pdf/golifx/common"
 import "github.com/stretchr/testify/mock"
 
+import "net"
+import "time"
+
 type Device struct {
 	SubscriptionTarget
 	mock.Mock
@@ -21,6 +25,68 @@ func (_m *Device) ID() uint64 {
 	return r0
 }
 
+// MAC provides a mock function with given fields:
+func (_m *Device) MAC() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Address provides a mock function with given fields:
+func (_m *Device) Address() *net.UDPAddr {
+	ret := _m.Called()
+
+	var r0 *net.UDPAddr
+	if rf, ok := ret.Get(0).(func() *net.UDPAddr); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*net.UDPAddr)
+		}
+	}
+
+	return r0
+}
+
+// Services provides a mock function with given fields:
+func (_m *Device) Services() []common.Service {
+	ret := _m.Called()
+
+	var r0 []common.Service
+	if rf, ok := ret.Get(0).(func() []common.Service); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Service)
+		}
+	}
+
+	return r0
+}
+
+// Subnet provides a mock function with given fields:
+func (_m *Device) Subnet() *net.IPNet {
+	ret := _m.Called()
+
+	var r0 *net.IPNet
+	if rf, ok := ret.Get(0).(func() *net.IPNet); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*net.IPNet)
+		}
+	}
+
+	return r0
+}
+
 // GetLabel provides a mock function with given fields:
 func (_m *Device) GetLabel() (string, error) {
 	ret := _m.Called()
@@ -77,6 +143,27 @@ func (_m *Device) GetPower() (bool, error) {
 	return r0, r1
 }
 
+// GetPowerState provides a mock function with given fields:
+func (_m *Device) GetPowerState() (common.Power, error) {
+	ret := _m.Called()
+
+	var r0 common.Power
+	if rf, ok := ret.Get(0).(func() common.Power); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(common.Power)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CachedPower provides a mock function with given fields:
 func (_m *Device) CachedPower() bool {
 	ret := _m.Called()
@@ -139,3 +226,185 @@ func (_m *Device) CachedFirmwareVersion() string {
 
 	return r0
 }
+
+// Ping provides a mock function with given fields:
+func (_m *Device) Ping() (time.Duration, error) {
+	ret := _m.Called()
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func() time.Duration); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWifiInfo provides a mock function with given fields:
+func (_m *Device) GetWifiInfo() (common.WifiInfo, error) {
+	ret := _m.Called()
+
+	var r0 common.WifiInfo
+	if rf, ok := ret.Get(0).(func() common.WifiInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(common.WifiInfo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignalHistory provides a mock function with given fields:
+func (_m *Device) SignalHistory() []common.SignalSample {
+	ret := _m.Called()
+
+	var r0 []common.SignalSample
+	if rf, ok := ret.Get(0).(func() []common.SignalSample); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.SignalSample)
+		}
+	}
+
+	return r0
+}
+
+// LastOnColor provides a mock function with given fields:
+func (_m *Device) LastOnColor() (common.Color, bool) {
+	ret := _m.Called()
+
+	var r0 common.Color
+	if rf, ok := ret.Get(0).(func() common.Color); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(common.Color)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func() bool); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// SetGroup provides a mock function with given fields: id, label
+func (_m *Device) SetGroup(id [16]byte, label string) error {
+	ret := _m.Called(id, label)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([16]byte, string) error); ok {
+		r0 = rf(id, label)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetLocation provides a mock function with given fields: id, label
+func (_m *Device) SetLocation(id [16]byte, label string) error {
+	ret := _m.Called(id, label)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([16]byte, string) error); ok {
+		r0 = rf(id, label)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// IsOnline provides a mock function with given fields:
+func (_m *Device) IsOnline() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// GetProductInfo provides a mock function with given fields:
+func (_m *Device) GetProductInfo() (common.ProductInfo, error) {
+	ret := _m.Called()
+
+	var r0 common.ProductInfo
+	if rf, ok := ret.Get(0).(func() common.ProductInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(common.ProductInfo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LastRebootReason provides a mock function with given fields:
+func (_m *Device) LastRebootReason() (string, error) {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LastSeen provides a mock function with given fields:
+func (_m *Device) LastSeen() time.Time {
+	ret := _m.Called()
+
+	var r0 time.Time
+	if rf, ok := ret.Get(0).(func() time.Time); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	return r0
+}
+
+// InvalidateCache provides a mock function with given fields:
+func (_m *Device) InvalidateCache() {
+	_m.Called()
+}
@@ -126,6 +126,20 @@ func (_m *Group) SetColor(color common.Color, duration time.Duration) error {
 	return r0
 }
 
+// SetColorWave provides a mock function with given fields: color, duration, stagger
+func (_m *Group) SetColorWave(color common.Color, duration time.Duration, stagger time.Duration) error {
+	ret := _m.Called(color, duration, stagger)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Color, time.Duration, time.Duration) error); ok {
+		r0 = rf(color, duration, stagger)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetPower provides a mock function with given fields: state
 func (_m *Group) SetPower(state bool) error {
 	ret := _m.Called(state)
@@ -0,0 +1,60 @@
+package mocks
+
+import "github.com/pdf/golifx/common"
+import "github.com/stretchr/testify/mock"
+
+import "time"
+
+type HevLight struct {
+	Light
+	mock.Mock
+}
+
+// StartHevCycle provides a mock function with given fields: duration
+func (_m *HevLight) StartHevCycle(duration time.Duration) error {
+	ret := _m.Called(duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Duration) error); ok {
+		r0 = rf(duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StopHevCycle provides a mock function with given fields:
+func (_m *HevLight) StopHevCycle() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetHevCycle provides a mock function with given fields:
+func (_m *HevLight) GetHevCycle() (common.HevStatus, error) {
+	ret := _m.Called()
+
+	var r0 common.HevStatus
+	if rf, ok := ret.Get(0).(func() common.HevStatus); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(common.HevStatus)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
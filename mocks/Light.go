@@ -3,6 +3,7 @@ package mocks
 import "github.com/pdf/golifx/common"
 import "github.com/stretchr/testify/mock"
 
+import "context"
 import "time"
 
 type Light struct {
@@ -24,6 +25,211 @@ func (_m *Light) SetColor(color common.Color, duration time.Duration) error {
 	return r0
 }
 
+// SetColorIfOn provides a mock function with given fields: color, duration
+func (_m *Light) SetColorIfOn(color common.Color, duration time.Duration) (bool, error) {
+	ret := _m.Called(color, duration)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(common.Color, time.Duration) bool); ok {
+		r0 = rf(color, duration)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Color, time.Duration) error); ok {
+		r1 = rf(color, duration)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetColorClamped provides a mock function with given fields: color, duration
+func (_m *Light) SetColorClamped(color common.Color, duration time.Duration) (common.Color, error) {
+	ret := _m.Called(color, duration)
+
+	var r0 common.Color
+	if rf, ok := ret.Get(0).(func(common.Color, time.Duration) common.Color); ok {
+		r0 = rf(color, duration)
+	} else {
+		r0 = ret.Get(0).(common.Color)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Color, time.Duration) error); ok {
+		r1 = rf(color, duration)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetColorVerified provides a mock function with given fields: color, duration, tolerance, delay
+func (_m *Light) SetColorVerified(color common.Color, duration time.Duration, tolerance uint16, delay time.Duration) error {
+	ret := _m.Called(color, duration, tolerance, delay)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Color, time.Duration, uint16, time.Duration) error); ok {
+		r0 = rf(color, duration, tolerance, delay)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetColorByName provides a mock function with given fields: name, duration
+func (_m *Light) SetColorByName(name string, duration time.Duration) error {
+	ret := _m.Called(name, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Duration) error); ok {
+		r0 = rf(name, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AdjustKelvin provides a mock function with given fields: delta, duration
+func (_m *Light) AdjustKelvin(delta int, duration time.Duration) error {
+	ret := _m.Called(delta, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, time.Duration) error); ok {
+		r0 = rf(delta, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AdjustBrightness provides a mock function with given fields: delta, duration
+func (_m *Light) AdjustBrightness(delta int, duration time.Duration) error {
+	ret := _m.Called(delta, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, time.Duration) error); ok {
+		r0 = rf(delta, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FlashColor provides a mock function with given fields: color, period, cycles
+func (_m *Light) FlashColor(color common.Color, period time.Duration, cycles uint16) error {
+	ret := _m.Called(color, period, cycles)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Color, time.Duration, uint16) error); ok {
+		r0 = rf(color, period, cycles)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetBrightnessPercent provides a mock function with given fields: percent, duration
+func (_m *Light) SetBrightnessPercent(percent float64, duration time.Duration) error {
+	ret := _m.Called(percent, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(float64, time.Duration) error); ok {
+		r0 = rf(percent, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateColor provides a mock function with given fields: fn, duration
+func (_m *Light) UpdateColor(fn func(common.Color) common.Color, duration time.Duration) error {
+	ret := _m.Called(fn, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(func(common.Color) common.Color, time.Duration) error); ok {
+		r0 = rf(fn, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetColorAck provides a mock function with given fields: color, duration
+func (_m *Light) SetColorAck(color common.Color, duration time.Duration) (bool, error) {
+	ret := _m.Called(color, duration)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(common.Color, time.Duration) bool); ok {
+		r0 = rf(color, duration)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Color, time.Duration) error); ok {
+		r1 = rf(color, duration)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Tags provides a mock function with given fields:
+func (_m *Light) Tags() ([]string, error) {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAccessPoint provides a mock function with given fields:
+func (_m *Light) GetAccessPoint() (common.AccessPoint, error) {
+	ret := _m.Called()
+
+	var r0 common.AccessPoint
+	if rf, ok := ret.Get(0).(func() common.AccessPoint); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(common.AccessPoint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetColor provides a mock function with given fields:
 func (_m *Light) GetColor() (common.Color, error) {
 	ret := _m.Called()
@@ -59,6 +265,20 @@ func (_m *Light) CachedColor() common.Color {
 	return r0
 }
 
+// WaitUntilColor provides a mock function with given fields: ctx, target, tolerance
+func (_m *Light) WaitUntilColor(ctx context.Context, target common.Color, tolerance uint16) error {
+	ret := _m.Called(ctx, target, tolerance)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, common.Color, uint16) error); ok {
+		r0 = rf(ctx, target, tolerance)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetPowerDuration provides a mock function with given fields: state, duration
 func (_m *Light) SetPowerDuration(state bool, duration time.Duration) error {
 	ret := _m.Called(state, duration)
@@ -72,3 +292,17 @@ func (_m *Light) SetPowerDuration(state bool, duration time.Duration) error {
 
 	return r0
 }
+
+// TransitionRemaining provides a mock function with given fields:
+func (_m *Light) TransitionRemaining() time.Duration {
+	ret := _m.Called()
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func() time.Duration); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	return r0
+}
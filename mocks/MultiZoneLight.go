@@ -0,0 +1,162 @@
+package mocks
+
+import "github.com/pdf/golifx/common"
+import "github.com/stretchr/testify/mock"
+
+import "time"
+
+type MultiZoneLight struct {
+	Light
+	mock.Mock
+}
+
+// ZoneCount provides a mock function with given fields:
+func (_m *MultiZoneLight) ZoneCount() (uint8, error) {
+	ret := _m.Called()
+
+	var r0 uint8
+	if rf, ok := ret.Get(0).(func() uint8); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint8)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetZoneColors provides a mock function with given fields:
+func (_m *MultiZoneLight) GetZoneColors() ([]common.Color, error) {
+	ret := _m.Called()
+
+	var r0 []common.Color
+	if rf, ok := ret.Get(0).(func() []common.Color); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Color)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetZoneColors provides a mock function with given fields: colors, duration
+func (_m *MultiZoneLight) SetZoneColors(colors []common.Color, duration time.Duration) error {
+	ret := _m.Called(colors, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]common.Color, time.Duration) error); ok {
+		r0 = rf(colors, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetColorZones provides a mock function with given fields: start, end, color, duration
+func (_m *MultiZoneLight) SetColorZones(start uint8, end uint8, color common.Color, duration time.Duration) error {
+	ret := _m.Called(start, end, color, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint8, uint8, common.Color, time.Duration) error); ok {
+		r0 = rf(start, end, color, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetColorFraction provides a mock function with given fields: start, end, color, duration
+func (_m *MultiZoneLight) SetColorFraction(start float64, end float64, color common.Color, duration time.Duration) error {
+	ret := _m.Called(start, end, color, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(float64, float64, common.Color, time.Duration) error); ok {
+		r0 = rf(start, end, color, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetColorZonesStaged provides a mock function with given fields: start, end, color
+func (_m *MultiZoneLight) SetColorZonesStaged(start uint8, end uint8, color common.Color) error {
+	ret := _m.Called(start, end, color)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint8, uint8, common.Color) error); ok {
+		r0 = rf(start, end, color)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ApplyZones provides a mock function with given fields: duration
+func (_m *MultiZoneLight) ApplyZones(duration time.Duration) error {
+	ret := _m.Called(duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Duration) error); ok {
+		r0 = rf(duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetGradient provides a mock function with given fields: from, to, duration
+func (_m *MultiZoneLight) SetGradient(from common.Color, to common.Color, duration time.Duration) error {
+	ret := _m.Called(from, to, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Color, common.Color, time.Duration) error); ok {
+		r0 = rf(from, to, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyZones provides a mock function with given fields: expected, tolerance
+func (_m *MultiZoneLight) VerifyZones(expected []common.Color, tolerance uint16) ([]int, error) {
+	ret := _m.Called(expected, tolerance)
+
+	var r0 []int
+	if rf, ok := ret.Get(0).(func([]common.Color, uint16) []int); ok {
+		r0 = rf(expected, tolerance)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]common.Color, uint16) error); ok {
+		r1 = rf(expected, tolerance)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
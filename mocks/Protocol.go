@@ -142,6 +142,27 @@ func (_m *Protocol) GetDevice(id uint64) (common.Device, error) {
 	return r0, r1
 }
 
+// DeviceFromCache provides a mock function with given fields: id, address
+func (_m *Protocol) DeviceFromCache(id uint64, address string) (common.Light, error) {
+	ret := _m.Called(id, address)
+
+	var r0 common.Light
+	if rf, ok := ret.Get(0).(func(uint64, string) common.Light); ok {
+		r0 = rf(id, address)
+	} else {
+		r0 = ret.Get(0).(common.Light)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64, string) error); ok {
+		r1 = rf(id, address)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Discover provides a mock function with given fields:
 func (_m *Protocol) Discover() error {
 	ret := _m.Called()
@@ -166,6 +187,67 @@ func (_m *Protocol) SetRetryInterval(retryInterval *time.Duration) {
 	_m.Called(retryInterval)
 }
 
+// SetClient provides a mock function with given fields: client
+func (_m *Protocol) SetClient(client common.ClientConfig) {
+	_m.Called(client)
+}
+
+// SetPort provides a mock function with given fields: port
+func (_m *Protocol) SetPort(port int) error {
+	ret := _m.Called(port)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(port)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetBroadcastAddress provides a mock function with given fields: address
+func (_m *Protocol) SetBroadcastAddress(address string) error {
+	ret := _m.Called(address)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(address)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddBroadcastAddress provides a mock function with given fields: address
+func (_m *Protocol) AddBroadcastAddress(address string) error {
+	ret := _m.Called(address)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(address)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetSource provides a mock function with given fields: source
+func (_m *Protocol) SetSource(source uint32) error {
+	ret := _m.Called(source)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint32) error); ok {
+		r0 = rf(source)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Close provides a mock function with given fields:
 func (_m *Protocol) Close() error {
 	ret := _m.Called()
@@ -221,3 +303,17 @@ func (_m *Protocol) SetColor(color common.Color, duration time.Duration) error {
 
 	return r0
 }
+
+// BroadcastWaveform provides a mock function with given fields: w
+func (_m *Protocol) BroadcastWaveform(w common.Waveform) error {
+	ret := _m.Called(w)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Waveform) error); ok {
+		r0 = rf(w)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import "github.com/pdf/golifx/common"
+import "github.com/stretchr/testify/mock"
+
+type RelayDevice struct {
+	Device
+	mock.Mock
+}
+
+// SetIndicatorColor provides a mock function with given fields: color
+func (_m *RelayDevice) SetIndicatorColor(color common.Color) error {
+	ret := _m.Called(color)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Color) error); ok {
+		r0 = rf(color)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,71 @@
+package mocks
+
+import "github.com/pdf/golifx/common"
+import "github.com/stretchr/testify/mock"
+
+import "time"
+
+type TileLight struct {
+	Light
+	mock.Mock
+}
+
+// GetTileChain provides a mock function with given fields:
+func (_m *TileLight) GetTileChain() ([]common.Tile, error) {
+	ret := _m.Called()
+
+	var r0 []common.Tile
+	if rf, ok := ret.Get(0).(func() []common.Tile); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Tile)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTileState64 provides a mock function with given fields: index
+func (_m *TileLight) GetTileState64(index uint8) ([]common.Color, error) {
+	ret := _m.Called(index)
+
+	var r0 []common.Color
+	if rf, ok := ret.Get(0).(func(uint8) []common.Color); ok {
+		r0 = rf(index)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Color)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint8) error); ok {
+		r1 = rf(index)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetTileState64 provides a mock function with given fields: index, width, colors, duration
+func (_m *TileLight) SetTileState64(index uint8, width uint8, colors []common.Color, duration time.Duration) error {
+	ret := _m.Called(index, width, colors, duration)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint8, uint8, []common.Color, time.Duration) error); ok {
+		r0 = rf(index, width, colors, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,251 @@
+package golifx
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+// Option configures a Client at construction, see NewClient.  Each option has
+// an equivalent setter on Client for changing the configuration at runtime.
+type Option func(*Client) error
+
+// WithTimeout sets the time that client operations wait for results, see
+// Client.SetTimeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		c.SetTimeout(timeout)
+		return nil
+	}
+}
+
+// WithRetryInterval sets the retry interval for client operations, see
+// Client.SetRetryInterval.  Apply after WithTimeout, as the interval is limited
+// by the timeout.
+func WithRetryInterval(retryInterval time.Duration) Option {
+	return func(c *Client) error {
+		c.SetRetryInterval(retryInterval)
+		return nil
+	}
+}
+
+// WithPollInterval sets the interval at which device state is polled, see
+// Client.SetPollInterval
+func WithPollInterval(pollInterval time.Duration) Option {
+	return func(c *Client) error {
+		c.SetPollInterval(pollInterval)
+		return nil
+	}
+}
+
+// WithDiscoveryInterval causes the client to discover devices every interval,
+// following the initial discovery, see Client.SetDiscoveryInterval
+func WithDiscoveryInterval(interval time.Duration) Option {
+	return func(c *Client) error {
+		if interval < 0 {
+			return common.ErrInvalidArgument
+		}
+		c.discoveryInterval = interval
+		return nil
+	}
+}
+
+// WithCacheTTL sets the period for which reported device state is considered
+// fresh, see Client.SetCacheTTL
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) error {
+		c.SetCacheTTL(ttl)
+		return nil
+	}
+}
+
+// WithColorDebounce sets the window within which color changes to each light
+// are coalesced, see Client.SetColorDebounce
+func WithColorDebounce(debounce time.Duration) Option {
+	return func(c *Client) error {
+		c.SetColorDebounce(debounce)
+		return nil
+	}
+}
+
+// WithSkipRedundantWrites enables skipping redundant color changes, see
+// Client.SetSkipRedundantWrites
+func WithSkipRedundantWrites(skip bool) Option {
+	return func(c *Client) error {
+		c.SetSkipRedundantWrites(skip)
+		return nil
+	}
+}
+
+// WithRestoreOnPower enables restoring colors on power on, see
+// Client.SetRestoreOnPower
+func WithRestoreOnPower(restore bool) Option {
+	return func(c *Client) error {
+		c.SetRestoreOnPower(restore)
+		return nil
+	}
+}
+
+// WithColorMemory enables remembering colors on power off, see
+// Client.SetColorMemory
+func WithColorMemory(memory bool) Option {
+	return func(c *Client) error {
+		c.SetColorMemory(memory)
+		return nil
+	}
+}
+
+// WithMinBrightness sets the brightness below which color changes are raised,
+// see Client.SetMinBrightness
+func WithMinBrightness(brightness uint16) Option {
+	return func(c *Client) error {
+		c.SetMinBrightness(brightness)
+		return nil
+	}
+}
+
+// WithPrefetchDeviceInfo enables requesting device information on discovery,
+// see Client.SetPrefetchDeviceInfo.  Unlike the setter, this applies to the
+// initial discovery.
+func WithPrefetchDeviceInfo(prefetch bool) Option {
+	return func(c *Client) error {
+		c.SetPrefetchDeviceInfo(prefetch)
+		return nil
+	}
+}
+
+// WithSpanHook sets the hook that starts a tracing span for each request, see
+// Client.SetSpanHook.  Unlike the setter, this applies to the initial
+// discovery.
+func WithSpanHook(hook common.SpanHook) Option {
+	return func(c *Client) error {
+		c.SetSpanHook(hook)
+		return nil
+	}
+}
+
+// WithSignalSampling enables sampling the WiFi signal strength of each device,
+// see Client.SetSignalSampling
+func WithSignalSampling(interval time.Duration, length int) Option {
+	return func(c *Client) error {
+		c.SetSignalSampling(interval, length)
+		return nil
+	}
+}
+
+// WithExpectedDeviceCount sets the number of devices expected to be present,
+// see Client.SetExpectedDeviceCount
+func WithExpectedDeviceCount(count int) Option {
+	return func(c *Client) error {
+		c.SetExpectedDeviceCount(count)
+		return nil
+	}
+}
+
+// WithTracer sets a writer to which every packet sent or received is logged,
+// see Client.SetTracer.  Unlike the setter, this traces the initial discovery.
+func WithTracer(w io.Writer) Option {
+	return func(c *Client) error {
+		c.SetTracer(w)
+		return nil
+	}
+}
+
+// WithDecodeErrorHandler sets the handler called with each received packet
+// that could not be decoded, see Client.OnDecodeError.  Unlike the setter, this
+// applies to responses to the initial discovery.
+func WithDecodeErrorHandler(handler common.DecodeErrorHandler) Option {
+	return func(c *Client) error {
+		c.OnDecodeError(handler)
+		return nil
+	}
+}
+
+// WithLogger assigns the logger, see SetLogger.  The logger is global to the
+// package, so this affects all clients.
+func WithLogger(logger common.Logger) Option {
+	return func(c *Client) error {
+		SetLogger(logger)
+		return nil
+	}
+}
+
+// WithPort sets the port that discovery requests are sent to, see
+// Client.SetPort.  Apply after WithBroadcastAddress or WithInterface, which
+// reset the port to 56700 unless specified.
+func WithPort(port int) Option {
+	return func(c *Client) error {
+		return c.protocol.SetPort(port)
+	}
+}
+
+// WithBroadcastAddress sets the address that discovery requests are sent to,
+// see Client.SetBroadcastAddress
+func WithBroadcastAddress(address string) Option {
+	return func(c *Client) error {
+		return c.protocol.SetBroadcastAddress(address)
+	}
+}
+
+// WithInterface sends discovery requests to the broadcast address of the first
+// IPv4 network on the named interface, for hosts with several interfaces where
+// the default broadcast does not reach the devices.  Returns
+// common.ErrInvalidArgument if the interface does not exist, or has no IPv4
+// network.
+func WithInterface(name string) Option {
+	return func(c *Client) error {
+		address, err := interfaceBroadcast(name)
+		if err != nil {
+			return err
+		}
+		return c.protocol.SetBroadcastAddress(address)
+	}
+}
+
+// WithSource sets the source identifier that requests are tagged with, which
+// devices echo back in responses so that they may be matched to the client,
+// see common.Protocol.SetSource.  A random source is chosen for each protocol
+// instance by default.  Setting a fixed source is useful for identifying the
+// client in packet captures, or where a firewall or intermediary filters on
+// the source.  Returns common.ErrInvalidArgument if the source is zero.
+func WithSource(source uint32) Option {
+	return func(c *Client) error {
+		return c.protocol.SetSource(source)
+	}
+}
+
+// interfaceBroadcast returns the broadcast address of the first IPv4 network
+// on the named interface
+func interfaceBroadcast(name string) (string, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		common.Log.Debugf("Unknown interface %s: %v", name, err)
+		return ``, common.ErrInvalidArgument
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return ``, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP.To4()
+		if ip == nil {
+			continue
+		}
+		mask := ipNet.Mask
+		if len(mask) == net.IPv6len {
+			mask = mask[12:]
+		}
+		broadcast := make(net.IP, net.IPv4len)
+		for i := range ip {
+			broadcast[i] = ip[i] | ^mask[i]
+		}
+		return broadcast.String(), nil
+	}
+	return ``, common.ErrInvalidArgument
+}
@@ -0,0 +1,22 @@
+// Package otelspan provides a common.SpanHook that records the requests of a
+// golifx client as OpenTelemetry spans.  The package is only built with the
+// `otel` build tag, so that the core library does not depend on OpenTelemetry:
+//
+//	go build -tags otel ./...
+//
+// The adapter is built and tested against OpenTelemetry v1.24.0, as pinned in
+// glide.yaml.
+//
+// Each request sent to a device is spanned from sending until its response,
+// acknowledgement or error, with the target device ID and message type as
+// attributes, and failures recorded as errors:
+//
+//	client, err := golifx.NewClient(&protocol.V2{Reliable: true},
+//		golifx.WithSpanHook(otelspan.New(ctx, nil)),
+//	)
+//
+// Requests sent by calls that take a context, eg Light.WaitUntilColor or
+// golifx.Client.Transition, are spanned as children of the span in that
+// context.  Other requests are children of the span in the context passed to
+// New, if any.
+package otelspan
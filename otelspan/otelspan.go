@@ -0,0 +1,76 @@
+//go:build otel
+
+package otelspan
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pdf/golifx/common"
+)
+
+// InstrumentationName is the name of the tracer obtained from the global
+// provider when no tracer is passed to New
+const InstrumentationName = `github.com/pdf/golifx`
+
+// Span attribute keys
+const (
+	AttributeDeviceID = `lifx.device.id`
+	AttributeMessage  = `lifx.message.type`
+	AttributeRequest  = `lifx.cloud.request`
+)
+
+// Hook starts OpenTelemetry spans for client requests, and implements
+// common.SpanHook
+type Hook struct {
+	ctx    context.Context
+	tracer trace.Tracer
+}
+
+// New returns a *Hook that starts spans with tracer, or with the tracer of the
+// global provider if nil.  Spans are children of the span in the context of
+// the call that sent the request, or otherwise of the span in ctx, if any.
+func New(ctx context.Context, tracer trace.Tracer) *Hook {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if tracer == nil {
+		tracer = otel.Tracer(InstrumentationName)
+	}
+	return &Hook{ctx: ctx, tracer: tracer}
+}
+
+// StartSpan starts a client span named name, with the attributes that are set,
+// as a child of the span in ctx, falling back to the context passed to New.
+// Returns the func that ends it, recording err if not nil.
+func (h *Hook) StartSpan(ctx context.Context, name string, attrs common.SpanAttributes) func(err error) {
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = h.ctx
+	}
+
+	var kv []attribute.KeyValue
+	if attrs.Request != `` {
+		kv = append(kv, attribute.String(AttributeRequest, attrs.Request))
+	} else {
+		kv = append(kv,
+			attribute.Int64(AttributeDeviceID, int64(attrs.DeviceID)),
+			attribute.Int(AttributeMessage, int(attrs.Message)),
+		)
+	}
+
+	_, span := h.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(kv...),
+	)
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
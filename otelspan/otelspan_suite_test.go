@@ -0,0 +1,15 @@
+//go:build otel
+
+package otelspan_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestOtelspan(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Otelspan Suite")
+}
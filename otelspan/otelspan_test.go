@@ -0,0 +1,82 @@
+//go:build otel
+
+package otelspan_test
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pdf/golifx/common"
+	. "github.com/pdf/golifx/otelspan"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hook", func() {
+	var (
+		recorder *tracetest.SpanRecorder
+		provider *sdktrace.TracerProvider
+		hook     *Hook
+	)
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		hook = New(context.Background(), provider.Tracer(`test`))
+	})
+
+	It("should implement common.SpanHook", func() {
+		var _ common.SpanHook = hook
+	})
+
+	It("should record device requests", func() {
+		hook.StartSpan(context.Background(), common.SpanSend, common.SpanAttributes{DeviceID: 1, Message: 102})(nil)
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name()).To(Equal(common.SpanSend))
+		Expect(spans[0].Attributes()).To(ConsistOf(
+			attribute.Int64(AttributeDeviceID, 1),
+			attribute.Int(AttributeMessage, 102),
+		))
+		Expect(spans[0].Status().Code).NotTo(Equal(codes.Error))
+	})
+
+	It("should record errors", func() {
+		hook.StartSpan(context.Background(), common.SpanSend, common.SpanAttributes{DeviceID: 1, Message: 101})(common.ErrTimeout)
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status().Code).To(Equal(codes.Error))
+		Expect(spans[0].Status().Description).To(Equal(common.ErrTimeout.Error()))
+		Expect(spans[0].Events()).To(HaveLen(1))
+	})
+
+	It("should parent spans to the span in the context of the call", func() {
+		ctx, parent := provider.Tracer(`test`).Start(context.Background(), `parent`)
+		hook.StartSpan(ctx, common.SpanSend, common.SpanAttributes{DeviceID: 1, Message: 101})(nil)
+		parent.End()
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name()).To(Equal(common.SpanSend))
+		Expect(spans[0].Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(spans[0].SpanContext().TraceID()).To(Equal(parent.SpanContext().TraceID()))
+	})
+
+	It("should parent spans to the span in the context passed to New otherwise", func() {
+		ctx, parent := provider.Tracer(`test`).Start(context.Background(), `parent`)
+		hook = New(ctx, provider.Tracer(`test`))
+		hook.StartSpan(context.Background(), common.SpanSend, common.SpanAttributes{DeviceID: 1, Message: 101})(nil)
+		parent.End()
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+	})
+})
@@ -1,7 +1,10 @@
 package protocol
 
 import (
+	"math/rand"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,17 +14,28 @@ import (
 	"github.com/pdf/golifx/protocol/v2/shared"
 )
 
+// DefaultReadTimeout is the default V2.ReadTimeout
+const DefaultReadTimeout = time.Second
+
 // V2 implements the LIFX LAN protocol version 2.
 type V2 struct {
 	// Port determines UDP port for this protocol instance
 	Port int
 	// Reliable enables reliable comms, requests ACKs for all operations to
 	// ensure they're delivered (recommended)
-	Reliable      bool
+	Reliable bool
+	// ReadTimeout is the deadline for each read from the socket, after which
+	// the receive loop wakes to check for shutdown, defaults to
+	// DefaultReadTimeout.  This is independent of the client timeout for
+	// responses.
+	ReadTimeout   time.Duration
 	initialized   bool
+	broadcastPort int
+	broadcastIP   net.IP
 	socket        *net.UDPConn
 	timeout       *time.Duration
 	retryInterval *time.Duration
+	client        common.ClientConfig
 	broadcast     *device.Light
 	lastDiscovery time.Time
 	deviceQueue   chan device.GenericDevice
@@ -31,6 +45,15 @@ type V2 struct {
 	locations     map[string]*device.Location
 	groups        map[string]*device.Group
 	quitChan      chan struct{}
+
+	// extraAddrs are additional broadcast domains, each with a broadcast
+	// device in extraBroadcasts once initialized
+	extraAddrs      []*net.UDPAddr
+	extraBroadcasts []*device.Light
+	subnets         []*net.IPNet
+	// source tags requests from this instance, and identifies responses to
+	// it, see SetSource
+	source uint32
 	sync.RWMutex
 }
 
@@ -80,14 +103,24 @@ func (p *V2) init() error {
 		return err
 	}
 	p.socket = socket
+	for p.source == 0 {
+		p.source = rand.Uint32()
+	}
+	if p.broadcastPort == 0 {
+		p.broadcastPort = shared.DefaultPort
+	}
+	if p.broadcastIP == nil {
+		p.broadcastIP = net.IPv4bcast
+	}
 	addr := net.UDPAddr{
-		IP:   net.IPv4(255, 255, 255, 255),
-		Port: shared.DefaultPort,
+		IP:   p.broadcastIP,
+		Port: p.broadcastPort,
 	}
-	broadcastDev, err := device.New(&addr, p.socket, p.timeout, p.retryInterval, false, nil)
+	broadcastDev, err := device.New(&addr, p.socket, p.timeout, p.retryInterval, false, p.client, nil)
 	if err != nil {
 		return err
 	}
+	broadcastDev.SetSource(p.source)
 	p.broadcast = &device.Light{Device: broadcastDev}
 	broadcastSub, err := p.broadcast.NewSubscription()
 	if err != nil {
@@ -100,6 +133,14 @@ func (p *V2) init() error {
 	p.subscriptions = make(map[string]*common.Subscription)
 	p.quitChan = make(chan struct{})
 	go p.broadcastLimiter(broadcastSub.Events())
+	for _, addr := range p.extraAddrs {
+		if err := p.addBroadcast(addr); err != nil {
+			return err
+		}
+	}
+	if p.ReadTimeout <= 0 {
+		p.ReadTimeout = DefaultReadTimeout
+	}
 	go p.dispatcher()
 	go p.addDevices()
 	p.initialized = true
@@ -139,6 +180,204 @@ func (p *V2) SetRetryInterval(retryInterval *time.Duration) {
 	p.Unlock()
 }
 
+// SetClient attaches the client to the protocol
+func (p *V2) SetClient(client common.ClientConfig) {
+	p.Lock()
+	p.client = client
+	p.Unlock()
+}
+
+// SetPort sets the port that discovery requests are broadcast to, defaults to
+// 56700.  Devices report the port they accept commands on during discovery, so
+// this is the only port that must be known in advance.  Real LIFX devices only
+// respond to discovery on 56700, so changing the port is only useful for
+// communicating with emulated devices.
+func (p *V2) SetPort(port int) error {
+	if port <= 0 || port > 65535 {
+		return common.ErrInvalidArgument
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.broadcastPort = port
+	p.updateBroadcastAddress()
+	return nil
+}
+
+// SetSource sets the source identifier that requests are tagged with, and that
+// identifies responses to this instance, defaulting to a random value chosen
+// for each instance.  Known devices are updated, but responses to requests in
+// flight are then treated as unsolicited, so the source is best set before
+// discovery.  Returns common.ErrInvalidArgument if the source is zero, which
+// requests that devices broadcast their responses.
+func (p *V2) SetSource(source uint32) error {
+	if source == 0 {
+		return common.ErrInvalidArgument
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.source = source
+	if !p.initialized {
+		return nil
+	}
+	p.broadcast.SetSource(source)
+	for _, dev := range p.extraBroadcasts {
+		dev.SetSource(source)
+	}
+	for _, dev := range p.devices {
+		dev.SetSource(source)
+	}
+	return nil
+}
+
+// getSource returns the source identifier of this instance
+func (p *V2) getSource() uint32 {
+	p.RLock()
+	defer p.RUnlock()
+	return p.source
+}
+
+// SetBroadcastAddress sets the address that discovery requests are broadcast
+// to, in host:port form, defaulting to 255.255.255.255:56700.  The port
+// defaults to 56700 if omitted.  Setting the subnet broadcast address (eg
+// 192.168.1.255) explicitly is useful where broadcasts to 255.255.255.255 are
+// not routed to the devices, such as from Docker bridge networks.  Returns
+// common.ErrInvalidArgument if the address is not a valid IPv4 address.
+func (p *V2) SetBroadcastAddress(address string) error {
+	addr, err := parseAddress(address)
+	if err != nil {
+		return err
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.broadcastIP = addr.IP
+	p.broadcastPort = addr.Port
+	p.updateBroadcastAddress()
+	return nil
+}
+
+// AddBroadcastAddress adds a further address that discovery requests are
+// broadcast to, alongside the address set by SetBroadcastAddress, so that one
+// client may discover devices in several broadcast domains, such as VLANs
+// routed to the host.  The address is either a broadcast address in host:port
+// form as per SetBroadcastAddress, or a network in CIDR form (eg
+// 10.0.20.0/24), whose broadcast address is used.  Devices record the network
+// they were discovered in (see Device.Subnet), which is known for networks
+// given in CIDR form, or those the host has an interface on.  Returns
+// common.ErrInvalidArgument if the address is not a valid IPv4 address or
+// network.
+func (p *V2) AddBroadcastAddress(address string) error {
+	var (
+		addr   *net.UDPAddr
+		subnet *net.IPNet
+		err    error
+	)
+	if strings.Contains(address, `/`) {
+		addr, subnet, err = parseBroadcastNetwork(address)
+	} else {
+		addr, err = parseAddress(address)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.extraAddrs = append(p.extraAddrs, addr)
+	if subnet != nil {
+		p.subnets = append(p.subnets, subnet)
+	}
+	if p.initialized {
+		return p.addBroadcast(addr)
+	}
+	return nil
+}
+
+// addBroadcast creates a broadcast device for addr.  The caller must hold the
+// lock.
+func (p *V2) addBroadcast(addr *net.UDPAddr) error {
+	dev, err := device.New(addr, p.socket, p.timeout, p.retryInterval, false, p.client, nil)
+	if err != nil {
+		return err
+	}
+	dev.SetSource(p.source)
+	broadcast := &device.Light{Device: dev}
+	sub, err := broadcast.NewSubscription()
+	if err != nil {
+		return err
+	}
+	p.extraBroadcasts = append(p.extraBroadcasts, broadcast)
+	go p.broadcastLimiter(sub.Events())
+	return nil
+}
+
+// subnetFor returns the network containing ip, from those given in CIDR form
+// to AddBroadcastAddress, or the interfaces of the host, or nil if unknown
+func (p *V2) subnetFor(ip net.IP) *net.IPNet {
+	p.RLock()
+	subnets := p.subnets
+	p.RUnlock()
+	for _, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return subnet
+		}
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.Contains(ip) {
+			return &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask}
+		}
+	}
+	return nil
+}
+
+// updateBroadcastAddress applies the configured broadcast address to the
+// broadcast device, if initialized.  The caller must hold the lock.
+func (p *V2) updateBroadcastAddress() {
+	if p.broadcast == nil {
+		return
+	}
+	ip := p.broadcastIP
+	if ip == nil {
+		ip = net.IPv4bcast
+	}
+	p.broadcast.SetAddress(&net.UDPAddr{
+		IP:   ip,
+		Port: p.broadcastPort,
+	})
+}
+
+// parseAddress parses an IPv4 address in host:port form, the port defaulting to
+// 56700 if omitted
+func parseAddress(address string) (*net.UDPAddr, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, strconv.Itoa(shared.DefaultPort))
+	}
+	addr, err := net.ResolveUDPAddr(`udp4`, address)
+	if err != nil || addr.IP == nil || addr.IP.To4() == nil || addr.Port == 0 {
+		return nil, common.ErrInvalidArgument
+	}
+	return addr, nil
+}
+
+// parseBroadcastNetwork returns the broadcast address, on the default port, of
+// the IPv4 network in CIDR form
+func parseBroadcastNetwork(network string) (*net.UDPAddr, *net.IPNet, error) {
+	_, subnet, err := net.ParseCIDR(network)
+	if err != nil || subnet.IP.To4() == nil {
+		return nil, nil, common.ErrInvalidArgument
+	}
+	ip := subnet.IP.To4()
+	bcast := make(net.IP, net.IPv4len)
+	for i := range bcast {
+		bcast[i] = ip[i] | ^subnet.Mask[len(subnet.Mask)-net.IPv4len+i]
+	}
+	return &net.UDPAddr{IP: bcast, Port: shared.DefaultPort}, subnet, nil
+}
+
 // Discover initiates device discovery, this may be a noop in some future
 // protocol versions.  This is called immediately when the client connects to
 // the protocol
@@ -147,57 +386,39 @@ func (p *V2) Discover() error {
 		return err
 	}
 	if p.lastDiscovery.After(time.Time{}) {
-		var extinct []device.GenericDevice
+		var unresponsive []device.GenericDevice
 		p.RLock()
 		for _, dev := range p.devices {
 			// If the device has not been seen in twice the time since the last
-			// discovery, mark it as extinct
+			// discovery, mark it as offline
 			if dev.Seen().Before(time.Now().Add(time.Since(p.lastDiscovery) * -2)) {
-				extinct = append(extinct, dev)
+				unresponsive = append(unresponsive, dev)
 			}
 		}
 		p.RUnlock()
-		// Remove extinct devices
-		for _, dev := range extinct {
-			p.removeDevice(dev.ID())
-
-			locationID := dev.CachedLocation()
-			location, err := p.getLocation(locationID)
-			if err == nil {
-				if err = location.RemoveDevice(dev); err != nil {
-					common.Log.Warnf("Failed removing extinct device '%d' from location (%s): %v", dev.ID(), locationID, err)
-				}
-				if len(location.Devices()) == 0 {
-					p.removeLocation(location.ID())
-					if err = p.publish(common.EventExpiredLocation{Location: location}); err != nil {
-						common.Log.Warnf("Failed publishing expired event for location '%s'", locationID)
-					}
-				}
-			}
-
-			groupID := dev.CachedGroup()
-			group, err := p.getGroup(groupID)
-			if err == nil {
-				if err = group.RemoveDevice(dev); err != nil {
-					common.Log.Warnf("Failed removing extinct device '%d' from group (%s): %v", dev.ID(), groupID, err)
-				}
-				if len(group.Devices()) == 0 {
-					p.removeGroup(group.ID())
-					if err = p.publish(common.EventExpiredGroup{Group: group}); err != nil {
-						common.Log.Warnf("Failed publishing expired event for group '%s'", groupID)
-					}
-				}
+		// Devices are retained while offline, so that consumers see a stable
+		// set of devices across transient outages
+		for _, dev := range unresponsive {
+			if !dev.SetOnline(false) {
+				continue
 			}
-
-			err = p.publish(common.EventExpiredDevice{Device: dev})
-			if err != nil {
-				common.Log.Warnf("Failed removing extinct device '%d' from client: %v", dev.ID(), err)
+			common.Log.Debugf("Device is offline: %d", dev.ID())
+			if err := p.publish(common.EventOfflineDevice{Device: dev}); err != nil {
+				common.Log.Warnf("Failed publishing offline event for device '%d': %v", dev.ID(), err)
 			}
 		}
 	}
 	if err := p.broadcast.Discover(); err != nil {
 		return err
 	}
+	p.RLock()
+	extra := p.extraBroadcasts
+	p.RUnlock()
+	for _, broadcast := range extra {
+		if err := broadcast.Discover(); err != nil {
+			return err
+		}
+	}
 	p.Lock()
 	p.lastDiscovery = time.Now()
 	p.Unlock()
@@ -221,10 +442,13 @@ func (p *V2) SetPower(state bool) error {
 // SetPowerDuration sets the power state globally, on all devices, transitioning
 // over the specified duration
 func (p *V2) SetPowerDuration(state bool, duration time.Duration) error {
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
 	p.RLock()
 	defer p.RUnlock()
 	for _, dev := range p.devices {
-		l, ok := dev.(*device.Light)
+		l, ok := dev.(device.GenericLight)
 		if !ok {
 			continue
 		}
@@ -236,13 +460,40 @@ func (p *V2) SetPowerDuration(state bool, duration time.Duration) error {
 	return nil
 }
 
+// BroadcastWaveform sends the waveform in a single broadcast packet to each
+// broadcast address, so that all lights run the waveform in unison, rather than
+// one after another as when sent to each light.  Broadcasts are not
+// acknowledged, so delivery is not guaranteed.  Returns
+// common.ErrInvalidArgument if the waveform is invalid (see
+// common.Waveform.Validate).
+func (p *V2) BroadcastWaveform(w common.Waveform) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	if err := p.init(); err != nil {
+		return err
+	}
+	p.RLock()
+	broadcasts := append([]*device.Light{p.broadcast}, p.extraBroadcasts...)
+	p.RUnlock()
+	for _, broadcast := range broadcasts {
+		if err := broadcast.SetWaveform(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetColor changes the color globally, on all lights, transitioning over the
 // specified duration
 func (p *V2) SetColor(color common.Color, duration time.Duration) error {
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
 	p.RLock()
 	defer p.RUnlock()
 	for _, dev := range p.devices {
-		l, ok := dev.(*device.Light)
+		l, ok := dev.(device.GenericLight)
 		if !ok {
 			continue
 		}
@@ -287,6 +538,11 @@ func (p *V2) Close() error {
 	if err := p.broadcast.Close(); err != nil {
 		return err
 	}
+	for _, broadcast := range p.extraBroadcasts {
+		if err := broadcast.Close(); err != nil {
+			return err
+		}
+	}
 
 	select {
 	case <-p.quitChan:
@@ -296,6 +552,10 @@ func (p *V2) Close() error {
 		close(p.quitChan)
 		p.wg.Wait()
 		close(p.deviceQueue)
+		// Unblocks any read in progress by the dispatcher
+		if err := p.socket.Close(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -326,33 +586,48 @@ func (p *V2) broadcastLimiter(events <-chan interface{}) {
 	}
 }
 
+// dispatcher receives packets until the protocol is closed.  The socket and
+// devices are closed by Close.
 func (p *V2) dispatcher() {
 	for {
 		select {
 		case <-p.quitChan:
-			p.Lock()
-			for _, dev := range p.devices {
-				if err := dev.Close(); err != nil {
-					common.Log.Errorf("Failed closing device '%d': %v", dev.ID(), err)
-				}
-			}
-			if err := p.socket.Close(); err != nil {
-				common.Log.Errorf("Failed closing socket: %v", err)
-			}
-			p.Unlock()
 			return
 		default:
 			buf := make([]byte, 1500)
+			// Wake periodically on a silent network to check for shutdown
+			if err := p.socket.SetReadDeadline(time.Now().Add(p.ReadTimeout)); err != nil {
+				common.Log.Errorf("Failed setting socket read deadline: %v", err)
+			}
 			n, addr, err := p.socket.ReadFromUDP(buf)
 			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					continue
+				}
+				select {
+				case <-p.quitChan:
+					// The socket was closed by Close
+					return
+				default:
+				}
 				common.Log.Errorf("Failed reading from socket: %v", err)
 				continue
 			}
 			pkt, err := packet.Decode(buf[:n])
 			if err != nil {
-				common.Log.Errorf("Failed decoding packet: %v", err)
+				common.Log.Warnf("Dropping packet from %v that could not be decoded: %v", addr, err)
+				if p.client != nil {
+					if handler := p.client.GetDecodeErrorHandler(); handler != nil {
+						handler(buf[:n], err)
+					}
+				}
 				continue
 			}
+			if p.client != nil {
+				if w := p.client.GetTracer(); w != nil {
+					pkt.Trace(w, packet.TraceReceive, addr)
+				}
+			}
 			go p.process(pkt, addr)
 		}
 	}
@@ -446,6 +721,54 @@ func (p *V2) GetDevice(id uint64) (common.Device, error) {
 	return p.getDevice(id)
 }
 
+// DeviceFromCache returns a light with the known id, that sends requests
+// directly to address, in host:port form with the port defaulting to 56700,
+// without waiting for it to be discovered, see common.Protocol.  The light is
+// classified by product if it is later discovered.  Returns
+// common.ErrInvalidArgument if the address is not a valid IPv4 address.
+func (p *V2) DeviceFromCache(id uint64, address string) (common.Light, error) {
+	addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.init(); err != nil {
+		return nil, err
+	}
+	if dev, err := p.getDevice(id); err == nil {
+		if light, ok := dev.(common.Light); ok {
+			return light, nil
+		}
+		return nil, common.ErrDeviceInvalidType
+	}
+
+	light := &device.Light{Device: device.NewFromAddress(id, addr, p.socket, p.timeout, p.retryInterval, p.Reliable, p.client)}
+	light.SetSubnet(p.subnetFor(addr.IP))
+	light.SetSource(p.getSource())
+	p.Lock()
+	if dev, ok := p.devices[id]; ok {
+		// Discovered in the meantime
+		p.Unlock()
+		if light, ok := dev.(common.Light); ok {
+			return light, nil
+		}
+		return nil, common.ErrDeviceInvalidType
+	}
+	p.devices[id] = light
+	p.Unlock()
+
+	sub, err := light.NewSubscription()
+	if err != nil {
+		common.Log.Warnf("Error obtaining subscription from %d", id)
+	} else {
+		go p.broadcastLimiter(sub.Events())
+	}
+	if err := p.publish(common.EventNewDevice{Device: light}); err != nil {
+		common.Log.Errorf("Error adding cached device to client: %v", err)
+	}
+
+	return light, nil
+}
+
 func (p *V2) getDevice(id uint64) (device.GenericDevice, error) {
 	p.RLock()
 	dev, ok := p.devices[id]
@@ -465,11 +788,17 @@ func (p *V2) process(pkt *packet.Packet, addr *net.UDPAddr) {
 		dev, err := p.getDevice(pkt.Target)
 		if err == nil {
 			dev.SetSeen(time.Now())
+			if dev.SetOnline(true) {
+				common.Log.Debugf("Device is online: %d", dev.ID())
+				if err := p.publish(common.EventOnlineDevice{Device: dev}); err != nil {
+					common.Log.Warnf("Failed publishing online event for device '%d': %v", dev.ID(), err)
+				}
+			}
 		}
 	}
 
 	// Broadcast packets, or packets generated by other clients
-	if pkt.GetSource() != packet.ClientID {
+	if pkt.GetSource() != p.getSource() {
 		switch pkt.GetType() {
 		case device.StatePower:
 			dev, err := p.getDevice(pkt.GetTarget())
@@ -490,7 +819,7 @@ func (p *V2) process(pkt *packet.Packet, addr *net.UDPAddr) {
 			}
 			err = dev.SetStateLabel(pkt)
 			if err != nil {
-				common.Log.Debugf("Failed setting StatePower on device: source %v, type %v, sequence %v, target %v, tagged %v, resRequired %v, ackRequired %v", pkt.GetSource(), pkt.GetType(), pkt.GetSequence(), pkt.GetTarget(), pkt.GetTagged(), pkt.GetResRequired(), pkt.GetAckRequired())
+				common.Log.Debugf("Failed setting StateLabel on device: source %v, type %v, sequence %v, target %v, tagged %v, resRequired %v, ackRequired %v", pkt.GetSource(), pkt.GetType(), pkt.GetSequence(), pkt.GetTarget(), pkt.GetTagged(), pkt.GetResRequired(), pkt.GetAckRequired())
 				return
 			}
 		case device.State:
@@ -499,7 +828,7 @@ func (p *V2) process(pkt *packet.Packet, addr *net.UDPAddr) {
 				common.Log.Debugf("Skipping State packet for unknown device: source %d, type %d, sequence %d, target %d, tagged %v, resRequired %v, ackRequired %v", pkt.GetSource(), pkt.GetType(), pkt.GetSequence(), pkt.GetTarget(), pkt.GetTagged(), pkt.GetResRequired(), pkt.GetAckRequired())
 				return
 			}
-			light, ok := dev.(*device.Light)
+			light, ok := dev.(device.GenericLight)
 			if !ok {
 				common.Log.Debugf("Skipping State packet for non-light device: source %d, type %d, sequence %d, target %d, tagged %v, resRequired %v, ackRequired %v", pkt.GetSource(), pkt.GetType(), pkt.GetSequence(), pkt.GetTarget(), pkt.GetTagged(), pkt.GetResRequired(), pkt.GetAckRequired())
 				return
@@ -527,13 +856,27 @@ func (p *V2) process(pkt *packet.Packet, addr *net.UDPAddr) {
 	switch pkt.GetType() {
 	case device.StateService:
 		dev, err := p.getDevice(pkt.Target)
+		if err == nil {
+			previous := dev.Address().String()
+			if err := dev.SetStateService(pkt, addr); err != nil {
+				common.Log.Debugf("Failed setting StateService on device %d: %v", dev.ID(), err)
+				return
+			}
+			if current := dev.Address().String(); current != previous {
+				// Known device that has moved, eg after a DHCP lease change
+				common.Log.Debugf("Device %d moved from %v to %v", dev.ID(), previous, current)
+				dev.SetSubnet(p.subnetFor(addr.IP))
+			}
+		}
 		if err != nil {
 			// New device
-			dev, err = device.New(addr, p.socket, p.timeout, p.retryInterval, p.Reliable, pkt)
+			dev, err = device.New(addr, p.socket, p.timeout, p.retryInterval, p.Reliable, p.client, pkt)
 			if err != nil {
 				common.Log.Errorf("Failed creating device: %v", err)
 				return
 			}
+			dev.SetSubnet(p.subnetFor(addr.IP))
+			dev.SetSource(p.getSource())
 		}
 		p.wg.Add(1)
 		p.deviceQueue <- dev
@@ -576,12 +919,6 @@ func (p *V2) addLocation(pkt *packet.Packet) {
 	}
 }
 
-func (p *V2) removeLocation(id string) {
-	p.Lock()
-	delete(p.locations, id)
-	p.Unlock()
-}
-
 func (p *V2) addGroup(pkt *packet.Packet) {
 	g, err := device.NewGroup(pkt)
 	if err != nil {
@@ -605,21 +942,29 @@ func (p *V2) addGroup(pkt *packet.Packet) {
 	}
 }
 
-func (p *V2) removeGroup(id string) {
-	p.Lock()
-	delete(p.groups, id)
-	p.Unlock()
-}
-
 func (p *V2) addDevices() {
 	for dev := range p.deviceQueue {
 		p.addDevice(dev)
 		// Perform state discovery on lights
-		if l, ok := dev.(*device.Light); ok {
+		if l, ok := dev.(device.GenericLight); ok {
 			if err := l.Get(); err != nil {
 				common.Log.Debugf("Failed getting light state: %v", err)
 			}
 		}
+		if p.client != nil && p.client.GetPrefetchDeviceInfo() {
+			p.prefetchDeviceInfo(dev)
+		}
+	}
+}
+
+// prefetchDeviceInfo requests the product and firmware information of dev, so
+// that it is cached before it is needed
+func (p *V2) prefetchDeviceInfo(dev device.GenericDevice) {
+	if _, err := dev.GetProductInfo(); err != nil && err != common.ErrNotFound {
+		common.Log.Debugf("Failed prefetching product info for %d: %v", dev.ID(), err)
+	}
+	if _, err := dev.GetFirmwareVersion(); err != nil {
+		common.Log.Debugf("Failed prefetching firmware version for %d: %v", dev.ID(), err)
 	}
 }
 
@@ -665,12 +1010,6 @@ func (p *V2) addDevice(dev device.GenericDevice) {
 	common.Log.Debugf("Added device to client: %d", dev.ID())
 }
 
-func (p *V2) removeDevice(id uint64) {
-	p.Lock()
-	delete(p.devices, id)
-	p.Unlock()
-}
-
 func (p *V2) updateLocationGroup(dev device.GenericDevice) {
 	groupID, err := dev.GetGroup()
 	if err != nil {
@@ -686,6 +1025,7 @@ func (p *V2) updateLocationGroup(dev device.GenericDevice) {
 	if err = group.AddDevice(dev); err != nil {
 		common.Log.Debugf("Error adding device to group: %v", err)
 	}
+	p.removeFromOtherGroups(dev, groupID)
 
 	locationID, err := dev.GetLocation()
 	if err != nil {
@@ -703,41 +1043,117 @@ func (p *V2) updateLocationGroup(dev device.GenericDevice) {
 	if err = location.AddDevice(dev); err != nil {
 		common.Log.Debugf("Error adding device to location: %v", err)
 	}
+	p.removeFromOtherLocations(dev, locationID)
+}
+
+// removeFromOtherGroups removes dev from any group other than groupID, for
+// when the device has been moved to another group
+func (p *V2) removeFromOtherGroups(dev device.GenericDevice, groupID string) {
+	p.RLock()
+	groups := make([]*device.Group, 0, len(p.groups))
+	for id, group := range p.groups {
+		if id != groupID {
+			groups = append(groups, group)
+		}
+	}
+	p.RUnlock()
+	for _, group := range groups {
+		if err := group.RemoveDevice(dev); err == nil {
+			common.Log.Debugf("Removed device from previous group (%s): %v", group.ID(), dev.ID())
+		}
+	}
+}
+
+// removeFromOtherLocations removes dev from any location other than
+// locationID, for when the device has been moved to another location
+func (p *V2) removeFromOtherLocations(dev device.GenericDevice, locationID string) {
+	p.RLock()
+	locations := make([]*device.Location, 0, len(p.locations))
+	for id, location := range p.locations {
+		if id != locationID {
+			locations = append(locations, location)
+		}
+	}
+	p.RUnlock()
+	for _, location := range locations {
+		if err := location.RemoveDevice(dev); err == nil {
+			common.Log.Debugf("Removed device from previous location (%s): %v", location.ID(), dev.ID())
+		}
+	}
 }
 
-// classifyDevice either constructs a device.Light from the passed dev, or returns
-// the dev untouched
+// classifyDevice constructs a device.Light, device.MultiZoneLight,
+// device.TileLight, device.HevLight or device.RelayDevice from the passed dev according to its
+// entry in the product table, or returns the dev untouched if the product is
+// unknown.  If the product can not be determined, eg because the device did not
+// respond, the dev is treated as a plain device.Light, as it is still
+// reachable for commands, and remains provisional so that classification is
+// retried when the device is next discovered.
 func (p *V2) classifyDevice(dev device.GenericDevice) device.GenericDevice {
 	common.Log.Debugf("Attempting to determine device type for: %d", dev.ID())
-	vendor, err := dev.GetHardwareVendor()
-	if err != nil {
-		common.Log.Errorf("Error retrieving device hardware vendor: %v", err)
+	var d *device.Device
+	switch dev := dev.(type) {
+	case *device.Device:
+		d = dev
+	case *device.Light:
+		// A light from a previous failed classification
+		d = dev.Device
+	default:
+		dev.SetProvisional(false)
 		return dev
 	}
-	product, err := dev.GetHardwareProduct()
-	if err != nil {
-		common.Log.Errorf("Error retrieving device hardware product: %v", err)
+
+	info, err := dev.GetProductInfo()
+	if err == common.ErrNotFound {
+		common.Log.Debugf("Unknown product for device %d: %+v", dev.ID(), info)
+		dev.SetProvisional(false)
 		return dev
 	}
+	if err != nil {
+		common.Log.Warnf("Error retrieving device product info, treating %d as a light until retried: %v", dev.ID(), err)
+		if _, ok := dev.(*device.Light); ok {
+			return dev
+		}
+		l := &device.Light{Device: d}
+		p.Lock()
+		p.devices[l.ID()] = l
+		p.Unlock()
+		return l
+	}
 
 	defer dev.SetProvisional(false)
 
-	switch vendor {
-	case device.VendorLifx:
-		switch product {
-		case device.ProductLifxOriginal1000, device.ProductLifxColor650, device.ProductLifxWhite800LowVoltage, device.ProductLifxWhite800HighVoltage, device.ProductLifxWhite900BR30, device.ProductLifxColor1000BR30, device.ProductLifxColor1000:
-			p.Lock()
-			d := dev.(*device.Device)
-			d.Lock()
-			l := &device.Light{Device: d}
-			common.Log.Debugf("Device is a light: %v", l.ID())
-			// Replace the known dev with our constructed light
-			p.devices[l.ID()] = l
-			d.Unlock()
-			p.Unlock()
-			return l
-		}
+	p.Lock()
+	defer p.Unlock()
+	d.Lock()
+	defer d.Unlock()
+
+	// Keep the state of a light from a previous failed classification
+	light, ok := dev.(*device.Light)
+	if !ok {
+		light = &device.Light{Device: d}
+	}
+
+	var l device.GenericDevice
+	if info.MultiZone {
+		l = &device.MultiZoneLight{Light: light}
+		common.Log.Debugf("Device is a multizone light (%s): %v", info.Name, l.ID())
+	} else if info.Matrix {
+		l = &device.TileLight{Light: light}
+		common.Log.Debugf("Device is a tile light (%s): %v", info.Name, l.ID())
+	} else if info.HEV {
+		l = &device.HevLight{Light: light}
+		common.Log.Debugf("Device is a HEV light (%s): %v", info.Name, l.ID())
+	} else if info.Relays {
+		l = &device.RelayDevice{Device: d}
+		common.Log.Debugf("Device is a relay device (%s): %v", info.Name, l.ID())
+	} else {
+		// All other products in the table are lights
+		l = light
+		common.Log.Debugf("Device is a light (%s): %v", info.Name, l.ID())
 	}
+	// Replace the known dev with our constructed light
+	p.devices[l.ID()] = l
 
-	return dev
+	return l
 }
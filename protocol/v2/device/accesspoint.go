@@ -0,0 +1,56 @@
+package device
+
+import (
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/protocol/v2/packet"
+	"github.com/pdf/golifx/protocol/v2/shared"
+)
+
+// Access point messages are from the legacy v1 protocol, and are only answered
+// by older firmware
+const (
+	GetAccessPoints  shared.Message = 304
+	StateAccessPoint shared.Message = 306
+)
+
+type stateAccessPoint struct {
+	Interface uint8
+	SSID      [32]byte
+	Security  uint8
+	Strength  uint16 `struc:"little"`
+	Channel   uint16 `struc:"little"`
+}
+
+// GetAccessPoint requests the WiFi access point the light is associated with,
+// returning the first access point reported.  Returns common.ErrNotSupported
+// if the light reports the request as unhandled.
+func (l *Light) GetAccessPoint() (common.AccessPoint, error) {
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(GetAccessPoints)
+	req, err := l.Send(pkt, l.reliable, true)
+	if err != nil {
+		return common.AccessPoint{}, err
+	}
+
+	common.Log.Debugf("Waiting for access point (%d)", l.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return common.AccessPoint{}, pktResponse.Error
+	}
+	if pktResponse.Result.GetType() != StateAccessPoint {
+		common.Log.Debugf("Access point not supported by %d", l.id)
+		return common.AccessPoint{}, common.ErrNotSupported
+	}
+
+	s := &stateAccessPoint{}
+	if err := pktResponse.Result.DecodePayload(s); err != nil {
+		return common.AccessPoint{}, err
+	}
+
+	return common.AccessPoint{
+		SSID:     decodeString(s.SSID[:]),
+		Security: common.WifiSecurity(s.Security),
+		Strength: s.Strength,
+		Channel:  s.Channel,
+	}, nil
+}
@@ -5,6 +5,8 @@
 package device
 
 import (
+	"context"
+	"encoding/binary"
 	"fmt"
 	"math"
 	"net"
@@ -39,8 +41,10 @@ const (
 	StateInfo         shared.Message = 35
 	Acknowledgement   shared.Message = 45
 	GetLocation       shared.Message = 48
+	SetLocation       shared.Message = 49
 	StateLocation     shared.Message = 50
 	GetGroup          shared.Message = 51
+	SetGroup          shared.Message = 52
 	StateGroup        shared.Message = 53
 	EchoRequest       shared.Message = 58
 	EchoResponse      shared.Message = 59
@@ -54,12 +58,21 @@ const (
 	ProductLifxWhite900BR30        uint32 = 18
 	ProductLifxColor1000BR30       uint32 = 20
 	ProductLifxColor1000           uint32 = 22
+	ProductLifxZ                   uint32 = 31
+	ProductLifxZ2                  uint32 = 32
+	ProductLifxBeam                uint32 = 38
 )
 
 type response struct {
 	ch   packet.Chan
 	done doneChan
 	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// finish closes the done channel, which both the requestor and Close may do
+func (r *response) finish() {
+	r.once.Do(func() { close(r.done) })
 }
 
 type doneChan chan struct{}
@@ -69,10 +82,13 @@ type Device struct {
 	id                    uint64
 	address               *net.UDPAddr
 	power                 uint16
+	powerUpdated          time.Time
 	label                 string
+	labelStale            bool
 	hardwareVersion       stateVersion
 	firmwareVersion       uint32
 	firmwareVersionString string
+	firmwareStale         bool
 	provisional           bool
 
 	locationID string
@@ -86,9 +102,16 @@ type Device struct {
 	quitChan      chan struct{}
 	timeout       *time.Duration
 	retryInterval *time.Duration
+	client        common.ClientConfig
 	limiter       *time.Timer
+	writeQueue    chan struct{}
 	seen          time.Time
+	offline       bool
+	signalHistory []common.SignalSample
 	reliable      bool
+	services      []common.Service
+	subnet        *net.IPNet
+	source        uint32
 	sync.RWMutex
 }
 
@@ -117,6 +140,17 @@ type stateHostFirmware struct {
 	Version  uint32 `struc:"little"`
 }
 
+type stateWifiInfo struct {
+	Signal   float32 `struc:"little"`
+	Tx       uint32  `struc:"little"`
+	Rx       uint32  `struc:"little"`
+	Reserved int16   `struc:"little"`
+}
+
+type payloadEcho struct {
+	Payload [64]byte `struc:"little"`
+}
+
 type payloadPower struct {
 	Level uint16 `struc:"little"`
 }
@@ -129,14 +163,16 @@ func (f *stateHostFirmware) String() string {
 	return fmt.Sprintf("%d.%d", (f.Version&0xffff0000)>>16, f.Version&0xffff)
 }
 
-func (d *Device) init(addr *net.UDPAddr, requestSocket *net.UDPConn, timeout *time.Duration, retryInterval *time.Duration, reliable bool) {
+func (d *Device) init(addr *net.UDPAddr, requestSocket *net.UDPConn, timeout *time.Duration, retryInterval *time.Duration, reliable bool, client common.ClientConfig) {
 	d.Lock()
 	d.address = addr
 	d.requestSocket = requestSocket
 	d.timeout = timeout
 	d.retryInterval = retryInterval
+	d.client = client
 	d.reliable = reliable
 	d.limiter = time.NewTimer(shared.RateLimit)
+	d.writeQueue = make(chan struct{}, 1)
 	d.responseMap = make(responseMap)
 	d.responseInput = make(packet.Chan, 32)
 	d.subscriptions = make(map[string]*common.Subscription)
@@ -149,6 +185,12 @@ func (d *Device) ID() uint64 {
 	return d.id
 }
 
+func (d *Device) MAC() string {
+	target := make([]byte, 8)
+	binary.LittleEndian.PutUint64(target, d.id)
+	return net.HardwareAddr(target[:6]).String()
+}
+
 func (d *Device) Discover() error {
 	pkt := packet.New(d.address, d.requestSocket)
 	pkt.SetType(GetService)
@@ -199,7 +241,10 @@ func (d *Device) SetStateLabel(pkt *packet.Packet) error {
 		return err
 	}
 	common.Log.Debugf("Got label (%d): %v", d.id, string(l.Label[:]))
-	newLabel := stripNull(string(l.Label[:]))
+	newLabel := decodeString(l.Label[:])
+	d.Lock()
+	d.labelStale = false
+	d.Unlock()
 	if newLabel != d.CachedLabel() {
 		d.Lock()
 		d.label = newLabel
@@ -254,22 +299,17 @@ func (d *Device) SetStateHostFirmware(pkt *packet.Packet) error {
 		return err
 	}
 	common.Log.Debugf("Got firmware version (%d): %d", d.id, f.Version)
-	d.RLock()
-	version := d.firmwareVersion
-	d.RUnlock()
-	if f.Version != version {
-		d.Lock()
-		d.firmwareVersion = f.Version
-		d.firmwareVersionString = f.String()
-		d.Unlock()
-	}
+	d.Lock()
+	d.firmwareVersion = f.Version
+	d.firmwareVersionString = f.String()
+	d.firmwareStale = false
+	d.Unlock()
 
 	return nil
 }
 
 func (d *Device) GetLabel() (string, error) {
-	label := d.CachedLabel()
-	if len(label) != 0 {
+	if label, ok := d.cachedLabelFresh(); ok {
 		return label, nil
 	}
 
@@ -283,7 +323,7 @@ func (d *Device) GetLabel() (string, error) {
 	common.Log.Debugf("Waiting for label (%d)", d.id)
 	pktResponse := <-req
 	if pktResponse.Error != nil {
-		return ``, err
+		return ``, pktResponse.Error
 	}
 
 	err = d.SetStateLabel(pktResponse.Result)
@@ -295,7 +335,7 @@ func (d *Device) GetLabel() (string, error) {
 }
 
 func (d *Device) SetLabel(label string) error {
-	if d.CachedLabel() == label {
+	if cached, ok := d.cachedLabelFresh(); ok && cached == label {
 		return nil
 	}
 
@@ -321,10 +361,30 @@ func (d *Device) SetLabel(label string) error {
 
 	d.Lock()
 	d.label = label
+	d.labelStale = false
 	d.Unlock()
 	return d.publish(common.EventUpdateLabel{Label: label})
 }
 
+// cachedLabelFresh returns the cached label, and whether it is known and has
+// not been invalidated
+func (d *Device) cachedLabelFresh() (string, bool) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.label, len(d.label) != 0 && !d.labelStale
+}
+
+// InvalidateCache marks the cached power, label and firmware version as stale, so that they are
+// requested from the device on next access.  The cached values continue to be
+// returned by CachedPower and CachedLabel until replaced.
+func (d *Device) InvalidateCache() {
+	d.Lock()
+	d.powerUpdated = time.Time{}
+	d.labelStale = true
+	d.firmwareStale = true
+	d.Unlock()
+}
+
 func (d *Device) CachedLabel() string {
 	d.RLock()
 	defer d.RUnlock()
@@ -336,14 +396,22 @@ func (d *Device) SetStatePower(pkt *packet.Packet) error {
 	if err := pkt.DecodePayload(&p); err != nil {
 		return err
 	}
-	common.Log.Debugf("Got power (%d): %d", d.id, d.power)
+	common.Log.Debugf("Got power (%d): %d", d.id, p.Level)
 
-	state := p.Level > 0
-	if d.CachedPower() != state {
-		d.Lock()
-		d.power = p.Level
-		d.Unlock()
-		if err := d.publish(common.EventUpdatePower{Power: state}); err != nil {
+	return d.updatePower(p.Level)
+}
+
+// updatePower caches the power level reported by the device, and publishes an
+// event if the power state has changed
+func (d *Device) updatePower(level uint16) error {
+	d.Lock()
+	changed := d.power > 0 != (level > 0)
+	d.power = level
+	d.powerUpdated = time.Now()
+	d.Unlock()
+
+	if changed {
+		if err := d.publish(common.EventUpdatePower{Power: level > 0}); err != nil {
 			return err
 		}
 	}
@@ -351,26 +419,58 @@ func (d *Device) SetStatePower(pkt *packet.Packet) error {
 	return nil
 }
 
+// cachedPowerFresh returns true if the cached power level was reported within
+// the client cache TTL
+func (d *Device) cachedPowerFresh() bool {
+	ttl := d.cacheTTL()
+	if ttl <= 0 {
+		return false
+	}
+	d.RLock()
+	defer d.RUnlock()
+	return !d.powerUpdated.IsZero() && time.Since(d.powerUpdated) < ttl
+}
+
 func (d *Device) GetPower() (bool, error) {
+	power, err := d.GetPowerState()
+	if err != nil {
+		return false, err
+	}
+	return power.On(), nil
+}
+
+// GetPowerState requests the power level of the device, or returns the cached
+// level if fresh
+func (d *Device) GetPowerState() (common.Power, error) {
+	if d.cachedPowerFresh() {
+		return d.cachedPowerState(), nil
+	}
+
 	pkt := packet.New(d.address, d.requestSocket)
 	pkt.SetType(GetPower)
 	req, err := d.Send(pkt, d.reliable, true)
 	if err != nil {
-		return false, err
+		return common.PowerOff, err
 	}
 
 	common.Log.Debugf("Waiting for power (%d)", d.id)
 	pktResponse := <-req
 	if pktResponse.Error != nil {
-		return false, err
+		return common.PowerOff, pktResponse.Error
 	}
 
 	err = d.SetStatePower(pktResponse.Result)
 	if err != nil {
-		return false, err
+		return common.PowerOff, err
 	}
 
-	return d.CachedPower(), nil
+	return d.cachedPowerState(), nil
+}
+
+func (d *Device) cachedPowerState() common.Power {
+	d.RLock()
+	defer d.RUnlock()
+	return common.Power(d.power)
 }
 
 func (d *Device) CachedPower() bool {
@@ -402,10 +502,7 @@ func (d *Device) SetPower(state bool) error {
 		common.Log.Debugf("Setting power state on %d acknowledged", d.id)
 	}
 
-	d.Lock()
-	d.power = p.Level
-	d.Unlock()
-	return d.publish(common.EventUpdatePower{Power: p.Level > 0})
+	return d.updatePower(p.Level)
 }
 
 func (d *Device) CachedLocation() string {
@@ -425,7 +522,7 @@ func (d *Device) GetLocation() (ret string, err error) {
 	common.Log.Debugf("Waiting for location (%d)", d.id)
 	pktResponse := <-req
 	if pktResponse.Error != nil {
-		return ret, err
+		return ret, pktResponse.Error
 	}
 
 	err = d.SetStateLocation(pktResponse.Result)
@@ -436,6 +533,12 @@ func (d *Device) GetLocation() (ret string, err error) {
 	return d.CachedLocation(), nil
 }
 
+// SetLocation moves the device to the location with the specified id and
+// label, see SetGroup
+func (d *Device) SetLocation(id [16]byte, label string) error {
+	return d.setGroup(SetLocation, id, label)
+}
+
 func (d *Device) CachedGroup() string {
 	d.RLock()
 	defer d.RUnlock()
@@ -453,7 +556,7 @@ func (d *Device) GetGroup() (ret string, err error) {
 	common.Log.Debugf("Waiting for group (%d)", d.id)
 	pktResponse := <-req
 	if pktResponse.Error != nil {
-		return ret, err
+		return ret, pktResponse.Error
 	}
 
 	err = d.SetStateGroup(pktResponse.Result)
@@ -464,6 +567,44 @@ func (d *Device) GetGroup() (ret string, err error) {
 	return d.CachedGroup(), nil
 }
 
+// SetGroup moves the device to the group with the specified id and label.  The
+// device reports the new group in response, which is then cached.
+func (d *Device) SetGroup(id [16]byte, label string) error {
+	return d.setGroup(SetGroup, id, label)
+}
+
+// setGroup sends a SetGroup or SetLocation message, which share their payload.
+// The updated_at timestamp is set to now, so that other clients treat this as
+// the latest label for the group.
+func (d *Device) setGroup(msg shared.Message, id [16]byte, label string) error {
+	p := &stateGroup{
+		ID:        id,
+		UpdatedAt: uint64(time.Now().UnixNano()),
+	}
+	copy(p.Label[:], label)
+
+	pkt := packet.New(d.address, d.requestSocket)
+	pkt.SetType(msg)
+	if err := pkt.SetPayload(p); err != nil {
+		return err
+	}
+
+	common.Log.Debugf("Setting group/location on %d: %s (%s)", d.id, encodeGroupID(id), label)
+	req, err := d.Send(pkt, d.reliable, true)
+	if err != nil {
+		return err
+	}
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return pktResponse.Error
+	}
+
+	if msg == SetLocation {
+		return d.SetStateLocation(pktResponse.Result)
+	}
+	return d.SetStateGroup(pktResponse.Result)
+}
+
 func (d *Device) GetHardwareVendor() (uint32, error) {
 	if d.CachedHardwareProduct() != 0 {
 		return d.CachedHardwareVendor(), nil
@@ -505,7 +646,7 @@ func (d *Device) GetHardwareVersion() (uint32, error) {
 	common.Log.Debugf("Waiting for hardware version (%d)", d.id)
 	pktResponse := <-req
 	if pktResponse.Error != nil {
-		return 0, err
+		return 0, pktResponse.Error
 	}
 
 	v := stateVersion{}
@@ -545,7 +686,19 @@ func (d *Device) CachedFirmwareVersion() string {
 	return d.firmwareVersionString
 }
 
+// GetFirmwareVersion requests the firmware version of the device, or returns
+// the cached version if known and the client prefetches device info (see
+// golifx.Client.SetPrefetchDeviceInfo)
 func (d *Device) GetFirmwareVersion() (ret string, err error) {
+	if d.prefetchDeviceInfo() {
+		d.RLock()
+		version, fresh := d.firmwareVersionString, !d.firmwareStale
+		d.RUnlock()
+		if len(version) != 0 && fresh {
+			return version, nil
+		}
+	}
+
 	pkt := packet.New(d.address, d.requestSocket)
 	pkt.SetType(GetHostFirmware)
 	req, err := d.Send(pkt, d.reliable, true)
@@ -556,7 +709,7 @@ func (d *Device) GetFirmwareVersion() (ret string, err error) {
 	common.Log.Debugf("Waiting for firmware data (%d)", d.id)
 	pktResponse := <-req
 	if pktResponse.Error != nil {
-		return ret, err
+		return ret, pktResponse.Error
 	}
 
 	err = d.SetStateHostFirmware(pktResponse.Result)
@@ -567,14 +720,164 @@ func (d *Device) GetFirmwareVersion() (ret string, err error) {
 	return d.CachedFirmwareVersion(), nil
 }
 
+// Ping sends an EchoRequest with a unique payload, and returns the round trip
+// time once the device echoes it back.  Returns common.ErrProtocol if the
+// payload is not echoed correctly.
+func (d *Device) Ping() (time.Duration, error) {
+	// The payload only needs to be unique enough to match the response
+	p := &payloadEcho{}
+	binary.LittleEndian.PutUint64(p.Payload[:], uint64(time.Now().UnixNano()))
+	pkt := packet.New(d.address, d.requestSocket)
+	pkt.SetType(EchoRequest)
+	if err := pkt.SetPayload(p); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	req, err := d.Send(pkt, false, true)
+	if err != nil {
+		return 0, err
+	}
+
+	common.Log.Debugf("Waiting for echo response (%d)", d.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return 0, pktResponse.Error
+	}
+	rtt := time.Since(start)
+
+	res := &payloadEcho{}
+	if err := pktResponse.Result.DecodePayload(res); err != nil {
+		return 0, err
+	}
+	if res.Payload != p.Payload {
+		return 0, common.ErrProtocol
+	}
+
+	return rtt, nil
+}
+
+// GetWifiInfo requests the WiFi signal strength and traffic counters
+func (d *Device) GetWifiInfo() (common.WifiInfo, error) {
+	pkt := packet.New(d.address, d.requestSocket)
+	pkt.SetType(GetWifiInfo)
+	req, err := d.Send(pkt, d.reliable, true)
+	if err != nil {
+		return common.WifiInfo{}, err
+	}
+
+	common.Log.Debugf("Waiting for wifi info (%d)", d.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return common.WifiInfo{}, pktResponse.Error
+	}
+
+	s := &stateWifiInfo{}
+	if err := pktResponse.Result.DecodePayload(s); err != nil {
+		return common.WifiInfo{}, err
+	}
+
+	if length := d.signalHistoryLength(); length > 0 {
+		d.Lock()
+		d.signalHistory = common.AppendSignalSample(d.signalHistory, common.SignalSample{
+			Time:   time.Now(),
+			Signal: s.Signal,
+		}, length)
+		d.Unlock()
+	}
+
+	return common.WifiInfo{Signal: s.Signal, Tx: s.Tx, Rx: s.Rx}, nil
+}
+
+// LastOnColor always returns false, only lights remember their color
+func (d *Device) LastOnColor() (common.Color, bool) {
+	return common.Color{}, false
+}
+
+// SignalHistory returns the signal strength recorded by GetWifiInfo while the
+// client samples signal strength, oldest first
+func (d *Device) SignalHistory() []common.SignalSample {
+	d.RLock()
+	defer d.RUnlock()
+	return append([]common.SignalSample(nil), d.signalHistory...)
+}
+
 func (d *Device) Handle(pkt *packet.Packet) {
 	d.responseInput <- &packet.Response{Result: pkt}
 }
 
-func (d *Device) GetAddress() *net.UDPAddr {
+func (d *Device) Address() *net.UDPAddr {
+	d.RLock()
+	defer d.RUnlock()
 	return d.address
 }
 
+// Services returns the services advertised by the device
+func (d *Device) Services() []common.Service {
+	d.RLock()
+	defer d.RUnlock()
+	services := make([]common.Service, len(d.services))
+	copy(services, d.services)
+	return services
+}
+
+// SetStateService records the service advertised in a StateService packet
+// received from addr.  Devices may advertise several services, and only the
+// UDP service is used for communication, so the device address is set to the
+// IP of addr with the port reported for the UDP service.
+func (d *Device) SetStateService(pkt *packet.Packet, addr *net.UDPAddr) error {
+	p := &stateService{}
+	if err := pkt.DecodePayload(p); err != nil {
+		return err
+	}
+	service := common.Service{Type: common.ServiceType(p.Service), Port: p.Port}
+
+	d.Lock()
+	defer d.Unlock()
+	found := false
+	for i := range d.services {
+		if d.services[i].Type == service.Type {
+			d.services[i] = service
+			found = true
+		}
+	}
+	if !found {
+		d.services = append(d.services, service)
+	}
+	if p.Service == shared.ServiceUDP && p.Port != 0 {
+		d.address = &net.UDPAddr{IP: addr.IP, Port: int(p.Port), Zone: addr.Zone}
+	}
+
+	return nil
+}
+
+// Subnet returns the network the device was discovered in, or nil if unknown
+func (d *Device) Subnet() *net.IPNet {
+	d.RLock()
+	defer d.RUnlock()
+	return d.subnet
+}
+
+func (d *Device) SetSubnet(subnet *net.IPNet) {
+	d.Lock()
+	d.subnet = subnet
+	d.Unlock()
+}
+
+// SetSource sets the source identifier that requests to the device are tagged
+// with, zero retains the source set by packet.New
+func (d *Device) SetSource(source uint32) {
+	d.Lock()
+	d.source = source
+	d.Unlock()
+}
+
+func (d *Device) SetAddress(addr *net.UDPAddr) {
+	d.Lock()
+	d.address = addr
+	d.Unlock()
+}
+
 func (d *Device) ResetLimiter() {
 	d.Lock()
 	d.limiter.Reset(shared.RateLimit)
@@ -595,13 +898,45 @@ func (d *Device) resetLimiter(broadcast bool) {
 }
 
 func (d *Device) Send(pkt *packet.Packet, ackRequired, responseRequired bool) (packet.Chan, error) {
+	return d.SendContext(context.Background(), pkt, ackRequired, responseRequired)
+}
+
+// SendContext sends pkt as per Send, spanning the request as a child of the
+// span in ctx, if any.  The request is not cancelled when ctx is done.
+func (d *Device) SendContext(ctx context.Context, pkt *packet.Packet, ackRequired, responseRequired bool) (packet.Chan, error) {
 	proxyChan := make(packet.Chan)
 
+	// Broadcast vs direct
+	broadcast := d.id == 0
+
+	// Writes to the device are queued, so that each is sent, and acknowledged
+	// if required, before the next, avoiding interleaving on the wire
+	release := func() {}
+	if !broadcast && !responseRequired {
+		release = d.queueWrite()
+	}
+	// The queue is released, and the span ended, with the outcome of the
+	// request
+	end := d.startSpan(ctx, pkt)
+	var once sync.Once
+	complete := func(err error) {
+		once.Do(func() {
+			release()
+			end(err)
+		})
+	}
+	pending := false
+
 	// Rate limiter
 	<-d.limiter.C
 
-	// Broadcast vs direct
-	broadcast := d.id == 0
+	d.RLock()
+	source := d.source
+	d.RUnlock()
+	if source != 0 {
+		pkt.SetSource(source)
+	}
+
 	if broadcast {
 		// Broadcast can't be reliable
 		ackRequired = false
@@ -617,10 +952,12 @@ func (d *Device) Send(pkt *packet.Packet, ackRequired, responseRequired bool) (p
 		if ackRequired || responseRequired {
 			seq, res := d.addSeq()
 			pkt.SetSequence(seq)
+			pending = true
 
 			go func() {
 				defer func() {
-					close(res.done)
+					complete(nil)
+					res.finish()
 					close(proxyChan)
 				}()
 
@@ -631,6 +968,11 @@ func (d *Device) Send(pkt *packet.Packet, ackRequired, responseRequired bool) (p
 
 				if d.timeout == nil || *d.timeout == 0 {
 					timeout = make(<-chan time.Time)
+					// The acknowledgement may never arrive, so release the
+					// write queue after the default timeout regardless,
+					// rather than blocking later writes to the device
+					queueTimer := time.AfterFunc(common.DefaultTimeout, release)
+					defer queueTimer.Stop()
 				} else {
 					timeout = time.After(*d.timeout)
 				}
@@ -639,9 +981,15 @@ func (d *Device) Send(pkt *packet.Packet, ackRequired, responseRequired bool) (p
 					select {
 					case pktResponse, ok := <-res.ch:
 						if !ok {
+							// Closed without a response
+							complete(common.ErrClosed)
+							proxyChan <- &packet.Response{
+								Error: common.ErrClosed,
+							}
 							return
 						}
-						if pktResponse.Result.GetType() == Acknowledgement {
+						// Errors, eg on close, carry no result
+						if pktResponse.Error == nil && pktResponse.Result.GetType() == Acknowledgement {
 							common.Log.Debugf("Got ACK for seq %d on device %d, cancelling retries", seq, d.ID())
 							ticker.Stop()
 							// Ack does not resolve outstanding request,
@@ -650,17 +998,20 @@ func (d *Device) Send(pkt *packet.Packet, ackRequired, responseRequired bool) (p
 								continue
 							}
 						}
+						complete(pktResponse.Error)
 						proxyChan <- pktResponse
 						return
 					case <-ticker.C:
 						common.Log.Debugf("Retrying send for seq %d on device %d after %d milliseconds", seq, d.ID(), *d.retryInterval/time.Millisecond)
-						if err := pkt.Write(); err != nil {
+						if err := d.write(pkt); err != nil {
+							complete(err)
 							proxyChan <- &packet.Response{
 								Error: err,
 							}
 							return
 						}
 					case <-timeout:
+						complete(common.ErrTimeout)
 						proxyChan <- &packet.Response{
 							Error: common.ErrTimeout,
 						}
@@ -671,12 +1022,124 @@ func (d *Device) Send(pkt *packet.Packet, ackRequired, responseRequired bool) (p
 		}
 	}
 
-	err := pkt.Write()
+	err := d.write(pkt)
 	d.resetLimiter(broadcast)
+	if err != nil || !pending {
+		complete(err)
+	}
 
 	return proxyChan, err
 }
 
+// startSpan starts a span for the request pkt sent for ctx if the client has a
+// span hook, and returns the func that ends it
+func (d *Device) startSpan(ctx context.Context, pkt *packet.Packet) (end func(err error)) {
+	if d.client == nil {
+		return func(error) {}
+	}
+	hook := d.client.GetSpanHook()
+	if hook == nil {
+		return func(error) {}
+	}
+	return hook.StartSpan(ctx, common.SpanSend, common.SpanAttributes{
+		DeviceID: d.id,
+		Message:  uint16(pkt.GetType()),
+	})
+}
+
+// queueWrite waits for earlier writes to the device to complete, in the order
+// they were queued, and returns the func that completes this write, which may
+// be called more than once.  Devices that were not initialised, eg in tests,
+// are not queued.
+func (d *Device) queueWrite() (release func()) {
+	if d.writeQueue == nil {
+		return func() {}
+	}
+	d.writeQueue <- struct{}{}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-d.writeQueue })
+	}
+}
+
+// write sends the packet, tracing it first if the client has a tracer
+func (d *Device) write(pkt *packet.Packet) error {
+	if d.client != nil {
+		if w := d.client.GetTracer(); w != nil {
+			pkt.Trace(w, packet.TraceSend, d.Address())
+		}
+	}
+	return pkt.Write()
+}
+
+// pollInterval returns the client poll interval, or the default if no client
+// is attached
+func (d *Device) pollInterval() time.Duration {
+	if d.client == nil {
+		return common.DefaultPollInterval
+	}
+	interval := d.client.GetPollInterval()
+	if interval == nil || *interval <= 0 {
+		return common.DefaultPollInterval
+	}
+	return *interval
+}
+
+// cacheTTL returns the client cache TTL, or zero (caching disabled) if no client
+// is attached
+func (d *Device) cacheTTL() time.Duration {
+	if d.client == nil {
+		return 0
+	}
+	ttl := d.client.GetCacheTTL()
+	if ttl == nil {
+		return 0
+	}
+	return *ttl
+}
+
+// minBrightness returns the client brightness floor, or zero (no floor) if no
+// client is attached
+func (d *Device) minBrightness() uint16 {
+	if d.client == nil {
+		return 0
+	}
+	return d.client.GetMinBrightness()
+}
+
+// prefetchDeviceInfo returns whether the client prefetches device info, false
+// if no client is attached
+func (d *Device) prefetchDeviceInfo() bool {
+	return d.client != nil && d.client.GetPrefetchDeviceInfo()
+}
+
+// signalHistoryLength returns the number of signal samples kept by the client,
+// or zero (sampling disabled) if no client is attached
+func (d *Device) signalHistoryLength() int {
+	if d.client == nil {
+		return 0
+	}
+	return d.client.GetSignalHistoryLength()
+}
+
+// colorDebounce returns the client color debounce window, or zero (debouncing
+// disabled) if no client is attached
+func (d *Device) colorDebounce() time.Duration {
+	if d.client == nil {
+		return 0
+	}
+	debounce := d.client.GetColorDebounce()
+	if debounce == nil {
+		return 0
+	}
+	return *debounce
+}
+
+// LastSeen returns the time a message was last received from the device
+func (d *Device) LastSeen() time.Time {
+	return d.Seen()
+}
+
 func (d *Device) Seen() time.Time {
 	d.RLock()
 	defer d.RUnlock()
@@ -689,6 +1152,24 @@ func (d *Device) SetSeen(seen time.Time) {
 	d.Unlock()
 }
 
+// IsOnline returns false if the device has stopped responding, eg because it
+// has been powered off at the wall, until it is seen again
+func (d *Device) IsOnline() bool {
+	d.RLock()
+	defer d.RUnlock()
+	return !d.offline
+}
+
+// SetOnline marks the device online or offline, returning true if this changed
+// the state
+func (d *Device) SetOnline(online bool) bool {
+	d.Lock()
+	defer d.Unlock()
+	changed := d.offline == online
+	d.offline = !online
+	return changed
+}
+
 // Close cleans up Device resources
 func (d *Device) Close() error {
 	for _, sub := range d.subscriptions {
@@ -707,9 +1188,8 @@ func (d *Device) Close() error {
 		for seq, res := range d.responseMap {
 			select {
 			case res.ch <- &packet.Response{Error: common.ErrClosed}:
-			case <-res.done:
 			default:
-				close(res.done)
+				res.finish()
 			}
 			res.wg.Wait()
 			close(res.ch)
@@ -741,7 +1221,7 @@ func (d *Device) handler() {
 			seq := pktResponse.Result.GetSequence()
 			res, ok = d.getSeq(seq)
 			if !ok {
-				common.Log.Warnf("Couldn't find requestor for seq %d on device %d", seq, d.id)
+				d.handleUnsolicited(pktResponse.Result)
 				continue
 			}
 			common.Log.Debugf("Returning packet to for seq %d to caller on device %d", seq, d.id)
@@ -756,6 +1236,25 @@ func (d *Device) handler() {
 	}
 }
 
+// handleUnsolicited updates cached state from packets that have no pending
+// requestor, such as late responses to requests that have timed out, so that
+// the state the device reported is not lost
+func (d *Device) handleUnsolicited(pkt *packet.Packet) {
+	var err error
+	switch pkt.GetType() {
+	case StateLabel:
+		err = d.SetStateLabel(pkt)
+	case StatePower:
+		err = d.SetStatePower(pkt)
+	default:
+		common.Log.Warnf("Couldn't find requestor for seq %d on device %d", pkt.GetSequence(), d.id)
+		return
+	}
+	if err != nil {
+		common.Log.Debugf("Failed handling unsolicited packet type %d on device %d: %v", pkt.GetType(), d.id, err)
+	}
+}
+
 func (d *Device) addSeq() (seq uint8, res *response) {
 	d.Lock()
 	d.sequence++
@@ -811,19 +1310,27 @@ func (d *Device) publish(event interface{}) error {
 	return nil
 }
 
-func New(addr *net.UDPAddr, requestSocket *net.UDPConn, timeout *time.Duration, retryInterval *time.Duration, reliable bool, pkt *packet.Packet) (*Device, error) {
+// NewFromAddress returns a *Device with the known id, that sends requests to
+// addr without having been discovered.  The device remains provisional, so
+// that it is classified if it is later discovered.
+func NewFromAddress(id uint64, addr *net.UDPAddr, requestSocket *net.UDPConn, timeout *time.Duration, retryInterval *time.Duration, reliable bool, client common.ClientConfig) *Device {
+	d := &Device{id: id}
+	d.init(addr, requestSocket, timeout, retryInterval, reliable, client)
+
+	go d.handler()
+
+	return d
+}
+
+func New(addr *net.UDPAddr, requestSocket *net.UDPConn, timeout *time.Duration, retryInterval *time.Duration, reliable bool, client common.ClientConfig, pkt *packet.Packet) (*Device, error) {
 	d := &Device{}
-	d.init(addr, requestSocket, timeout, retryInterval, reliable)
+	d.init(addr, requestSocket, timeout, retryInterval, reliable, client)
 
 	if pkt != nil {
 		d.id = pkt.Target
-		service := &stateService{}
-
-		if err := pkt.DecodePayload(service); err != nil {
+		if err := d.SetStateService(pkt, addr); err != nil {
 			return nil, err
 		}
-
-		d.address.Port = int(service.Port)
 	}
 
 	go d.handler()
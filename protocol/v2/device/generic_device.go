@@ -1,6 +1,7 @@
 package device
 
 import (
+	"net"
 	"time"
 
 	"github.com/pdf/golifx/common"
@@ -13,6 +14,11 @@ type GenericDevice interface {
 	Close() error
 	Seen() time.Time
 	SetSeen(time.Time)
+	SetAddress(*net.UDPAddr)
+	SetSubnet(*net.IPNet)
+	SetSource(uint32)
+	SetStateService(*packet.Packet, *net.UDPAddr) error
+	SetOnline(bool) bool
 	Provisional() bool
 	SetProvisional(bool)
 	SetStatePower(*packet.Packet) error
@@ -27,3 +33,11 @@ type GenericDevice interface {
 	GetHardwareProduct() (uint32, error)
 	ResetLimiter()
 }
+
+// GenericLight is implemented by all light device types
+type GenericLight interface {
+	GenericDevice
+	common.Light
+	Get() error
+	SetState(*packet.Packet) error
+}
@@ -2,6 +2,7 @@ package device
 
 import (
 	"encoding/base64"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -46,7 +47,7 @@ func (g *Group) ID() string {
 func (g *Group) GetLabel() string {
 	g.RLock()
 	defer g.RUnlock()
-	return stripNull(string(g.label[:]))
+	return decodeString(g.label[:])
 }
 
 func (g *Group) Devices() (devices []common.Device) {
@@ -286,6 +287,14 @@ func (g *Group) SetColor(color common.Color, duration time.Duration) error {
 	return err
 }
 
+// SetColorWave changes the color of the lights in the group in order of ID,
+// offset by stagger, see common.ColorWave
+func (g *Group) SetColorWave(color common.Color, duration, stagger time.Duration) error {
+	lights := g.Lights()
+	sort.Slice(lights, func(i, j int) bool { return lights[i].ID() < lights[j].ID() })
+	return common.ColorWave(lights, color, duration, stagger)
+}
+
 func (g *Group) SetPower(state bool) error {
 	var (
 		wg       sync.WaitGroup
@@ -346,6 +355,12 @@ func (g *Group) SetPowerDuration(state bool, duration time.Duration) error {
 	return err
 }
 
+// encodeGroupID returns the unpadded URL-safe base64 encoding of a group or
+// location ID
+func encodeGroupID(id [16]byte) string {
+	return strings.Replace(base64.URLEncoding.EncodeToString(id[:]), `=`, ``, -1)
+}
+
 func (g *Group) Parse(pkt *packet.Packet) error {
 	var shouldUpdate, labelUpdate bool
 
@@ -363,10 +378,7 @@ func (g *Group) Parse(pkt *packet.Packet) error {
 	if shouldUpdate {
 		g.Lock()
 		g.id = s.ID
-		g.idEncoded = strings.Replace(
-			base64.URLEncoding.EncodeToString(s.ID[:]),
-			`=`, ``, -1,
-		)
+		g.idEncoded = encodeGroupID(s.ID)
 		g.updatedAt = s.UpdatedAt
 		if g.label != s.Label {
 			g.label = s.Label
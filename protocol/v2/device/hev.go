@@ -0,0 +1,103 @@
+package device
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/protocol/v2/packet"
+	"github.com/pdf/golifx/protocol/v2/shared"
+)
+
+const (
+	GetHevCycle   shared.Message = 142
+	SetHevCycle   shared.Message = 143
+	StateHevCycle shared.Message = 144
+)
+
+// HevLight is a light with a germicidal HEV clean cycle
+type HevLight struct {
+	*Light
+}
+
+type payloadSetHevCycle struct {
+	Enable   uint8
+	Duration uint32 `struc:"little"`
+}
+
+// stateHevCycle durations are in seconds
+type stateHevCycle struct {
+	Duration  uint32 `struc:"little"`
+	Remaining uint32 `struc:"little"`
+	LastPower uint8
+}
+
+// StartHevCycle starts a clean cycle for the duration, rounded down to whole
+// seconds, or the configured default duration if zero
+func (l *HevLight) StartHevCycle(duration time.Duration) error {
+	if duration < 0 || duration > common.MaxHevCycleDuration {
+		return common.ErrInvalidArgument
+	}
+	return l.setHevCycle(true, duration)
+}
+
+// StopHevCycle stops the running clean cycle
+func (l *HevLight) StopHevCycle() error {
+	return l.setHevCycle(false, 0)
+}
+
+func (l *HevLight) setHevCycle(enable bool, duration time.Duration) error {
+	p := &payloadSetHevCycle{Duration: uint32(duration / time.Second)}
+	if enable {
+		p.Enable = 1
+	}
+
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(SetHevCycle)
+	if err := pkt.SetPayload(p); err != nil {
+		return err
+	}
+
+	common.Log.Debugf("Setting HEV cycle on %v: %+v", l.id, p)
+	req, err := l.Send(pkt, l.reliable, false)
+	if err != nil {
+		return err
+	}
+	if l.reliable {
+		// Wait for ack
+		<-req
+		common.Log.Debugf("Setting HEV cycle on %v acknowledged", l.id)
+	}
+
+	return nil
+}
+
+// GetHevCycle requests the state of the clean cycle
+func (l *HevLight) GetHevCycle() (common.HevStatus, error) {
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(GetHevCycle)
+	req, err := l.Send(pkt, l.reliable, true)
+	if err != nil {
+		return common.HevStatus{}, err
+	}
+
+	common.Log.Debugf("Waiting for HEV cycle (%d)", l.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return common.HevStatus{}, pktResponse.Error
+	}
+	if pktResponse.Result.GetType() != StateHevCycle {
+		return common.HevStatus{}, common.ErrProtocol
+	}
+
+	s := &stateHevCycle{}
+	if err := pktResponse.Result.DecodePayload(s); err != nil {
+		return common.HevStatus{}, err
+	}
+	common.Log.Debugf("Got HEV cycle (%d): %+v", l.id, s)
+
+	return common.HevStatus{
+		Duration:  time.Duration(s.Duration) * time.Second,
+		Remaining: time.Duration(s.Remaining) * time.Second,
+		LastPower: s.LastPower != 0,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package device
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/protocol/v2/packet"
+)
+
+// stateInfo times are in nanoseconds, downtime is accurate to five seconds
+type stateInfo struct {
+	Time     uint64 `struc:"little"`
+	Uptime   uint64 `struc:"little"`
+	Downtime uint64 `struc:"little"`
+}
+
+// downtimeAccuracy is the accuracy of the downtime reported by StateInfo,
+// shorter downtimes are indistinguishable from none
+const downtimeAccuracy = 5 * time.Second
+
+// LastRebootReason requests the device info, and infers the reason the device
+// last booted from the time it was without power beforehand, see
+// common.RebootReasonPowerLoss.  Returns common.ErrNotSupported if the device
+// reports the request as unhandled.
+func (d *Device) LastRebootReason() (string, error) {
+	pkt := packet.New(d.address, d.requestSocket)
+	pkt.SetType(GetInfo)
+	req, err := d.Send(pkt, d.reliable, true)
+	if err != nil {
+		return ``, err
+	}
+
+	common.Log.Debugf("Waiting for info (%d)", d.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return ``, pktResponse.Error
+	}
+	if pktResponse.Result.GetType() != StateInfo {
+		common.Log.Debugf("Info not supported by %d", d.id)
+		return ``, common.ErrNotSupported
+	}
+
+	s := &stateInfo{}
+	if err := pktResponse.Result.DecodePayload(s); err != nil {
+		return ``, err
+	}
+
+	if time.Duration(s.Downtime) >= downtimeAccuracy {
+		return common.RebootReasonPowerLoss, nil
+	}
+	return common.RebootReasonRestart, nil
+}
@@ -1,7 +1,9 @@
 package device
 
 import (
+	"context"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/pdf/golifx/common"
@@ -12,6 +14,7 @@ import (
 const (
 	Get             shared.Message = 101
 	SetColor        shared.Message = 102
+	SetWaveform     shared.Message = 103
 	State           shared.Message = 107
 	LightGetPower   shared.Message = 116
 	LightSetPower   shared.Message = 117
@@ -20,7 +23,19 @@ const (
 
 type Light struct {
 	*Device
-	color common.Color
+	color         common.Color
+	restoreColor  *common.Color
+	lastOnColor   *common.Color
+	pendingColor  *pendingColor
+	debounceTimer *time.Timer
+	transitionEnd time.Time
+	colorStale    bool
+	updateMutex   sync.Mutex
+}
+
+type pendingColor struct {
+	color    common.Color
+	duration time.Duration
 }
 
 type payloadColor struct {
@@ -29,6 +44,36 @@ type payloadColor struct {
 	Duration uint32
 }
 
+type payloadWaveform struct {
+	Reserved  uint8
+	Transient uint8
+	Color     common.Color
+	Period    uint32
+	Cycles    float32
+	SkewRatio int16
+	Waveform  uint8
+}
+
+// newPayloadWaveform encodes a validated waveform, mapping the skew ratio from
+// 0 to 1 onto the full int16 range
+func newPayloadWaveform(w common.Waveform) *payloadWaveform {
+	skew := w.SkewRatio
+	if skew == 0 {
+		skew = 0.5
+	}
+	p := &payloadWaveform{
+		Color:     w.Color,
+		Period:    uint32(w.Period / time.Millisecond),
+		Cycles:    w.Cycles,
+		SkewRatio: int16(math.Round(float64(skew)*math.MaxUint16) + math.MinInt16),
+		Waveform:  uint8(w.Type),
+	}
+	if w.Transient {
+		p.Transient = 1
+	}
+	return p
+}
+
 type payloadPowerDuration struct {
 	Level    uint16
 	Duration uint32
@@ -50,6 +95,10 @@ func (l *Light) SetState(pkt *packet.Packet) error {
 	}
 	common.Log.Debugf("Got light state (%d): %+v", l.id, s)
 
+	l.Lock()
+	l.colorStale = false
+	l.labelStale = false
+	l.Unlock()
 	if !common.ColorEqual(s.Color, l.CachedColor()) {
 		l.Lock()
 		l.color = s.Color
@@ -58,15 +107,10 @@ func (l *Light) SetState(pkt *packet.Packet) error {
 			return err
 		}
 	}
-	if s.Power > 0 != l.CachedPower() {
-		l.Lock()
-		l.power = s.Power
-		l.Unlock()
-		if err := l.publish(common.EventUpdatePower{Power: l.power > 0}); err != nil {
-			return err
-		}
+	if err := l.updatePower(s.Power); err != nil {
+		return err
 	}
-	newLabel := stripNull(string(s.Label[:]))
+	newLabel := decodeString(s.Label[:])
 	if newLabel != l.CachedLabel() {
 		l.Lock()
 		l.label = newLabel
@@ -80,9 +124,15 @@ func (l *Light) SetState(pkt *packet.Packet) error {
 }
 
 func (l *Light) Get() error {
+	return l.get(context.Background())
+}
+
+// get requests the state of the light, spanning the request as a child of the
+// span in ctx
+func (l *Light) get(ctx context.Context) error {
 	pkt := packet.New(l.address, l.requestSocket)
 	pkt.SetType(Get)
-	req, err := l.Send(pkt, l.reliable, true)
+	req, err := l.SendContext(ctx, pkt, l.reliable, true)
 	if err != nil {
 		return err
 	}
@@ -97,38 +147,310 @@ func (l *Light) Get() error {
 }
 
 func (l *Light) SetColor(color common.Color, duration time.Duration) error {
-	if common.ColorEqual(color, l.CachedColor()) {
+	return l.SetColorContext(context.Background(), color, duration)
+}
+
+// SetColorContext sets the color as per SetColor, spanning the request as a
+// child of the span in ctx.  Debounced colors are sent without ctx.
+func (l *Light) SetColorContext(ctx context.Context, color common.Color, duration time.Duration) error {
+	if _, err := durationMillis(duration); err != nil {
+		return err
+	}
+
+	if debounce := l.colorDebounce(); debounce > 0 {
+		l.debounceColor(color, duration, debounce)
 		return nil
 	}
 
+	if l.redundantColor(color) {
+		return nil
+	}
+
+	return l.setColor(ctx, color, duration)
+}
+
+// debounceColor queues the color to be sent when the debounce window elapses,
+// replacing any color already queued in the window
+func (l *Light) debounceColor(color common.Color, duration time.Duration, debounce time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+	l.pendingColor = &pendingColor{color: color, duration: duration}
+	if l.debounceTimer == nil {
+		l.debounceTimer = time.AfterFunc(debounce, l.flushColor)
+	}
+}
+
+// flushColor sends the queued color, and schedules another flush if a color was
+// queued while sending
+func (l *Light) flushColor() {
+	l.Lock()
+	pending := l.pendingColor
+	l.pendingColor = nil
+	l.Unlock()
+
+	if pending != nil && !l.redundantColor(pending.color) {
+		if err := l.setColor(context.Background(), pending.color, pending.duration); err != nil {
+			common.Log.Warnf("Failed setting debounced color on %d: %v", l.id, err)
+		}
+	}
+
+	debounce := l.colorDebounce()
+	l.Lock()
+	defer l.Unlock()
+	if l.pendingColor == nil {
+		l.debounceTimer = nil
+		return
+	}
+	l.debounceTimer.Reset(debounce)
+}
+
+// redundantColor returns true if setting color would not change the cached
+// color, allowing for common.RedundantWriteTolerance if the client skips
+// redundant writes
+func (l *Light) redundantColor(color common.Color) bool {
+	color = common.FloorBrightness(color, l.minBrightness())
+	l.RLock()
+	cached, stale := l.color, l.colorStale
+	l.RUnlock()
+	if stale {
+		return false
+	}
+	if l.client != nil && l.client.GetSkipRedundantWrites() {
+		return common.ColorApproxEqual(color, cached, common.RedundantWriteTolerance)
+	}
+	return common.ColorEqual(color, cached)
+}
+
+// UpdateColor requests the current color, applies fn to it, and sets the
+// result.  Calls are serialized per light, so concurrent updates each see the
+// result of the previous update.  The read and write are separate requests, so
+// a change made in between by other clients, or by SetColor on this client, is
+// overwritten.  The current color may be served from the cache (see
+// golifx.Client.SetCacheTTL).
+func (l *Light) UpdateColor(fn func(common.Color) common.Color, duration time.Duration) error {
+	if _, err := durationMillis(duration); err != nil {
+		return err
+	}
+
+	l.updateMutex.Lock()
+	defer l.updateMutex.Unlock()
+
+	color, err := l.GetColor()
+	if err != nil {
+		return err
+	}
+
+	return l.SetColor(fn(color), duration)
+}
+
+// SetColorIfOn changes the color of the light if it is currently powered on,
+// returning whether the color was applied
+func (l *Light) SetColorIfOn(color common.Color, duration time.Duration) (bool, error) {
+	power, err := l.GetPower()
+	if err != nil {
+		return false, err
+	}
+	if !power {
+		return false, nil
+	}
+	if err := l.SetColor(color, duration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetColorClamped clamps color into the supported range and sets it, returning
+// the color applied
+func (l *Light) SetColorClamped(color common.Color, duration time.Duration) (common.Color, error) {
+	color = common.ClampColor(color)
+	if err := l.SetColor(color, duration); err != nil {
+		return common.Color{}, err
+	}
+	return color, nil
+}
+
+// SetBrightnessPercent sets the brightness of the light to percent, in the
+// range 0 to 100 (clamped), preserving the other color components
+func (l *Light) SetBrightnessPercent(percent float64, duration time.Duration) error {
+	brightness := common.BrightnessFromPercent(percent)
+	return l.UpdateColor(func(color common.Color) common.Color {
+		color.Brightness = brightness
+		return color
+	}, duration)
+}
+
+// AdjustKelvin adds delta to the color temperature of the light, clamped to the
+// supported range
+func (l *Light) AdjustKelvin(delta int, duration time.Duration) error {
+	return l.UpdateColor(func(color common.Color) common.Color {
+		return common.AdjustKelvin(color, delta)
+	}, duration)
+}
+
+// AdjustBrightness adds delta to the brightness of the light, clamped to the
+// range 0 to 65535
+func (l *Light) AdjustBrightness(delta int, duration time.Duration) error {
+	return l.UpdateColor(func(color common.Color) common.Color {
+		return common.AdjustBrightness(color, delta)
+	}, duration)
+}
+
+// FlashColor sends a transient pulse waveform, so that the light switches to
+// color for the first half of each period and reverts to its own color once the
+// cycles complete
+func (l *Light) FlashColor(color common.Color, period time.Duration, cycles uint16) error {
+	if cycles == 0 {
+		return common.ErrInvalidArgument
+	}
+	return l.SetWaveform(common.Waveform{
+		Type:      common.WaveformPulse,
+		Color:     color,
+		Period:    period,
+		Cycles:    float32(cycles),
+		SkewRatio: 0.5,
+		Transient: true,
+	})
+}
+
+// SetWaveform sends the waveform to the light, returns
+// common.ErrInvalidArgument if the waveform is invalid (see
+// common.Waveform.Validate)
+func (l *Light) SetWaveform(w common.Waveform) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	p := newPayloadWaveform(w)
+
+	common.Log.Debugf("Setting waveform on %d: %+v", l.id, p)
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(SetWaveform)
+	if err := pkt.SetPayload(p); err != nil {
+		return err
+	}
+	req, err := l.Send(pkt, l.reliable, false)
+	if err != nil {
+		return err
+	}
+	if l.reliable {
+		// Wait for ack
+		<-req
+		common.Log.Debugf("Setting waveform on %d acknowledged", l.id)
+	}
+
+	return nil
+}
+
+func (l *Light) SetColorByName(name string, duration time.Duration) error {
+	color, err := common.NamedColor(name)
+	if err != nil {
+		return err
+	}
+
+	return l.SetColor(color, duration)
+}
+
+// SetColorVerified sets the color, then sleeps for the transition duration plus
+// delay before requesting the color from the light, and returns
+// common.ErrVerifyFailed unless it is within tolerance of color (see
+// common.ColorApproxEqual).  Durations shorter than shared.RateLimit are
+// extended to it, so the light has at least that long to apply the color.
+func (l *Light) SetColorVerified(color common.Color, duration time.Duration, tolerance uint16, delay time.Duration) error {
+	if _, err := durationMillis(duration); err != nil {
+		return err
+	}
+	if duration < shared.RateLimit {
+		duration = shared.RateLimit
+	}
+	if err := l.setColor(context.Background(), color, duration); err != nil {
+		return err
+	}
+
+	time.Sleep(duration + delay)
+
+	current, err := l.GetColor()
+	if err != nil {
+		return err
+	}
+	if !common.ColorApproxEqual(current, color, tolerance) {
+		common.Log.Warnf("Color verification failed on %d, requested %+v, got %+v", l.id, color, current)
+		return common.ErrVerifyFailed
+	}
+
+	return nil
+}
+
+// SetColorAck changes the color of the light, always requesting an
+// acknowledgement regardless of the reliability setting, and waiting for it with
+// the usual retries.  Returns true once acknowledged, or false and
+// common.ErrTimeout if no acknowledgement arrived before the timeout.  The
+// request is always sent, even if the cached color matches.
+func (l *Light) SetColorAck(color common.Color, duration time.Duration) (bool, error) {
+	if _, err := durationMillis(duration); err != nil {
+		return false, err
+	}
+	return l.sendColor(context.Background(), color, duration, true)
+}
+
+func (l *Light) setColor(ctx context.Context, color common.Color, duration time.Duration) error {
+	// Missing acknowledgements are not reported outside of SetColorAck
+	if _, err := l.sendColor(ctx, color, duration, l.reliable); err != nil && err != common.ErrTimeout && err != common.ErrClosed {
+		return err
+	}
+	return nil
+}
+
+// sendColor sends the color, waiting for an acknowledgement if ack is set, and
+// returns whether it was acknowledged.  The cached color is updated regardless
+// of the acknowledgement.
+func (l *Light) sendColor(ctx context.Context, color common.Color, duration time.Duration, ack bool) (acked bool, err error) {
 	common.Log.Debugf("Setting color on %d", l.id)
+	color = common.FloorBrightness(color, l.minBrightness())
 	if duration < shared.RateLimit {
 		duration = shared.RateLimit
 	}
+	millis, err := durationMillis(duration)
+	if err != nil {
+		return false, err
+	}
 	p := &payloadColor{
 		Color:    color,
-		Duration: uint32(duration / time.Millisecond),
+		Duration: millis,
 	}
 
 	pkt := packet.New(l.address, l.requestSocket)
 	pkt.SetType(SetColor)
 	if err := pkt.SetPayload(p); err != nil {
-		return err
+		return false, err
 	}
-	req, err := l.Send(pkt, l.reliable, false)
+	req, err := l.SendContext(ctx, pkt, ack, false)
 	if err != nil {
-		return err
+		return false, err
 	}
-	if l.reliable {
+	var ackErr error
+	if ack {
 		// Wait for ack
-		<-req
-		common.Log.Debugf("Setting color on %d acknowledged", l.id)
+		pktResponse, ok := <-req
+		switch {
+		case !ok:
+			ackErr = common.ErrClosed
+		case pktResponse.Error != nil:
+			ackErr = pktResponse.Error
+		default:
+			acked = true
+			common.Log.Debugf("Setting color on %d acknowledged", l.id)
+		}
 	}
 
 	l.Lock()
 	l.color = color
+	l.restoreColor = nil
+	l.transitionEnd = time.Now().Add(duration)
+	l.colorStale = false
 	l.Unlock()
-	return l.publish(common.EventUpdateColor{Color: l.color})
+	if err := l.publish(common.EventUpdateColor{Color: l.color}); err != nil {
+		return acked, err
+	}
+	return acked, ackErr
 }
 
 func (l *Light) GetColor() (common.Color, error) {
@@ -138,18 +460,116 @@ func (l *Light) GetColor() (common.Color, error) {
 	return l.CachedColor(), nil
 }
 
+// WaitUntilColor polls the light at the client poll interval until it reports
+// a color within tolerance of target, or ctx is done, in which case the context
+// error is returned.  Errors retrieving the color are logged and polling
+// continues.
+func (l *Light) WaitUntilColor(ctx context.Context, target common.Color, tolerance uint16) error {
+	ticker := time.NewTicker(l.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		if err := l.get(ctx); err != nil {
+			common.Log.Debugf("Failed getting color while waiting on %d: %v", l.id, err)
+		} else if common.ColorApproxEqual(l.CachedColor(), target, tolerance) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 func (l *Light) CachedColor() common.Color {
 	l.RLock()
 	defer l.RUnlock()
 	return l.color
 }
 
+// SetPower sets the power of the light, restoring the remembered color first
+// if the client restores colors on power on
+func (l *Light) SetPower(state bool) error {
+	if err := l.restoreOnPower(state); err != nil {
+		return err
+	}
+	return l.Device.SetPower(state)
+}
+
+// restoreOnPower remembers the color when powering off, if the client restores
+// or remembers colors, and applies the remembered color when powering on, if
+// the client restores colors
+func (l *Light) restoreOnPower(state bool) error {
+	if l.client == nil {
+		return nil
+	}
+	restore := l.client.GetRestoreOnPower()
+
+	if !state {
+		memory := l.client.GetColorMemory()
+		if !restore && !memory {
+			return nil
+		}
+		color, err := l.GetColor()
+		if err != nil {
+			common.Log.Debugf("Failed getting color to remember on %d, using cached color: %v", l.id, err)
+			color = l.CachedColor()
+		}
+		l.Lock()
+		if restore {
+			l.restoreColor = &color
+		}
+		l.Unlock()
+		// Only the color of a light that is on is remembered, so that
+		// powering off twice does not replace it
+		if memory && l.CachedPower() {
+			l.Lock()
+			l.lastOnColor = &color
+			l.Unlock()
+		}
+		return nil
+	}
+
+	if !restore {
+		return nil
+	}
+
+	l.RLock()
+	color := l.restoreColor
+	l.RUnlock()
+	if color == nil || common.ColorEqual(*color, l.CachedColor()) {
+		return nil
+	}
+	common.Log.Debugf("Restoring color on %d", l.id)
+	return l.setColor(context.Background(), *color, 0)
+}
+
+// LastOnColor returns the color the light had when the client last powered it
+// off, if the client remembers colors
+func (l *Light) LastOnColor() (common.Color, bool) {
+	l.RLock()
+	defer l.RUnlock()
+	if l.lastOnColor == nil {
+		return common.Color{}, false
+	}
+	return *l.lastOnColor, true
+}
+
 func (l *Light) SetPowerDuration(state bool, duration time.Duration) error {
+	millis, err := durationMillis(duration)
+	if err != nil {
+		return err
+	}
+	if err := l.restoreOnPower(state); err != nil {
+		return err
+	}
 	p := new(payloadPowerDuration)
 	if state {
 		p.Level = math.MaxUint16
 	}
-	p.Duration = uint32(duration / time.Millisecond)
+	p.Duration = millis
 
 	pkt := packet.New(l.address, l.requestSocket)
 	pkt.SetType(LightSetPower)
@@ -167,9 +587,30 @@ func (l *Light) SetPowerDuration(state bool, duration time.Duration) error {
 		<-req
 		common.Log.Debugf("Setting power state on %d acknowledged", l.id)
 	}
+	l.Lock()
+	l.transitionEnd = time.Now().Add(duration)
+	l.Unlock()
+
+	return l.updatePower(p.Level)
+}
 
+// InvalidateCache marks the cached color, power and label as stale, so that
+// they are requested from the light on next access, and the next SetColor is
+// sent even if it matches the cached color
+func (l *Light) InvalidateCache() {
+	l.Device.InvalidateCache()
 	l.Lock()
-	l.power = p.Level
+	l.colorStale = true
 	l.Unlock()
-	return l.publish(common.EventUpdatePower{Power: l.power > 0})
+}
+
+// TransitionRemaining returns the estimated time until the last color or power
+// transition sent to the light completes, measured from when it was sent
+func (l *Light) TransitionRemaining() time.Duration {
+	l.RLock()
+	defer l.RUnlock()
+	if remaining := time.Until(l.transitionEnd); remaining > 0 {
+		return remaining
+	}
+	return 0
 }
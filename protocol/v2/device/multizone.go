@@ -0,0 +1,410 @@
+package device
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/protocol/v2/packet"
+	"github.com/pdf/golifx/protocol/v2/shared"
+)
+
+const (
+	SetColorZones  shared.Message = 501
+	GetColorZones  shared.Message = 502
+	StateZone      shared.Message = 503
+	StateMultiZone shared.Message = 506
+
+	// multiZoneChunkSize is the number of zones reported by each
+	// StateMultiZone packet
+	multiZoneChunkSize = 8
+
+	applicationNoApply   uint8 = 0
+	applicationApply     uint8 = 1
+	applicationApplyOnly uint8 = 2
+)
+
+type MultiZoneLight struct {
+	*Light
+	zoneCount uint8
+	zones     []common.Color
+	staged    []stagedZones
+}
+
+// stagedZones is a change sent without applying, cached once applied
+type stagedZones struct {
+	start, end uint8
+	color      common.Color
+}
+
+type payloadGetColorZones struct {
+	StartIndex uint8
+	EndIndex   uint8
+}
+
+type payloadSetColorZones struct {
+	StartIndex uint8
+	EndIndex   uint8
+	Color      common.Color
+	Duration   uint32
+	Apply      uint8
+}
+
+type stateZone struct {
+	Count uint8
+	Index uint8
+	Color common.Color
+}
+
+type stateMultiZone struct {
+	Count  uint8
+	Index  uint8
+	Colors [multiZoneChunkSize]common.Color
+}
+
+// SetStateZones caches the zone count and colors reported by a StateZone or
+// StateMultiZone packet
+func (l *MultiZoneLight) SetStateZones(pkt *packet.Packet) error {
+	switch pkt.GetType() {
+	case StateZone:
+		s := stateZone{}
+		if err := pkt.DecodePayload(&s); err != nil {
+			return err
+		}
+		common.Log.Debugf("Got zone state (%d): %+v", l.id, s)
+		l.updateZones(s.Count, s.Index, s.Color)
+	case StateMultiZone:
+		s := stateMultiZone{}
+		if err := pkt.DecodePayload(&s); err != nil {
+			return err
+		}
+		common.Log.Debugf("Got multizone state (%d): %+v", l.id, s)
+		l.updateZones(s.Count, s.Index, s.Colors[:]...)
+	default:
+		return common.ErrProtocol
+	}
+
+	return nil
+}
+
+func (l *MultiZoneLight) updateZones(count, index uint8, colors ...common.Color) {
+	l.Lock()
+	defer l.Unlock()
+	if len(l.zones) != int(count) {
+		zones := make([]common.Color, count)
+		copy(zones, l.zones)
+		l.zones = zones
+	}
+	l.zoneCount = count
+	for i, color := range colors {
+		if int(index)+i >= int(count) {
+			break
+		}
+		l.zones[int(index)+i] = color
+	}
+}
+
+func (l *MultiZoneLight) cachedZoneCount() uint8 {
+	l.RLock()
+	defer l.RUnlock()
+	return l.zoneCount
+}
+
+func (l *MultiZoneLight) getColorZones(start, end uint8) error {
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(GetColorZones)
+	if err := pkt.SetPayload(&payloadGetColorZones{StartIndex: start, EndIndex: end}); err != nil {
+		return err
+	}
+	req, err := l.Send(pkt, l.reliable, true)
+	if err != nil {
+		return err
+	}
+
+	common.Log.Debugf("Waiting for zones %d-%d (%d)", start, end, l.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return pktResponse.Error
+	}
+
+	return l.SetStateZones(pktResponse.Result)
+}
+
+// ZoneCount returns the number of zones on the light, requesting it from the
+// light if it is not yet known
+func (l *MultiZoneLight) ZoneCount() (uint8, error) {
+	if count := l.cachedZoneCount(); count > 0 {
+		return count, nil
+	}
+	if err := l.getColorZones(0, 0); err != nil {
+		return 0, err
+	}
+
+	return l.cachedZoneCount(), nil
+}
+
+// GetZoneColors requests the color of each zone, in chunks of the eight zones
+// reported by each StateMultiZone response
+func (l *MultiZoneLight) GetZoneColors() ([]common.Color, error) {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return nil, err
+	}
+	for start := 0; start < int(count); start += multiZoneChunkSize {
+		end := start + multiZoneChunkSize - 1
+		if end >= int(count) {
+			end = int(count) - 1
+		}
+		if err := l.getColorZones(uint8(start), uint8(end)); err != nil {
+			return nil, err
+		}
+	}
+
+	l.RLock()
+	defer l.RUnlock()
+	colors := make([]common.Color, len(l.zones))
+	copy(colors, l.zones)
+	return colors, nil
+}
+
+// VerifyZones requests the color of each zone, and returns the indexes of the
+// zones that do not match expected within tolerance
+func (l *MultiZoneLight) VerifyZones(expected []common.Color, tolerance uint16) ([]int, error) {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return nil, err
+	}
+	if len(expected) != int(count) {
+		return nil, common.ErrInvalidArgument
+	}
+	colors, err := l.GetZoneColors()
+	if err != nil {
+		return nil, err
+	}
+	return common.MismatchedZones(colors, expected, tolerance)
+}
+
+// SetZoneColors sends a SetColorZones request for each run of adjacent zones
+// sharing a color, applying the changes with the final request so that all
+// zones transition together.  Each request is subject to the rate limit, so
+// setting many distinct colors takes some time.
+func (l *MultiZoneLight) SetZoneColors(colors []common.Color, duration time.Duration) error {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return err
+	}
+	if len(colors) != int(count) {
+		return common.ErrInvalidArgument
+	}
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	floor := l.minBrightness()
+	floored := make([]common.Color, len(colors))
+	for i, color := range colors {
+		floored[i] = common.FloorBrightness(color, floor)
+	}
+	colors = floored
+	if duration < shared.RateLimit {
+		duration = shared.RateLimit
+	}
+	millis, err := durationMillis(duration)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(colors); {
+		end := start
+		for end+1 < len(colors) && common.ColorEqual(colors[end+1], colors[start]) {
+			end++
+		}
+		p := &payloadSetColorZones{
+			StartIndex: uint8(start),
+			EndIndex:   uint8(end),
+			Color:      colors[start],
+			Duration:   millis,
+			Apply:      applicationNoApply,
+		}
+		if end == len(colors)-1 {
+			p.Apply = applicationApply
+		}
+		if err := l.setColorZones(p); err != nil {
+			return err
+		}
+		start = end + 1
+	}
+
+	// Every zone was overwritten, so staged changes are superseded
+	l.Lock()
+	l.staged = nil
+	l.Unlock()
+	l.updateZones(count, 0, colors...)
+	return nil
+}
+
+// SetColorZones sets the zones from start to end (inclusive) to color with a
+// single request, leaving other zones untouched
+func (l *MultiZoneLight) SetColorZones(start, end uint8, color common.Color, duration time.Duration) error {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return err
+	}
+	if start > end || end >= count {
+		return common.ErrInvalidArgument
+	}
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	if duration < shared.RateLimit {
+		duration = shared.RateLimit
+	}
+	millis, err := durationMillis(duration)
+	if err != nil {
+		return err
+	}
+	color = common.FloorBrightness(color, l.minBrightness())
+
+	p := &payloadSetColorZones{
+		StartIndex: start,
+		EndIndex:   end,
+		Color:      color,
+		Duration:   millis,
+		Apply:      applicationApply,
+	}
+	if err := l.setColorZones(p); err != nil {
+		return err
+	}
+
+	// Applying also displays any staged changes
+	l.applyStaged(count)
+	l.updateZones(count, start, fillColors(start, end, color)...)
+	return nil
+}
+
+// SetColorZonesStaged sends the change of zones from start to end (inclusive)
+// to color without applying it, see common.MultiZoneLight
+func (l *MultiZoneLight) SetColorZonesStaged(start, end uint8, color common.Color) error {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return err
+	}
+	if start > end || end >= count {
+		return common.ErrInvalidArgument
+	}
+	color = common.FloorBrightness(color, l.minBrightness())
+
+	p := &payloadSetColorZones{
+		StartIndex: start,
+		EndIndex:   end,
+		Color:      color,
+		Apply:      applicationNoApply,
+	}
+	if err := l.setColorZones(p); err != nil {
+		return err
+	}
+
+	l.Lock()
+	l.staged = append(l.staged, stagedZones{start: start, end: end, color: color})
+	l.Unlock()
+	return nil
+}
+
+// ApplyZones applies the changes staged by SetColorZonesStaged, transitioning
+// over the specified duration
+func (l *MultiZoneLight) ApplyZones(duration time.Duration) error {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return err
+	}
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	if duration < shared.RateLimit {
+		duration = shared.RateLimit
+	}
+	millis, err := durationMillis(duration)
+	if err != nil {
+		return err
+	}
+
+	// The zones and color are ignored when only applying
+	p := &payloadSetColorZones{
+		Duration: millis,
+		Apply:    applicationApplyOnly,
+	}
+	if err := l.setColorZones(p); err != nil {
+		return err
+	}
+
+	l.applyStaged(count)
+	return nil
+}
+
+// applyStaged updates the cached zones with the staged changes, in the order
+// they were staged
+func (l *MultiZoneLight) applyStaged(count uint8) {
+	l.Lock()
+	staged := l.staged
+	l.staged = nil
+	l.Unlock()
+	for _, s := range staged {
+		l.updateZones(count, s.start, fillColors(s.start, s.end, s.color)...)
+	}
+}
+
+// fillColors returns color repeated for each zone from start to end
+// (inclusive)
+func fillColors(start, end uint8, color common.Color) []common.Color {
+	colors := make([]common.Color, int(end-start)+1)
+	for i := range colors {
+		colors[i] = color
+	}
+	return colors
+}
+
+// SetColorFraction changes the color of the portion of the light from start
+// to end, as fractions of its length, see common.ZoneRange
+func (l *MultiZoneLight) SetColorFraction(start, end float64, color common.Color, duration time.Duration) error {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return err
+	}
+	first, last, err := common.ZoneRange(count, start, end)
+	if err != nil {
+		return err
+	}
+	return l.SetColorZones(first, last, color, duration)
+}
+
+// SetGradient sets a gradient across all zones of the light, returns
+// common.ErrInvalidArgument if the light reports no zones
+func (l *MultiZoneLight) SetGradient(from, to common.Color, duration time.Duration) error {
+	count, err := l.ZoneCount()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return common.ErrInvalidArgument
+	}
+
+	return l.SetZoneColors(common.Gradient(from, to, int(count)), duration)
+}
+
+func (l *MultiZoneLight) setColorZones(p *payloadSetColorZones) error {
+	common.Log.Debugf("Setting zones %d-%d on %d", p.StartIndex, p.EndIndex, l.id)
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(SetColorZones)
+	if err := pkt.SetPayload(p); err != nil {
+		return err
+	}
+	req, err := l.Send(pkt, l.reliable, false)
+	if err != nil {
+		return err
+	}
+	if l.reliable {
+		// Wait for ack
+		<-req
+		common.Log.Debugf("Setting zones %d-%d on %d acknowledged", p.StartIndex, p.EndIndex, l.id)
+	}
+
+	return nil
+}
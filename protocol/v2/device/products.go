@@ -0,0 +1,23 @@
+package device
+
+import "github.com/pdf/golifx/common"
+
+// GetProductInfo returns the product table entry for the device, requesting
+// the hardware version from the device if it is not yet known.  Returns
+// common.ErrNotFound if the product is not in the table.
+func (d *Device) GetProductInfo() (common.ProductInfo, error) {
+	vendor, err := d.GetHardwareVendor()
+	if err != nil {
+		return common.ProductInfo{}, err
+	}
+	product, err := d.GetHardwareProduct()
+	if err != nil {
+		return common.ProductInfo{}, err
+	}
+	info, ok := common.LookupProduct(vendor, product)
+	if !ok {
+		return common.ProductInfo{Vendor: vendor, Product: product}, common.ErrNotFound
+	}
+
+	return info, nil
+}
@@ -0,0 +1,93 @@
+package device
+
+import (
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/protocol/v2/packet"
+	"github.com/pdf/golifx/protocol/v2/shared"
+)
+
+const (
+	GetButtonConfig   shared.Message = 909
+	SetButtonConfig   shared.Message = 910
+	StateButtonConfig shared.Message = 911
+)
+
+// RelayDevice is a device that switches loads via relays, such as the LIFX
+// Switch
+type RelayDevice struct {
+	*Device
+}
+
+// buttonConfig is the payload of both SetButtonConfig and StateButtonConfig,
+// the backlight colors are shown while the load is on and off respectively
+type buttonConfig struct {
+	HapticDuration uint16
+	BacklightOn    common.Color
+	BacklightOff   common.Color
+}
+
+// SetIndicatorColor sets the backlight color shown while the load is on.  The
+// device only accepts the whole button configuration, so the configuration is
+// requested first, and the haptic duration and off color are sent unchanged.
+func (d *RelayDevice) SetIndicatorColor(color common.Color) error {
+	info, err := d.GetProductInfo()
+	if err != nil && err != common.ErrNotFound {
+		return err
+	}
+	if !info.Buttons {
+		return common.ErrNotSupported
+	}
+
+	config, err := d.getButtonConfig()
+	if err != nil {
+		return err
+	}
+	config.BacklightOn = color
+
+	pkt := packet.New(d.address, d.requestSocket)
+	pkt.SetType(SetButtonConfig)
+	if err := pkt.SetPayload(config); err != nil {
+		return err
+	}
+
+	common.Log.Debugf("Setting button config on %v: %+v", d.id, config)
+	req, err := d.Send(pkt, d.reliable, false)
+	if err != nil {
+		return err
+	}
+	if d.reliable {
+		// Wait for ack
+		<-req
+		common.Log.Debugf("Setting button config on %v acknowledged", d.id)
+	}
+
+	return nil
+}
+
+// getButtonConfig requests the button configuration, returning
+// common.ErrNotSupported if the device does not answer with it
+func (d *RelayDevice) getButtonConfig() (*buttonConfig, error) {
+	pkt := packet.New(d.address, d.requestSocket)
+	pkt.SetType(GetButtonConfig)
+	req, err := d.Send(pkt, d.reliable, true)
+	if err != nil {
+		return nil, err
+	}
+
+	common.Log.Debugf("Waiting for button config (%d)", d.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return nil, pktResponse.Error
+	}
+	if pktResponse.Result.GetType() != StateButtonConfig {
+		return nil, common.ErrNotSupported
+	}
+
+	config := &buttonConfig{}
+	if err := pktResponse.Result.DecodePayload(config); err != nil {
+		return nil, err
+	}
+	common.Log.Debugf("Got button config (%d): %+v", d.id, config)
+
+	return config, nil
+}
@@ -0,0 +1,114 @@
+package device
+
+import (
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/protocol/v2/packet"
+	"github.com/pdf/golifx/protocol/v2/shared"
+)
+
+// Tag messages are from the legacy v1 protocol, and are only answered by older
+// firmware
+const (
+	GetTags        shared.Message = 26
+	StateTags      shared.Message = 28
+	GetTagLabels   shared.Message = 29
+	StateTagLabels shared.Message = 31
+)
+
+type payloadTags struct {
+	Tags uint64
+}
+
+type stateTagLabels struct {
+	Tags  uint64
+	Label [32]byte
+}
+
+// Tags returns the labels of the legacy tags reported by the device.  Devices
+// that do not support tags either report the messages as unhandled, or ignore
+// them, in which case an empty slice is returned after the client timeout.
+func (l *Light) Tags() ([]string, error) {
+	mask, ok, err := l.getTags()
+	if err != nil || !ok {
+		return []string{}, err
+	}
+
+	tags := make([]string, 0)
+	for bit := uint(0); bit < 64; bit++ {
+		tag := uint64(1) << bit
+		if mask&tag == 0 {
+			continue
+		}
+		label, ok, err := l.getTagLabel(tag)
+		if err != nil {
+			return nil, err
+		}
+		if ok && label != `` {
+			tags = append(tags, label)
+		}
+	}
+
+	return tags, nil
+}
+
+// getTags requests the tag bitmask, ok is false if tags are not supported
+func (l *Light) getTags() (mask uint64, ok bool, err error) {
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(GetTags)
+	res, ok, err := l.tagRequest(pkt, StateTags)
+	if err != nil || !ok {
+		return 0, ok, err
+	}
+
+	p := &payloadTags{}
+	if err := res.DecodePayload(p); err != nil {
+		return 0, false, err
+	}
+
+	return p.Tags, true, nil
+}
+
+// getTagLabel requests the label for a single tag, ok is false if tags are not
+// supported
+func (l *Light) getTagLabel(tag uint64) (label string, ok bool, err error) {
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(GetTagLabels)
+	if err := pkt.SetPayload(&payloadTags{Tags: tag}); err != nil {
+		return ``, false, err
+	}
+	res, ok, err := l.tagRequest(pkt, StateTagLabels)
+	if err != nil || !ok {
+		return ``, ok, err
+	}
+
+	s := &stateTagLabels{}
+	if err := res.DecodePayload(s); err != nil {
+		return ``, false, err
+	}
+
+	return decodeString(s.Label[:]), true, nil
+}
+
+// tagRequest sends pkt and waits for a response of type expected, ok is false
+// if the device reports the request as unhandled, or does not respond
+func (l *Light) tagRequest(pkt *packet.Packet, expected shared.Message) (res *packet.Packet, ok bool, err error) {
+	req, err := l.Send(pkt, l.reliable, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	common.Log.Debugf("Waiting for tags (%d)", l.id)
+	pktResponse := <-req
+	if pktResponse.Error == common.ErrTimeout {
+		return nil, false, nil
+	}
+	if pktResponse.Error != nil {
+		return nil, false, pktResponse.Error
+	}
+	if pktResponse.Result.GetType() != expected {
+		common.Log.Debugf("Tags not supported by %d", l.id)
+		return nil, false, nil
+	}
+
+	return pktResponse.Result, true, nil
+}
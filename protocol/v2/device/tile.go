@@ -0,0 +1,205 @@
+package device
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/protocol/v2/packet"
+	"github.com/pdf/golifx/protocol/v2/shared"
+)
+
+const (
+	GetDeviceChain   shared.Message = 701
+	StateDeviceChain shared.Message = 702
+	GetTileState64   shared.Message = 707
+	StateTileState64 shared.Message = 711
+	SetTileState64   shared.Message = 715
+
+	// deviceChainSize is the number of tiles reported by each
+	// StateDeviceChain packet
+	deviceChainSize = 16
+)
+
+// TileLight is a light made up of a chain of matrix tiles
+type TileLight struct {
+	*Light
+}
+
+type tileDevice struct {
+	AccelX               int16
+	AccelY               int16
+	AccelZ               int16
+	_                    int16
+	UserX                float32
+	UserY                float32
+	Width                uint8
+	Height               uint8
+	_                    uint8
+	DeviceVersionVendor  uint32
+	DeviceVersionProduct uint32
+	_                    uint32
+	FirmwareBuild        uint64
+	_                    uint64
+	FirmwareVersionMinor uint16
+	FirmwareVersionMajor uint16
+	_                    uint32
+}
+
+type stateDeviceChain struct {
+	StartIndex uint8
+	Tiles      [deviceChainSize]tileDevice
+	Count      uint8
+}
+
+type payloadGetTileState64 struct {
+	Index  uint8
+	Length uint8
+	_      uint8
+	X      uint8
+	Y      uint8
+	Width  uint8
+}
+
+type payloadSetTileState64 struct {
+	Index    uint8
+	Length   uint8
+	_        uint8
+	X        uint8
+	Y        uint8
+	Width    uint8
+	Duration uint32
+	Colors   [common.MaxTilePixels]common.Color
+}
+
+type stateTileState64 struct {
+	Index  uint8
+	_      uint8
+	X      uint8
+	Y      uint8
+	Width  uint8
+	Colors [common.MaxTilePixels]common.Color
+}
+
+// GetTileChain requests the tiles in the chain
+func (l *TileLight) GetTileChain() ([]common.Tile, error) {
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(GetDeviceChain)
+	req, err := l.Send(pkt, l.reliable, true)
+	if err != nil {
+		return nil, err
+	}
+
+	common.Log.Debugf("Waiting for device chain (%d)", l.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return nil, pktResponse.Error
+	}
+	if pktResponse.Result.GetType() != StateDeviceChain {
+		return nil, common.ErrProtocol
+	}
+
+	s := &stateDeviceChain{}
+	if err := pktResponse.Result.DecodePayload(s); err != nil {
+		return nil, err
+	}
+	common.Log.Debugf("Got device chain (%d): %+v", l.id, s)
+
+	count := int(s.Count)
+	if count > deviceChainSize {
+		count = deviceChainSize
+	}
+	tiles := make([]common.Tile, count)
+	for i := range tiles {
+		t := s.Tiles[i]
+		tiles[i] = common.Tile{
+			Index:  s.StartIndex + uint8(i),
+			UserX:  t.UserX,
+			UserY:  t.UserY,
+			Width:  t.Width,
+			Height: t.Height,
+			AccelX: t.AccelX,
+			AccelY: t.AccelY,
+			AccelZ: t.AccelZ,
+		}
+	}
+
+	return tiles, nil
+}
+
+// GetTileState64 requests the color of each pixel of the tile at index.  Tiles
+// are assumed to be 8 pixels wide, as are all tiles currently produced.
+func (l *TileLight) GetTileState64(index uint8) ([]common.Color, error) {
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(GetTileState64)
+	if err := pkt.SetPayload(&payloadGetTileState64{Index: index, Length: 1, Width: 8}); err != nil {
+		return nil, err
+	}
+	req, err := l.Send(pkt, l.reliable, true)
+	if err != nil {
+		return nil, err
+	}
+
+	common.Log.Debugf("Waiting for tile state %d (%d)", index, l.id)
+	pktResponse := <-req
+	if pktResponse.Error != nil {
+		return nil, pktResponse.Error
+	}
+	if pktResponse.Result.GetType() != StateTileState64 {
+		return nil, common.ErrProtocol
+	}
+
+	s := &stateTileState64{}
+	if err := pktResponse.Result.DecodePayload(s); err != nil {
+		return nil, err
+	}
+	common.Log.Debugf("Got tile state %d (%d)", s.Index, l.id)
+
+	colors := make([]common.Color, len(s.Colors))
+	copy(colors, s.Colors[:])
+	return colors, nil
+}
+
+// SetTileState64 changes the color of each pixel of the tile at index, from
+// colors in rows of width pixels
+func (l *TileLight) SetTileState64(index, width uint8, colors []common.Color, duration time.Duration) error {
+	if width == 0 || len(colors) > common.MaxTilePixels {
+		return common.ErrInvalidArgument
+	}
+	if err := common.ValidateDuration(duration); err != nil {
+		return err
+	}
+	if duration < shared.RateLimit {
+		duration = shared.RateLimit
+	}
+	millis, err := durationMillis(duration)
+	if err != nil {
+		return err
+	}
+
+	p := &payloadSetTileState64{
+		Index:    index,
+		Length:   1,
+		Width:    width,
+		Duration: millis,
+	}
+	copy(p.Colors[:], colors)
+
+	pkt := packet.New(l.address, l.requestSocket)
+	pkt.SetType(SetTileState64)
+	if err := pkt.SetPayload(p); err != nil {
+		return err
+	}
+
+	common.Log.Debugf("Setting tile state %d on %v", index, l.id)
+	req, err := l.Send(pkt, l.reliable, false)
+	if err != nil {
+		return err
+	}
+	if l.reliable {
+		// Wait for ack
+		<-req
+		common.Log.Debugf("Setting tile state %d on %v acknowledged", index, l.id)
+	}
+
+	return nil
+}
@@ -1,7 +1,29 @@
 package device
 
-import "strings"
+import (
+	"bytes"
+	"strings"
+	"time"
 
-func stripNull(s string) string {
-	return strings.Replace(s, string(0), ``, -1)
+	"github.com/pdf/golifx/common"
+)
+
+// decodeString decodes the fixed length, null padded string fields of the
+// protocol, eg labels.  The string ends at the first null, or fills the field
+// if there is none, and invalid UTF-8, eg a multi-byte character truncated by
+// the field length, is replaced with the Unicode replacement character.
+func decodeString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.ToValidUTF8(string(b), "\uFFFD")
+}
+
+// durationMillis converts the duration to the milliseconds encoded on the wire,
+// returning common.ErrInvalidArgument if it is out of range
+func durationMillis(duration time.Duration) (uint32, error) {
+	if err := common.ValidateDuration(duration); err != nil {
+		return 0, err
+	}
+	return uint32(duration / time.Millisecond), nil
 }
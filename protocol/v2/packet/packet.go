@@ -7,6 +7,7 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"math/rand"
 	"net"
 	"sync"
@@ -277,13 +278,34 @@ func New(destination *net.UDPAddr, socket *net.UDPConn) *Packet {
 	return p
 }
 
-func Decode(buf []byte) (*Packet, error) {
-	pkt := new(Packet)
-	err := struc.Unpack(bytes.NewBuffer(buf), pkt)
-	if err != nil {
-		return pkt, err
+// Decode decodes the packet in buf, as received from the network.  Returns an
+// error wrapping common.ErrProtocol if buf is shorter than the header, the
+// size in the header is shorter than the header or longer than buf, or the
+// protocol number is not 1024.  Bytes in buf beyond the size in the header are
+// ignored.
+func Decode(buf []byte) (pkt *Packet, err error) {
+	if len(buf) < headerBytes {
+		return nil, fmt.Errorf("%w: packet of %d bytes is shorter than the header", common.ErrProtocol, len(buf))
+	}
+	defer func() {
+		// Never allow a malformed packet to bring down the receive loop
+		if r := recover(); r != nil {
+			pkt, err = nil, fmt.Errorf("%w: panic decoding packet: %v", common.ErrProtocol, r)
+		}
+	}()
+
+	pkt = new(Packet)
+	if err := struc.Unpack(bytes.NewBuffer(buf), pkt); err != nil {
+		return nil, fmt.Errorf("%w: %v", common.ErrProtocol, err)
+	}
+	size := int(pkt.Frame.Size)
+	if size < headerBytes || size > len(buf) {
+		return nil, fmt.Errorf("%w: packet size %d invalid for %d bytes received", common.ErrProtocol, size, len(buf))
+	}
+	if protocol := pkt.GetProtocol(); protocol != 1024 {
+		return nil, fmt.Errorf("%w: unknown protocol number %d", common.ErrProtocol, protocol)
 	}
-	pkt.payload = buf[headerBytes:]
+	pkt.payload = buf[headerBytes:size]
 	return pkt, nil
 }
 
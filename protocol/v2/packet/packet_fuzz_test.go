@@ -0,0 +1,73 @@
+package packet_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/protocol/v2/packet"
+)
+
+// header returns a valid header for a packet of msg with payload
+func header(msg uint16, payload []byte) []byte {
+	buf := make([]byte, 36, 36+len(payload))
+	binary.LittleEndian.PutUint16(buf[0:], uint16(len(buf)+len(payload)))
+	// Addressable, protocol 1024
+	binary.LittleEndian.PutUint16(buf[2:], 1<<12|1024)
+	binary.LittleEndian.PutUint16(buf[32:], msg)
+	return append(buf, payload...)
+}
+
+// statePayload resembles the state payloads decoded from devices, with fixed
+// size arrays, floats and nested structs
+type statePayload struct {
+	Color    common.Color
+	Reserved int16
+	Power    uint16
+	Label    [32]byte
+	Signal   float32
+	Zones    [8]common.Color
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte(`garbage`))
+	f.Add(header(2, nil))
+	f.Add(header(107, make([]byte, 52)))
+	f.Add(header(107, make([]byte, 52))[:40])
+	f.Add(append(header(3, []byte{1, 0x7c, 0xdd, 0, 0}), 0xff, 0xff))
+
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		pkt, err := packet.Decode(buf)
+		if err != nil {
+			if !errors.Is(err, common.ErrProtocol) {
+				t.Fatalf("error %v does not wrap common.ErrProtocol", err)
+			}
+			return
+		}
+		size := int(binary.LittleEndian.Uint16(buf))
+		if len(pkt.GetPayload()) != size-36 {
+			t.Fatalf("payload of %d bytes for packet size %d", len(pkt.GetPayload()), size)
+		}
+	})
+}
+
+func FuzzDecodePayload(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(make([]byte, 8))
+	f.Add(make([]byte, 52))
+	f.Add(make([]byte, 1024))
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		if len(payload) > 1500-36 {
+			return
+		}
+		pkt, err := packet.Decode(header(107, payload))
+		if err != nil {
+			t.Fatalf("failed decoding valid header: %v", err)
+		}
+		// Malformed payloads must fail to decode, rather than panic
+		_ = pkt.DecodePayload(&statePayload{})
+	})
+}
@@ -0,0 +1,48 @@
+package packet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	// TraceSend labels traced packets sent to a device
+	TraceSend = `send`
+	// TraceReceive labels traced packets received from a device
+	TraceReceive = `recv`
+)
+
+// Trace writes a single line describing the packet to w, with the decoded
+// header fields and the hex-encoded payload, for debugging.  The direction
+// should be one of TraceSend or TraceReceive, and addr is the remote address.
+func (p *Packet) Trace(w io.Writer, direction string, addr *net.UDPAddr) {
+	target := make([]byte, 8)
+	binary.LittleEndian.PutUint64(target, p.GetTarget())
+
+	p.mutex.RLock()
+	payload := hex.EncodeToString(p.payload)
+	p.mutex.RUnlock()
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%s %s %v type=%d source=%d sequence=%d target=%s tagged=%v ack=%v res=%v payload=%s\n",
+		time.Now().Format(`15:04:05.000`),
+		direction,
+		addr,
+		p.GetType(),
+		p.GetSource(),
+		p.GetSequence(),
+		net.HardwareAddr(target[:6]),
+		p.GetTagged(),
+		p.GetAckRequired(),
+		p.GetResRequired(),
+		payload,
+	)
+	// Write the line in a single call, so lines are not interleaved by
+	// concurrent writers
+	_, _ = w.Write(buf.Bytes())
+}
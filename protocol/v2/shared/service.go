@@ -3,8 +3,9 @@ package shared
 type Service uint8
 
 const (
-	ServiceUDP Service = iota
+	ServiceUDP Service = iota + 1
 	serviceReserved0
 	serviceReserved1
 	serviceReserved2
+	serviceReserved3
 )
@@ -0,0 +1,689 @@
+package protocol_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx"
+	"github.com/pdf/golifx/common"
+	. "github.com/pdf/golifx/protocol"
+	"github.com/pdf/golifx/protocol/v2/device"
+	"github.com/pdf/golifx/protocol/v2/packet"
+	"github.com/pdf/golifx/protocol/v2/shared"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// fakeNetwork is a broadcast domain of fake devices sharing one socket.  Each
+// device answers discovery, firmware and WiFi requests, label requests if it
+// has a label, light state requests if it has a color, echoes group and
+// location changes, acknowledges other requests after ackDelay, and every
+// packet received from the client is recorded.
+type fakeNetwork struct {
+	socket   *net.UDPConn
+	ids      []uint64
+	packets  []*packet.Packet
+	ackDelay time.Duration
+	// labels are the raw labels of the devices that answer label requests
+	labels map[uint64][32]byte
+	// colors are the colors reported by the devices that answer light state
+	// requests
+	colors map[uint64]common.Color
+	// events records the type of each packet received, and of each
+	// acknowledgement sent, in order
+	events []shared.Message
+	sync.Mutex
+}
+
+type fakeStateService struct {
+	Service uint8
+	Port    uint32
+}
+
+type fakeLightState struct {
+	Color     common.Color
+	Reserved0 int16
+	Power     uint16
+	Label     [32]byte
+	Reserved1 uint64
+}
+
+type fakeStateWifiInfo struct {
+	Signal   float32
+	Tx       uint32
+	Rx       uint32
+	Reserved int16
+}
+
+type fakeStateHostFirmware struct {
+	Build    uint64
+	Reserved uint64
+	Version  uint32
+}
+
+func newFakeNetwork(ids ...uint64) *fakeNetwork {
+	socket, err := net.ListenUDP(`udp4`, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	Expect(err).NotTo(HaveOccurred())
+	n := &fakeNetwork{socket: socket, ids: ids}
+	go n.serve()
+	return n
+}
+
+func (n *fakeNetwork) port() int {
+	return n.socket.LocalAddr().(*net.UDPAddr).Port
+}
+
+func (n *fakeNetwork) serve() {
+	buf := make([]byte, 1024)
+	for {
+		length, addr, err := n.socket.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		pkt, err := packet.Decode(append([]byte(nil), buf[:length]...))
+		if err != nil {
+			continue
+		}
+		n.Lock()
+		n.packets = append(n.packets, pkt)
+		n.events = append(n.events, pkt.GetType())
+		n.Unlock()
+		switch pkt.GetType() {
+		case device.GetService:
+			for _, id := range n.ids {
+				n.reply(pkt, addr, id, device.StateService, &fakeStateService{Service: uint8(common.ServiceUDP), Port: uint32(n.port())})
+			}
+		case device.GetHostFirmware:
+			n.reply(pkt, addr, pkt.GetTarget(), device.StateHostFirmware, &fakeStateHostFirmware{Version: 2<<16 | 80})
+		case device.GetLabel:
+			n.Lock()
+			label, ok := n.labels[pkt.GetTarget()]
+			n.Unlock()
+			if ok {
+				n.reply(pkt, addr, pkt.GetTarget(), device.StateLabel, &label)
+			}
+		case device.Get:
+			n.Lock()
+			color, ok := n.colors[pkt.GetTarget()]
+			n.Unlock()
+			if ok {
+				n.reply(pkt, addr, pkt.GetTarget(), device.State, &fakeLightState{Color: color})
+			}
+		case device.SetGroup, device.SetLocation:
+			// The state message follows the set message, with the same payload
+			var state [56]byte
+			copy(state[:], pkt.GetPayload())
+			n.reply(pkt, addr, pkt.GetTarget(), pkt.GetType()+1, &state)
+		case device.GetWifiInfo:
+			n.reply(pkt, addr, pkt.GetTarget(), device.StateWifiInfo, &fakeStateWifiInfo{Signal: 1e-5})
+		default:
+			if pkt.GetAckRequired() && !pkt.GetResRequired() {
+				go n.ack(pkt, addr)
+			}
+		}
+	}
+}
+
+// ack acknowledges pkt after the ackDelay
+func (n *fakeNetwork) ack(pkt *packet.Packet, addr *net.UDPAddr) {
+	n.Lock()
+	delay := n.ackDelay
+	n.Unlock()
+	time.Sleep(delay)
+	n.Lock()
+	n.events = append(n.events, device.Acknowledgement)
+	n.Unlock()
+	n.reply(pkt, addr, pkt.GetTarget(), device.Acknowledgement, &struct{}{})
+}
+
+// reply sends a response to pkt from the device with the target id
+func (n *fakeNetwork) reply(pkt *packet.Packet, addr *net.UDPAddr, id uint64, msg shared.Message, payload interface{}) {
+	res := packet.New(addr, n.socket)
+	res.SetType(msg)
+	res.SetTarget(id)
+	res.SetSource(pkt.GetSource())
+	res.SetSequence(pkt.GetSequence())
+	if err := res.SetPayload(payload); err != nil {
+		return
+	}
+	_ = res.Write()
+}
+
+// sequence returns the events recorded so far of type msg, and
+// acknowledgements
+func (n *fakeNetwork) sequence(msg shared.Message) []shared.Message {
+	n.Lock()
+	defer n.Unlock()
+	var events []shared.Message
+	for _, event := range n.events {
+		if event == msg || event == device.Acknowledgement {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// received returns the packets of type msg received so far
+func (n *fakeNetwork) received(msg uint16) []*packet.Packet {
+	n.Lock()
+	defer n.Unlock()
+	var packets []*packet.Packet
+	for _, pkt := range n.packets {
+		if uint16(pkt.GetType()) == msg {
+			packets = append(packets, pkt)
+		}
+	}
+	return packets
+}
+
+func (n *fakeNetwork) close() {
+	_ = n.socket.Close()
+}
+
+// recordedSpan is a span ended by a spanRecorder
+type recordedSpan struct {
+	ctx   context.Context
+	name  string
+	attrs common.SpanAttributes
+	err   error
+}
+
+// spanRecorder is a common.SpanHook that records ended spans
+type spanRecorder struct {
+	spans []recordedSpan
+	sync.Mutex
+}
+
+func (r *spanRecorder) StartSpan(ctx context.Context, name string, attrs common.SpanAttributes) func(error) {
+	return func(err error) {
+		r.Lock()
+		r.spans = append(r.spans, recordedSpan{ctx: ctx, name: name, attrs: attrs, err: err})
+		r.Unlock()
+	}
+}
+
+// ended returns the spans ended for requests of type msg to the device id
+func (r *spanRecorder) ended(id uint64, msg shared.Message) []recordedSpan {
+	r.Lock()
+	defer r.Unlock()
+	var spans []recordedSpan
+	for _, span := range r.spans {
+		if span.attrs.DeviceID == id && span.attrs.Message == uint16(msg) {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// freePort returns a UDP port that is not in use
+func freePort() int {
+	socket, err := net.ListenUDP(`udp4`, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	Expect(err).NotTo(HaveOccurred())
+	defer func() { _ = socket.Close() }()
+	return socket.LocalAddr().(*net.UDPAddr).Port
+}
+
+var _ = Describe("V2", func() {
+	var (
+		network *fakeNetwork
+		proto   *V2
+		client  *golifx.Client
+	)
+
+	BeforeEach(func() {
+		var err error
+		network = newFakeNetwork(1, 2, 3)
+		proto = &V2{Reliable: true, Port: freePort()}
+		client, err = golifx.NewClient(proto,
+			golifx.WithTimeout(50*time.Millisecond),
+			golifx.WithBroadcastAddress(`127.0.0.1:`+strconv.Itoa(network.port())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = client.Close()
+		network.close()
+	})
+
+	It("should broadcast a waveform in a single packet", func() {
+		Eventually(func() int {
+			devices, _ := proto.GetDevices()
+			return len(devices)
+		}, 5*time.Second).Should(Equal(3))
+
+		color := common.Color{Hue: 1, Saturation: 2, Brightness: 3, Kelvin: 3500}
+		Expect(client.BroadcastWaveform(common.Waveform{
+			Type:      common.WaveformPulse,
+			Color:     color,
+			Period:    time.Second,
+			Cycles:    3,
+			Transient: true,
+		})).To(Succeed())
+
+		Eventually(func() int {
+			return len(network.received(uint16(device.SetWaveform)))
+		}).Should(Equal(1))
+		Consistently(func() int {
+			return len(network.received(uint16(device.SetWaveform)))
+		}, 200*time.Millisecond).Should(Equal(1))
+
+		pkt := network.received(uint16(device.SetWaveform))[0]
+		// Untargeted, so that every device on the network applies it
+		Expect(pkt.GetTarget()).To(BeZero())
+		Expect(pkt.GetTagged()).To(BeTrue())
+		Expect(pkt.GetPayload()).To(HaveLen(21))
+	})
+
+	It("should prefetch firmware versions on discovery when enabled", func() {
+		prefetch := &V2{Reliable: true, Port: freePort()}
+		prefetchClient, err := golifx.NewClient(prefetch,
+			golifx.WithTimeout(50*time.Millisecond),
+			golifx.WithPrefetchDeviceInfo(true),
+			golifx.WithBroadcastAddress(`127.0.0.1:`+strconv.Itoa(network.port())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = prefetchClient.Close() }()
+
+		Eventually(func() int {
+			return len(network.received(uint16(device.GetHostFirmware)))
+		}, 5*time.Second).Should(Equal(3))
+
+		dev, err := prefetchClient.GetDeviceByID(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dev.GetFirmwareVersion()).NotTo(BeEmpty())
+		Expect(network.received(uint16(device.GetHostFirmware))).To(HaveLen(3))
+
+		dev.InvalidateCache()
+		Expect(dev.GetFirmwareVersion()).NotTo(BeEmpty())
+		Expect(network.received(uint16(device.GetHostFirmware))).To(HaveLen(4))
+	})
+
+	It("should include lights that did not report their product", func() {
+		// The fake network does not answer version requests
+		Eventually(func() int {
+			lights, _ := client.GetLights()
+			return len(lights)
+		}, 5*time.Second).Should(Equal(3))
+
+		light, err := client.GetLightByID(2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.ID()).To(Equal(uint64(2)))
+		_, err = light.GetLabel()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should serialize concurrent writes to a device", func() {
+		// Longer than the rate limit between packets, shorter than the retry
+		// interval
+		network.Lock()
+		network.ackDelay = 70 * time.Millisecond
+		network.Unlock()
+
+		queued := &V2{Reliable: true, Port: freePort()}
+		queuedClient, err := golifx.NewClient(queued,
+			golifx.WithTimeout(time.Second),
+			golifx.WithBroadcastAddress(`127.0.0.1:`+strconv.Itoa(network.port())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = queuedClient.Close() }()
+
+		Eventually(func() int {
+			devices, _ := queued.GetDevices()
+			return len(devices)
+		}, 5*time.Second).Should(Equal(3))
+		dev, err := queuedClient.GetDeviceByID(1)
+		Expect(err).NotTo(HaveOccurred())
+
+		const writes = 4
+		var wg sync.WaitGroup
+		for i := 0; i < writes; i++ {
+			wg.Add(1)
+			go func(label string) {
+				defer wg.Done()
+				defer GinkgoRecover()
+				Expect(dev.SetLabel(label)).To(Succeed())
+			}(strconv.Itoa(i))
+			// Stagger submission, well within the ack delay
+			time.Sleep(5 * time.Millisecond)
+		}
+		wg.Wait()
+
+		// Each write is sent only once the previous write was acknowledged
+		var expected []shared.Message
+		for i := 0; i < writes; i++ {
+			expected = append(expected, device.SetLabel, device.Acknowledgement)
+		}
+		Expect(network.sequence(device.SetLabel)).To(Equal(expected))
+
+		// In the order submitted
+		for i, pkt := range network.received(uint16(device.SetLabel)) {
+			Expect(pkt.GetPayload()[0]).To(Equal(strconv.Itoa(i)[0]))
+		}
+		Expect(dev.GetLabel()).To(Equal(strconv.Itoa(writes - 1)))
+	})
+
+	It("should not hold up writes behind an unacknowledged write without a timeout", func() {
+		// Not acknowledged within the test
+		network.Lock()
+		network.ackDelay = time.Minute
+		network.Unlock()
+
+		// Discovery requests the fake network does not answer would block
+		// without a timeout, so the device is addressed without discovery
+		unbounded := &V2{Reliable: true, Port: freePort()}
+		Expect(unbounded.SetBroadcastAddress(`127.0.0.1:` + strconv.Itoa(freePort()))).To(Succeed())
+		unboundedClient, err := golifx.NewClient(unbounded, golifx.WithTimeout(0))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = unboundedClient.Close() }()
+		dev, err := unboundedClient.DeviceFromCache(`01:00:00:00:00:00`, `127.0.0.1:`+strconv.Itoa(network.port()))
+		Expect(err).NotTo(HaveOccurred())
+
+		sent := func(label string) bool {
+			for _, pkt := range network.received(uint16(device.SetLabel)) {
+				if strings.TrimRight(string(pkt.GetPayload()), "\x00") == label {
+					return true
+				}
+			}
+			return false
+		}
+		// Both block until the client closes, awaiting acknowledgement
+		go func() { _ = dev.SetLabel(`first`) }()
+		Eventually(func() bool { return sent(`first`) }).Should(BeTrue())
+		go func() { _ = dev.SetLabel(`second`) }()
+
+		time.Sleep(common.DefaultTimeout / 2)
+		Expect(sent(`second`)).To(BeFalse())
+		Eventually(func() bool { return sent(`second`) }, common.DefaultTimeout).Should(BeTrue())
+	})
+
+	It("should tag requests with the source of each instance", func() {
+		sourced := &V2{Reliable: true, Port: freePort()}
+		sourcedClient, err := golifx.NewClient(sourced,
+			golifx.WithTimeout(50*time.Millisecond),
+			golifx.WithSource(42),
+			golifx.WithBroadcastAddress(`127.0.0.1:`+strconv.Itoa(network.port())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = sourcedClient.Close() }()
+
+		// Both instances match responses to their own source
+		for _, c := range []*golifx.Client{client, sourcedClient} {
+			Eventually(func() int {
+				lights, _ := c.GetLights()
+				return len(lights)
+			}, 5*time.Second).Should(Equal(3))
+		}
+		light, err := sourcedClient.GetLightByID(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.SetPower(true)).To(Succeed())
+
+		sources := make(map[uint32]bool)
+		for _, pkt := range network.received(uint16(device.GetService)) {
+			sources[pkt.GetSource()] = true
+		}
+		Expect(sources).To(HaveLen(2))
+		Expect(sources).To(HaveKey(uint32(42)))
+		Expect(network.received(uint16(device.SetPower))).To(HaveLen(1))
+		Expect(network.received(uint16(device.SetPower))[0].GetSource()).To(Equal(uint32(42)))
+	})
+
+	It("should keep the configured number of signal samples", func() {
+		client.SetSignalSampling(20*time.Millisecond, 3)
+		Eventually(func() int {
+			devices, _ := proto.GetDevices()
+			return len(devices)
+		}, 5*time.Second).Should(Equal(3))
+		dev, err := client.GetDeviceByID(3)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() int {
+			return len(network.received(uint16(device.GetWifiInfo)))
+		}).Should(BeNumerically(`>=`, 12))
+		// The latest request may still be awaiting its response
+		Eventually(dev.SignalHistory).Should(HaveLen(3))
+		samples := dev.SignalHistory()
+		Expect(samples).To(HaveLen(3))
+		Expect(samples[2].RSSI()).To(Equal(-50))
+	})
+
+	It("should span requests when a span hook is set", func() {
+		recorder := &spanRecorder{}
+		client.SetSpanHook(recorder)
+		Eventually(func() int {
+			devices, _ := proto.GetDevices()
+			return len(devices)
+		}, 5*time.Second).Should(Equal(3))
+		dev, err := client.GetDeviceByID(1)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(dev.SetLabel(`spanned`)).To(Succeed())
+		spans := recorder.ended(1, device.SetLabel)
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].name).To(Equal(common.SpanSend))
+		Expect(spans[0].err).NotTo(HaveOccurred())
+
+		// The fake network does not answer label requests
+		dev.InvalidateCache()
+		_, err = dev.GetLabel()
+		Expect(err).To(Equal(common.ErrTimeout))
+		Eventually(func() []recordedSpan {
+			return recorder.ended(1, device.GetLabel)
+		}).ShouldNot(BeEmpty())
+		Expect(recorder.ended(1, device.GetLabel)[0].err).To(Equal(common.ErrTimeout))
+	})
+
+	It("should drop packets that can not be decoded", func() {
+		type decodeError struct {
+			buf []byte
+			err error
+		}
+		errs := make(chan decodeError, 3)
+		client.OnDecodeError(func(buf []byte, err error) {
+			errs <- decodeError{buf: buf, err: err}
+		})
+		Eventually(func() int {
+			devices, _ := proto.GetDevices()
+			return len(devices)
+		}, 5*time.Second).Should(Equal(3))
+
+		// A header claiming more bytes than were sent
+		oversized := make([]byte, 36)
+		binary.LittleEndian.PutUint16(oversized, 100)
+		// A header with no protocol number
+		unknown := make([]byte, 36)
+		binary.LittleEndian.PutUint16(unknown, 36)
+		addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: proto.Port}
+		for _, buf := range [][]byte{[]byte(`garbage`), oversized, unknown} {
+			_, err := network.socket.WriteToUDP(buf, addr)
+			Expect(err).NotTo(HaveOccurred())
+			var e decodeError
+			Eventually(errs, time.Second).Should(Receive(&e))
+			Expect(e.buf).To(Equal(buf))
+			Expect(errors.Is(e.err, common.ErrProtocol)).To(BeTrue())
+		}
+
+		// The receive loop is still running
+		dev, err := client.GetDeviceByID(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dev.SetLabel(`still running`)).To(Succeed())
+	})
+
+	DescribeTable("decoding labels",
+		func(raw string, expected string) {
+			var label [32]byte
+			copy(label[:], raw)
+			network.Lock()
+			network.labels = map[uint64][32]byte{1: label}
+			network.Unlock()
+
+			Eventually(func() int {
+				devices, _ := proto.GetDevices()
+				return len(devices)
+			}, 5*time.Second).Should(Equal(3))
+			dev, err := client.GetDeviceByID(1)
+			Expect(err).NotTo(HaveOccurred())
+			dev.InvalidateCache()
+			Expect(dev.GetLabel()).To(Equal(expected))
+		},
+		Entry("empty", "", ""),
+		Entry("null terminated", "Kitchen", "Kitchen"),
+		Entry("garbage after the terminator", "Kitchen\x00\xff\xfeold label", "Kitchen"),
+		Entry("filling the field", strings.Repeat("a", 32), strings.Repeat("a", 32)),
+		Entry("multi-byte characters", "Küche", "Küche"),
+		Entry("a character truncated by the field", strings.Repeat("a", 31)+"ü", strings.Repeat("a", 31)+"\uFFFD"),
+		Entry("invalid UTF-8", "ab\xffcd", "ab\uFFFDcd"),
+	)
+
+	It("should release the socket on close", func() {
+		silent := &V2{Port: freePort(), ReadTimeout: 50 * time.Millisecond}
+		Expect(silent.SetBroadcastAddress(`127.0.0.1:` + strconv.Itoa(freePort()))).To(Succeed())
+		silentClient, err := golifx.NewClient(silent, golifx.WithTimeout(50*time.Millisecond))
+		Expect(err).NotTo(HaveOccurred())
+
+		closed := make(chan error)
+		go func() { closed <- silentClient.Close() }()
+		Eventually(closed, time.Second).Should(Receive(BeNil()))
+
+		socket, err := net.ListenUDP(`udp4`, &net.UDPAddr{Port: silent.Port})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(socket.Close()).To(Succeed())
+	})
+
+	It("should address cached devices without discovery", func() {
+		// Discovery is broadcast where no devices listen
+		undiscovered := &V2{Reliable: true, Port: freePort()}
+		Expect(undiscovered.SetBroadcastAddress(`127.0.0.1:` + strconv.Itoa(freePort()))).To(Succeed())
+		cachedClient, err := golifx.NewClient(undiscovered, golifx.WithTimeout(100*time.Millisecond))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = cachedClient.Close() }()
+
+		var label [32]byte
+		copy(label[:], `Cached`)
+		network.Lock()
+		network.labels = map[uint64][32]byte{1: label}
+		network.Unlock()
+
+		_, err = cachedClient.DeviceFromCache(`01:00:00:00:00:00`, `not an address`)
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+		_, err = cachedClient.DeviceFromCache(`invalid`, `127.0.0.1`)
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+
+		light, err := cachedClient.DeviceFromCache(`01:00:00:00:00:00`, `127.0.0.1:`+strconv.Itoa(network.port()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.ID()).To(Equal(uint64(1)))
+		Expect(light.GetLabel()).To(Equal(`Cached`))
+		Expect(cachedClient.GetLightByID(1)).To(BeIdenticalTo(light))
+		again, err := cachedClient.DeviceFromCache(`01:00:00:00:00:00`, `127.0.0.1:`+strconv.Itoa(network.port()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(BeIdenticalTo(light))
+
+		unreachable, err := cachedClient.DeviceFromCache(`02:00:00:00:00:00`, `127.0.0.1:`+strconv.Itoa(freePort()))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = unreachable.GetLabel()
+		Expect(err).To(Equal(common.ErrTimeout))
+	})
+
+	Context("verifying colors", func() {
+		var (
+			light common.Light
+			color = common.Color{Hue: 1000, Saturation: 65535, Brightness: 65535, Kelvin: 3500}
+		)
+
+		BeforeEach(func() {
+			Eventually(func() error {
+				var err error
+				light, err = client.GetLightByID(1)
+				return err
+			}, 5*time.Second).Should(Succeed())
+		})
+
+		It("should succeed when the light reports the color within tolerance", func() {
+			reported := color
+			reported.Hue += 10
+			network.Lock()
+			network.colors = map[uint64]common.Color{1: reported}
+			network.Unlock()
+
+			Expect(light.SetColorVerified(color, 0, 10, 0)).To(Succeed())
+			Expect(network.received(uint16(device.SetColor))).To(HaveLen(1))
+			Expect(network.received(uint16(device.Get))).To(HaveLen(1))
+		})
+
+		It("should fail when the light reports another color", func() {
+			network.Lock()
+			network.colors = map[uint64]common.Color{1: {Kelvin: 3500}}
+			network.Unlock()
+
+			Expect(light.SetColorVerified(color, 0, 10, 0)).To(Equal(common.ErrVerifyFailed))
+		})
+
+		It("should wait for the transition and delay before reading back", func() {
+			network.Lock()
+			network.colors = map[uint64]common.Color{1: color}
+			network.Unlock()
+
+			const delay = 100 * time.Millisecond
+			start := time.Now()
+			Expect(light.SetColorVerified(color, 0, 0, delay)).To(Succeed())
+			// Durations shorter than the rate limit are extended to it
+			Expect(time.Since(start)).To(BeNumerically(`>=`, shared.RateLimit+delay))
+		})
+
+		It("should span reads while waiting with the context of the wait", func() {
+			network.Lock()
+			network.colors = map[uint64]common.Color{1: color}
+			network.Unlock()
+			recorder := &spanRecorder{}
+			client.SetSpanHook(recorder)
+
+			type key struct{}
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, `wait`), 5*time.Second)
+			defer cancel()
+			Expect(light.WaitUntilColor(ctx, color, 0)).To(Succeed())
+			spans := recorder.ended(1, device.Get)
+			Expect(spans).NotTo(BeEmpty())
+			Expect(spans[0].ctx.Value(key{})).To(Equal(`wait`))
+		})
+	})
+
+	DescribeTable("moving devices between groups and locations",
+		func(msg shared.Message, set func(common.Device, [16]byte, string) error) {
+			var dev common.Device
+			Eventually(func() error {
+				var err error
+				dev, err = client.GetDeviceByID(1)
+				return err
+			}, 5*time.Second).Should(Succeed())
+			id := [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+			before := time.Now()
+			Expect(set(dev, id, `Kitchen`)).To(Succeed())
+			after := time.Now()
+
+			packets := network.received(uint16(msg))
+			Expect(packets).To(HaveLen(1))
+			payload := packets[0].GetPayload()
+			Expect(payload).To(HaveLen(56))
+			Expect(payload[:16]).To(Equal(id[:]))
+			Expect(strings.TrimRight(string(payload[16:48]), "\x00")).To(Equal(`Kitchen`))
+			updatedAt := int64(binary.LittleEndian.Uint64(payload[48:]))
+			Expect(updatedAt).To(BeNumerically(`>=`, before.UnixNano()))
+			Expect(updatedAt).To(BeNumerically(`<=`, after.UnixNano()))
+		},
+		Entry("group", device.SetGroup, common.Device.SetGroup),
+		Entry("location", device.SetLocation, common.Device.SetLocation),
+	)
+
+	It("should reject invalid waveforms", func() {
+		Expect(client.BroadcastWaveform(common.Waveform{Type: common.WaveformSine})).To(Equal(common.ErrInvalidArgument))
+	})
+})
@@ -0,0 +1,20 @@
+// Package rpc exposes a common.Client as the gRPC service defined in
+// lifx.proto, for integration with services in other processes or languages.
+// The package is only built with the `rpc` build tag, so that the core library
+// carries no server code or gRPC dependency:
+//
+//	go build -tags rpc ./...
+//
+// Lights are controlled with the unary GetLights, SetColor and SetPower
+// methods, and device events are delivered by the server streaming Subscribe
+// method.  Errors are returned as gRPC status codes, eg common.ErrNotFound as
+// codes.NotFound.
+//
+// The generated code is regenerated with buf, and the protoc-gen-go and
+// protoc-gen-go-grpc plugins on the PATH:
+//
+//	go generate ./rpc
+package rpc
+
+//go:generate buf generate
+//go:generate sed -i "1i //go:build rpc\n" lifx.pb.go lifx_grpc.pb.go
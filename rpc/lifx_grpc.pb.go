@@ -0,0 +1,268 @@
+//go:build rpc
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: lifx.proto
+
+// Package golifx.rpc mirrors the golifx client API, see the rpc Go package.
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Lifx_GetLights_FullMethodName = "/golifx.rpc.Lifx/GetLights"
+	Lifx_SetColor_FullMethodName  = "/golifx.rpc.Lifx/SetColor"
+	Lifx_SetPower_FullMethodName  = "/golifx.rpc.Lifx/SetPower"
+	Lifx_Subscribe_FullMethodName = "/golifx.rpc.Lifx/Subscribe"
+)
+
+// LifxClient is the client API for Lifx service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Lifx controls the lights known to a golifx client
+type LifxClient interface {
+	// GetLights returns the lights known to the client, ordered by ID
+	GetLights(ctx context.Context, in *GetLightsRequest, opts ...grpc.CallOption) (*GetLightsResponse, error)
+	// SetColor sets the color of a light, or all lights
+	SetColor(ctx context.Context, in *SetColorRequest, opts ...grpc.CallOption) (*SetColorResponse, error)
+	// SetPower sets the power of a light, or all lights
+	SetPower(ctx context.Context, in *SetPowerRequest, opts ...grpc.CallOption) (*SetPowerResponse, error)
+	// Subscribe streams device events until the call is cancelled
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Lifx_SubscribeClient, error)
+}
+
+type lifxClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLifxClient(cc grpc.ClientConnInterface) LifxClient {
+	return &lifxClient{cc}
+}
+
+func (c *lifxClient) GetLights(ctx context.Context, in *GetLightsRequest, opts ...grpc.CallOption) (*GetLightsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLightsResponse)
+	err := c.cc.Invoke(ctx, Lifx_GetLights_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifxClient) SetColor(ctx context.Context, in *SetColorRequest, opts ...grpc.CallOption) (*SetColorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetColorResponse)
+	err := c.cc.Invoke(ctx, Lifx_SetColor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifxClient) SetPower(ctx context.Context, in *SetPowerRequest, opts ...grpc.CallOption) (*SetPowerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetPowerResponse)
+	err := c.cc.Invoke(ctx, Lifx_SetPower_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifxClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Lifx_SubscribeClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Lifx_ServiceDesc.Streams[0], Lifx_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &lifxSubscribeClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Lifx_SubscribeClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type lifxSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *lifxSubscribeClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LifxServer is the server API for Lifx service.
+// All implementations must embed UnimplementedLifxServer
+// for forward compatibility
+//
+// Lifx controls the lights known to a golifx client
+type LifxServer interface {
+	// GetLights returns the lights known to the client, ordered by ID
+	GetLights(context.Context, *GetLightsRequest) (*GetLightsResponse, error)
+	// SetColor sets the color of a light, or all lights
+	SetColor(context.Context, *SetColorRequest) (*SetColorResponse, error)
+	// SetPower sets the power of a light, or all lights
+	SetPower(context.Context, *SetPowerRequest) (*SetPowerResponse, error)
+	// Subscribe streams device events until the call is cancelled
+	Subscribe(*SubscribeRequest, Lifx_SubscribeServer) error
+	mustEmbedUnimplementedLifxServer()
+}
+
+// UnimplementedLifxServer must be embedded to have forward compatible implementations.
+type UnimplementedLifxServer struct {
+}
+
+func (UnimplementedLifxServer) GetLights(context.Context, *GetLightsRequest) (*GetLightsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLights not implemented")
+}
+func (UnimplementedLifxServer) SetColor(context.Context, *SetColorRequest) (*SetColorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetColor not implemented")
+}
+func (UnimplementedLifxServer) SetPower(context.Context, *SetPowerRequest) (*SetPowerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPower not implemented")
+}
+func (UnimplementedLifxServer) Subscribe(*SubscribeRequest, Lifx_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedLifxServer) mustEmbedUnimplementedLifxServer() {}
+
+// UnsafeLifxServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LifxServer will
+// result in compilation errors.
+type UnsafeLifxServer interface {
+	mustEmbedUnimplementedLifxServer()
+}
+
+func RegisterLifxServer(s grpc.ServiceRegistrar, srv LifxServer) {
+	s.RegisterService(&Lifx_ServiceDesc, srv)
+}
+
+func _Lifx_GetLights_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLightsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifxServer).GetLights(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lifx_GetLights_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifxServer).GetLights(ctx, req.(*GetLightsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lifx_SetColor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetColorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifxServer).SetColor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lifx_SetColor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifxServer).SetColor(ctx, req.(*SetColorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lifx_SetPower_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPowerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifxServer).SetPower(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lifx_SetPower_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifxServer).SetPower(ctx, req.(*SetPowerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lifx_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LifxServer).Subscribe(m, &lifxSubscribeServer{ServerStream: stream})
+}
+
+type Lifx_SubscribeServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type lifxSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *lifxSubscribeServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Lifx_ServiceDesc is the grpc.ServiceDesc for Lifx service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Lifx_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "golifx.rpc.Lifx",
+	HandlerType: (*LifxServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLights",
+			Handler:    _Lifx_GetLights_Handler,
+		},
+		{
+			MethodName: "SetColor",
+			Handler:    _Lifx_SetColor_Handler,
+		},
+		{
+			MethodName: "SetPower",
+			Handler:    _Lifx_SetPower_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Lifx_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "lifx.proto",
+}
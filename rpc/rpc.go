@@ -0,0 +1,413 @@
+//go:build rpc
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/pdf/golifx/common"
+)
+
+// EventQueueSize is the number of events queued for each Subscribe stream,
+// when full the oldest event is dropped
+const EventQueueSize = 256
+
+// eventTypes maps common.DeviceEvent types to their EventType
+var eventTypes = map[string]EventType{
+	common.DeviceEventNew:     EventType_EVENT_TYPE_DEVICE_NEW,
+	common.DeviceEventExpired: EventType_EVENT_TYPE_DEVICE_EXPIRED,
+	common.DeviceEventOffline: EventType_EVENT_TYPE_DEVICE_OFFLINE,
+	common.DeviceEventOnline:  EventType_EVENT_TYPE_DEVICE_ONLINE,
+	common.DeviceEventLabel:   EventType_EVENT_TYPE_LABEL_UPDATE,
+	common.DeviceEventPower:   EventType_EVENT_TYPE_POWER_UPDATE,
+	common.DeviceEventColor:   EventType_EVENT_TYPE_COLOR_UPDATE,
+}
+
+// Server implements the Lifx service, see Register
+type Server struct {
+	UnimplementedLifxServer
+	client common.Client
+}
+
+// NewServer returns a *Server that performs requests via client
+func NewServer(client common.Client) *Server {
+	return &Server{client: client}
+}
+
+// Register registers s on server, for serving with the options and transport
+// of your choosing
+func Register(server *grpc.Server, s *Server) {
+	RegisterLifxServer(server, s)
+}
+
+// GetLights returns the lights known to the client, ordered by ID
+func (s *Server) GetLights(ctx context.Context, req *GetLightsRequest) (*GetLightsResponse, error) {
+	lights, err := s.client.GetLights()
+	if err != nil {
+		return nil, statusError(err)
+	}
+	res := &GetLightsResponse{Lights: make([]*Light, len(lights))}
+	for i, l := range lights {
+		label, _ := l.GetLabel()
+		res.Lights[i] = &Light{
+			Device: &Device{Id: l.ID(), Mac: l.MAC()},
+			Label:  label,
+			Online: l.IsOnline(),
+			Power:  l.CachedPower(),
+			Color:  protoColor(l.CachedColor()),
+		}
+	}
+	return res, nil
+}
+
+// SetColor sets the color of a light, or all lights
+func (s *Server) SetColor(ctx context.Context, req *SetColorRequest) (*SetColorResponse, error) {
+	color, err := commonColor(req.GetColor())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	duration, err := protoDuration(req.GetDuration())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	if req.GetId() == 0 {
+		err = s.client.SetColor(color, duration)
+	} else {
+		var light common.Light
+		if light, err = s.client.GetLightByID(req.GetId()); err == nil {
+			err = light.SetColor(color, duration)
+		}
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &SetColorResponse{}, nil
+}
+
+// SetPower sets the power of a light, or all lights
+func (s *Server) SetPower(ctx context.Context, req *SetPowerRequest) (*SetPowerResponse, error) {
+	duration, err := protoDuration(req.GetDuration())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	if req.GetId() == 0 {
+		err = s.client.SetPowerDuration(req.GetPower(), duration)
+	} else {
+		var light common.Light
+		if light, err = s.client.GetLightByID(req.GetId()); err == nil {
+			err = light.SetPowerDuration(req.GetPower(), duration)
+		}
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &SetPowerResponse{}, nil
+}
+
+// Subscribe streams device events until the call is cancelled, or the client
+// is closed.  Headers are sent once subscribed, so callers may wait on them to
+// be sure no later events are missed.
+func (s *Server) Subscribe(req *SubscribeRequest, stream Lifx_SubscribeServer) error {
+	sub, err := newSubscription(s.client)
+	if err != nil {
+		return statusError(err)
+	}
+	defer sub.close()
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	for {
+		events, err := sub.next(ctx)
+		if err != nil {
+			return statusError(err)
+		}
+		for _, queued := range events {
+			e := queued.event
+			if queued.dev != nil {
+				// Resolved here rather than when queued, as it may require a
+				// request to the device
+				e.Label, _ = queued.dev.GetLabel()
+			}
+			if err := stream.Send(protoEvent(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// statusError converts err to a gRPC status error
+func statusError(err error) error {
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, common.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, common.ErrInvalidArgument):
+		code = codes.InvalidArgument
+	case errors.Is(err, common.ErrTimeout):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, common.ErrClosed):
+		code = codes.Unavailable
+	case errors.Is(err, common.ErrNotSupported), errors.Is(err, common.ErrDeviceInvalidType):
+		code = codes.FailedPrecondition
+	default:
+		var notImplemented *common.ErrNotImplemented
+		if errors.As(err, &notImplemented) {
+			code = codes.Unimplemented
+		}
+	}
+	return status.Error(code, err.Error())
+}
+
+func protoColor(c common.Color) *Color {
+	return &Color{
+		Hue:        uint32(c.Hue),
+		Saturation: uint32(c.Saturation),
+		Brightness: uint32(c.Brightness),
+		Kelvin:     uint32(c.Kelvin),
+	}
+}
+
+// commonColor converts c, returning common.ErrInvalidArgument if a component
+// is out of range for a common.Color
+func commonColor(c *Color) (common.Color, error) {
+	for _, v := range []uint32{c.GetHue(), c.GetSaturation(), c.GetBrightness(), c.GetKelvin()} {
+		if v > math.MaxUint16 {
+			return common.Color{}, common.ErrInvalidArgument
+		}
+	}
+	return common.Color{
+		Hue:        uint16(c.GetHue()),
+		Saturation: uint16(c.GetSaturation()),
+		Brightness: uint16(c.GetBrightness()),
+		Kelvin:     uint16(c.GetKelvin()),
+	}, nil
+}
+
+// protoDuration converts d, which may be nil for no duration, returning
+// common.ErrInvalidArgument if it is out of range or negative
+func protoDuration(d *durationpb.Duration) (time.Duration, error) {
+	if d == nil {
+		return 0, nil
+	}
+	if err := d.CheckValid(); err != nil || d.AsDuration() < 0 {
+		return 0, common.ErrInvalidArgument
+	}
+	return d.AsDuration(), nil
+}
+
+func protoEvent(e common.DeviceEvent) *Event {
+	pe := &Event{
+		Type:   eventTypes[e.Type],
+		Time:   timestamppb.New(e.Time),
+		Device: &Device{Id: e.DeviceID, Mac: e.MAC},
+	}
+	if e.Label != `` || e.Type == common.DeviceEventLabel {
+		label := e.Label
+		pe.Label = &label
+	}
+	if e.Power != nil {
+		power := *e.Power
+		pe.Power = &power
+	}
+	if e.Color != nil {
+		pe.Color = protoColor(*e.Color)
+	}
+	return pe
+}
+
+// queuedEvent is an event queued for a stream, with the device to resolve the
+// label from before sending, if any
+type queuedEvent struct {
+	event common.DeviceEvent
+	dev   common.Device
+}
+
+// subscription queues client and device events for a Subscribe stream
+type subscription struct {
+	queue    []queuedEvent
+	notify   chan struct{}
+	quitChan chan struct{}
+	// ended is closed once the client subscription ends, when the client is
+	// closed
+	ended   chan struct{}
+	devices map[uint64]*common.Subscription
+	wg      sync.WaitGroup
+	sync.Mutex
+}
+
+func newSubscription(client common.Client) (*subscription, error) {
+	sub := &subscription{
+		notify:   make(chan struct{}, 1),
+		quitChan: make(chan struct{}),
+		ended:    make(chan struct{}),
+		devices:  make(map[uint64]*common.Subscription),
+	}
+	clientSub, err := client.NewSubscription()
+	if err != nil {
+		return nil, err
+	}
+	// Subscribe before taking the known devices, so none are missed
+	devices, _ := client.GetDevices()
+	for _, dev := range devices {
+		sub.watchDevice(dev)
+	}
+
+	sub.wg.Add(1)
+	go sub.run(clientSub)
+	return sub, nil
+}
+
+// run consumes client events until the subscription is closed
+func (s *subscription) run(clientSub *common.Subscription) {
+	defer s.wg.Done()
+	events := clientSub.Events()
+	for {
+		select {
+		case <-s.quitChan:
+			if err := clientSub.Close(); err != nil {
+				common.Log.Warnf("Failed closing RPC subscription: %v", err)
+			}
+			return
+		case event, ok := <-events:
+			if !ok {
+				close(s.ended)
+				return
+			}
+			var dev common.Device
+			switch event := event.(type) {
+			case common.EventNewDevice:
+				s.watchDevice(event.Device)
+				dev = event.Device
+			case common.EventExpiredDevice:
+				s.unwatchDevice(event.Device)
+			}
+			if e, ok := common.NewDeviceEvent(event, nil); ok {
+				s.enqueue(queuedEvent{event: e, dev: dev})
+			}
+		}
+	}
+}
+
+// watchDevice subscribes to updates from dev, if not already subscribed
+func (s *subscription) watchDevice(dev common.Device) {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.devices[dev.ID()]; ok {
+		return
+	}
+	select {
+	case <-s.quitChan:
+		return
+	default:
+	}
+	sub, err := dev.NewSubscription()
+	if err != nil {
+		common.Log.Warnf("Failed subscribing RPC subscription to %d: %v", dev.ID(), err)
+		return
+	}
+	s.devices[dev.ID()] = sub
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for event := range sub.Events() {
+			if e, ok := common.NewDeviceEvent(event, dev); ok {
+				s.enqueue(queuedEvent{event: e})
+			}
+		}
+	}()
+}
+
+// unwatchDevice closes the subscription to dev
+func (s *subscription) unwatchDevice(dev common.Device) {
+	s.Lock()
+	sub, ok := s.devices[dev.ID()]
+	delete(s.devices, dev.ID())
+	s.Unlock()
+	if ok {
+		if err := sub.Close(); err != nil {
+			common.Log.Warnf("Failed closing RPC subscription to %d: %v", dev.ID(), err)
+		}
+	}
+}
+
+// enqueue adds the event to the queue, dropping the oldest event if the queue
+// is full
+func (s *subscription) enqueue(e queuedEvent) {
+	s.Lock()
+	if len(s.queue) >= EventQueueSize {
+		common.Log.Warnf("RPC event queue full, dropping %s event for %d", s.queue[0].event.Type, s.queue[0].event.DeviceID)
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, e)
+	s.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the queued events, waiting for an event if none are queued.
+// Returns the context error if ctx is done first, or common.ErrClosed if the
+// subscription or client is closed.
+func (s *subscription) next(ctx context.Context) ([]queuedEvent, error) {
+	for {
+		s.Lock()
+		if len(s.queue) > 0 {
+			events := s.queue
+			s.queue = nil
+			s.Unlock()
+			return events, nil
+		}
+		s.Unlock()
+
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.ended:
+			return nil, common.ErrClosed
+		case <-s.quitChan:
+			return nil, common.ErrClosed
+		}
+	}
+}
+
+func (s *subscription) close() {
+	s.Lock()
+	select {
+	case <-s.quitChan:
+		s.Unlock()
+		return
+	default:
+		close(s.quitChan)
+	}
+	devices := s.devices
+	s.devices = make(map[uint64]*common.Subscription)
+	s.Unlock()
+
+	for id, sub := range devices {
+		if err := sub.Close(); err != nil {
+			common.Log.Warnf("Failed closing RPC subscription to %d: %v", id, err)
+		}
+	}
+	s.wg.Wait()
+}
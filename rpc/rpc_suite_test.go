@@ -0,0 +1,15 @@
+//go:build rpc
+
+package rpc_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRPC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RPC Suite")
+}
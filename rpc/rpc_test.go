@@ -0,0 +1,137 @@
+//go:build rpc
+
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/pdf/golifx"
+	"github.com/pdf/golifx/common"
+	"github.com/pdf/golifx/fakedevice"
+
+	. "github.com/pdf/golifx/rpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RPC", func() {
+	var (
+		proto  *fakedevice.Protocol
+		light  *fakedevice.Light
+		client *golifx.Client
+		server *grpc.Server
+		conn   *grpc.ClientConn
+		remote LifxClient
+		ctx    context.Context
+		cancel context.CancelFunc
+		color  = common.Color{Hue: 1000, Saturation: 65535, Brightness: 65535, Kelvin: 3500}
+	)
+
+	BeforeEach(func() {
+		var err error
+		light = fakedevice.NewLight(1, `one`, common.Color{})
+		proto = fakedevice.NewProtocol(light)
+		client, err = golifx.NewClient(proto)
+		Expect(err).NotTo(HaveOccurred())
+
+		l := bufconn.Listen(1024 * 1024)
+		server = grpc.NewServer()
+		Register(server, NewServer(client))
+		go func() { _ = server.Serve(l) }()
+
+		conn, err = grpc.NewClient(`passthrough:///bufconn`,
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return l.Dial()
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		remote = NewLifxClient(conn)
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	})
+
+	AfterEach(func() {
+		cancel()
+		_ = conn.Close()
+		server.Stop()
+		_ = client.Close()
+	})
+
+	It("should list lights", func() {
+		res, err := remote.GetLights(ctx, &GetLightsRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.GetLights()).To(HaveLen(1))
+		l := res.GetLights()[0]
+		Expect(l.GetDevice().GetId()).To(Equal(uint64(1)))
+		Expect(l.GetDevice().GetMac()).To(Equal(light.MAC()))
+		Expect(l.GetLabel()).To(Equal(`one`))
+		Expect(l.GetOnline()).To(BeTrue())
+	})
+
+	It("should set the color and power of a light", func() {
+		_, err := remote.SetColor(ctx, &SetColorRequest{
+			Id:       1,
+			Color:    &Color{Hue: 1000, Saturation: 65535, Brightness: 65535, Kelvin: 3500},
+			Duration: durationpb.New(time.Millisecond),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.CachedColor()).To(Equal(color))
+		_, err = remote.SetPower(ctx, &SetPowerRequest{Id: 1, Power: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(light.CachedPower()).To(BeTrue())
+	})
+
+	It("should return NotFound for unknown lights", func() {
+		client.SetTimeout(10 * time.Millisecond)
+		_, err := remote.SetPower(ctx, &SetPowerRequest{Id: 2, Power: true})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should return InvalidArgument for out of range arguments", func() {
+		_, err := remote.SetColor(ctx, &SetColorRequest{Id: 1, Color: &Color{Hue: 65536}})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		_, err = remote.SetPower(ctx, &SetPowerRequest{Id: 1, Duration: durationpb.New(-time.Second)})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("should stream events to subscribers", func() {
+		stream, err := remote.Subscribe(ctx, &SubscribeRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Header()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(light.SetColor(color, 0)).To(Succeed())
+		event, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.GetType()).To(Equal(EventType_EVENT_TYPE_COLOR_UPDATE))
+		Expect(event.GetDevice().GetId()).To(Equal(uint64(1)))
+		Expect(event.GetColor().GetHue()).To(Equal(uint32(color.Hue)))
+
+		Expect(proto.AddDevice(fakedevice.NewLight(2, `two`, common.Color{}))).To(Succeed())
+		event, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.GetType()).To(Equal(EventType_EVENT_TYPE_DEVICE_NEW))
+		Expect(event.GetLabel()).To(Equal(`two`))
+	})
+
+	It("should end streams when the client closes", func() {
+		stream, err := remote.Subscribe(ctx, &SubscribeRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Header()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Close()).To(Succeed())
+		_, err = stream.Recv()
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	})
+
+})
@@ -0,0 +1,139 @@
+package golifx
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+const (
+	// DefaultScheduleInterval is the interval at which a schedule updates
+	// lights if Schedule.Interval is not set
+	DefaultScheduleInterval = time.Minute
+
+	day = 24 * time.Hour
+)
+
+// ScheduleEntry is a point in a daily schedule, at which lights should be the
+// specified color
+type ScheduleEntry struct {
+	// At is the time of day for the entry, as the offset from local midnight
+	At time.Duration
+	// Color is the color lights should be at the time of the entry
+	Color common.Color
+}
+
+// Schedule is a daily color schedule, for eg circadian lighting.  Between
+// entries the color is interpolated (see common.LerpColor), wrapping from the
+// last entry of the day to the first.
+type Schedule struct {
+	// Entries are the points in the schedule, in order of time of day.  Use
+	// NewSchedule to obtain a validated and sorted schedule.
+	Entries []ScheduleEntry
+	// Interval determines how often lights are updated, each update
+	// transitions to the scheduled color over the interval, so changes are
+	// smooth.  Defaults to DefaultScheduleInterval.
+	Interval time.Duration
+}
+
+// NewSchedule returns a *Schedule with the entries sorted by time of day.
+// Returns common.ErrInvalidArgument if no entries are provided, or any entry
+// is not within a day of midnight.
+func NewSchedule(entries ...ScheduleEntry) (*Schedule, error) {
+	if len(entries) == 0 {
+		return nil, common.ErrInvalidArgument
+	}
+	for _, e := range entries {
+		if e.At < 0 || e.At >= day {
+			return nil, common.ErrInvalidArgument
+		}
+	}
+	sorted := make([]ScheduleEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At < sorted[j].At })
+
+	return &Schedule{Entries: sorted}, nil
+}
+
+// ColorAt returns the scheduled color at the wall-clock time t
+func (s *Schedule) ColorAt(t time.Time) common.Color {
+	offset := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second +
+		time.Duration(t.Nanosecond())
+
+	// Find the last entry at or before offset, the previous day's last entry
+	// applies before the first entry of the day
+	prev := len(s.Entries) - 1
+	for i, e := range s.Entries {
+		if e.At > offset {
+			break
+		}
+		prev = i
+	}
+	next := (prev + 1) % len(s.Entries)
+	from, to := s.Entries[prev], s.Entries[next]
+
+	span := to.At - from.At
+	if span <= 0 {
+		span += day
+	}
+	elapsed := offset - from.At
+	if elapsed < 0 {
+		elapsed += day
+	}
+
+	return common.LerpColor(from.Color, to.Color, float64(elapsed)/float64(span))
+}
+
+func (s *Schedule) interval() time.Duration {
+	if s.Interval <= 0 {
+		return DefaultScheduleInterval
+	}
+	return s.Interval
+}
+
+// RunSchedule applies the schedule to the lights until ctx is done, or
+// animations are cancelled on the client, at which point the context error is
+// returned.  If no lights are specified, the schedule is applied to all
+// lights, including those discovered while running.  The lights are
+// immediately set to the color scheduled for the current time, so on restart
+// they resume the schedule rather than starting over.  Errors setting colors
+// are logged, and the schedule continues.
+func (c *Client) RunSchedule(ctx context.Context, schedule *Schedule, lights ...common.Light) error {
+	ctx, done := c.animate(ctx)
+	defer done()
+
+	interval := schedule.interval()
+	apply := func(color common.Color, duration time.Duration) {
+		if len(lights) == 0 {
+			if err := c.SetColor(color, duration); err != nil {
+				common.Log.Warnf("Failed applying schedule: %v", err)
+			}
+			return
+		}
+		for _, light := range lights {
+			if err := light.SetColor(color, duration); err != nil {
+				common.Log.Warnf("Failed applying schedule to %d: %v", light.ID(), err)
+			}
+		}
+	}
+
+	apply(schedule.ColorAt(time.Now()), 0)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Transition to the color scheduled at the next tick
+		apply(schedule.ColorAt(time.Now().Add(interval)), interval)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package golifx_test
+
+import (
+	"time"
+
+	. "github.com/pdf/golifx"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pdf/golifx/common"
+)
+
+var _ = Describe("Schedule", func() {
+	var (
+		dim    = common.Color{Brightness: 0, Kelvin: 2700}
+		bright = common.Color{Brightness: 60000, Kelvin: 6500}
+		at     = func(hour, min int) time.Time {
+			return time.Date(2020, 1, 1, hour, min, 0, 0, time.Local)
+		}
+	)
+
+	It("should reject empty or out of range schedules", func() {
+		_, err := NewSchedule()
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+		_, err = NewSchedule(ScheduleEntry{At: 24 * time.Hour})
+		Expect(err).To(Equal(common.ErrInvalidArgument))
+	})
+
+	It("should sort entries by time of day", func() {
+		s, err := NewSchedule(ScheduleEntry{At: 18 * time.Hour, Color: dim}, ScheduleEntry{At: 6 * time.Hour, Color: bright})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Entries[0].Color).To(Equal(bright))
+	})
+
+	Context("with entries", func() {
+		var s *Schedule
+
+		BeforeEach(func() {
+			s, _ = NewSchedule(ScheduleEntry{At: 6 * time.Hour, Color: dim}, ScheduleEntry{At: 12 * time.Hour, Color: bright})
+		})
+
+		It("should return the entry color at the entry time", func() {
+			Expect(s.ColorAt(at(6, 0))).To(Equal(dim))
+			Expect(s.ColorAt(at(12, 0))).To(Equal(bright))
+		})
+
+		It("should interpolate between entries", func() {
+			Expect(s.ColorAt(at(9, 0))).To(Equal(common.LerpColor(dim, bright, 0.5)))
+		})
+
+		It("should wrap from the last entry to the first over midnight", func() {
+			// 12:00 -> 06:00 spans 18 hours, midnight is 12 hours in
+			Expect(s.ColorAt(at(0, 0))).To(Equal(common.LerpColor(bright, dim, 12.0/18)))
+		})
+	})
+
+})
@@ -0,0 +1,97 @@
+package golifx
+
+import (
+	"sync"
+
+	"github.com/pdf/golifx/common"
+)
+
+var shared struct {
+	client *Client
+	refs   int
+	sync.Mutex
+}
+
+// ClientHandle is a reference to a Client shared between callers, returned by
+// SharedClient.  All methods of Client are available on the handle, but Close
+// only releases the handle, the shared Client is closed once every handle has
+// been closed.
+type ClientHandle struct {
+	*Client
+	subscriptions map[string]*common.Subscription
+	closed        bool
+	sync.Mutex
+}
+
+// SharedClient returns a handle to a Client shared by every caller in the
+// process, so that independent subsystems may control lights without each
+// binding their own UDP socket.  The first call creates the Client using the
+// protocol returned by newProtocol, subsequent calls return a handle to the
+// same Client without calling newProtocol, until every handle has been closed.
+// Settings such as the timeout are shared by all handles, so should be
+// configured by one owner.
+func SharedClient(newProtocol func() common.Protocol) (*ClientHandle, error) {
+	shared.Lock()
+	defer shared.Unlock()
+
+	if shared.client == nil {
+		client, err := NewClient(newProtocol())
+		if err != nil {
+			return nil, err
+		}
+		shared.client = client
+	}
+	shared.refs++
+
+	return &ClientHandle{
+		Client:        shared.client,
+		subscriptions: make(map[string]*common.Subscription),
+	}, nil
+}
+
+// NewSubscription returns a new *common.Subscription for receiving events from
+// the shared client, which is closed along with the handle
+func (h *ClientHandle) NewSubscription() (*common.Subscription, error) {
+	h.Lock()
+	defer h.Unlock()
+	if h.closed {
+		return nil, common.ErrClosed
+	}
+	sub, err := h.Client.NewSubscription()
+	if err != nil {
+		return nil, err
+	}
+	h.subscriptions[sub.ID()] = sub
+	return sub, nil
+}
+
+// Close releases the handle and any subscriptions created through it, closing
+// the shared Client if this was the last open handle.  Returns
+// common.ErrClosed if the handle was already closed.
+func (h *ClientHandle) Close() error {
+	h.Lock()
+	if h.closed {
+		h.Unlock()
+		return common.ErrClosed
+	}
+	h.closed = true
+	subs := h.subscriptions
+	h.subscriptions = nil
+	h.Unlock()
+
+	for _, sub := range subs {
+		if err := sub.Close(); err != nil && err != common.ErrClosed {
+			common.Log.Warnf("Failed closing subscription: %v", err)
+		}
+	}
+
+	shared.Lock()
+	defer shared.Unlock()
+	shared.refs--
+	if shared.refs > 0 {
+		return nil
+	}
+	client := shared.client
+	shared.client = nil
+	return client.Close()
+}
@@ -0,0 +1,103 @@
+package golifx
+
+import (
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+type signalSampler struct {
+	interval time.Duration
+	quitChan chan struct{}
+	done     chan struct{}
+}
+
+// SetSignalSampling enables requesting the WiFi signal strength of each online
+// device every interval, keeping the most recent length samples per device,
+// which are available via Device.SignalHistory.  This helps to spot devices
+// with an intermittently weak signal that a single reading would miss.  Each
+// sample is a request per device, so sampling is disabled by default.  A length
+// of 0 keeps common.DefaultSignalHistoryLength samples, and the special
+// interval of 0 disables sampling.  Once enabled, the signal strength is also
+// recorded whenever Device.GetWifiInfo is called.
+func (c *Client) SetSignalSampling(interval time.Duration, length int) {
+	if interval <= 0 {
+		length = 0
+	} else if length <= 0 {
+		length = common.DefaultSignalHistoryLength
+	}
+
+	c.Lock()
+	previous := c.signalSampler
+	c.signalSampler = nil
+	c.signalHistoryLength = length
+	c.Unlock()
+	if previous != nil {
+		previous.close()
+	}
+	if interval <= 0 {
+		return
+	}
+
+	s := &signalSampler{
+		interval: interval,
+		quitChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run(c)
+
+	c.Lock()
+	c.signalSampler = s
+	c.Unlock()
+}
+
+// GetSignalHistoryLength returns the number of signal samples kept per device,
+// or 0 if signal sampling is disabled
+func (c *Client) GetSignalHistoryLength() int {
+	c.RLock()
+	defer c.RUnlock()
+	return c.signalHistoryLength
+}
+
+// run samples every interval until the sampler is closed
+func (s *signalSampler) run(c *Client) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.quitChan:
+			return
+		case <-ticker.C:
+			s.sample(c)
+		}
+	}
+}
+
+// sample requests the signal strength of each online device, which the device
+// records in its history
+func (s *signalSampler) sample(c *Client) {
+	devices, err := c.protocol.GetDevices()
+	if err != nil {
+		return
+	}
+	for _, dev := range devices {
+		select {
+		case <-s.quitChan:
+			return
+		default:
+		}
+		if !dev.IsOnline() {
+			continue
+		}
+		if _, err := dev.GetWifiInfo(); err != nil {
+			common.Log.Debugf("Failed sampling signal strength of %d: %v", dev.ID(), err)
+		}
+	}
+}
+
+func (s *signalSampler) close() {
+	close(s.quitChan)
+	<-s.done
+}
@@ -0,0 +1,84 @@
+package golifx
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pdf/golifx/common"
+)
+
+// syncWriter serializes writes to the underlying writer, as traffic may be
+// traced from many goroutines concurrently
+type syncWriter struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// SetTracer sets a writer to which every packet sent or received by the client
+// is logged, with decoded header fields and a hex payload, for debugging
+// protocol issues.  Packets sent before the tracer is set, such as the initial
+// discovery request, are not traced.  Tracing is disabled by default, and may
+// be disabled again by passing nil.
+func (c *Client) SetTracer(w io.Writer) {
+	c.Lock()
+	defer c.Unlock()
+	if w == nil {
+		c.tracer = nil
+		return
+	}
+	c.tracer = &syncWriter{w: w}
+}
+
+// GetTracer returns the currently configured tracer, or nil if tracing is
+// disabled
+func (c *Client) GetTracer() io.Writer {
+	c.RLock()
+	defer c.RUnlock()
+	return c.tracer
+}
+
+// SetSpanHook sets a hook that starts a tracing span for each request sent to
+// a device, including discovery, with the target device ID and message type as
+// attributes, ending it with the error if the request fails.  See the otelspan
+// package for an OpenTelemetry hook.  Spans are disabled by default, and may be
+// disabled again by passing nil.
+func (c *Client) SetSpanHook(hook common.SpanHook) {
+	c.Lock()
+	c.spanHook = hook
+	c.Unlock()
+}
+
+// GetSpanHook returns the currently configured span hook, or nil if spans are
+// disabled
+func (c *Client) GetSpanHook() common.SpanHook {
+	c.RLock()
+	defer c.RUnlock()
+	return c.spanHook
+}
+
+// OnDecodeError sets a handler called with the raw bytes of each packet
+// received that could not be decoded, eg because it was truncated or
+// malformed, and the decoding error.  Such packets are always dropped with a
+// logged warning, the handler allows observing them, eg when fuzzing.  The
+// handler is called from the receive loop, so should return promptly.  No
+// handler is set by default, and the handler may be removed again by passing
+// nil.
+func (c *Client) OnDecodeError(handler common.DecodeErrorHandler) {
+	c.Lock()
+	c.decodeErrorHandler = handler
+	c.Unlock()
+}
+
+// GetDecodeErrorHandler returns the currently configured decode error
+// handler, or nil if none is set
+func (c *Client) GetDecodeErrorHandler() common.DecodeErrorHandler {
+	c.RLock()
+	defer c.RUnlock()
+	return c.decodeErrorHandler
+}
@@ -0,0 +1,288 @@
+package golifx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pdf/golifx/common"
+)
+
+const (
+	// WebhookQueueSize is the number of events queued for delivery to the
+	// event webhook, when full the oldest event is dropped
+	WebhookQueueSize = 256
+	// WebhookMaxAttempts is the number of times delivery of each event is
+	// attempted before it is dropped
+	WebhookMaxAttempts = 5
+	// WebhookBackoff is the delay before the first retry of a failed delivery,
+	// doubling for each subsequent retry
+	WebhookBackoff = 500 * time.Millisecond
+)
+
+// webhookBackoff is the WebhookBackoff in use, overridden by tests
+var webhookBackoff = WebhookBackoff
+
+// queuedEvent is an event queued for delivery, with the device to resolve the
+// label from before delivering, if any
+type queuedEvent struct {
+	event common.DeviceEvent
+	dev   common.Device
+}
+
+type webhook struct {
+	url        string
+	httpClient *http.Client
+	backoff    time.Duration
+	queue      []queuedEvent
+	notify     chan struct{}
+	quitChan   chan struct{}
+	devices    map[uint64]*common.Subscription
+	wg         sync.WaitGroup
+	sync.Mutex
+}
+
+// SetEventWebhook sets a URL to which device events (discovery, offline/online,
+// and label, power and color updates) are POSTed as JSON, see
+// common.DeviceEvent.  Events are queued and delivered in order by a background
+// goroutine so that a slow endpoint does not block the client, if the queue
+// fills the oldest events are dropped (see WebhookQueueSize).  Failed
+// deliveries are retried with exponential backoff (see WebhookMaxAttempts and
+// WebhookBackoff).  Only events observed by the client are delivered, changes
+// made by other clients are included once the client polls or is notified of
+// them.  Passing an empty URL disables the webhook.  Returns
+// common.ErrInvalidArgument if the URL is not an absolute http(s) URL.
+func (c *Client) SetEventWebhook(webhookURL string) error {
+	if webhookURL != `` {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != `http` && u.Scheme != `https`) || u.Host == `` {
+			return common.ErrInvalidArgument
+		}
+	}
+
+	c.Lock()
+	previous := c.webhook
+	c.webhook = nil
+	c.Unlock()
+	if previous != nil {
+		previous.close()
+	}
+	if webhookURL == `` {
+		return nil
+	}
+
+	w := &webhook{
+		url:        webhookURL,
+		httpClient: &http.Client{Timeout: common.DefaultTimeout},
+		backoff:    webhookBackoff,
+		notify:     make(chan struct{}, 1),
+		quitChan:   make(chan struct{}),
+		devices:    make(map[uint64]*common.Subscription),
+	}
+	sub, err := c.NewSubscription()
+	if err != nil {
+		return err
+	}
+	// Subscribe before taking the known devices, so none are missed
+	devices, _ := c.protocol.GetDevices()
+	for _, dev := range devices {
+		w.watchDevice(dev)
+	}
+
+	w.wg.Add(2)
+	go w.run(sub)
+	go w.deliver()
+
+	c.Lock()
+	c.webhook = w
+	c.Unlock()
+
+	return nil
+}
+
+// run consumes client events until the webhook is closed
+func (w *webhook) run(sub *common.Subscription) {
+	defer w.wg.Done()
+
+	events := sub.Events()
+	for {
+		select {
+		case <-w.quitChan:
+			if err := sub.Close(); err != nil {
+				common.Log.Warnf("Failed closing webhook subscription: %v", err)
+			}
+			return
+		case event, ok := <-events:
+			if !ok {
+				// The client was closed
+				return
+			}
+			var dev common.Device
+			switch event := event.(type) {
+			case common.EventNewDevice:
+				w.watchDevice(event.Device)
+				dev = event.Device
+			case common.EventExpiredDevice:
+				w.unwatchDevice(event.Device)
+			}
+			if e, ok := common.NewDeviceEvent(event, nil); ok {
+				w.enqueue(queuedEvent{event: e, dev: dev})
+			}
+		}
+	}
+}
+
+// watchDevice subscribes to updates from dev, if not already subscribed
+func (w *webhook) watchDevice(dev common.Device) {
+	w.Lock()
+	defer w.Unlock()
+	if _, ok := w.devices[dev.ID()]; ok {
+		return
+	}
+	select {
+	case <-w.quitChan:
+		return
+	default:
+	}
+	sub, err := dev.NewSubscription()
+	if err != nil {
+		common.Log.Warnf("Failed subscribing webhook to %d: %v", dev.ID(), err)
+		return
+	}
+	w.devices[dev.ID()] = sub
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for event := range sub.Events() {
+			if e, ok := common.NewDeviceEvent(event, dev); ok {
+				w.enqueue(queuedEvent{event: e})
+			}
+		}
+	}()
+}
+
+// unwatchDevice closes the subscription to dev
+func (w *webhook) unwatchDevice(dev common.Device) {
+	w.Lock()
+	sub, ok := w.devices[dev.ID()]
+	delete(w.devices, dev.ID())
+	w.Unlock()
+	if ok {
+		if err := sub.Close(); err != nil {
+			common.Log.Warnf("Failed closing webhook subscription to %d: %v", dev.ID(), err)
+		}
+	}
+}
+
+// enqueue adds the event to the delivery queue, dropping the oldest event if
+// the queue is full
+func (w *webhook) enqueue(e queuedEvent) {
+	w.Lock()
+	if len(w.queue) >= WebhookQueueSize {
+		common.Log.Warnf("Webhook queue full, dropping %s event for %d", w.queue[0].event.Type, w.queue[0].event.DeviceID)
+		w.queue = w.queue[1:]
+	}
+	w.queue = append(w.queue, e)
+	w.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// deliver POSTs queued events in order until the webhook is closed
+func (w *webhook) deliver() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.quitChan:
+			return
+		default:
+		}
+		w.Lock()
+		if len(w.queue) == 0 {
+			w.Unlock()
+			select {
+			case <-w.quitChan:
+				return
+			case <-w.notify:
+			}
+			continue
+		}
+		queued := w.queue[0]
+		w.queue = w.queue[1:]
+		w.Unlock()
+
+		e := queued.event
+		if queued.dev != nil {
+			// Resolved here rather than when queued, as it may require a
+			// request to the device
+			e.Label, _ = queued.dev.GetLabel()
+		}
+		w.post(e)
+	}
+}
+
+// post delivers a single event, retrying with backoff on failure
+func (w *webhook) post(e common.DeviceEvent) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		common.Log.Warnf("Failed encoding webhook event: %v", err)
+		return
+	}
+
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		err = w.send(body)
+		if err == nil {
+			return
+		}
+		if attempt >= WebhookMaxAttempts {
+			common.Log.Warnf("Dropping %s event for %d after %d webhook attempts: %v", e.Type, e.DeviceID, attempt, err)
+			return
+		}
+		common.Log.Debugf("Webhook delivery failed, retrying in %v: %v", backoff, err)
+		select {
+		case <-w.quitChan:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (w *webhook) send(body []byte) error {
+	res, err := w.httpClient.Post(w.url, `application/json`, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err := res.Body.Close(); err != nil {
+		common.Log.Debugf("Failed closing webhook response body: %v", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// close stops delivery and releases subscriptions, queued events are dropped
+func (w *webhook) close() {
+	w.Lock()
+	close(w.quitChan)
+	devices := w.devices
+	w.devices = make(map[uint64]*common.Subscription)
+	w.Unlock()
+
+	for id, sub := range devices {
+		if err := sub.Close(); err != nil {
+			common.Log.Warnf("Failed closing webhook subscription to %d: %v", id, err)
+		}
+	}
+	w.wg.Wait()
+}